@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-andiamo/flagpole"
+	"os"
+)
+
+const (
+	subCmdExamples     = "examples"
+	subCmdExamplesDesc = "Check OAS yaml/json examples match their schemas"
+)
+
+type checkExamplesFlags struct {
+	CommonFlags
+	Json *bool `name:"json" alias:"j" usage:"output mismatches as JSON (default: false)" default:"false" example:"[-json]"`
+}
+
+var checkExamplesFlagsParser = flagpole.MustNewParser[checkExamplesFlags](flagpole.StopOnHelp(true), flagpole.DefaultedOptionals(true), flagpole.IgnoreUnknownFlags(true))
+
+func checkExamples(args []string) {
+	flags, err := checkExamplesFlagsParser.Parse(args)
+	if err != nil || (flags.Help != nil && *flags.Help) {
+		out := os.Stdout
+		code := 0
+		if err != nil {
+			out = os.Stderr
+			code = 2
+		}
+		checkExamplesFlagsParser.Usage(out, err, cmdCheck, subCmdExamples)
+		os.Exit(code)
+	}
+
+	def, err := readDefinition(flags.In)
+	if err != nil {
+		fail(1, fmt.Errorf("read definition: %w", err))
+	}
+	diags := def.CheckExamples()
+	if *flags.Json {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(diags)
+	} else if len(diags) == 0 {
+		_, _ = fmt.Fprintln(os.Stderr, "ok, all examples match their schemas")
+	} else {
+		for _, d := range diags {
+			printDiagnostic(d)
+		}
+	}
+	if len(diags) > 0 {
+		os.Exit(2)
+	}
+	os.Exit(0)
+}
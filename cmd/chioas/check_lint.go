@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-andiamo/chioas"
+	"github.com/go-andiamo/flagpole"
+	"os"
+)
+
+const (
+	subCmdLint     = "lint"
+	subCmdLintDesc = "Lint OAS yaml/json (duplicate operationIds, path params, required properties, enums, examples, unused components etc.)"
+)
+
+type checkLintFlags struct {
+	CommonFlags
+	Json       *bool `name:"json"        alias:"j"  usage:"output diagnostics as JSON (default: false)"              default:"false" example:"[-json]"`
+	NoWarnings *bool `name:"no-warnings" alias:"nw" usage:"suppress warnings (default: false)"                       default:"false" example:"[-no-warnings]"`
+	Strict     *bool `name:"strict"      alias:"s"  usage:"treat warnings as errors for exit code (default: false)" default:"false" example:"[-strict]"`
+}
+
+var checkLintFlagsParser = flagpole.MustNewParser[checkLintFlags](flagpole.StopOnHelp(true), flagpole.DefaultedOptionals(true), flagpole.IgnoreUnknownFlags(true))
+
+func checkLint(args []string) {
+	flags, err := checkLintFlagsParser.Parse(args)
+	if err != nil || (flags.Help != nil && *flags.Help) {
+		out := os.Stdout
+		code := 0
+		if err != nil {
+			out = os.Stderr
+			code = 2
+		}
+		checkLintFlagsParser.Usage(out, err, cmdCheck, subCmdLint)
+		os.Exit(code)
+	}
+
+	def, err := readDefinition(flags.In)
+	if err != nil {
+		fail(1, fmt.Errorf("read definition: %w", err))
+	}
+	diags := make(chioas.Diagnostics, 0)
+	failed := false
+	for _, d := range def.Validate() {
+		if d.Code == chioas.DiagMissingHandler {
+			// definitions read from yaml/json never have handlers...
+			continue
+		}
+		if d.Severity == chioas.SeverityWarning {
+			if *flags.NoWarnings {
+				continue
+			}
+			failed = failed || *flags.Strict
+		} else {
+			failed = true
+		}
+		diags = append(diags, d)
+	}
+	if *flags.Json {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(diags)
+	} else {
+		for _, d := range diags {
+			printDiagnostic(d)
+		}
+		if len(diags) == 0 {
+			_, _ = fmt.Fprintln(os.Stderr, "ok, no problems found")
+		}
+	}
+	if failed {
+		os.Exit(2)
+	}
+	os.Exit(0)
+}
+
+func printDiagnostic(d chioas.Diagnostic) {
+	_, _ = fmt.Fprintf(os.Stderr, "%s: %s\n", d.Severity, d.Message)
+	_, _ = fmt.Fprintf(os.Stderr, "    code: %s\n", d.Code)
+	_, _ = fmt.Fprintf(os.Stderr, "  location: %s\n", d.Location)
+}
@@ -67,6 +67,9 @@ func printRefError(err *chioas.RefError) {
 	if err.Method != "" {
 		_, _ = fmt.Fprintf(os.Stderr, "  method: %s\n", err.Method)
 	}
+	if err.Pointer != "" {
+		_, _ = fmt.Fprintf(os.Stderr, " pointer: %s\n", err.Pointer)
+	}
 	if err.Item != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "    type: %T\n", err.Item)
 	}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-andiamo/chioas/diff"
+	"github.com/go-andiamo/flagpole"
+	"os"
+	"slices"
+)
+
+const (
+	cmdDiff     = "diff"
+	cmdDiffDesc = "Compare two OAS yaml/json definitions and report breaking and non-breaking changes"
+)
+
+const (
+	diffFormatText          = "text"
+	diffFormatJson          = "json"
+	diffFormatMarkdown      = "markdown"
+	diffFormatChangelog     = "changelog"
+	diffFormatChangelogHtml = "changelog-html"
+)
+
+type diffFlags struct {
+	Help           *bool  `name:"help"             alias:"h"  usage:"show help"`
+	Base           string `name:"base"             alias:"b"  required:"true" usage:"base (old) definition file (.yaml|.json)" example:"-base <filename>"`
+	Head           string `name:"head"             alias:"hd" required:"true" usage:"head (new) definition file (.yaml|.json)" example:"-head <filename>"`
+	Format         string `name:"format"           alias:"f"  usage:"output format - text, json, markdown, changelog (markdown grouped by tag) or changelog-html (default: \"text\")" default:"text" example:"[-format <text|json|markdown|changelog|changelog-html>]"`
+	Title          string `name:"title"            alias:"t"  usage:"title for changelog formats (default: \"API Changelog\")" example:"[-title <title>]"`
+	Breaking       *bool  `name:"breaking"         alias:"br" usage:"only report breaking changes (default: false)" default:"false" example:"[-breaking]"`
+	FailOnBreaking *bool  `name:"fail-on-breaking" alias:"fb" usage:"exit with code 2 if there are breaking changes (default: false)" default:"false" example:"[-fail-on-breaking]"`
+}
+
+var diffFlagsParser = flagpole.MustNewParser[diffFlags](flagpole.StopOnHelp(true), flagpole.DefaultedOptionals(true), flagpole.IgnoreUnknownFlags(true))
+
+func compareDefinitions(args []string) {
+	flags, err := diffFlagsParser.Parse(args)
+	if err == nil && !slices.Contains([]string{diffFormatText, diffFormatJson, diffFormatMarkdown, diffFormatChangelog, diffFormatChangelogHtml}, flags.Format) {
+		err = fmt.Errorf("unknown format %q", flags.Format)
+	}
+	if err != nil || (flags.Help != nil && *flags.Help) {
+		out := os.Stdout
+		code := 0
+		if err != nil {
+			out = os.Stderr
+			code = 2
+		}
+		diffFlagsParser.Usage(out, err, cmdDiff)
+		os.Exit(code)
+	}
+
+	base, err := readDefinition(flags.Base)
+	if err != nil {
+		fail(1, fmt.Errorf("read base definition: %w", err))
+	}
+	head, err := readDefinition(flags.Head)
+	if err != nil {
+		fail(1, fmt.Errorf("read head definition: %w", err))
+	}
+	changes := diff.Compare(base, head)
+	if *flags.Breaking {
+		changes = changes.Breaking()
+	}
+	switch flags.Format {
+	case diffFormatJson:
+		err = changes.WriteJson(os.Stdout)
+	case diffFormatMarkdown:
+		err = changes.WriteMarkdown(os.Stdout)
+	case diffFormatChangelog:
+		err = diff.NewChangelog(flags.Title, changes).WriteMarkdown(os.Stdout)
+	case diffFormatChangelogHtml:
+		err = diff.NewChangelog(flags.Title, changes).WriteHtml(os.Stdout)
+	default:
+		err = changes.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fail(1, err)
+	}
+	if *flags.FailOnBreaking && changes.HasBreaking() {
+		os.Exit(2)
+	}
+	os.Exit(0)
+}
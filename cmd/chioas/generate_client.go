@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-andiamo/chioas"
+	"github.com/go-andiamo/chioas/codegen"
+	"github.com/go-andiamo/flagpole"
+	"io"
+	"os"
+)
+
+const (
+	subCmdClient     = "client"
+	subCmdClientDesc = `Generate typed Go http client from existing OAS yaml/json`
+)
+
+type genClientFlags struct {
+	CommonFlags
+	OutDir     *string `name:"outdir"   alias:"od"  usage:"output directory for generated code"                          default:""          example:"[-outdir <dir>]"`
+	OutFn      *string `name:"outf"     alias:"of"  usage:"output filename for generated code (default: \"client.go\")"  default:"client.go" example:"[-outf <filename>]"`
+	Pkg        *string `name:"pkg"      alias:"pk"  usage:"package for generated code (default: \"api\")"                default:"api"       example:"[-pkg <name>]"`
+	ClientName *string `name:"name"     alias:"n"   usage:"name of the generated client type (default: \"Client\")"      default:"Client"    example:"[-name <name>]"`
+	OASTags    *bool   `name:"oas-tags" alias:"oas" usage:"oas tags for struct fields (default: false)"                  default:"false"     example:"[-oas-tags]"`
+	GoDoc      *bool   `name:"godoc"    alias:"gd"  usage:"include godoc comment for each method and struct (default: false)" default:"false" example:"[-godoc]"`
+	CommonSupplementaryFlags
+}
+
+var genClientFlagsParser = flagpole.MustNewParser[genClientFlags](flagpole.StopOnHelp(true), flagpole.DefaultedOptionals(true), flagpole.IgnoreUnknownFlags(true))
+
+func generateClient(args []string) {
+	flags, err := genClientFlagsParser.Parse(args)
+	if err != nil || (flags.Help != nil && *flags.Help) {
+		out := os.Stdout
+		code := 0
+		if err != nil {
+			out = os.Stderr
+			code = 2
+		}
+		genClientFlagsParser.Usage(out, err, cmdGen, subCmdClient)
+		os.Exit(code)
+	}
+
+	def, err := readDefinition(flags.In)
+	if err != nil {
+		fail(1, fmt.Errorf("read definition: %w", err))
+	}
+	options := codegen.ClientOptions{
+		Package:    *flags.Pkg,
+		ClientName: *flags.ClientName,
+		OASTags:    *flags.OASTags,
+		GoDoc:      *flags.GoDoc,
+		Format:     !*flags.NoFormat,
+	}
+	if err = generateDefinitionClient(def, options, *flags.OutDir, *flags.OutFn, *flags.Overwrite); err != nil {
+		fail(1, fmt.Errorf("generate client: %w", err))
+	}
+}
+
+func generateDefinitionClient(def *chioas.Definition, options codegen.ClientOptions, outDir string, outFn string, overwrite bool) (err error) {
+	var f io.WriteCloser
+	if f, err = createFile(outFn, outDir, overwrite, "client.go"); err == nil {
+		defer func() {
+			_ = f.Close()
+		}()
+		err = codegen.GenerateClient(def, f, options)
+	}
+	return err
+}
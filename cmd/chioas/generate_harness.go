@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-andiamo/chioas/codegen"
+	"github.com/go-andiamo/flagpole"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	subCmdHarness     = "harness"
+	subCmdHarnessDesc = `Generate a Go test harness that builds chioas definition code from an existing chi router`
+)
+
+type genHarnessFlags struct {
+	Help       *bool   `name:"help"        alias:"h"  usage:"show help"`
+	Router     string  `name:"router"      alias:"r"  required:"true" usage:"Go expression (in the package) for the chi router - e.g. \"newRouter()\"" example:"-router <expr>"`
+	OutDir     *string `name:"outdir"      alias:"od" usage:"output directory for generated harness"                                      default:""                       example:"[-outdir <dir>]"`
+	OutFn      *string `name:"outf"        alias:"of" usage:"output filename for generated harness (default: \"definition_gen_test.go\")" default:"definition_gen_test.go" example:"[-outf <filename>]"`
+	Pkg        *string `name:"pkg"         alias:"pk" usage:"package for generated harness (default: \"api\")"                            default:"api"                    example:"[-pkg <name>]"`
+	TestName   *string `name:"test"        alias:"t"  usage:"name of the generated test (default: \"TestGenerateDefinition\")"            default:"TestGenerateDefinition" example:"[-test <name>]"`
+	GenFn      *string `name:"gen"         alias:"g"  usage:"filename the harness writes definition code to (default: \"definition.go\")" default:"definition.go"          example:"[-gen <filename>]"`
+	VarName    *string `name:"var"         alias:"v"  usage:"name for the top-level variable (default: definition)"                       default:""                       example:"[-var <name>]"`
+	OmitZero   *bool   `name:"omit-zero"   alias:"oz" usage:"omit zero-valued fields (default: false)"                                    default:"false"                  example:"[-omit-zero]"`
+	HTTPConsts *bool   `name:"http-consts" alias:"ht" usage:"use http.MethodGet, http.Status etc. (default: false)"                       default:"false"                  example:"[-http-consts]"`
+	Exclude    *string `name:"exclude"     alias:"x"  usage:"comma separated http methods to exclude (optional) - e.g. \"OPTIONS,HEAD\""  default:""                       example:"[-exclude <methods>]"`
+	CommonSupplementaryFlags
+}
+
+var genHarnessFlagsParser = flagpole.MustNewParser[genHarnessFlags](flagpole.StopOnHelp(true), flagpole.DefaultedOptionals(true), flagpole.IgnoreUnknownFlags(true))
+
+func generateHarness(args []string) {
+	flags, err := genHarnessFlagsParser.Parse(args)
+	if err != nil || (flags.Help != nil && *flags.Help) {
+		out := os.Stdout
+		code := 0
+		if err != nil {
+			out = os.Stderr
+			code = 2
+		}
+		genHarnessFlagsParser.Usage(out, err, cmdGen, subCmdHarness)
+		os.Exit(code)
+	}
+
+	options := codegen.RouterHarnessOptions{
+		Package:  *flags.Pkg,
+		Router:   flags.Router,
+		TestName: *flags.TestName,
+		OutFile:  *flags.GenFn,
+		Code: codegen.Options{
+			VarName:        *flags.VarName,
+			OmitZeroValues: *flags.OmitZero,
+			UseHttpConsts:  *flags.HTTPConsts,
+		},
+		Format: !*flags.NoFormat,
+	}
+	for _, m := range strings.Split(*flags.Exclude, ",") {
+		if m = strings.TrimSpace(m); m != "" {
+			options.ExcludeMethods = append(options.ExcludeMethods, m)
+		}
+	}
+	if err = generateRouterHarness(options, *flags.OutDir, *flags.OutFn, *flags.Overwrite); err != nil {
+		fail(1, fmt.Errorf("generate harness: %w", err))
+	}
+}
+
+func generateRouterHarness(options codegen.RouterHarnessOptions, outDir string, outFn string, overwrite bool) (err error) {
+	var f io.WriteCloser
+	if f, err = createFile(outFn, outDir, overwrite, "definition_gen_test.go"); err == nil {
+		defer func() {
+			_ = f.Close()
+		}()
+		err = codegen.GenerateRouterHarness(f, options)
+	}
+	return err
+}
@@ -27,6 +27,7 @@ type genStructsFlags struct {
 	Keep          *bool   `name:"keep"             alias:"k"  usage:"keep references to components as separate structs (default: false)" default:"false"  example:"[-keep]"`
 	Path          *string `name:"path"                        usage:"api path to generate stubs for (optional) - e.g. \"/api/pets\""                      example:"[-path </api/foo>]"`
 	GoDoc         *bool   `name:"godoc"            alias:"gd" usage:"include godoc comment for each struct (default: false)"        default:"false"       example:"[-godoc]"`
+	Enums         *bool   `name:"enums"            alias:"en" usage:"generate named types (with consts) for enums (default: false)" default:"false"       example:"[-enums]"`
 	CommonSupplementaryFlags
 }
 
@@ -56,6 +57,7 @@ func generateStructs(args []string) {
 		NoResponses:             *flags.NoResponses,
 		OASTags:                 *flags.OASTags,
 		GoDoc:                   *flags.GoDoc,
+		EnumTypes:               *flags.Enums,
 		KeepComponentProperties: *flags.Keep,
 		Format:                  !*flags.NoFormat,
 	}
@@ -25,6 +25,8 @@ type genStubsFlags struct {
 	Naming      *int    `name:"naming"       alias:"n"  usage:"handler func naming strategy (default: 0)\n        0: default naming strategy\n        1: try to use OAS operationId\n        2: try to use existing handler or x-handler" default:"0" example:"[-naming <0|1|2>]"`
 	Path        *string `name:"path"                    usage:"api path to generate stubs for (optional) - e.g. \"/api/pets\""                      example:"[-path </api/foo>]"`
 	GoDoc       *bool   `name:"godoc"        alias:"gd" usage:"include godoc comment for each handler (default: false)"       default:"false"       example:"[-godoc]"`
+	Typed       *bool   `name:"typed"        alias:"t"  usage:"generate a typed interface (with named param types, structs and definition binding) instead of handler funcs (default: false)" default:"false" example:"[-typed]"`
+	Interface   *string `name:"interface"    alias:"if" usage:"name of the typed interface - when -typed is specified (default: \"TypedApi\")" default:"TypedApi" example:"[-interface <name>]"`
 	CommonSupplementaryFlags
 }
 
@@ -49,15 +51,20 @@ func generateStubs(args []string) {
 	}
 	stubNamer := &stubNaming{strategy: *flags.Naming}
 	options := codegen.HandlerStubOptions{
-		Package:     *flags.Pkg,
-		PublicFuncs: *flags.PublicFuncs,
-		Receiver:    *flags.Receiver,
-		PathParams:  *flags.PathParams,
-		StubNaming:  stubNamer,
-		GoDoc:       *flags.GoDoc,
-		Format:      !*flags.NoFormat,
+		Package:        *flags.Pkg,
+		PublicFuncs:    *flags.PublicFuncs,
+		Receiver:       *flags.Receiver,
+		PathParams:     *flags.PathParams,
+		StubNaming:     stubNamer,
+		GoDoc:          *flags.GoDoc,
+		Format:         !*flags.NoFormat,
+		Typed:          *flags.Typed,
+		TypedInterface: *flags.Interface,
 	}
 	if flags.Path != nil {
+		if *flags.Typed {
+			fail(1, fmt.Errorf("typed stubs cannot be generated for a path: %q", *flags.Path))
+		}
 		pathDef := getPath(*flags.Path, def)
 		if pathDef == nil {
 			fail(1, fmt.Errorf("unknown path: %q", *flags.Path))
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-andiamo/chioas"
+	"github.com/go-andiamo/chioas/codegen"
+	"github.com/go-andiamo/flagpole"
+	"io"
+	"os"
+)
+
+const (
+	subCmdTs     = "ts"
+	subCmdTsDesc = `Generate TypeScript types (and optional fetch client) from existing OAS yaml/json`
+)
+
+type genTsFlags struct {
+	CommonFlags
+	OutDir    *string `name:"outdir"    alias:"od" usage:"output directory for generated code"                        default:""       example:"[-outdir <dir>]"`
+	OutFn     *string `name:"outf"      alias:"of" usage:"output filename for generated code (default: \"api.ts\")"   default:"api.ts" example:"[-outf <filename>]"`
+	Client    *bool   `name:"client"    alias:"c"  usage:"also generate a fetch client function per operation (default: false)" default:"false" example:"[-client]"`
+	Comments  *bool   `name:"comments"  alias:"cm" usage:"include doc comments for types and functions (default: false)"        default:"false" example:"[-comments]"`
+	Overwrite *bool   `name:"overwrite" alias:"ov" usage:"allow overwriting existing file (default: false)"           default:"false"  example:"[-overwrite]"`
+}
+
+var genTsFlagsParser = flagpole.MustNewParser[genTsFlags](flagpole.StopOnHelp(true), flagpole.DefaultedOptionals(true), flagpole.IgnoreUnknownFlags(true))
+
+func generateTs(args []string) {
+	flags, err := genTsFlagsParser.Parse(args)
+	if err != nil || (flags.Help != nil && *flags.Help) {
+		out := os.Stdout
+		code := 0
+		if err != nil {
+			out = os.Stderr
+			code = 2
+		}
+		genTsFlagsParser.Usage(out, err, cmdGen, subCmdTs)
+		os.Exit(code)
+	}
+
+	def, err := readDefinition(flags.In)
+	if err != nil {
+		fail(1, fmt.Errorf("read definition: %w", err))
+	}
+	options := codegen.TypeScriptOptions{
+		Client:   *flags.Client,
+		Comments: *flags.Comments,
+	}
+	if err = generateDefinitionTs(def, options, *flags.OutDir, *flags.OutFn, *flags.Overwrite); err != nil {
+		fail(1, fmt.Errorf("generate ts: %w", err))
+	}
+}
+
+func generateDefinitionTs(def *chioas.Definition, options codegen.TypeScriptOptions, outDir string, outFn string, overwrite bool) (err error) {
+	var f io.WriteCloser
+	if f, err = createFile(outFn, outDir, overwrite, "api.ts"); err == nil {
+		defer func() {
+			_ = f.Close()
+		}()
+		err = codegen.GenerateTypeScript(def, f, options)
+	}
+	return err
+}
@@ -41,6 +41,12 @@ func main() {
 		generate(os.Args[2:])
 	case cmdCheck:
 		check(os.Args[2:])
+	case cmdMock:
+		mock(os.Args[2:])
+	case cmdDiff:
+		compareDefinitions(os.Args[2:])
+	case cmdOverlay:
+		applyOverlays(os.Args[2:])
 	case flagVersion, "-" + flagVersion, "version", "-v", "--v":
 		fmt.Println("CLI version: " + cliVersion)
 		if info, ok := debug.ReadBuildInfo(); ok {
@@ -65,6 +71,12 @@ func generate(args []string) {
 		generateStubs(args[1:])
 	case subCmdStructs:
 		generateStructs(args[1:])
+	case subCmdClient:
+		generateClient(args[1:])
+	case subCmdTs:
+		generateTs(args[1:])
+	case subCmdHarness:
+		generateHarness(args[1:])
 	case flagHelp:
 		usageGen("")
 	default:
@@ -80,6 +92,10 @@ func check(args []string) {
 	switch args[0] {
 	case subCmdRefs:
 		checkRefs(args[1:])
+	case subCmdLint:
+		checkLint(args[1:])
+	case subCmdExamples:
+		checkExamples(args[1:])
 	case flagHelp:
 		usageCheck("")
 	default:
@@ -94,8 +110,8 @@ func createFile(filename string, outDir string, overwrite bool, defaultFilename
 	if filename == "" {
 		filename = defaultFilename
 	}
-	if !strings.HasSuffix(filename, ".go") {
-		filename += ".go"
+	if ext := filepath.Ext(defaultFilename); !strings.HasSuffix(filename, ext) {
+		filename += ext
 	}
 	if outDir != "" {
 		err = os.MkdirAll(outDir, 0o755)
@@ -119,15 +135,7 @@ func createFile(filename string, outDir string, overwrite bool, defaultFilename
 }
 
 func readDefinition(path string) (result *chioas.Definition, err error) {
-	var data []byte
-	var ext string
-	if path == "-" {
-		data, err = io.ReadAll(os.Stdin)
-		ext = sniff(data)
-	} else {
-		data, err = os.ReadFile(path)
-		ext = strings.ToLower(filepath.Ext(path))
-	}
+	data, ext, err := readData(path)
 	if err != nil {
 		return nil, err
 	}
@@ -144,6 +152,17 @@ func readDefinition(path string) (result *chioas.Definition, err error) {
 	}
 }
 
+func readData(path string) (data []byte, ext string, err error) {
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+		ext = sniff(data)
+	} else {
+		data, err = os.ReadFile(path)
+		ext = strings.ToLower(filepath.Ext(path))
+	}
+	return data, ext, err
+}
+
 func sniff(b []byte) string {
 	trim := strings.TrimLeftFunc(string(b), func(r rune) bool { return r == ' ' || r == '\n' || r == '\r' || r == '\t' })
 	if strings.HasPrefix(trim, "{") {
@@ -161,6 +180,14 @@ func usageCheck(msg string) {
 	_, _ = fmt.Fprintln(out, "Description: "+subCmdRefsDesc)
 	_, _ = fmt.Fprintln(out, "Usage:")
 	_, _ = fmt.Fprintln(out, "    "+cmdChioas+" "+cmdCheck+" "+subCmdRefs+" "+flagHelp)
+	_, _ = fmt.Fprintln(out, "")
+	_, _ = fmt.Fprintln(out, "Description: "+subCmdLintDesc)
+	_, _ = fmt.Fprintln(out, "Usage:")
+	_, _ = fmt.Fprintln(out, "    "+cmdChioas+" "+cmdCheck+" "+subCmdLint+" "+flagHelp)
+	_, _ = fmt.Fprintln(out, "")
+	_, _ = fmt.Fprintln(out, "Description: "+subCmdExamplesDesc)
+	_, _ = fmt.Fprintln(out, "Usage:")
+	_, _ = fmt.Fprintln(out, "    "+cmdChioas+" "+cmdCheck+" "+subCmdExamples+" "+flagHelp)
 	if msg != "" {
 		os.Exit(2)
 	} else {
@@ -185,6 +212,18 @@ func usageGen(msg string) {
 	_, _ = fmt.Fprintln(out, "Description: "+subCmdStructsDesc)
 	_, _ = fmt.Fprintln(out, "Usage:")
 	_, _ = fmt.Fprintln(out, "    "+cmdChioas+" "+cmdGen+" "+subCmdStructs+" "+flagHelp)
+	_, _ = fmt.Fprintln(out, "")
+	_, _ = fmt.Fprintln(out, "Description: "+subCmdClientDesc)
+	_, _ = fmt.Fprintln(out, "Usage:")
+	_, _ = fmt.Fprintln(out, "    "+cmdChioas+" "+cmdGen+" "+subCmdClient+" "+flagHelp)
+	_, _ = fmt.Fprintln(out, "")
+	_, _ = fmt.Fprintln(out, "Description: "+subCmdTsDesc)
+	_, _ = fmt.Fprintln(out, "Usage:")
+	_, _ = fmt.Fprintln(out, "    "+cmdChioas+" "+cmdGen+" "+subCmdTs+" "+flagHelp)
+	_, _ = fmt.Fprintln(out, "")
+	_, _ = fmt.Fprintln(out, "Description: "+subCmdHarnessDesc)
+	_, _ = fmt.Fprintln(out, "Usage:")
+	_, _ = fmt.Fprintln(out, "    "+cmdChioas+" "+cmdGen+" "+subCmdHarness+" "+flagHelp)
 	if msg != "" {
 		os.Exit(2)
 	} else {
@@ -203,6 +242,12 @@ func usage(msg string) {
 	_, _ = fmt.Fprintln(out, "        Show help for generate commands")
 	_, _ = fmt.Fprintln(out, "    "+cmdChioas+" "+cmdCheck+" "+flagHelp)
 	_, _ = fmt.Fprintln(out, "        Show help for check commands")
+	_, _ = fmt.Fprintln(out, "    "+cmdChioas+" "+cmdMock+" "+flagHelp)
+	_, _ = fmt.Fprintln(out, "        Show help for mock command")
+	_, _ = fmt.Fprintln(out, "    "+cmdChioas+" "+cmdDiff+" "+flagHelp)
+	_, _ = fmt.Fprintln(out, "        Show help for diff command")
+	_, _ = fmt.Fprintln(out, "    "+cmdChioas+" "+cmdOverlay+" "+flagHelp)
+	_, _ = fmt.Fprintln(out, "        Show help for overlay command")
 	_, _ = fmt.Fprintln(out, "    "+cmdChioas+" "+flagVersion)
 	_, _ = fmt.Fprintln(out, "        Show the current CLI version")
 	_, _ = fmt.Fprintln(out, "    "+cmdChioas+" "+flagHelp)
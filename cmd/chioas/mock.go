@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-andiamo/chioas"
+	"github.com/go-andiamo/flagpole"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"os"
+)
+
+const (
+	cmdMock     = "mock"
+	cmdMockDesc = "Serve a mock API from OAS yaml/json (responses from examples or generated from schemas)"
+)
+
+type mockFlags struct {
+	CommonFlags
+	Addr         string `name:"addr"          alias:"a"  usage:"address to listen on (default: \":8080\")"     default:":8080" example:"[-addr <address>]"`
+	NoValidation *bool  `name:"no-validation" alias:"nv" usage:"do not validate incoming requests (default: false)" default:"false" example:"[-no-validation]"`
+	Stateful     *bool  `name:"stateful"      alias:"s"  usage:"serve inferred resource collections from an in-memory store (default: false)" default:"false" example:"[-stateful]"`
+}
+
+var mockFlagsParser = flagpole.MustNewParser[mockFlags](flagpole.StopOnHelp(true), flagpole.DefaultedOptionals(true), flagpole.IgnoreUnknownFlags(true))
+
+func mock(args []string) {
+	flags, err := mockFlagsParser.Parse(args)
+	if err != nil || (flags.Help != nil && *flags.Help) {
+		out := os.Stdout
+		code := 0
+		if err != nil {
+			out = os.Stderr
+			code = 2
+		}
+		mockFlagsParser.Usage(out, err, cmdMock)
+		os.Exit(code)
+	}
+
+	def, err := readDefinition(flags.In)
+	if err != nil {
+		fail(1, fmt.Errorf("read definition: %w", err))
+	}
+	def.MethodHandlerBuilder = chioas.NewMockMethodHandlerBuilder(def, &chioas.MockOptions{
+		MockAll:      true,
+		NoValidation: *flags.NoValidation,
+		Stateful:     *flags.Stateful,
+	})
+	router := chi.NewRouter()
+	if err = def.SetupRoutes(router, nil); err != nil {
+		fail(1, fmt.Errorf("setup routes: %w", err))
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "serving mock api on %s\n", flags.Addr)
+	if err = http.ListenAndServe(flags.Addr, router); err != nil {
+		fail(1, err)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/go-andiamo/chioas/overlay"
+	"github.com/go-andiamo/flagpole"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	cmdOverlay     = "overlay"
+	cmdOverlayDesc = "Apply OpenAPI Overlay documents to OAS yaml/json"
+)
+
+type overlayFlags struct {
+	CommonFlags
+	Overlays  string `name:"overlay"   alias:"o"  required:"true" usage:"comma separated overlay files (.yaml|.json) - applied in order" example:"-overlay <filenames>"`
+	Out       string `name:"out"       alias:"of" usage:"output file (default: stdout)" default:"-" example:"[-out <filename>]"`
+	Overwrite *bool  `name:"overwrite" alias:"ov" usage:"allow overwriting existing file (default: false)" default:"false" example:"[-overwrite]"`
+}
+
+var overlayFlagsParser = flagpole.MustNewParser[overlayFlags](flagpole.StopOnHelp(true), flagpole.DefaultedOptionals(true), flagpole.IgnoreUnknownFlags(true))
+
+func applyOverlays(args []string) {
+	flags, err := overlayFlagsParser.Parse(args)
+	if err != nil || (flags.Help != nil && *flags.Help) {
+		out := os.Stdout
+		code := 0
+		if err != nil {
+			out = os.Stderr
+			code = 2
+		}
+		overlayFlagsParser.Usage(out, err, cmdOverlay)
+		os.Exit(code)
+	}
+
+	data, _, err := readData(flags.In)
+	if err != nil {
+		fail(1, fmt.Errorf("read definition: %w", err))
+	}
+	for _, filename := range strings.Split(flags.Overlays, ",") {
+		if filename = strings.TrimSpace(filename); filename != "" {
+			var o *overlay.Overlay
+			if o, err = readOverlay(filename); err == nil {
+				data, err = o.Apply(data)
+			}
+			if err != nil {
+				fail(1, fmt.Errorf("overlay %s: %w", filename, err))
+			}
+		}
+	}
+	outDir, filename := "-", ""
+	if flags.Out != "-" {
+		outDir, filename = filepath.Dir(flags.Out), filepath.Base(flags.Out)
+	}
+	f, err := createFile(filename, outDir, *flags.Overwrite, filename)
+	if err != nil {
+		fail(1, fmt.Errorf("create file: %w", err))
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	if _, err = f.Write(data); err != nil {
+		fail(1, fmt.Errorf("write output: %w", err))
+	}
+}
+
+func readOverlay(filename string) (*overlay.Overlay, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return overlay.Parse(bytes.NewReader(data))
+}
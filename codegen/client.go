@@ -0,0 +1,611 @@
+package codegen
+
+import (
+	"bytes"
+	"github.com/go-andiamo/chioas"
+	"github.com/go-andiamo/chioas/internal/refs"
+	"github.com/go-andiamo/chioas/internal/tags"
+	"github.com/go-andiamo/chioas/internal/values"
+	"github.com/go-andiamo/urit"
+	"go/token"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ClientOptions is the options for GenerateClient
+type ClientOptions struct {
+	Package      string // e.g. "api" (default "api")
+	SkipPrologue bool   // don't write package & imports
+	ClientName   string // the name of the generated client type (default "Client")
+	OASTags      bool   // if true, writes `oas:"..."` tags for generated schema struct fields
+	GoDoc        bool   // if true, writes a godoc comment for each client method and schema struct
+	// Format if set, formats output in canonical gofmt style (and checks syntax)
+	//
+	// Note: using this option means the output will be buffered before writing to the final writer
+	Format  bool
+	UseCRLF bool // true to use \r\n as the line terminator
+}
+
+const defaultClientName = "Client"
+
+type ClientItemType interface {
+	chioas.Definition | *chioas.Definition
+}
+
+// GenerateClient writes Go source for a typed http client of the specified definition to w using the supplied
+// ClientOptions.
+//
+// The generated client has one method per operation - named from the operationId (or, where there is no operationId,
+// the same naming as handler stubs) - with path, query, header and cookie params as arguments. Request and response
+// types are the schema structs (as generated by GenerateSchemaStructs - with component schemas kept as their own
+// structs), which are written into the same source.
+//
+// Non-success responses are returned as an *ApiError (generated) - with the body decoded where the status code has a
+// declared response schema.
+//
+// The underlying http client and auth are pluggable - see generated options WithHttpClient, WithRequestEditor,
+// WithBearerToken and WithApiKey
+//
+// Errors:
+//   - Returns the first write error encountered. It does not close w.
+func GenerateClient[T ClientItemType](item T, w io.Writer, opts ClientOptions) error {
+	var def chioas.Definition
+	switch it := any(item).(type) {
+	case chioas.Definition:
+		def = it
+	case *chioas.Definition:
+		def = *it
+	}
+	// generate the schema structs first (so that the names of request/response types are known)...
+	var structs bytes.Buffer
+	sw, err := generateOperationStructs(def, &structs, opts.OASTags, opts.GoDoc, opts.UseCRLF)
+	if err != nil {
+		return err
+	}
+	cw := newClientWriter(w, opts, sw)
+	cw.writePrologue()
+	cw.writeStatics()
+	context := strings.Trim(def.DocOptions.Context, "/")
+	if context != "" {
+		context = "/" + context
+	}
+	walkMethods(def, func(path string, pathParams chioas.PathParams, method string, mDef chioas.Method) {
+		cw.generateMethod(context, defValue(path, "root"), path, pathParams, method, mDef)
+	})
+	if cw.err == nil {
+		_, cw.err = cw.w.Write(structs.Bytes())
+	}
+	return cw.format()
+}
+
+func newClientWriter(w io.Writer, opts ClientOptions, sw *structsWriter) *clientWriter {
+	return &clientWriter{
+		writer:  newWriter(w, opts.Format, opts.UseCRLF),
+		opts:    opts,
+		sw:      sw,
+		deduper: newNameDeDuper(),
+		name:    defValue(opts.ClientName, defaultClientName),
+	}
+}
+
+type clientWriter struct {
+	*writer
+	opts    ClientOptions
+	sw      *structsWriter
+	deduper *nameDeDuper
+	name    string
+}
+
+func defValue(v, def string) string {
+	if v != "" {
+		return v
+	}
+	return def
+}
+
+func (w *clientWriter) writePrologue() {
+	if w.err == nil && !w.opts.SkipPrologue {
+		w.writeLine(0, "package "+defValue(w.opts.Package, defaultPackage), true)
+		w.writeLine(0, "import (", false)
+		for _, imp := range []string{"bytes", "context", "encoding/json", "fmt", "io", "net/http", "net/url", "strings"} {
+			w.writeLine(1, strconv.Quote(imp), false)
+		}
+		w.writeLine(0, ")", true)
+	}
+}
+
+func (w *clientWriter) writeStatics() {
+	lines := strings.Split(strings.ReplaceAll(clientStatics, "{{client}}", w.name), "\n")
+	for _, line := range lines {
+		w.writeLine(0, line, false)
+	}
+}
+
+// opParam is a param argument of a generated operation method
+type opParam struct {
+	name     string
+	argName  string
+	in       string
+	goType   string
+	required bool
+	isArray  bool
+}
+
+func (p opParam) arg() string {
+	if !p.required && !p.isArray {
+		return p.argName + " *" + p.goType
+	}
+	return p.argName + " " + p.goType
+}
+
+func (p opParam) value() string {
+	if !p.required && !p.isArray {
+		return "fmt.Sprint(*" + p.argName + ")"
+	}
+	return "fmt.Sprint(" + p.argName + ")"
+}
+
+// opResult is the success return type of a generated operation method
+type opResult struct {
+	goType  string // empty for no result value
+	isArray bool
+	raw     bool
+}
+
+func (r opResult) zero() string {
+	if r.goType == "" {
+		return ""
+	}
+	return "nil, "
+}
+
+var reservedArgNames = []string{"c", "ctx", "body", "path", "query", "req", "res", "err", "result", "errValue", "data", "v"}
+
+func (w *clientWriter) generateMethod(context string, key string, path string, pathParams chioas.PathParams, method string, def chioas.Method) {
+	if w.err != nil {
+		return
+	}
+	fullPath := context + path
+	if fullPath == "" {
+		fullPath = "/"
+	}
+	name := toPascal(def.OperationId)
+	if name == "" {
+		name = toPascal(defaultStubNaming.Name(path, method, def))
+	}
+	name = w.deduper.take(name)
+	argNames := newNameDeDuper()
+	for _, r := range reservedArgNames {
+		argNames.take(r)
+	}
+	pathExpr, pathArgs := w.pathExpression(fullPath, pathParams, argNames)
+	params := w.sw.params(def.QueryParams, argNames)
+	body, bodyArg, contentType := w.sw.requestBody(key, method, def.Request)
+	result, errorTypes := w.sw.responses(key, method, def.Responses)
+
+	args := []string{"ctx context.Context"}
+	for _, p := range pathArgs {
+		args = append(args, p.arg())
+	}
+	for _, p := range params {
+		args = append(args, p.arg())
+	}
+	if bodyArg != "" {
+		args = append(args, "body "+bodyArg)
+	}
+	returns := "error"
+	if result.goType != "" {
+		returns = "(" + result.goType + ", error)"
+	}
+	if w.opts.GoDoc {
+		w.writeLine(0, "// "+name+" "+method+" "+fullPath, false)
+		if def.Description != "" {
+			w.writeLine(0, "//", false)
+			w.writeLine(0, "// "+strings.ReplaceAll(def.Description, "\n", "\n// "), false)
+		}
+	}
+	w.writeLine(0, "func (c *"+w.name+") "+name+"("+strings.Join(args, ", ")+") "+returns+" {", false)
+	w.writeLine(1, "path := "+pathExpr, false)
+	queryArg := "nil"
+	hasQuery := false
+	for _, p := range params {
+		if p.in == values.Query {
+			if !hasQuery {
+				w.writeLine(1, "query := url.Values{}", false)
+				queryArg = "query"
+				hasQuery = true
+			}
+			w.writeParam(p, func(v string) string { return "query.Add(" + strconv.Quote(p.name) + ", " + v + ")" })
+		}
+	}
+	bodyValue := "nil"
+	if bodyArg != "" {
+		bodyValue = "body"
+		if body.optional {
+			w.writeLine(1, "var reqBody any", false)
+			w.writeLine(1, "if body != nil {", false)
+			w.writeLine(2, "reqBody = body", false)
+			w.writeLine(1, "}", false)
+			bodyValue = "reqBody"
+		}
+	}
+	w.writeLine(1, "req, err := c.newRequest(ctx, "+strconv.Quote(method)+", path, "+queryArg+", "+bodyValue+", "+strconv.Quote(contentType)+")", false)
+	w.writeLine(1, "if err != nil {", false)
+	w.writeLine(2, "return "+result.zero()+"err", false)
+	w.writeLine(1, "}", false)
+	for _, p := range params {
+		switch p.in {
+		case values.Header:
+			w.writeParam(p, func(v string) string { return "req.Header.Add(" + strconv.Quote(p.name) + ", " + v + ")" })
+		case values.Cookie:
+			w.writeParam(p, func(v string) string {
+				return "req.AddCookie(&http.Cookie{Name: " + strconv.Quote(p.name) + ", Value: " + v + "})"
+			})
+		}
+	}
+	w.writeLine(1, "res, err := c.do(req)", false)
+	w.writeLine(1, "if err != nil {", false)
+	w.writeLine(2, "return "+result.zero()+"err", false)
+	w.writeLine(1, "}", false)
+	w.writeLine(1, "defer func() {", false)
+	w.writeLine(2, "_ = res.Body.Close()", false)
+	w.writeLine(1, "}()", false)
+	w.writeLine(1, "if res.StatusCode >= 200 && res.StatusCode < 300 {", false)
+	switch {
+	case result.goType == "":
+		w.writeLine(2, "return nil", false)
+	case result.raw:
+		w.writeLine(2, "return io.ReadAll(res.Body)", false)
+	case result.isArray:
+		w.writeLine(2, "result := make("+result.goType+", 0)", false)
+		w.writeLine(2, "if err = decodeResponse(res, &result); err != nil {", false)
+		w.writeLine(3, "return nil, err", false)
+		w.writeLine(2, "}", false)
+		w.writeLine(2, "return result, nil", false)
+	default:
+		w.writeLine(2, "result := &"+result.goType[1:]+"{}", false)
+		w.writeLine(2, "if err = decodeResponse(res, result); err != nil {", false)
+		w.writeLine(3, "return nil, err", false)
+		w.writeLine(2, "}", false)
+		w.writeLine(2, "return result, nil", false)
+	}
+	w.writeLine(1, "}", false)
+	errValue := "nil"
+	if len(errorTypes) > 0 {
+		errValue = "errValue"
+		w.writeLine(1, "var errValue any", false)
+		w.writeLine(1, "switch res.StatusCode {", false)
+		for _, status := range sortedKeys(errorTypes) {
+			w.writeLine(1, "case "+strconv.Itoa(status)+":", false)
+			w.writeLine(2, "errValue = "+errorTypes[status], false)
+		}
+		w.writeLine(1, "}", false)
+	}
+	w.writeLine(1, "return "+result.zero()+"newApiError(res, "+errValue+")", false)
+	w.writeLine(0, "}", true)
+}
+
+func (w *clientWriter) writeParam(p opParam, add func(v string) string) {
+	switch {
+	case p.isArray:
+		w.writeLine(1, "for _, v := range "+p.argName+" {", false)
+		w.writeLine(2, add("fmt.Sprint(v)"), false)
+		w.writeLine(1, "}", false)
+	case !p.required:
+		w.writeLine(1, "if "+p.argName+" != nil {", false)
+		w.writeLine(2, add(p.value()), false)
+		w.writeLine(1, "}", false)
+	default:
+		w.writeLine(1, add(p.value()), false)
+	}
+}
+
+var clientPathVarRegex = regexp.MustCompile(`\{([^}]+)}`)
+
+func (w *clientWriter) pathExpression(path string, pathParams chioas.PathParams, argNames *nameDeDuper) (string, []opParam) {
+	if t, err := urit.NewTemplate(path); err == nil {
+		path = t.Template(true)
+	}
+	parts := make([]string, 0)
+	params := make([]opParam, 0)
+	last := 0
+	for _, loc := range clientPathVarRegex.FindAllStringSubmatchIndex(path, -1) {
+		if loc[0] > last {
+			parts = append(parts, strconv.Quote(path[last:loc[0]]))
+		}
+		name := path[loc[2]:loc[3]]
+		p := opParam{name: name, argName: argName(name, argNames), in: values.Path, goType: values.TypeString, required: true}
+		if pp, ok := pathParams[name]; ok {
+			p.goType, _ = w.sw.schemaGoType(pp.Schema, pp.SchemaRef)
+		}
+		if p.goType == values.TypeString {
+			parts = append(parts, "url.PathEscape("+p.argName+")")
+		} else {
+			parts = append(parts, "url.PathEscape(fmt.Sprint("+p.argName+"))")
+		}
+		params = append(params, p)
+		last = loc[1]
+	}
+	if last < len(path) {
+		parts = append(parts, strconv.Quote(path[last:]))
+	}
+	return strings.Join(parts, " + "), params
+}
+
+func argName(name string, argNames *nameDeDuper) string {
+	result := toPascal(name)
+	if result == "" {
+		result = "param"
+	} else {
+		rs := []rune(result)
+		rs[0] = []rune(strings.ToLower(string(rs[0])))[0]
+		result = string(rs)
+	}
+	if token.IsKeyword(result) || (result[0] >= '0' && result[0] <= '9') {
+		result = "p" + toPascal(result)
+	}
+	return argNames.take(result)
+}
+
+func (w *structsWriter) params(qps chioas.QueryParams, argNames *nameDeDuper) []opParam {
+	result := make([]opParam, 0, len(qps))
+	for _, qp := range qps {
+		name, in, required, schema, schemaRef := qp.Name, qp.In, qp.Required, qp.Schema, qp.SchemaRef
+		if qp.Ref != "" {
+			if w.components == nil {
+				continue
+			}
+			cp, ok := w.components.Parameters[refs.Normalize(tags.Parameters, qp.Ref)]
+			if !ok {
+				continue
+			}
+			name, in, required, schema, schemaRef = cp.Name, cp.In, cp.Required, cp.Schema, cp.SchemaRef
+		}
+		if in == "" {
+			in = values.Query
+		}
+		if in == values.Path {
+			continue
+		}
+		p := opParam{name: name, argName: argName(name, argNames), in: in, required: required}
+		p.goType, p.isArray = w.schemaGoType(schema, schemaRef)
+		if p.isArray {
+			p.goType = "[]" + p.goType
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+// schemaGoType determines the go type for a param schema (returns the item type for arrays)
+func (w *structsWriter) schemaGoType(schema *chioas.Schema, schemaRef string) (goType string, isArray bool) {
+	if schema == nil && schemaRef != "" {
+		if s, _, err := w.resolveSchema(schemaRef, nil); err == nil {
+			schema = s
+		}
+	}
+	if schema == nil {
+		return values.TypeString, false
+	}
+	switch schema.Type {
+	case values.TypeInteger:
+		return "int", false
+	case values.TypeNumber:
+		return "float64", false
+	case values.TypeBoolean:
+		return "bool", false
+	case values.TypeArray:
+		return values.TypeString, true
+	}
+	return values.TypeString, false
+}
+
+// opBody is the request body of a generated operation method
+type opBody struct {
+	optional bool
+}
+
+func (w *structsWriter) requestBody(key string, method string, def *chioas.Request) (body opBody, arg string, contentType string) {
+	if def == nil {
+		return
+	}
+	r, err := w.resolveRequest(def, nil)
+	if err != nil {
+		return
+	}
+	contentType = defValue(r.ContentType, tags.ApplicationJson)
+	if !isJsonContentType(contentType) {
+		return body, "io.Reader", contentType
+	}
+	arg = "any"
+	if name, ok := w.typeNames[typeNameKey(method, key, "request")]; ok {
+		switch {
+		case r.IsArray:
+			arg = "[]" + name
+		case r.Required:
+			arg = name
+		default:
+			arg = "*" + name
+			body.optional = true
+		}
+	}
+	return
+}
+
+func isJsonContentType(contentType string) bool {
+	return strings.Contains(strings.ToLower(contentType), "json")
+}
+
+func (w *structsWriter) responses(key string, method string, rs chioas.Responses) (result opResult, errorTypes map[int]string) {
+	errorTypes = map[int]string{}
+	statuses := make([]int, 0, len(rs))
+	for status := range rs {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	successDone := false
+	for _, status := range statuses {
+		def := rs[status]
+		r, err := w.resolveResponse(&def, nil)
+		if err != nil || r.NoContent || status == http.StatusNoContent || (r.Schema == nil && r.SchemaRef == "") {
+			continue
+		}
+		name, hasType := w.typeNames[typeNameKey(method, key, strconv.Itoa(status))]
+		isJson := isJsonContentType(defValue(r.ContentType, tags.ApplicationJson))
+		if status >= 200 && status < 300 {
+			if successDone {
+				continue
+			}
+			successDone = true
+			switch {
+			case !isJson:
+				result = opResult{goType: "[]byte", raw: true}
+			case !hasType:
+				result = opResult{goType: "json.RawMessage", isArray: true}
+			case r.IsArray:
+				result = opResult{goType: "[]" + name, isArray: true}
+			default:
+				result = opResult{goType: "*" + name}
+			}
+		} else if isJson && hasType {
+			if r.IsArray {
+				errorTypes[status] = "&[]" + name + "{}"
+			} else {
+				errorTypes[status] = "&" + name + "{}"
+			}
+		}
+	}
+	return
+}
+
+const clientStatics = `// {{client}} is a client for the API
+type {{client}} struct {
+	baseUrl        string
+	httpClient     HttpDoer
+	requestEditors []RequestEditor
+}
+
+// HttpDoer is the interface for the http client used by {{client}} (e.g. *http.Client)
+type HttpDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// RequestEditor is a function that can modify requests before they are sent (e.g. to add auth)
+type RequestEditor func(ctx context.Context, req *http.Request) error
+
+// {{client}}Option is an option for New{{client}}
+type {{client}}Option func(c *{{client}})
+
+// New{{client}} creates a new {{client}} for the API at baseUrl (e.g. "https://api.example.com")
+func New{{client}}(baseUrl string, options ...{{client}}Option) *{{client}} {
+	c := &{{client}}{
+		baseUrl:    strings.TrimSuffix(baseUrl, "/"),
+		httpClient: http.DefaultClient,
+	}
+	for _, o := range options {
+		o(c)
+	}
+	return c
+}
+
+// WithHttpClient sets the http client used by the {{client}} (defaults to http.DefaultClient)
+func WithHttpClient(httpClient HttpDoer) {{client}}Option {
+	return func(c *{{client}}) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRequestEditor adds a RequestEditor that is called for every request
+func WithRequestEditor(fn RequestEditor) {{client}}Option {
+	return func(c *{{client}}) {
+		c.requestEditors = append(c.requestEditors, fn)
+	}
+}
+
+// WithBearerToken sets the "Authorization" header to "Bearer <token>" for every request
+func WithBearerToken(token string) {{client}}Option {
+	return WithRequestEditor(func(ctx context.Context, req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	})
+}
+
+// WithApiKey sets the named header to the api key for every request
+func WithApiKey(header string, key string) {{client}}Option {
+	return WithRequestEditor(func(ctx context.Context, req *http.Request) error {
+		req.Header.Set(header, key)
+		return nil
+	})
+}
+
+// ApiError is the error returned when the API responds with a non-success status code
+type ApiError struct {
+	// StatusCode is the http status code of the response
+	StatusCode int
+	// Body is the raw body of the response
+	Body []byte
+	// Value is the decoded body - where the status code has a declared response schema (otherwise nil)
+	Value any
+}
+
+func (e *ApiError) Error() string {
+	return fmt.Sprintf("unexpected response status code %d", e.StatusCode)
+}
+
+func (c *{{client}}) newRequest(ctx context.Context, method string, path string, query url.Values, body any, contentType string) (*http.Request, error) {
+	u := c.baseUrl + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var r io.Reader
+	if body != nil {
+		if br, ok := body.(io.Reader); ok {
+			r = br
+		} else {
+			data, err := json.Marshal(body)
+			if err != nil {
+				return nil, err
+			}
+			r = bytes.NewReader(data)
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req, nil
+}
+
+func (c *{{client}}) do(req *http.Request) (*http.Response, error) {
+	for _, fn := range c.requestEditors {
+		if err := fn(req.Context(), req); err != nil {
+			return nil, err
+		}
+	}
+	return c.httpClient.Do(req)
+}
+
+func decodeResponse(res *http.Response, v any) error {
+	data, err := io.ReadAll(res.Body)
+	if err != nil || len(data) == 0 {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func newApiError(res *http.Response, v any) error {
+	result := &ApiError{StatusCode: res.StatusCode}
+	result.Body, _ = io.ReadAll(res.Body)
+	if v != nil && len(result.Body) > 0 && json.Unmarshal(result.Body, v) == nil {
+		result.Value = v
+	}
+	return result
+}
+`
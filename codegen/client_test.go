@@ -0,0 +1,364 @@
+package codegen
+
+import (
+	"bytes"
+	"errors"
+	"github.com/go-andiamo/chioas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+var testClientDefinition = chioas.Definition{
+	DocOptions: chioas.DocOptions{Context: "api"},
+	Paths: chioas.Paths{
+		"/pets": {
+			Methods: chioas.Methods{
+				http.MethodGet: {
+					OperationId: "listPets",
+					QueryParams: chioas.QueryParams{
+						{Name: "limit", Schema: &chioas.Schema{Type: "integer"}},
+						{Name: "tags", Schema: &chioas.Schema{Type: "array"}},
+						{Name: "X-Trace", In: "header", Required: true},
+						{Ref: "session"},
+					},
+					Responses: chioas.Responses{
+						http.StatusOK: {IsArray: true, SchemaRef: "Pet"},
+					},
+				},
+				http.MethodPost: {
+					Request: &chioas.Request{Required: true, SchemaRef: "Pet"},
+					Responses: chioas.Responses{
+						http.StatusCreated:  {SchemaRef: "Pet"},
+						http.StatusConflict: {SchemaRef: "Error"},
+					},
+				},
+			},
+			Paths: chioas.Paths{
+				"/{petId:[0-9]+}": {
+					PathParams: chioas.PathParams{
+						"petId": {Schema: &chioas.Schema{Type: "integer"}},
+					},
+					Methods: chioas.Methods{
+						http.MethodDelete: {
+							OperationId: "deletePet",
+							Responses: chioas.Responses{
+								http.StatusNoContent: {},
+								http.StatusNotFound:  {SchemaRef: "Error"},
+							},
+						},
+					},
+					Paths: chioas.Paths{
+						"/photo": {
+							Methods: chioas.Methods{
+								http.MethodGet: {
+									Responses: chioas.Responses{
+										http.StatusOK: {ContentType: "image/png", Schema: &chioas.Schema{Type: "string", Format: "binary"}},
+									},
+								},
+								http.MethodPut: {
+									Request: &chioas.Request{ContentType: "image/png"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+	Components: &chioas.Components{
+		Schemas: chioas.Schemas{
+			{
+				Name:               "Pet",
+				Type:               "object",
+				RequiredProperties: []string{"name"},
+				Properties: chioas.Properties{
+					{Name: "id", Type: "integer"},
+					{Name: "name", Type: "string"},
+				},
+			},
+			{
+				Name: "Error",
+				Type: "object",
+				Properties: chioas.Properties{
+					{Name: "message", Type: "string"},
+				},
+			},
+		},
+		Parameters: chioas.CommonParameters{
+			"session": {Name: "session", In: "cookie"},
+		},
+	},
+}
+
+func TestGenerateClient(t *testing.T) {
+	var buf bytes.Buffer
+	err := GenerateClient(testClientDefinition, &buf, ClientOptions{Format: true, GoDoc: true, ClientName: "PetsClient"})
+	require.NoError(t, err)
+	code := buf.String()
+	assert.True(t, strings.HasPrefix(code, "package api\n"))
+	assert.Contains(t, code, "type PetsClient struct {")
+	assert.Contains(t, code, "func NewPetsClient(baseUrl string, options ...PetsClientOption) *PetsClient {")
+	assert.Contains(t, code, "type ApiError struct {")
+	assert.Contains(t, code, "type SchemaPet struct {")
+	assert.Contains(t, code, "type PostPetsRequest SchemaPet")
+
+	const listPets = `// ListPets GET /api/pets
+func (c *PetsClient) ListPets(ctx context.Context, limit *int, tags []string, xTrace string, session *string) ([]GetPetsOkResponse, error) {
+	path := "/api/pets"
+	query := url.Values{}
+	if limit != nil {
+		query.Add("limit", fmt.Sprint(*limit))
+	}
+	for _, v := range tags {
+		query.Add("tags", fmt.Sprint(v))
+	}
+	req, err := c.newRequest(ctx, "GET", path, query, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("X-Trace", fmt.Sprint(xTrace))
+	if session != nil {
+		req.AddCookie(&http.Cookie{Name: "session", Value: fmt.Sprint(*session)})
+	}
+	res, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		result := make([]GetPetsOkResponse, 0)
+		if err = decodeResponse(res, &result); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+	return nil, newApiError(res, nil)
+}
+`
+	assert.Contains(t, code, listPets)
+
+	const postPets = `// PostPets POST /api/pets
+func (c *PetsClient) PostPets(ctx context.Context, body PostPetsRequest) (*PostPetsCreatedResponse, error) {
+	path := "/api/pets"
+	req, err := c.newRequest(ctx, "POST", path, nil, body, "application/json")
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		result := &PostPetsCreatedResponse{}
+		if err = decodeResponse(res, result); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+	var errValue any
+	switch res.StatusCode {
+	case 409:
+		errValue = &PostPetsConflictResponse{}
+	}
+	return nil, newApiError(res, errValue)
+}
+`
+	assert.Contains(t, code, postPets)
+
+	const deletePet = `// DeletePet DELETE /api/pets/{petId:[0-9]+}
+func (c *PetsClient) DeletePet(ctx context.Context, petId int) error {
+	path := "/api/pets/" + url.PathEscape(fmt.Sprint(petId))
+	req, err := c.newRequest(ctx, "DELETE", path, nil, nil, "")
+	if err != nil {
+		return err
+	}
+	res, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+	var errValue any
+	switch res.StatusCode {
+	case 404:
+		errValue = &DeletePetsPetIdNotFoundResponse{}
+	}
+	return newApiError(res, errValue)
+}
+`
+	assert.Contains(t, code, deletePet)
+
+	assert.Contains(t, code, `func (c *PetsClient) GetPhoto(ctx context.Context, petId int) ([]byte, error) {`)
+	assert.Contains(t, code, "\t\treturn io.ReadAll(res.Body)\n")
+	assert.Contains(t, code, `func (c *PetsClient) PutPhoto(ctx context.Context, petId int, body io.Reader) error {`)
+	assert.Contains(t, code, `req, err := c.newRequest(ctx, "PUT", path, nil, body, "image/png")`)
+}
+
+func TestGenerateClient_Defaults(t *testing.T) {
+	var buf bytes.Buffer
+	err := GenerateClient(&chioas.Definition{
+		Methods: chioas.Methods{
+			http.MethodGet: {},
+		},
+	}, &buf, ClientOptions{Package: "foo"})
+	require.NoError(t, err)
+	code := buf.String()
+	assert.True(t, strings.HasPrefix(code, "package foo\n"))
+	assert.Contains(t, code, "type Client struct {")
+	assert.Contains(t, code, "func (c *Client) Get(ctx context.Context) error {\n\tpath := \"/\"\n")
+	assert.NotContains(t, code, "// Get GET /")
+}
+
+// testUntypedDefinition is a definition where object schemas have no explicit type (chioas treats these as objects)
+var testUntypedDefinition = chioas.Definition{
+	Paths: chioas.Paths{
+		"/pets": {
+			Methods: chioas.Methods{
+				http.MethodGet: {
+					Responses: chioas.Responses{
+						http.StatusOK: {IsArray: true, SchemaRef: "Pet"},
+					},
+				},
+				http.MethodPost: {
+					Request: &chioas.Request{SchemaRef: "Pet", Required: true},
+					Responses: chioas.Responses{
+						http.StatusCreated: {SchemaRef: "Pet"},
+					},
+				},
+			},
+			Paths: chioas.Paths{
+				"/{petId}": {
+					Methods: chioas.Methods{
+						http.MethodPut: {
+							Request: &chioas.Request{
+								Schema: &chioas.Schema{
+									Properties: chioas.Properties{
+										{Name: "name", Type: "string"},
+										{Name: "owner", SchemaRef: "Owner"},
+									},
+								},
+							},
+							Responses: chioas.Responses{
+								http.StatusOK: {SchemaRef: "Status"},
+							},
+						},
+					},
+				},
+			},
+		},
+		"/shapes": {
+			Methods: chioas.Methods{
+				http.MethodPost: {
+					Request: &chioas.Request{SchemaRef: "Shape", Required: true},
+					Responses: chioas.Responses{
+						http.StatusOK: {SchemaRef: "LabelledShape"},
+					},
+				},
+			},
+		},
+	},
+	Components: &chioas.Components{
+		Schemas: chioas.Schemas{
+			{
+				Name: "Pet",
+				Properties: chioas.Properties{
+					{Name: "name", Type: "string"},
+					{Name: "status", SchemaRef: "Status"},
+					{Name: "owner", SchemaRef: "Owner"},
+				},
+			},
+			{Name: "Owner", Properties: chioas.Properties{{Name: "name", Type: "string"}}},
+			{Name: "Status", Type: "string", Enum: []any{"available", "sold"}},
+			{Name: "Circle", Properties: chioas.Properties{{Name: "shapeType", Type: "string"}, {Name: "radius", Type: "number"}}},
+			{Name: "Square", Properties: chioas.Properties{{Name: "shapeType", Type: "string"}, {Name: "side", Type: "number"}}},
+			{
+				Name: "Shape",
+				Ofs: &chioas.Ofs{
+					OfType: chioas.OneOf,
+					Of:     []chioas.OfSchema{&chioas.Of{SchemaRef: "Circle"}, &chioas.Of{SchemaRef: "Square"}},
+				},
+				Discriminator: &chioas.Discriminator{
+					PropertyName: "shapeType",
+					Mapping:      map[string]string{"circle": "Circle", "square": "Square"},
+				},
+			},
+			{
+				Name: "LabelledShape",
+				Ofs: &chioas.Ofs{
+					OfType: chioas.AllOf,
+					Of: []chioas.OfSchema{
+						&chioas.Of{SchemaRef: "Circle"},
+						&chioas.Of{SchemaDef: &chioas.Schema{Properties: chioas.Properties{{Name: "label", Type: "string"}}}},
+					},
+				},
+			},
+		},
+	},
+}
+
+func TestGenerateClient_UntypedSchemas(t *testing.T) {
+	var buf bytes.Buffer
+	err := GenerateClient(testUntypedDefinition, &buf, ClientOptions{Format: true})
+	require.NoError(t, err)
+	code := buf.String()
+	typeCheck(t, code)
+	assert.Contains(t, code, `func (c *Client) PostPets(ctx context.Context, body PostPetsRequest) (*PostPetsCreatedResponse, error) {`)
+	assert.Contains(t, code, `func (c *Client) PutPetsPetId(ctx context.Context, petId string, body *PutPetsPetIdRequest) (json.RawMessage, error) {`)
+	assert.Contains(t, code, "type PostPetsRequest SchemaPet\n")
+	assert.Contains(t, code, "type SchemaPet struct {\n")
+	assert.Contains(t, code, "type SchemaOwner struct {\n")
+	assert.Contains(t, code, "type PutPetsPetIdRequest struct {\n")
+}
+
+func TestGenerateClient_TypeChecks(t *testing.T) {
+	var buf bytes.Buffer
+	err := GenerateClient(testClientDefinition, &buf, ClientOptions{GoDoc: true, OASTags: true})
+	require.NoError(t, err)
+	typeCheck(t, buf.String())
+}
+
+// typeCheckImporter imports packages from source (shared - so that imported packages are only checked once)
+var (
+	typeCheckFileSet  = token.NewFileSet()
+	typeCheckImporter = importer.ForCompiler(typeCheckFileSet, "source", nil)
+)
+
+// typeCheck checks that generated code compiles
+func typeCheck(t *testing.T, code string) {
+	t.Helper()
+	f, err := parser.ParseFile(typeCheckFileSet, "generated.go", code, 0)
+	require.NoError(t, err)
+	conf := types.Config{Importer: typeCheckImporter}
+	_, err = conf.Check("api", typeCheckFileSet, []*ast.File{f}, nil)
+	require.NoError(t, err, code)
+}
+
+func TestGenerateClient_Errors(t *testing.T) {
+	err := GenerateClient(testClientDefinition, &errorWriter{}, ClientOptions{})
+	require.Error(t, err)
+	err = GenerateClient(testClientDefinition, &errorWriter{}, ClientOptions{Format: true})
+	require.Error(t, err)
+}
+
+type errorWriter struct{}
+
+func (e *errorWriter) Write(p []byte) (n int, err error) {
+	return 0, errors.New("fooey")
+}
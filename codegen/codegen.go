@@ -926,6 +926,21 @@ func compareMethods(ma, mb string) bool {
 	return a < b
 }
 
+// walkMethods calls fn for each method of the definition - root methods first (with an empty path and nil path params)
+// and then the methods of each path (with the merged path params of the path and its ancestors)
+func walkMethods(def chioas.Definition, fn func(path string, pathParams chioas.PathParams, method string, mDef chioas.Method)) {
+	_ = def.Visit(&chioas.Visitor{
+		Method: func(ctx *chioas.VisitContext, m *chioas.Method) (chioas.VisitAction, error) {
+			path := ""
+			if ctx.Parent() != nil {
+				path = ctx.Path
+			}
+			fn(path, ctx.PathParams(), ctx.Method, *m)
+			return chioas.VisitSkip, nil
+		},
+	})
+}
+
 func sortedMethods(methods chioas.Methods) []string {
 	result := make([]string, 0, len(methods))
 	for k := range methods {
@@ -29,6 +29,15 @@ type HandlerStubOptions struct {
 	// Note: using this option means the output will be buffered before writing to the final writer
 	Format  bool
 	UseCRLF bool // true to use \r\n as the line terminator
+	// Typed if set, instead of http handler funcs, generates a Go interface with a typed method for each operation - along
+	// with named param types (implementing typed.NamedPathParam, typed.NamedQueryParam, typed.NamedHeader or
+	// typed.NamedCookie), request/response structs and a Bind func that binds an implementation of the interface to the
+	// chioas.Definition handlers (using typed.NewTypedMethodsHandlerBuilder)
+	//
+	// Note: typed stubs can only be generated for a chioas.Definition (and PublicFuncs, Receiver and PathParams are not used)
+	Typed bool
+	// TypedInterface is the name of the generated interface when Typed is set (default "TypedApi")
+	TypedInterface string
 }
 
 type stubNaming struct{}
@@ -69,19 +78,22 @@ func GenerateHandlerStubs[T StubItemType](item T, w io.Writer, opts HandlerStubO
 	if opts.StubNaming == nil {
 		opts.StubNaming = &stubNaming{}
 	}
+	if opts.Typed {
+		return generateTypedStubs(item, w, opts)
+	}
 	sw := newStubsWriter(w, opts)
 	sw.writePrologue()
 	switch it := any(item).(type) {
 	case chioas.Definition:
-		generateDefinitionStubs(it, sw)
+		generateStubs("Root", it, sw)
 	case *chioas.Definition:
-		generateDefinitionStubs(*it, sw)
+		generateStubs("Root", *it, sw)
 	case chioas.Paths:
-		generatePathsStubs("", it, sw)
+		generateStubs("", chioas.Definition{Paths: it}, sw)
 	case chioas.Path:
-		generatePathStubs("", it, sw)
+		generateStubs("", chioas.Definition{Methods: it.Methods, Paths: it.Paths}, sw)
 	case *chioas.Path:
-		generatePathStubs("", *it, sw)
+		generateStubs("", chioas.Definition{Methods: it.Methods, Paths: it.Paths}, sw)
 	case chioas.Method:
 		generateMethodStub("", "", it, sw)
 	case *chioas.Method:
@@ -90,28 +102,11 @@ func GenerateHandlerStubs[T StubItemType](item T, w io.Writer, opts HandlerStubO
 	return sw.format()
 }
 
-func generateDefinitionStubs(def chioas.Definition, sw *stubsWriter) {
-	generateMethodsStub("Root", def.Methods, sw)
-	generatePathsStubs("", def.Paths, sw)
-}
-
-func generatePathStubs(path string, def chioas.Path, sw *stubsWriter) {
-	generateMethodsStub(path, def.Methods, sw)
-	generatePathsStubs(path, def.Paths, sw)
-}
-
-func generatePathsStubs(path string, def chioas.Paths, sw *stubsWriter) {
-	ks := sortedKeys(def)
-	for _, k := range ks {
-		generatePathStubs(path+k, def[k], sw)
-	}
-}
-
-func generateMethodsStub(path string, def chioas.Methods, sw *stubsWriter) {
-	sms := sortedMethods(def)
-	for _, m := range sms {
-		generateMethodStub(path, m, def[m], sw)
-	}
+// generateStubs generates the stubs for all methods of the definition - where rootPath is the naming path for root methods
+func generateStubs(rootPath string, def chioas.Definition, sw *stubsWriter) {
+	walkMethods(def, func(path string, _ chioas.PathParams, method string, mDef chioas.Method) {
+		generateMethodStub(defValue(path, rootPath), method, mDef, sw)
+	})
 }
 
 var pathSplitter = splitter.MustCreateSplitter('/', splitter.CurlyBrackets).
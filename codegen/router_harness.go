@@ -0,0 +1,112 @@
+package codegen
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultHarnessTestName = "TestGenerateDefinition"
+	defaultHarnessOutFile  = "definition.go"
+	harnessEnvVar          = "CHIOAS_GEN"
+)
+
+// RouterHarnessOptions is the options for GenerateRouterHarness
+type RouterHarnessOptions struct {
+	Package string // e.g. "api" (default "api")
+	// Router is the Go expression (in the package) that yields the chi router - e.g. "newRouter()" (required)
+	Router   string
+	TestName string // name of the generated test func (default "TestGenerateDefinition")
+	OutFile  string // file that the harness writes the definition code to (default "definition.go")
+	// ExcludeMethods is an optional list of http methods not to be included (e.g. "OPTIONS")
+	ExcludeMethods []string
+	// Code is the options passed to GenerateCode by the harness (Package and Format are always set)
+	Code Options
+	// Format if set, formats output in canonical gofmt style (and checks syntax)
+	//
+	// Note: using this option means the output will be buffered before writing to the final writer
+	Format  bool
+	UseCRLF bool // true to use \r\n as the line terminator
+}
+
+// GenerateRouterHarness writes a Go test file (harness) that, when run, builds a chioas.Definition from an
+// existing chi router (using chioas.FromRouter) and writes it out as Go code (using GenerateCode)
+//
+// The generated test is skipped unless the CHIOAS_GEN env var is set - e.g.
+//
+//	CHIOAS_GEN=1 go test -run TestGenerateDefinition
+func GenerateRouterHarness(w io.Writer, opts RouterHarnessOptions) error {
+	if strings.TrimSpace(opts.Router) == "" {
+		return errors.New("router expression must be specified")
+	}
+	pkg := defValue(opts.Package, defaultPackage)
+	testName := defValue(opts.TestName, defaultHarnessTestName)
+	outFile := defValue(opts.OutFile, defaultHarnessOutFile)
+	wr := newWriter(w, opts.Format, opts.UseCRLF)
+	wr.writeLine(0, "package "+pkg, true)
+	wr.writeLine(0, "import (", false)
+	wr.writeLine(1, chioasPkg, false)
+	wr.writeLine(1, `"github.com/go-andiamo/chioas/codegen"`, false)
+	wr.writeLine(1, `"os"`, false)
+	wr.writeLine(1, `"testing"`, false)
+	wr.writeLine(0, ")", true)
+	wr.writeLine(0, "// "+testName+" generates "+outFile+" from the router", false)
+	wr.writeLine(0, "//", false)
+	wr.writeLine(0, "// run with: "+harnessEnvVar+"=1 go test -run "+testName, false)
+	wr.writeLine(0, "func "+testName+"(t *testing.T) {", false)
+	wr.writeLine(1, "if os.Getenv("+strconv.Quote(harnessEnvVar)+") == \"\" {", false)
+	wr.writeLine(2, "t.Skip("+strconv.Quote("set "+harnessEnvVar+" env var to generate "+outFile)+")", false)
+	wr.writeLine(1, "}", false)
+	routerOpts := "nil"
+	if len(opts.ExcludeMethods) > 0 {
+		xms := make([]string, 0, len(opts.ExcludeMethods))
+		for _, xm := range opts.ExcludeMethods {
+			xms = append(xms, strconv.Quote(strings.ToUpper(xm)))
+		}
+		routerOpts = "&chioas.FromRouterOptions{ExcludeMethods: []string{" + strings.Join(xms, ", ") + "}}"
+	}
+	wr.writeLine(1, "def, err := chioas.FromRouter("+opts.Router+", "+routerOpts+")", false)
+	wr.writeLine(1, "if err != nil {", false)
+	wr.writeLine(2, "t.Fatal(err)", false)
+	wr.writeLine(1, "}", false)
+	wr.writeLine(1, "f, err := os.Create("+strconv.Quote(outFile)+")", false)
+	wr.writeLine(1, "if err != nil {", false)
+	wr.writeLine(2, "t.Fatal(err)", false)
+	wr.writeLine(1, "}", false)
+	wr.writeLine(1, "defer func() {", false)
+	wr.writeLine(2, "_ = f.Close()", false)
+	wr.writeLine(1, "}()", false)
+	wr.writeLine(1, "if err = codegen.GenerateCode(def, f, "+harnessCodeOptions(pkg, opts.Code)+"); err != nil {", false)
+	wr.writeLine(2, "t.Fatal(err)", false)
+	wr.writeLine(1, "}", false)
+	wr.writeLine(0, "}", false)
+	return wr.format()
+}
+
+// harnessCodeOptions returns the Go literal for the code options used by the harness
+func harnessCodeOptions(pkg string, opts Options) string {
+	fields := []string{"Package: " + strconv.Quote(pkg)}
+	addString := func(name string, v string) {
+		if v != "" {
+			fields = append(fields, name+": "+strconv.Quote(v))
+		}
+	}
+	addBool := func(name string, v bool) {
+		if v {
+			fields = append(fields, name+": true")
+		}
+	}
+	addString("VarName", opts.VarName)
+	addString("ImportAlias", opts.ImportAlias)
+	addBool("OmitZeroValues", opts.OmitZeroValues)
+	addBool("HoistPaths", opts.HoistPaths)
+	addBool("HoistComponents", opts.HoistComponents)
+	addBool("PublicVars", opts.PublicVars)
+	addBool("UseHttpConsts", opts.UseHttpConsts)
+	addBool("InlineHandlers", opts.InlineHandlers)
+	addBool("UseCRLF", opts.UseCRLF)
+	fields = append(fields, "Format: true")
+	return "codegen.Options{" + strings.Join(fields, ", ") + "}"
+}
@@ -0,0 +1,81 @@
+package codegen
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestGenerateRouterHarness(t *testing.T) {
+	var buf bytes.Buffer
+	err := GenerateRouterHarness(&buf, RouterHarnessOptions{
+		Router:         "newRouter()",
+		ExcludeMethods: []string{"options"},
+		Code:           Options{VarName: "Spec", UseHttpConsts: true},
+		Format:         true,
+	})
+	require.NoError(t, err)
+	const expect = `package api
+
+import (
+	"github.com/go-andiamo/chioas"
+	"github.com/go-andiamo/chioas/codegen"
+	"os"
+	"testing"
+)
+
+// TestGenerateDefinition generates definition.go from the router
+//
+// run with: CHIOAS_GEN=1 go test -run TestGenerateDefinition
+func TestGenerateDefinition(t *testing.T) {
+	if os.Getenv("CHIOAS_GEN") == "" {
+		t.Skip("set CHIOAS_GEN env var to generate definition.go")
+	}
+	def, err := chioas.FromRouter(newRouter(), &chioas.FromRouterOptions{ExcludeMethods: []string{"OPTIONS"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create("definition.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	if err = codegen.GenerateCode(def, f, codegen.Options{Package: "api", VarName: "Spec", UseHttpConsts: true, Format: true}); err != nil {
+		t.Fatal(err)
+	}
+}
+`
+	assert.Equal(t, expect, buf.String())
+}
+
+func TestGenerateRouterHarness_Options(t *testing.T) {
+	var buf bytes.Buffer
+	err := GenerateRouterHarness(&buf, RouterHarnessOptions{
+		Package:  "foo",
+		Router:   "api.Router",
+		TestName: "TestGen",
+		OutFile:  "spec.go",
+		UseCRLF:  true,
+	})
+	require.NoError(t, err)
+	code := buf.String()
+	assert.Contains(t, code, "package foo\r\n")
+	assert.Contains(t, code, "func TestGen(t *testing.T) {\r\n")
+	assert.Contains(t, code, "\tdef, err := chioas.FromRouter(api.Router, nil)\r\n")
+	assert.Contains(t, code, `os.Create("spec.go")`)
+	assert.Contains(t, code, `codegen.Options{Package: "foo", Format: true}`)
+}
+
+func TestGenerateRouterHarness_Errors(t *testing.T) {
+	err := GenerateRouterHarness(&bytes.Buffer{}, RouterHarnessOptions{})
+	require.Error(t, err)
+	assert.Equal(t, "router expression must be specified", err.Error())
+
+	err = GenerateRouterHarness(&errorWriter{}, RouterHarnessOptions{Router: "r"})
+	require.Error(t, err)
+	err = GenerateRouterHarness(&bytes.Buffer{}, RouterHarnessOptions{Router: "r(", Format: true})
+	require.Error(t, err)
+}
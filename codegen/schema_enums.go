@@ -0,0 +1,298 @@
+package codegen
+
+import (
+	"github.com/go-andiamo/chioas"
+	"github.com/go-andiamo/chioas/internal/refs"
+	"github.com/go-andiamo/chioas/internal/tags"
+	"github.com/go-andiamo/chioas/internal/values"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// enumDef is an enum type to be written (see structsWriter.writeEnum)
+type enumDef struct {
+	name   string
+	goType string // underlying Go type - "string", "int" or "float64"
+	values []any
+	ref    string // the components schema name (if hoisted by $ref)
+	usage  string // for godoc - where the enum is used
+}
+
+// enumGoType returns the underlying Go type for an enum of the OAS type (empty string if enum type not supported)
+func enumGoType(oasType string, enum []any) string {
+	if len(enumValues(enum)) == 0 {
+		return ""
+	}
+	switch oasType {
+	case "", values.TypeString:
+		return "string"
+	case values.TypeInteger:
+		return "int"
+	case values.TypeNumber:
+		return "float64"
+	}
+	return ""
+}
+
+// enumValues returns the non-null enum values
+func enumValues(enum []any) []any {
+	result := make([]any, 0, len(enum))
+	for _, e := range enum {
+		if e != nil {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// propertyEnum determines the enum type (if any) for a property - returning the type name and the
+// oas type/enum values for the property (so that the `oas` tag round-trips)
+//
+// enums referenced by $ref are hoisted (issued once per components schema) - inline enums are named
+// by owner and property name and written after the owning struct
+func (w *structsWriter) propertyEnum(owner string, pty chioas.Property) (name string, oasType string, enum []any) {
+	if !w.opts.EnumTypes {
+		return "", "", nil
+	}
+	if pty.SchemaRef != "" {
+		if s, aref, err := w.resolveSchema(pty.SchemaRef, nil); err == nil {
+			if goType := enumGoType(s.Type, s.Enum); goType != "" {
+				return w.issueEnum(aref, goType, s.Enum), defValue(s.Type, values.TypeString), s.Enum
+			}
+		}
+	} else if goType := enumGoType(pty.Type, pty.Enum); goType != "" {
+		name = w.deduper.take(w.scopedName(owner + toPascal(pty.Name)))
+		w.pendingEnums = append(w.pendingEnums, enumDef{
+			name:   name,
+			goType: goType,
+			values: pty.Enum,
+			usage:  owner + "." + toPascal(pty.Name),
+		})
+		return name, defValue(pty.Type, values.TypeString), pty.Enum
+	}
+	return "", "", nil
+}
+
+func (w *structsWriter) issueEnum(aref string, goType string, enum []any) string {
+	if e, ok := w.issuedEnums[aref]; ok {
+		return e.name
+	}
+	name := w.deduper.take(w.scopedName(toPascal("schema " + aref)))
+	w.issuedEnums[aref] = enumDef{
+		name:   name,
+		goType: goType,
+		values: enum,
+		ref:    aref,
+	}
+	return name
+}
+
+// issueComponentEnums issues all enum schemas in the components (so that they are written even if not referenced)
+func (w *structsWriter) issueComponentEnums(components *chioas.Components) {
+	if w.opts.EnumTypes && components != nil {
+		for _, s := range components.Schemas {
+			if s.SchemaRef == "" {
+				if goType := enumGoType(s.Type, s.Enum); goType != "" {
+					w.issueEnum(s.Name, goType, s.Enum)
+				}
+			}
+		}
+	}
+}
+
+func (w *structsWriter) writePendingEnums() {
+	pending := w.pendingEnums
+	w.pendingEnums = nil
+	for _, e := range pending {
+		w.writeEnum(e)
+	}
+}
+
+func (w *structsWriter) generateIssuedEnums() {
+	if len(w.issuedEnums) > 0 {
+		enums := make([]enumDef, 0, len(w.issuedEnums))
+		for _, e := range w.issuedEnums {
+			enums = append(enums, e)
+		}
+		sort.Slice(enums, func(i, j int) bool {
+			return enums[i].name < enums[j].name
+		})
+		for _, e := range enums {
+			w.writeEnum(e)
+		}
+	}
+}
+
+func (w *structsWriter) writeEnum(e enumDef) {
+	if w.err != nil {
+		return
+	}
+	w.imports["fmt"] = struct{}{}
+	if e.goType != "string" {
+		w.imports["strconv"] = struct{}{}
+	}
+	if w.opts.GoDoc {
+		if e.ref != "" {
+			w.writeLine(0, "// "+e.name+" enum "+refs.ComponentsPrefix+tags.Schemas+"/"+e.ref, false)
+		} else {
+			w.writeLine(0, "// "+e.name+" enum for "+e.usage, false)
+		}
+	}
+	w.writeLine(0, "type "+e.name+" "+e.goType, true)
+	consts := make([]string, 0, len(e.values))
+	if values := enumValues(e.values); len(values) > 0 {
+		w.writeLine(0, "const (", false)
+		for _, v := range values {
+			if literal, ok := enumLiteral(e.goType, v); ok {
+				cName := w.deduper.take(e.name + enumConstSuffix(literal))
+				consts = append(consts, cName)
+				w.writeLine(1, cName+" "+e.name+" = "+literal, false)
+			}
+		}
+		w.writeLine(0, ")", true)
+	}
+	w.writeEnumGoDoc("IsValid returns whether the value is one of the enum values")
+	w.writeLine(0, "func (e "+e.name+") IsValid() bool {", false)
+	if len(consts) > 0 {
+		w.writeLine(1, "switch e {", false)
+		w.writeLine(1, "case "+strings.Join(consts, ", ")+":", false)
+		w.writeLine(2, "return true", false)
+		w.writeLine(1, "}", false)
+	}
+	w.writeLine(1, "return false", false)
+	w.writeLine(0, "}", true)
+	w.writeEnumGoDoc("MarshalText implements encoding.TextMarshaler (erroring if the value is not one of the enum values)")
+	w.writeLine(0, "func (e "+e.name+") MarshalText() ([]byte, error) {", false)
+	w.writeLine(1, "if !e.IsValid() {", false)
+	if e.goType == "string" {
+		w.writeLine(2, "return nil, fmt.Errorf(\"invalid "+e.name+" value: %q\", string(e))", false)
+	} else {
+		w.writeLine(2, "return nil, fmt.Errorf(\"invalid "+e.name+" value: %v\", "+e.goType+"(e))", false)
+	}
+	w.writeLine(1, "}", false)
+	switch e.goType {
+	case "string":
+		w.writeLine(1, "return []byte(e), nil", false)
+	case "int":
+		w.writeLine(1, "return []byte(strconv.Itoa(int(e))), nil", false)
+	default:
+		w.writeLine(1, "return []byte(strconv.FormatFloat(float64(e), 'f', -1, 64)), nil", false)
+	}
+	w.writeLine(0, "}", true)
+	w.writeEnumGoDoc("UnmarshalText implements encoding.TextUnmarshaler (erroring if the value is not one of the enum values)")
+	w.writeLine(0, "func (e *"+e.name+") UnmarshalText(text []byte) error {", false)
+	switch e.goType {
+	case "string":
+		w.writeLine(1, "v := "+e.name+"(text)", false)
+	case "int":
+		w.writeLine(1, "i, err := strconv.Atoi(string(text))", false)
+		w.writeLine(1, "if err != nil {", false)
+		w.writeLine(2, "return fmt.Errorf(\"invalid "+e.name+" value: %q\", text)", false)
+		w.writeLine(1, "}", false)
+		w.writeLine(1, "v := "+e.name+"(i)", false)
+	default:
+		w.writeLine(1, "f, err := strconv.ParseFloat(string(text), 64)", false)
+		w.writeLine(1, "if err != nil {", false)
+		w.writeLine(2, "return fmt.Errorf(\"invalid "+e.name+" value: %q\", text)", false)
+		w.writeLine(1, "}", false)
+		w.writeLine(1, "v := "+e.name+"(f)", false)
+	}
+	w.writeLine(1, "if !v.IsValid() {", false)
+	w.writeLine(2, "return fmt.Errorf(\"invalid "+e.name+" value: %q\", text)", false)
+	w.writeLine(1, "}", false)
+	w.writeLine(1, "*e = v", false)
+	w.writeLine(1, "return nil", false)
+	w.writeLine(0, "}", true)
+	if e.goType != "string" {
+		// numeric enums must marshal as JSON numbers (json would otherwise use the text marshaling and quote them)...
+		w.writeEnumGoDoc("MarshalJSON implements json.Marshaler")
+		w.writeLine(0, "func (e "+e.name+") MarshalJSON() ([]byte, error) {", false)
+		w.writeLine(1, "return e.MarshalText()", false)
+		w.writeLine(0, "}", true)
+		w.writeEnumGoDoc("UnmarshalJSON implements json.Unmarshaler")
+		w.writeLine(0, "func (e *"+e.name+") UnmarshalJSON(data []byte) error {", false)
+		w.writeLine(1, "if string(data) == \"null\" {", false)
+		w.writeLine(2, "return nil", false)
+		w.writeLine(1, "}", false)
+		w.writeLine(1, "return e.UnmarshalText(data)", false)
+		w.writeLine(0, "}", true)
+	}
+}
+
+func (w *structsWriter) writeEnumGoDoc(doc string) {
+	if w.opts.GoDoc {
+		w.writeLine(0, "// "+doc, false)
+	}
+}
+
+// enumLiteral returns the Go literal for an enum value (false if the value is not of the enum type)
+func enumLiteral(goType string, v any) (string, bool) {
+	switch goType {
+	case "string":
+		if s, ok := v.(string); ok {
+			return strconv.Quote(s), true
+		}
+	case "int":
+		switch vt := v.(type) {
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			return strconv.FormatInt(toInt64(vt), 10), true
+		case float32:
+			if float64(vt) == math.Trunc(float64(vt)) {
+				return strconv.FormatInt(int64(vt), 10), true
+			}
+		case float64:
+			if vt == math.Trunc(vt) {
+				return strconv.FormatInt(int64(vt), 10), true
+			}
+		}
+	case "float64":
+		switch vt := v.(type) {
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			return strconv.FormatInt(toInt64(vt), 10), true
+		case float32:
+			return strconv.FormatFloat(float64(vt), 'f', -1, 32), true
+		case float64:
+			return strconv.FormatFloat(vt, 'f', -1, 64), true
+		}
+	}
+	return "", false
+}
+
+func toInt64(v any) int64 {
+	switch vt := v.(type) {
+	case int:
+		return int64(vt)
+	case int8:
+		return int64(vt)
+	case int16:
+		return int64(vt)
+	case int32:
+		return int64(vt)
+	case int64:
+		return vt
+	case uint:
+		return int64(vt)
+	case uint8:
+		return int64(vt)
+	case uint16:
+		return int64(vt)
+	case uint32:
+		return int64(vt)
+	case uint64:
+		return int64(vt)
+	}
+	return 0
+}
+
+// enumConstSuffix returns the suffix (appended to the enum type name) for an enum const name
+func enumConstSuffix(literal string) string {
+	if s, err := strconv.Unquote(literal); err == nil {
+		if result := toPascal(s); result != "" {
+			return result
+		}
+		return "Empty"
+	}
+	return strings.NewReplacer("-", "Minus", ".", "_").Replace(literal)
+}
@@ -0,0 +1,214 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/go-andiamo/chioas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+var testEnumsDefinition = chioas.Definition{
+	Paths: chioas.Paths{
+		"/pets": {
+			Methods: chioas.Methods{
+				"POST": {
+					Request: &chioas.Request{
+						Schema: &chioas.Schema{
+							Type:               "object",
+							RequiredProperties: []string{"status"},
+							Properties: chioas.Properties{
+								{Name: "status", SchemaRef: "Status"},
+								{Name: "other", SchemaRef: "Status"},
+								{Name: "size", Enum: []any{"S", "x-large", "", nil}},
+								{Name: "level", Type: "integer", Enum: []any{1, 2.0, -3}},
+								{Name: "ratio", Type: "number", Enum: []any{1.5, 2}, Required: true},
+								{Name: "sub", Type: "object", Properties: chioas.Properties{{Name: "kind", Enum: []any{"a", "b"}}}},
+								{Name: "flag", Type: "boolean", Enum: []any{true}},
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+	Components: &chioas.Components{
+		Schemas: chioas.Schemas{
+			{Name: "Status", Type: "string", Enum: []any{"on", "off"}},
+			{Name: "Unused", Type: "integer", Enum: []any{1}},
+		},
+	},
+}
+
+func TestGenerateSchemaStructs_EnumTypes(t *testing.T) {
+	var buf bytes.Buffer
+	err := GenerateSchemaStructs(testEnumsDefinition, &buf, SchemaStructOptions{
+		EnumTypes:               true,
+		Format:                  true,
+		OASTags:                 true,
+		GoDoc:                   true,
+		PublicStructs:           true,
+		KeepComponentProperties: true,
+	})
+	require.NoError(t, err)
+	code := buf.String()
+	const expectStart = `package api
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// PostPetsRequest request POST /pets
+type PostPetsRequest struct {
+	Flag   *bool                 ~json:"flag" oas:"type:boolean,enum:[true]"~
+	Level  *PostPetsRequestLevel ~json:"level" oas:"type:integer,enum:[1,2,-3]"~
+	Other  *SchemaStatus         ~json:"other" oas:"$ref:'Status'"~
+	Ratio  PostPetsRequestRatio  ~json:"ratio" oas:"required,type:number,enum:[1.5,2]"~
+	Size   *PostPetsRequestSize  ~json:"size" oas:"type:string,enum:[\"S\",\"x-large\",\"\"]"~
+	Status SchemaStatus          ~json:"status" oas:"required,$ref:'Status'"~
+	Sub    *struct {
+		Kind *PostPetsRequestSubKind ~json:"kind" oas:"type:string,enum:[\"a\",\"b\"]"~
+	} ~json:"sub" oas:"type:object"~
+}
+
+// PostPetsRequestLevel enum for PostPetsRequest.Level
+type PostPetsRequestLevel int
+
+const (
+	PostPetsRequestLevel1      PostPetsRequestLevel = 1
+	PostPetsRequestLevel2      PostPetsRequestLevel = 2
+	PostPetsRequestLevelMinus3 PostPetsRequestLevel = -3
+)
+
+// IsValid returns whether the value is one of the enum values
+func (e PostPetsRequestLevel) IsValid() bool {
+	switch e {
+	case PostPetsRequestLevel1, PostPetsRequestLevel2, PostPetsRequestLevelMinus3:
+		return true
+	}
+	return false
+}
+`
+	assert.True(t, strings.HasPrefix(code, strings.ReplaceAll(expectStart, "~", "`")), code)
+	assert.Contains(t, code, `// UnmarshalJSON implements json.Unmarshaler
+func (e *PostPetsRequestLevel) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	return e.UnmarshalText(data)
+}
+`)
+	assert.Contains(t, code, `const (
+	PostPetsRequestRatio1_5 PostPetsRequestRatio = 1.5
+	PostPetsRequestRatio2   PostPetsRequestRatio = 2
+)
+`)
+	assert.Contains(t, code, `const (
+	PostPetsRequestSizeS      PostPetsRequestSize = "S"
+	PostPetsRequestSizeXLarge PostPetsRequestSize = "x-large"
+	PostPetsRequestSizeEmpty  PostPetsRequestSize = ""
+)
+`)
+	assert.Contains(t, code, `// UnmarshalText implements encoding.TextUnmarshaler (erroring if the value is not one of the enum values)
+func (e *PostPetsRequestSize) UnmarshalText(text []byte) error {
+	v := PostPetsRequestSize(text)
+	if !v.IsValid() {
+		return fmt.Errorf("invalid PostPetsRequestSize value: %q", text)
+	}
+	*e = v
+	return nil
+}
+`)
+	assert.NotContains(t, code, "func (e PostPetsRequestSize) MarshalJSON")
+	assert.Contains(t, code, "type PostPetsRequestSubKind string\n")
+	// hoisted enums written once...
+	assert.Equal(t, 1, strings.Count(code, "type SchemaStatus string\n"))
+	assert.Contains(t, code, "// SchemaStatus enum #/components/schemas/Status\n")
+	assert.Contains(t, code, "// SchemaUnused enum #/components/schemas/Unused\ntype SchemaUnused int\n")
+}
+
+func TestGenerateSchemaStructs_EnumTypesRefTags(t *testing.T) {
+	// the oas tag for an enum $ref property should read back as the $ref (not the inlined enum)...
+	type sample struct {
+		Other  *string `json:"other" oas:"$ref:'Status'"`
+		Status string  `json:"status" oas:"required,$ref:'Status'"`
+	}
+	s, err := chioas.SchemaFrom(sample{})
+	require.NoError(t, err)
+	require.Len(t, s.Properties, 2)
+	for _, pty := range s.Properties {
+		assert.Equal(t, "Status", pty.SchemaRef)
+		assert.Empty(t, pty.Enum)
+	}
+	assert.Equal(t, []string{"status"}, s.RequiredProperties)
+}
+
+func TestGenerateSchemaStructs_EnumTypesOff(t *testing.T) {
+	var buf bytes.Buffer
+	err := GenerateSchemaStructs(testEnumsDefinition, &buf, SchemaStructOptions{Format: true})
+	require.NoError(t, err)
+	code := buf.String()
+	assert.True(t, strings.HasPrefix(code, "package api\n\ntype postPetsRequest struct {\n"), code)
+	assert.Contains(t, code, "\tLevel  *int ")
+	assert.NotContains(t, code, "IsValid")
+}
+
+func TestGenerateSchemaStructs_EnumTypesComponents(t *testing.T) {
+	var buf bytes.Buffer
+	err := GenerateSchemaStructs(testEnumsDefinition.Components, &buf, SchemaStructOptions{EnumTypes: true})
+	require.NoError(t, err)
+	code := buf.String()
+	assert.Contains(t, code, "type schemaStatus string\n")
+	assert.Contains(t, code, "\tschemaStatusOn schemaStatus = \"on\"\n")
+	assert.Contains(t, code, "type schemaUnused int\n")
+	assert.Contains(t, code, "\t\"strconv\"\n")
+}
+
+func Test_enumLiteral(t *testing.T) {
+	testCases := []struct {
+		goType string
+		value  any
+		expect string
+		ok     bool
+	}{
+		{goType: "string", value: "a", expect: `"a"`, ok: true},
+		{goType: "string", value: 1},
+		{goType: "int", value: 1, expect: "1", ok: true},
+		{goType: "int", value: uint8(2), expect: "2", ok: true},
+		{goType: "int", value: 3.0, expect: "3", ok: true},
+		{goType: "int", value: float32(4), expect: "4", ok: true},
+		{goType: "int", value: 1.5},
+		{goType: "int", value: "1"},
+		{goType: "float64", value: 1, expect: "1", ok: true},
+		{goType: "float64", value: 1.5, expect: "1.5", ok: true},
+		{goType: "float64", value: float32(2.5), expect: "2.5", ok: true},
+		{goType: "float64", value: true},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]", i+1), func(t *testing.T) {
+			literal, ok := enumLiteral(tc.goType, tc.value)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.expect, literal)
+		})
+	}
+}
+
+func Test_enumConstSuffix(t *testing.T) {
+	testCases := map[string]string{
+		`"foo"`:      "Foo",
+		`"in-stock"`: "InStock",
+		`""`:         "Empty",
+		`"-"`:        "Empty",
+		"1":          "1",
+		"-1":         "Minus1",
+		"1.5":        "1_5",
+	}
+	for literal, expect := range testCases {
+		t.Run(literal, func(t *testing.T) {
+			assert.Equal(t, expect, enumConstSuffix(literal))
+		})
+	}
+}
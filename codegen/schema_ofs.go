@@ -0,0 +1,316 @@
+package codegen
+
+import (
+	"github.com/go-andiamo/chioas"
+	"github.com/go-andiamo/chioas/internal/refs"
+	"github.com/go-andiamo/chioas/internal/tags"
+	"github.com/go-andiamo/chioas/internal/values"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// isOfsSchema returns whether the schema is a composition (oneOf, anyOf, allOf or discriminator mapping)
+func isOfsSchema(s *chioas.Schema) bool {
+	return s.SchemaRef == "" && ((s.Ofs != nil && len(s.Ofs.Of) > 0) ||
+		(s.Discriminator != nil && s.Discriminator.PropertyName != "" && len(s.Discriminator.Mapping) > 0))
+}
+
+// isAllOfSchema returns whether the schema is an allOf composition
+func isAllOfSchema(s *chioas.Schema) bool {
+	return s.SchemaRef == "" && s.Ofs != nil && len(s.Ofs.Of) > 0 && s.Ofs.OfType == chioas.AllOf
+}
+
+// isObjectSchema returns whether the schema is an object schema - as with chioas, an empty type is an object (where
+// the schema has properties or ofs)
+func isObjectSchema(s *chioas.Schema) bool {
+	return s.Type == values.TypeObject || (s.Type == "" && (len(s.Properties) > 0 || (s.Ofs != nil && len(s.Ofs.Of) > 0)))
+}
+
+// isStructSchema returns whether a struct (or union struct) is generated for the schema
+func isStructSchema(s *chioas.Schema) bool {
+	return isObjectSchema(s) || isOfsSchema(s)
+}
+
+// unionVariant is a variant of a oneOf/anyOf union
+type unionVariant struct {
+	typeName string
+	values   []string       // discriminator values
+	inline   *chioas.Schema // inline object schema (written as a variant struct after the union)
+	goType   string         // underlying Go type for primitive variants (written as a named type)
+}
+
+// generateOfsStruct generates a schema with Ofs - allOf as a struct embedding the composed parts, oneOf/anyOf
+// as a union struct (holding a sealed interface value) with json marshaling dispatched on the discriminator
+func generateOfsStruct(name string, info *pathInfo, def chioas.Schema, sw *structsWriter) {
+	if isAllOfSchema(&def) {
+		sw.writeAllOf(name, info, def)
+	} else {
+		sw.writeUnion(name, info, def)
+	}
+}
+
+func (w *structsWriter) writeAllOf(name string, info *pathInfo, def chioas.Schema) {
+	if w.writeStructGoDoc(name, info) {
+		w.writeLine(0, "type "+name+" struct {", false)
+		ptys := append(chioas.Properties{}, def.Properties...)
+		reqdPtys := append([]string{}, def.RequiredProperties...)
+		for _, of := range def.Ofs.Of {
+			if of.IsRef() {
+				if s, aref, err := w.resolveSchema(of.Ref(), nil); err != nil {
+					w.writeLine(1, "// error - "+err.Error(), false)
+				} else if isAllOfSchema(s) || (isObjectSchema(s) && !isOfsSchema(s)) {
+					w.writeLine(1, w.issueSchema(aref), false)
+				} else {
+					w.writeLine(1, "// "+of.Ref()+" - not embedded (not an object schema)", false)
+				}
+			} else if s := of.Schema(); s != nil {
+				ptys = append(ptys, s.Properties...)
+				reqdPtys = append(reqdPtys, s.RequiredProperties...)
+			}
+		}
+		slices.SortStableFunc(ptys, func(a, b chioas.Property) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+		for _, pty := range ptys {
+			w.writeProperty(1, name, pty, reqdPtys)
+		}
+		w.writeLine(0, "}", true)
+		w.writePendingEnums()
+	}
+}
+
+func (w *structsWriter) writeUnion(name string, info *pathInfo, def chioas.Schema) {
+	w.imports["encoding/json"] = struct{}{}
+	w.imports["fmt"] = struct{}{}
+	iName := w.deduper.take(name + "Variant")
+	marker := "is" + strings.ToUpper(name[:1]) + name[1:]
+	variants := w.unionVariants(name, def)
+	if !w.writeStructGoDoc(name, info) {
+		return
+	}
+	w.writeLine(0, "type "+name+" struct {", false)
+	w.writeLine(1, "Value "+iName, false)
+	w.writeLine(0, "}", true)
+	if w.opts.GoDoc {
+		w.writeLine(0, "// "+iName+" is implemented by each variant of "+name, false)
+	}
+	w.writeLine(0, "type "+iName+" interface {", false)
+	w.writeLine(1, marker+"()", false)
+	w.writeLine(0, "}", true)
+	for _, v := range variants {
+		if v.goType != "" {
+			w.writeLine(0, "type "+v.typeName+" "+v.goType, true)
+		}
+		w.writeLine(0, "func ("+v.typeName+") "+marker+"() {}", true)
+	}
+	w.writeLine(0, "func (v "+name+") MarshalJSON() ([]byte, error) {", false)
+	if d := def.Discriminator; d != nil && d.PropertyName != "" && hasDiscriminatedVariants(variants) {
+		// set the discriminator property (so that the json unmarshals back to the same variant)...
+		w.needsMarshalDiscriminated = true
+		w.writeLine(1, "switch value := v.Value.(type) {", false)
+		for _, v := range variants {
+			if v.goType == "" && len(v.values) > 0 {
+				w.writeLine(1, "case "+v.typeName+", *"+v.typeName+":", false)
+				w.writeLine(2, "return marshalDiscriminated(value, "+strconv.Quote(d.PropertyName)+", "+v.values[0]+")", false)
+			}
+		}
+		w.writeLine(1, "}", false)
+	}
+	w.writeLine(1, "return json.Marshal(v.Value)", false)
+	w.writeLine(0, "}", true)
+	w.writeLine(0, "func (v *"+name+") UnmarshalJSON(data []byte) error {", false)
+	w.writeLine(1, "if string(data) == \"null\" {", false)
+	w.writeLine(2, "v.Value = nil", false)
+	w.writeLine(2, "return nil", false)
+	w.writeLine(1, "}", false)
+	if d := def.Discriminator; d != nil && d.PropertyName != "" {
+		w.writeLine(1, "var d struct {", false)
+		w.writeLine(2, "Value string `json:"+strconv.Quote(d.PropertyName)+"`", false)
+		w.writeLine(1, "}", false)
+		w.writeLine(1, "if err := json.Unmarshal(data, &d); err != nil {", false)
+		w.writeLine(2, "return err", false)
+		w.writeLine(1, "}", false)
+		w.writeLine(1, "switch d.Value {", false)
+		for _, v := range variants {
+			if len(v.values) > 0 {
+				w.writeLine(1, "case "+strings.Join(v.values, ", ")+":", false)
+				w.writeLine(2, "var value "+v.typeName, false)
+				w.writeLine(2, "if err := json.Unmarshal(data, &value); err != nil {", false)
+				w.writeLine(3, "return err", false)
+				w.writeLine(2, "}", false)
+				w.writeLine(2, "v.Value = value", false)
+			}
+		}
+		w.writeLine(1, "default:", false)
+		w.writeLine(2, "return fmt.Errorf(\"unknown "+name+" discriminator value: %q\", d.Value)", false)
+		w.writeLine(1, "}", false)
+		w.writeLine(1, "return nil", false)
+	} else {
+		// no discriminator - the first variant that strictly matches...
+		w.needsUnmarshalVariant = true
+		for _, v := range variants {
+			w.writeLine(1, "{", false)
+			w.writeLine(2, "var value "+v.typeName, false)
+			w.writeLine(2, "if err := unmarshalVariant(data, &value); err == nil {", false)
+			w.writeLine(3, "v.Value = value", false)
+			w.writeLine(3, "return nil", false)
+			w.writeLine(2, "}", false)
+			w.writeLine(1, "}", false)
+		}
+		w.writeLine(1, "return fmt.Errorf(\"json does not match any "+name+" variant\")", false)
+	}
+	w.writeLine(0, "}", true)
+	for _, v := range variants {
+		if v.inline != nil {
+			vs := *v.inline
+			if !isOfsSchema(&vs) {
+				vs.Type = values.TypeObject
+			}
+			generateSchemaStruct(&pathInfo{name: v.typeName, path: "variant of " + name, explicitName: true}, vs, w)
+		}
+	}
+}
+
+// hasDiscriminatedVariants returns whether any of the variants is a struct with discriminator values
+func hasDiscriminatedVariants(variants []unionVariant) bool {
+	return slices.ContainsFunc(variants, func(v unionVariant) bool {
+		return v.goType == "" && len(v.values) > 0
+	})
+}
+
+func (w *structsWriter) unionVariants(name string, def chioas.Schema) []unionVariant {
+	result := make([]unionVariant, 0)
+	d := def.Discriminator
+	if d != nil && d.PropertyName == "" {
+		d = nil
+	}
+	if def.Ofs != nil && len(def.Ofs.Of) > 0 {
+		for i, of := range def.Ofs.Of {
+			if of.IsRef() {
+				if v, ok := w.refVariant(name, i+1, of.Ref()); ok {
+					if d != nil {
+						v.values = discriminatorValues(d, refs.Normalize(tags.Schemas, of.Ref()))
+					}
+					result = append(result, v)
+				}
+			} else if s := of.Schema(); s != nil {
+				vName := w.deduper.take(name + "Variant" + strconv.Itoa(i+1))
+				if isStructSchema(s) || len(s.Properties) > 0 {
+					result = append(result, unionVariant{typeName: vName, inline: s})
+				} else {
+					result = append(result, unionVariant{typeName: vName, goType: variantGoType(s)})
+				}
+			}
+		}
+	} else if d != nil {
+		// discriminator without ofs - the variants are the mapped schemas...
+		seen := map[string]bool{}
+		for i, k := range sortedKeys(d.Mapping) {
+			ref := refs.Normalize(tags.Schemas, d.Mapping[k])
+			if !seen[ref] {
+				seen[ref] = true
+				if v, ok := w.refVariant(name, i+1, ref); ok {
+					v.values = discriminatorValues(d, ref)
+					result = append(result, v)
+				}
+			}
+		}
+	}
+	return mergeVariants(result)
+}
+
+// mergeVariants merges variants of the same type (a type can only implement the variant interface once)
+func mergeVariants(variants []unionVariant) []unionVariant {
+	result := make([]unionVariant, 0, len(variants))
+	indices := map[string]int{}
+	for _, v := range variants {
+		if i, ok := indices[v.typeName]; ok {
+			for _, dv := range v.values {
+				if !slices.Contains(result[i].values, dv) {
+					result[i].values = append(result[i].values, dv)
+				}
+			}
+		} else {
+			indices[v.typeName] = len(result)
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func (w *structsWriter) refVariant(name string, n int, ref string) (unionVariant, bool) {
+	s, aref, err := w.resolveSchema(ref, nil)
+	if err != nil {
+		return unionVariant{}, false
+	}
+	if isStructSchema(s) {
+		return unionVariant{typeName: w.issueSchema(aref)}, true
+	}
+	return unionVariant{typeName: w.deduper.take(name + "Variant" + strconv.Itoa(n)), goType: variantGoType(s)}, true
+}
+
+func variantGoType(s *chioas.Schema) string {
+	if t := primitiveGoType(s.Type); t != "any" {
+		return t
+	}
+	// methods cannot be declared on an interface type...
+	return "json.RawMessage"
+}
+
+// discriminatorValues returns the quoted discriminator values for a schema ref (the schema name if not mapped)
+func discriminatorValues(d *chioas.Discriminator, ref string) []string {
+	result := make([]string, 0)
+	for _, k := range sortedKeys(d.Mapping) {
+		if refs.Normalize(tags.Schemas, d.Mapping[k]) == ref {
+			result = append(result, strconv.Quote(k))
+		}
+	}
+	if len(result) == 0 && !strings.Contains(ref, "/") {
+		result = append(result, strconv.Quote(ref))
+	}
+	return result
+}
+
+func primitiveGoType(oasType string) string {
+	switch oasType {
+	case values.TypeString:
+		return "string"
+	case values.TypeBoolean:
+		return "bool"
+	case values.TypeInteger:
+		return "int"
+	case values.TypeNumber:
+		return "float64"
+	case values.TypeArray:
+		return "[]any"
+	}
+	return "any"
+}
+
+func (w *structsWriter) generateHelpers() {
+	if w.needsUnmarshalVariant {
+		w.imports["bytes"] = struct{}{}
+		w.writeLine(0, "func unmarshalVariant(data []byte, v any) error {", false)
+		w.writeLine(1, "dec := json.NewDecoder(bytes.NewReader(data))", false)
+		w.writeLine(1, "dec.DisallowUnknownFields()", false)
+		w.writeLine(1, "return dec.Decode(v)", false)
+		w.writeLine(0, "}", true)
+	}
+	if w.needsMarshalDiscriminated {
+		w.writeLine(0, "func marshalDiscriminated(v any, property string, value string) ([]byte, error) {", false)
+		w.writeLine(1, "data, err := json.Marshal(v)", false)
+		w.writeLine(1, "if err != nil || string(data) == \"null\" {", false)
+		w.writeLine(2, "return data, err", false)
+		w.writeLine(1, "}", false)
+		w.writeLine(1, "m := map[string]json.RawMessage{}", false)
+		w.writeLine(1, "if err = json.Unmarshal(data, &m); err != nil {", false)
+		w.writeLine(2, "return nil, err", false)
+		w.writeLine(1, "}", false)
+		w.writeLine(1, "if pv, ok := m[property]; !ok || string(pv) == \"null\" {", false)
+		w.writeLine(2, "m[property], _ = json.Marshal(value)", false)
+		w.writeLine(1, "}", false)
+		w.writeLine(1, "return json.Marshal(m)", false)
+		w.writeLine(0, "}", true)
+	}
+}
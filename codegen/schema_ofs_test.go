@@ -0,0 +1,326 @@
+package codegen
+
+import (
+	"bytes"
+	"github.com/go-andiamo/chioas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testOfsComponents = &chioas.Components{
+	Schemas: chioas.Schemas{
+		{
+			Name:               "Cat",
+			Type:               "object",
+			RequiredProperties: []string{"petType"},
+			Properties:         chioas.Properties{{Name: "petType", Type: "string"}, {Name: "lives", Type: "integer"}},
+		},
+		{
+			Name:       "Dog",
+			Type:       "object",
+			Properties: chioas.Properties{{Name: "petType", Type: "string"}},
+		},
+		{
+			Name: "Animal",
+			Discriminator: &chioas.Discriminator{
+				PropertyName: "petType",
+				Mapping:      map[string]string{"cat": "Cat", "kitty": "#/components/schemas/Cat"},
+			},
+			Ofs: &chioas.Ofs{Of: []chioas.OfSchema{&chioas.Of{SchemaRef: "Cat"}, &chioas.Of{SchemaRef: "Dog"}}},
+		},
+		{
+			Name: "Thing",
+			Ofs: &chioas.Ofs{
+				OfType: chioas.AnyOf,
+				Of: []chioas.OfSchema{
+					&chioas.Of{SchemaRef: "Cat"},
+					&chioas.Of{SchemaDef: &chioas.Schema{Type: "string"}},
+					&chioas.Of{SchemaDef: &chioas.Schema{Properties: chioas.Properties{{Name: "x", Type: "number"}}}},
+				},
+			},
+		},
+		{
+			Name: "Both",
+			Ofs: &chioas.Ofs{
+				OfType: chioas.AllOf,
+				Of: []chioas.OfSchema{
+					&chioas.Of{SchemaRef: "Cat"},
+					&chioas.Of{SchemaRef: "Thing"},
+					&chioas.Of{SchemaDef: &chioas.Schema{Type: "object", RequiredProperties: []string{"extra"}, Properties: chioas.Properties{{Name: "extra", Type: "boolean"}}}},
+				},
+			},
+		},
+		{
+			Name:          "Mapped",
+			Discriminator: &chioas.Discriminator{PropertyName: "kind", Mapping: map[string]string{"c": "Cat", "d": "Dog"}},
+		},
+	},
+}
+
+func TestGenerateSchemaStructs_Ofs(t *testing.T) {
+	def := chioas.Definition{
+		Paths: chioas.Paths{
+			"/pets": {
+				Methods: chioas.Methods{
+					"POST": {
+						Responses: chioas.Responses{
+							200: {
+								Schema: &chioas.Schema{
+									Type:               "object",
+									RequiredProperties: []string{"pet"},
+									Properties: chioas.Properties{
+										{Name: "pet", SchemaRef: "Animal"},
+										{Name: "things", Type: "array", SchemaRef: "Thing"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Components: testOfsComponents,
+	}
+	var buf bytes.Buffer
+	err := GenerateSchemaStructs(def, &buf, SchemaStructOptions{Format: true, PublicStructs: true})
+	require.NoError(t, err)
+	code := buf.String()
+	const expectStart = `package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+type PostPetsOkResponse struct {
+	Pet    SchemaAnimal  ~json:"pet"~
+	Things []SchemaThing ~json:"things"~
+}
+
+type SchemaAnimal struct {
+	Value SchemaAnimalVariant
+}
+
+type SchemaAnimalVariant interface {
+	isSchemaAnimal()
+}
+
+func (SchemaCat) isSchemaAnimal() {}
+
+func (SchemaDog) isSchemaAnimal() {}
+
+func (v SchemaAnimal) MarshalJSON() ([]byte, error) {
+	switch value := v.Value.(type) {
+	case SchemaCat, *SchemaCat:
+		return marshalDiscriminated(value, "petType", "cat")
+	case SchemaDog, *SchemaDog:
+		return marshalDiscriminated(value, "petType", "Dog")
+	}
+	return json.Marshal(v.Value)
+}
+
+func (v *SchemaAnimal) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		v.Value = nil
+		return nil
+	}
+	var d struct {
+		Value string ~json:"petType"~
+	}
+	if err := json.Unmarshal(data, &d); err != nil {
+		return err
+	}
+	switch d.Value {
+	case "cat", "kitty":
+		var value SchemaCat
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		v.Value = value
+	case "Dog":
+		var value SchemaDog
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		v.Value = value
+	default:
+		return fmt.Errorf("unknown SchemaAnimal discriminator value: %q", d.Value)
+	}
+	return nil
+}
+`
+	assert.True(t, strings.HasPrefix(code, strings.ReplaceAll(expectStart, "~", "`")), code)
+	assert.Contains(t, code, `func (SchemaCat) isSchemaThing() {}
+
+type SchemaThingVariant2 string
+
+func (SchemaThingVariant2) isSchemaThing() {}
+
+func (SchemaThingVariant3) isSchemaThing() {}
+`)
+	assert.Contains(t, code, `	{
+		var value SchemaThingVariant3
+		if err := unmarshalVariant(data, &value); err == nil {
+			v.Value = value
+			return nil
+		}
+	}
+	return fmt.Errorf("json does not match any SchemaThing variant")
+}
+
+type SchemaThingVariant3 struct {
+	X *float64 `+"`json:\"x\"`"+`
+}
+`)
+	assert.Contains(t, code, "func unmarshalVariant(data []byte, v any) error {\n")
+}
+
+func TestGenerateSchemaStructs_OfsComponents(t *testing.T) {
+	var buf bytes.Buffer
+	err := GenerateSchemaStructs(testOfsComponents, &buf, SchemaStructOptions{Format: true, GoDoc: true, PublicStructs: true})
+	require.NoError(t, err)
+	code := buf.String()
+	assert.Contains(t, code, `// SchemaBoth #/components/schemas/Both
+type SchemaBoth struct {
+	SchemaCat
+	// Thing - not embedded (not an object schema)
+	Extra bool `+"`json:\"extra\"`"+`
+}
+`)
+	assert.Contains(t, code, `// SchemaMappedVariant is implemented by each variant of SchemaMapped
+type SchemaMappedVariant interface {
+	isSchemaMapped()
+}
+
+func (SchemaCat) isSchemaMapped() {}
+
+func (SchemaDog) isSchemaMapped() {}
+`)
+	assert.Contains(t, code, "\tcase \"c\":\n\t\tvar value SchemaCat\n")
+	assert.Contains(t, code, "\tcase \"d\":\n\t\tvar value SchemaDog\n")
+	assert.Contains(t, code, "// SchemaThingVariant3 variant of SchemaThing\n")
+}
+
+func TestGenerateSchemaStructs_OfsRoundTrip(t *testing.T) {
+	goCmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not available")
+	}
+	var buf bytes.Buffer
+	err = GenerateSchemaStructs(testUntypedDefinition.Components, &buf, SchemaStructOptions{Package: "main", PublicStructs: true, Format: true})
+	require.NoError(t, err)
+	code := buf.String()
+	assert.Contains(t, code, "type SchemaLabelledShape struct {\n\tSchemaCircle\n")
+	assert.Contains(t, code, "func (SchemaCircle) isSchemaShape() {}\n")
+	assert.Contains(t, code, "func (SchemaSquare) isSchemaShape() {}\n")
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module roundtrip\n\ngo 1.24\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "structs.go"), buf.Bytes(), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(`package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	radius, side, label := 2.0, 3.0, "round"
+	for _, shape := range []SchemaShape{
+		{Value: SchemaCircle{Radius: &radius}},
+		{Value: &SchemaSquare{Side: &side}},
+	} {
+		data, err := json.Marshal(shape)
+		if err != nil {
+			panic(err)
+		}
+		var back SchemaShape
+		if err = json.Unmarshal(data, &back); err != nil {
+			panic(err)
+		}
+		fmt.Printf("%s %T\n", data, back.Value)
+	}
+	data, err := json.Marshal(SchemaLabelledShape{SchemaCircle: SchemaCircle{Radius: &radius}, Label: &label})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("%s\n", data)
+}
+`), 0o644))
+	cmd := exec.Command(goCmd, "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=", "GOWORK=off")
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.Equal(t, `{"radius":2,"shapeType":"circle"} main.SchemaCircle
+{"shapeType":"square","side":3} main.SchemaSquare
+{"radius":2,"shapeType":null,"label":"round"}
+`, string(out))
+}
+
+func TestGenerateHandlerStubs_TypedOfs(t *testing.T) {
+	var buf bytes.Buffer
+	err := GenerateHandlerStubs(chioas.Definition{
+		Paths: chioas.Paths{
+			"/pets": {
+				Methods: chioas.Methods{
+					"POST": {
+						Request:   &chioas.Request{SchemaRef: "Animal"},
+						Responses: chioas.Responses{200: {}},
+					},
+				},
+			},
+		},
+		Components: testOfsComponents,
+	}, &buf, HandlerStubOptions{Typed: true, Format: true})
+	require.NoError(t, err)
+	code := buf.String()
+	assert.Contains(t, code, "\t\"encoding/json\"\n\t\"fmt\"\n")
+	assert.Contains(t, code, "PostPets(ctx context.Context, body *PostPetsRequest) error\n")
+	assert.Contains(t, code, "type PostPetsRequest = SchemaAnimal\n")
+}
+
+func Test_discriminatorValues(t *testing.T) {
+	d := &chioas.Discriminator{
+		PropertyName: "type",
+		Mapping:      map[string]string{"b": "Foo", "a": "#/components/schemas/Foo", "c": "Bar"},
+	}
+	assert.Equal(t, []string{`"a"`, `"b"`}, discriminatorValues(d, "Foo"))
+	assert.Equal(t, []string{`"c"`}, discriminatorValues(d, "Bar"))
+	assert.Equal(t, []string{`"Baz"`}, discriminatorValues(d, "Baz"))
+	assert.Equal(t, []string{}, discriminatorValues(d, "http://example.com/schemas/Baz"))
+}
+
+func Test_mergeVariants(t *testing.T) {
+	result := mergeVariants([]unionVariant{
+		{typeName: "A", values: []string{`"a"`}},
+		{typeName: "B", values: []string{`"b"`}},
+		{typeName: "A", values: []string{`"a"`, `"aa"`}},
+	})
+	require.Len(t, result, 2)
+	assert.Equal(t, []string{`"a"`, `"aa"`}, result[0].values)
+	assert.Equal(t, []string{`"b"`}, result[1].values)
+}
+
+func Test_variantGoType(t *testing.T) {
+	testCases := map[string]string{
+		"string":  "string",
+		"boolean": "bool",
+		"integer": "int",
+		"number":  "float64",
+		"array":   "[]any",
+		"":        "json.RawMessage",
+		"null":    "json.RawMessage",
+	}
+	for oasType, expect := range testCases {
+		t.Run(oasType, func(t *testing.T) {
+			assert.Equal(t, expect, variantGoType(&chioas.Schema{Type: oasType}))
+		})
+	}
+}
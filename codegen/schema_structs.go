@@ -23,6 +23,9 @@ type SchemaStructOptions struct {
 	NoResponses   bool   // suppresses schema structs for method responses
 	OASTags       bool   // if true, writes `oas:"..."` tags for fields
 	GoDoc         bool   // if true, writes a godoc comment for each schema struct (indicating its usage)
+	// EnumTypes if true, generates a named type (with consts for each value, IsValid and text marshaling methods)
+	// for each string, integer or number property with an enum - enum schemas referenced by $ref are generated once
+	EnumTypes bool
 	// Components is the components to use for $ref resolution
 	//
 	// Usage:
@@ -56,6 +59,7 @@ type pathInfo struct {
 	method       string
 	infoType     infoType
 	explicitName bool
+	key          string // key for recording written type names (see structsWriter.typeNames)
 }
 
 func copyInfo(i *pathInfo, name, path, method string, t infoType) *pathInfo {
@@ -113,7 +117,10 @@ type StructItemType interface {
 
 func GenerateSchemaStructs[T StructItemType](item T, w io.Writer, opts SchemaStructOptions) error {
 	sw := newStructsWriter(w, opts)
-	sw.writePrologue()
+	// the body is written first (so that the imports it needs are known for the prologue)...
+	out := sw.w
+	body := &bytes.Buffer{}
+	sw.w = body
 	switch it := any(item).(type) {
 	case chioas.Definition:
 		sw.components = it.Components
@@ -145,29 +152,45 @@ func GenerateSchemaStructs[T StructItemType](item T, w io.Writer, opts SchemaStr
 		generateComponentsStructs(*it, sw)
 	}
 	sw.generateIssuedSchemas()
+	sw.generateIssuedEnums()
+	sw.generateHelpers()
+	sw.w = out
+	sw.writePrologue()
+	if sw.err == nil {
+		_, sw.err = sw.w.Write(body.Bytes())
+	}
 	return sw.format()
 }
 
 func (w *structsWriter) generateIssuedSchemas() {
-	if len(w.issuedSchemas) > 0 {
+	// writing a schema can issue further schemas - so repeat until all issued schemas are written...
+	written := make(map[string]bool, len(w.issuedSchemas))
+	for len(written) < len(w.issuedSchemas) {
 		names := make([]string, 0, len(w.issuedSchemas))
 		nameToSchema := make(map[string]*chioas.Schema, len(w.issuedSchemas))
 		nameToRef := make(map[string]string, len(w.issuedSchemas))
 		// sort...
 		for ref, name := range w.issuedSchemas {
-			names = append(names, name)
-			nameToSchema[name] = getComponentsSchema(w.components, ref)
-			nameToRef[name] = ref
+			if !written[ref] {
+				written[ref] = true
+				names = append(names, name)
+				nameToSchema[name] = getComponentsSchema(w.components, ref)
+				nameToRef[name] = ref
+			}
 		}
 		sort.Strings(names)
 		// write...
 		for _, name := range names {
-			schema := nameToSchema[name]
+			schema := *nameToSchema[name]
+			if schema.Type == values.TypeArray {
+				// array schemas are issued for their (object) items...
+				schema.Type = values.TypeObject
+			}
 			generateSchemaStruct(&pathInfo{
 				name:         name,
 				path:         refs.ComponentsPrefix + tags.Schemas + "/" + nameToRef[name],
 				explicitName: true,
-			}, *schema, w)
+			}, schema, w)
 		}
 	}
 }
@@ -187,10 +210,11 @@ func generateDefinitionStructs(def chioas.Definition, sw *structsWriter) {
 		// ensure that all components schemas are output
 		if def.Components != nil {
 			for _, s := range def.Components.Schemas {
-				if s.SchemaRef == "" && s.Type == values.TypeObject {
+				if s.SchemaRef == "" && isStructSchema(&s) {
 					sw.issueSchema(s.Name)
 				}
 			}
+			sw.issueComponentEnums(def.Components)
 		}
 	}
 	sms := sortedMethods(def.Methods)
@@ -221,6 +245,7 @@ func generateMethodStructs(info *pathInfo, def chioas.Method, sw *structsWriter)
 	}
 	if !sw.opts.NoRequests && def.Request != nil {
 		ir := copyInfo(info, toPascal(info.name+" Request"), "", "", infoTypeRequest)
+		ir.key = typeNameKey(info.method, info.path, "request")
 		generateRequestStructs(ir, def.Request, sw)
 	}
 	if !sw.opts.NoResponses {
@@ -228,6 +253,7 @@ func generateMethodStructs(info *pathInfo, def chioas.Method, sw *structsWriter)
 		for _, status := range ks {
 			response := def.Responses[status]
 			ir := copyInfo(info, toPascal(info.name+" "+statusCodeName(status)+" Response"), "", "", infoTypeResponse)
+			ir.key = typeNameKey(info.method, info.path, strconv.Itoa(status))
 			generateResponseStructs(ir, &response, sw)
 		}
 	}
@@ -240,9 +266,9 @@ func generateRequestStructs(info *pathInfo, def *chioas.Request, sw *structsWrit
 			if def.SchemaRef != "" {
 				if s, aref, err := sw.resolveSchema(def.SchemaRef, nil); err == nil {
 					schema = s
-					if sw.keep {
+					if sw.keep && isStructSchema(s) {
 						schema = nil
-						sw.writeTypeAlias(info, sw.issueSchema(aref))
+						sw.writeTypeAlias(info, sw.typeAliasOf(s, sw.issueSchema(aref)))
 					}
 				}
 			} else {
@@ -262,9 +288,9 @@ func generateResponseStructs(info *pathInfo, def *chioas.Response, sw *structsWr
 			if def.SchemaRef != "" {
 				if s, aref, err := sw.resolveSchema(def.SchemaRef, nil); err == nil {
 					schema = s
-					if sw.keep {
+					if sw.keep && isStructSchema(s) {
 						schema = nil
-						sw.writeTypeAlias(info, sw.issueSchema(aref))
+						sw.writeTypeAlias(info, sw.typeAliasOf(s, sw.issueSchema(aref)))
 					}
 				}
 			} else {
@@ -289,10 +315,11 @@ func generatePathsStructs(info *pathInfo, def chioas.Paths, sw *structsWriter) {
 
 func generateComponentsStructs(def chioas.Components, sw *structsWriter) {
 	for _, s := range def.Schemas {
-		if s.SchemaRef == "" && s.Type == values.TypeObject {
+		if s.SchemaRef == "" && isStructSchema(&s) {
 			sw.issueSchema(s.Name)
 		}
 	}
+	sw.issueComponentEnums(&def)
 	if !sw.opts.NoRequests {
 		ks := sortedKeys(def.Requests)
 		for _, k := range ks {
@@ -324,28 +351,19 @@ func generateComponentsStructs(def chioas.Components, sw *structsWriter) {
 }
 
 func generateSchemaStruct(info *pathInfo, def chioas.Schema, sw *structsWriter) {
-	if def.Type == values.TypeObject || def.SchemaRef != "" {
-		var name string
-		explicitName := false
-		if info != nil {
-			name = info.name
-			explicitName = info.explicitName
-		}
-		if name == "" {
-			name = def.Name
-		}
-		if name == "" {
-			name = "Schema"
-		}
-		sw.writeStructStart(name, explicitName, info)
+	if isOfsSchema(&def) {
+		generateOfsStruct(sw.structName(info, def), info, def, sw)
+	} else if isObjectSchema(&def) || def.SchemaRef != "" {
+		name := sw.writeStructStart(sw.structName(info, def), info)
 		if ptys, reqdPtys, err := sw.schemaProperties(def); err != nil {
 			sw.writeLine(1, "// error - "+err.Error(), false)
 		} else {
 			for _, pty := range ptys {
-				sw.writeProperty(1, pty, reqdPtys)
+				sw.writeProperty(1, name, pty, reqdPtys)
 			}
 		}
 		sw.writeLine(0, "}", true)
+		sw.writePendingEnums()
 	}
 }
 
@@ -356,17 +374,55 @@ func newStructsWriter(w io.Writer, opts SchemaStructOptions) *structsWriter {
 		deduper:       newNameDeDuper(),
 		components:    opts.Components,
 		issuedSchemas: make(map[string]string),
+		issuedEnums:   make(map[string]enumDef),
+		imports:       make(map[string]struct{}),
 		keep:          opts.KeepComponentProperties,
 	}
 }
 
 type structsWriter struct {
 	*writer
-	opts          SchemaStructOptions
-	deduper       *nameDeDuper
-	components    *chioas.Components
-	issuedSchemas map[string]string
-	keep          bool
+	opts                      SchemaStructOptions
+	deduper                   *nameDeDuper
+	components                *chioas.Components
+	issuedSchemas             map[string]string
+	issuedEnums               map[string]enumDef // hoisted enum types (by components schema name)
+	pendingEnums              []enumDef          // inline enum types to be written after the current struct
+	needsUnmarshalVariant     bool               // whether the unmarshalVariant helper func is needed (see generateHelpers)
+	needsMarshalDiscriminated bool               // whether the marshalDiscriminated helper func is needed (see generateHelpers)
+	imports                   map[string]struct{}
+	keep                      bool
+	typeNames                 map[string]string // if non-nil, records the type names written for method requests/responses
+}
+
+// generateOperationStructs generates the public schema structs for the requests/responses of all operations in def (with
+// component schemas kept as their own structs) - recording the written type names (see structsWriter.typeNames)
+func generateOperationStructs(def chioas.Definition, w io.Writer, oasTags bool, goDoc bool, useCRLF bool) (*structsWriter, error) {
+	sw := newStructsWriter(w, SchemaStructOptions{
+		SkipPrologue:            true,
+		PublicStructs:           true,
+		OASTags:                 oasTags,
+		GoDoc:                   goDoc,
+		KeepComponentProperties: true,
+		UseCRLF:                 useCRLF,
+	})
+	sw.typeNames = map[string]string{}
+	sw.components = def.Components
+	generateDefinitionStructs(def, sw)
+	sw.generateIssuedSchemas()
+	sw.generateIssuedEnums()
+	sw.generateHelpers()
+	return sw, sw.err
+}
+
+func typeNameKey(method string, path string, usage string) string {
+	return method + " " + path + " " + usage
+}
+
+func (w *structsWriter) recordTypeName(info *pathInfo, name string) {
+	if w.typeNames != nil && info != nil && info.key != "" {
+		w.typeNames[info.key] = name
+	}
 }
 
 func (w *structsWriter) writePrologue() {
@@ -376,6 +432,13 @@ func (w *structsWriter) writePrologue() {
 			pkg = defaultPackage
 		}
 		w.writeLine(0, "package "+pkg, true)
+		if len(w.imports) > 0 {
+			w.writeLine(0, "import (", false)
+			for _, imp := range sortedKeys(w.imports) {
+				w.writeLine(1, strconv.Quote(imp), false)
+			}
+			w.writeLine(0, ")", true)
+		}
 	}
 }
 
@@ -386,6 +449,14 @@ var (
 	bComment = []byte("// ")
 )
 
+// typeAliasOf returns the type for a type alias - compositions are true aliases (so that their json marshaling methods are kept)
+func (w *structsWriter) typeAliasOf(s *chioas.Schema, name string) string {
+	if isOfsSchema(s) {
+		return "= " + name
+	}
+	return name
+}
+
 func (w *structsWriter) writeTypeAlias(info *pathInfo, aType string) {
 	if w.err == nil {
 		name := info.name
@@ -397,6 +468,7 @@ func (w *structsWriter) writeTypeAlias(info *pathInfo, aType string) {
 		} else {
 			name = strings.ToLower(name[:1]) + name[1:]
 		}
+		w.recordTypeName(info, name)
 		if w.writeStructGoDoc(name, info) {
 			if _, w.err = w.w.Write(bType); w.err == nil {
 				if _, w.err = w.w.Write([]byte(name + " " + aType)); w.err == nil {
@@ -407,13 +479,31 @@ func (w *structsWriter) writeTypeAlias(info *pathInfo, aType string) {
 	}
 }
 
-func (w *structsWriter) writeStructStart(name string, explicitName bool, info *pathInfo) {
+// structName determines (and records) the type name for a schema struct
+func (w *structsWriter) structName(info *pathInfo, def chioas.Schema) string {
+	var name string
+	explicitName := false
+	if info != nil {
+		name = info.name
+		explicitName = info.explicitName
+	}
+	if name == "" {
+		name = def.Name
+	}
+	if name == "" {
+		name = "Schema"
+	}
+	if !explicitName {
+		name = w.deduper.take(w.scopedName(toPascal(name)))
+	} else {
+		name = w.scopedName(name)
+	}
+	w.recordTypeName(info, name)
+	return name
+}
+
+func (w *structsWriter) writeStructStart(name string, info *pathInfo) string {
 	if w.err == nil {
-		if !explicitName {
-			name = w.deduper.take(w.scopedName(toPascal(name)))
-		} else {
-			name = w.scopedName(name)
-		}
 		if w.writeStructGoDoc(name, info) {
 			if _, w.err = w.w.Write(bType); w.err == nil {
 				if _, w.err = w.w.Write([]byte(name)); w.err == nil {
@@ -424,6 +514,7 @@ func (w *structsWriter) writeStructStart(name string, explicitName bool, info *p
 			}
 		}
 	}
+	return name
 }
 
 func (w *structsWriter) writeStructGoDoc(name string, info *pathInfo) bool {
@@ -442,7 +533,7 @@ func (w *structsWriter) writeStructGoDoc(name string, info *pathInfo) bool {
 	return w.err == nil
 }
 
-func (w *structsWriter) writeProperty(indent int, pty chioas.Property, reqdPtys []string) {
+func (w *structsWriter) writeProperty(indent int, owner string, pty chioas.Property, reqdPtys []string) {
 	if w.err == nil && w.writeIndent(indent) {
 		fName := toPascal(pty.Name)
 		fType, aType, iType, reqd, innerPtys, subs, reqdSubs, err := w.propertyType(pty, reqdPtys)
@@ -451,6 +542,18 @@ func (w *structsWriter) writeProperty(indent int, pty chioas.Property, reqdPtys
 			w.writeLf(false)
 			return
 		}
+		if eName, eType, enum := w.propertyEnum(owner, pty); eName != "" {
+			fType, aType = eName, eType
+			if !reqd {
+				fType = "*" + eName
+			}
+			if pty.SchemaRef == "" {
+				pty.Type, pty.Enum = eType, enum
+			} else {
+				// the enum values are in the referenced schema - so the oas tag just keeps the $ref...
+				aType = ""
+			}
+		}
 		if _, w.err = w.w.Write([]byte(fName + " ")); w.err == nil {
 			if _, w.err = w.w.Write([]byte(fType)); w.err == nil {
 				if innerPtys {
@@ -458,7 +561,7 @@ func (w *structsWriter) writeProperty(indent int, pty chioas.Property, reqdPtys
 						w.writeLine(indent+1, "// no properties", false)
 					} else {
 						for _, sub := range subs {
-							w.writeProperty(indent+1, sub, reqdSubs)
+							w.writeProperty(indent+1, owner+fName, sub, reqdSubs)
 						}
 					}
 					if w.writeIndent(indent) {
@@ -483,8 +586,21 @@ func (w *structsWriter) propertyType(def chioas.Property, reqdPtys []string) (fT
 		subs = s.Properties
 		reqdSubs = s.RequiredProperties
 		aType = s.Type
+		if isObjectSchema(s) {
+			aType = values.TypeObject
+		}
 		reqd = slices.Contains(reqdPtys, def.Name)
-		if w.keep && w.components != nil && (aType == values.TypeArray || aType == values.TypeObject) {
+		if isOfsSchema(s) {
+			// compositions are always generated as their own type...
+			fType = w.issueSchema(aref)
+			if def.Type == values.TypeArray {
+				fType = "[]" + fType
+			} else if !reqd {
+				fType = "*" + fType
+			}
+			return
+		}
+		if w.keep && w.components != nil && ((aType == values.TypeArray && len(s.Properties) > 0) || aType == values.TypeObject) {
 			// keep component properties...
 			isArray := aType == values.TypeArray
 			fType = w.issueSchema(aref)
@@ -666,6 +782,8 @@ func (w *structsWriter) addOasTag(tb *bytes.Buffer, pty chioas.Property, aType s
 		}
 		if aType != "" {
 			tokens = append(tokens, "type:"+aType)
+		} else if pty.SchemaRef != "" {
+			tokens = append(tokens, "$ref:'"+pty.SchemaRef+"'")
 		}
 		if aType == "array" && iType != "" {
 			tokens = append(tokens, "itemType:"+iType)
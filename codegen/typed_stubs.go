@@ -0,0 +1,350 @@
+package codegen
+
+import (
+	"bytes"
+	"errors"
+	"github.com/go-andiamo/chioas"
+	"github.com/go-andiamo/chioas/internal/values"
+	"github.com/go-andiamo/urit"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const defaultTypedInterface = "TypedApi"
+
+// generateTypedStubs generates the typed interface, named param types, schema structs and the definition wiring
+// (see HandlerStubOptions.Typed)
+func generateTypedStubs(item any, w io.Writer, opts HandlerStubOptions) error {
+	var def chioas.Definition
+	switch it := item.(type) {
+	case chioas.Definition:
+		def = it
+	case *chioas.Definition:
+		def = *it
+	default:
+		return errors.New("typed stubs can only be generated for a chioas.Definition")
+	}
+	// generate the schema structs first (so that the names of request/response types are known)...
+	var structs bytes.Buffer
+	sw, err := generateOperationStructs(def, &structs, false, opts.GoDoc, opts.UseCRLF)
+	if err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	tw := &typedStubsWriter{
+		writer:     newWriter(&body, false, opts.UseCRLF),
+		opts:       opts,
+		sw:         sw,
+		deduper:    newNameDeDuper(),
+		name:       defValue(opts.TypedInterface, defaultTypedInterface),
+		paramTypes: map[string]*typedParamType{},
+		imports:    map[string]bool{"context": true, "github.com/go-andiamo/chioas": true, "github.com/go-andiamo/chioas/typed": true},
+	}
+	for imp := range sw.imports {
+		tw.imports[imp] = true
+	}
+	walkMethods(def, func(path string, pathParams chioas.PathParams, method string, mDef chioas.Method) {
+		tw.collectMethod(defValue(path, "Root"), defValue(path, "root"), path, pathParams, method, mDef)
+	})
+	tw.writeInterface()
+	tw.writeBinding()
+	tw.writeParamTypes()
+	if tw.err == nil {
+		_, tw.err = tw.w.Write(structs.Bytes())
+	}
+	if tw.err != nil {
+		return tw.err
+	}
+	out := newWriter(w, opts.Format, opts.UseCRLF)
+	if !opts.SkipPrologue {
+		out.writeLine(0, "package "+defValue(opts.Package, defaultPackage), true)
+		out.writeLine(0, "import (", false)
+		imps := sortedKeys(tw.imports)
+		for _, imp := range imps {
+			out.writeLine(1, strconv.Quote(imp), false)
+		}
+		out.writeLine(0, ")", true)
+	}
+	if out.err == nil {
+		_, out.err = out.w.Write(body.Bytes())
+	}
+	return out.format()
+}
+
+type typedStubsWriter struct {
+	*writer
+	opts       HandlerStubOptions
+	sw         *structsWriter
+	deduper    *nameDeDuper
+	name       string
+	operations []typedOperation
+	paramTypes map[string]*typedParamType
+	imports    map[string]bool
+}
+
+// typedOperation is a method of the generated typed interface
+type typedOperation struct {
+	name    string
+	path    string // path key for binding (empty for root)
+	docPath string
+	method  string
+	args    []string
+	returns string
+}
+
+// typedParamType is a generated named param type (implementing typed.NamedPathParam, typed.NamedQueryParam,
+// typed.NamedHeader or typed.NamedCookie)
+type typedParamType struct {
+	name   string
+	param  string
+	in     string
+	goType string
+}
+
+func (w *typedStubsWriter) collectMethod(namingPath string, key string, path string, pathParams chioas.PathParams, method string, def chioas.Method) {
+	name := w.opts.StubNaming.Name(namingPath, method, def)
+	if name == "" {
+		name = defaultStubNaming.Name(namingPath, method, def)
+	}
+	name = w.deduper.take(toPascal(name))
+	argNames := newNameDeDuper()
+	argNames.take("ctx")
+	argNames.take("body")
+	args := []string{"ctx context.Context"}
+	for _, p := range w.pathParams(path, pathParams) {
+		args = append(args, argName(p.name, argNames)+" "+w.paramType(p))
+	}
+	for _, p := range w.sw.params(def.QueryParams, argNames) {
+		t := w.paramType(p)
+		switch {
+		case p.in == values.Cookie:
+			// named cookies are always pointers...
+			t = "*" + t
+		case p.isArray:
+			t = "[]" + t
+		case !p.required:
+			t = "*" + t
+		}
+		args = append(args, p.argName+" "+t)
+	}
+	if arg := w.requestBody(key, method, def.Request); arg != "" {
+		args = append(args, "body "+arg)
+	}
+	docPath := path
+	if docPath == "" {
+		docPath = "/"
+	}
+	w.operations = append(w.operations, typedOperation{
+		name:    name,
+		path:    path,
+		docPath: docPath,
+		method:  method,
+		args:    args,
+		returns: w.returns(key, method, def.Responses),
+	})
+}
+
+func (w *typedStubsWriter) pathParams(path string, pathParams chioas.PathParams) []opParam {
+	result := make([]opParam, 0)
+	if t, err := urit.NewTemplate(path); err == nil {
+		path = t.Template(true)
+	}
+	for _, match := range clientPathVarRegex.FindAllStringSubmatch(path, -1) {
+		p := opParam{name: match[1], in: values.Path, goType: values.TypeString, required: true}
+		if pp, ok := pathParams[p.name]; ok {
+			p.goType, _ = w.sw.schemaGoType(pp.Schema, pp.SchemaRef)
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+var typedParamPrefixes = map[string]string{
+	values.Path:   "PathParam",
+	values.Query:  "QueryParam",
+	values.Header: "Header",
+	values.Cookie: "Cookie",
+}
+
+// paramType returns the name of the named param type for a param (issuing the type if not already issued)
+func (w *typedStubsWriter) paramType(p opParam) string {
+	goType := strings.TrimPrefix(p.goType, "[]")
+	switch p.in {
+	case values.Header:
+		// named headers must be of underlying type string...
+		goType = values.TypeString
+	case values.Cookie:
+		goType = "http.Cookie"
+		w.imports["net/http"] = true
+	}
+	key := p.in + " " + p.name + " " + goType
+	if pt, ok := w.paramTypes[key]; ok {
+		return pt.name
+	}
+	pt := &typedParamType{
+		name:   w.sw.deduper.take(toPascal(typedParamPrefixes[p.in] + " " + p.name)),
+		param:  p.name,
+		in:     p.in,
+		goType: goType,
+	}
+	w.paramTypes[key] = pt
+	if _, ok := typedUnmarshalers[goType]; ok {
+		w.imports["strconv"] = true
+	}
+	return pt.name
+}
+
+func (w *typedStubsWriter) requestBody(key string, method string, def *chioas.Request) string {
+	_, arg, _ := w.sw.requestBody(key, method, def)
+	switch arg {
+	case "io.Reader":
+		return "[]byte"
+	case "any":
+		w.imports["encoding/json"] = true
+		return "json.RawMessage"
+	}
+	return arg
+}
+
+func (w *typedStubsWriter) returns(key string, method string, rs chioas.Responses) string {
+	result, _ := w.sw.responses(key, method, rs)
+	successes := 0
+	status := http.StatusOK
+	for s := range rs {
+		if s >= 200 && s < 300 {
+			successes++
+			status = s
+		}
+	}
+	returns := make([]string, 0, 3)
+	if result.goType != "" {
+		if result.goType == "json.RawMessage" {
+			w.imports["encoding/json"] = true
+		}
+		returns = append(returns, result.goType)
+	}
+	if successes > 1 || status != http.StatusOK {
+		// the implementation must return the status code...
+		returns = append(returns, "int")
+	}
+	returns = append(returns, "error")
+	if len(returns) == 1 {
+		return returns[0]
+	}
+	return "(" + strings.Join(returns, ", ") + ")"
+}
+
+func (w *typedStubsWriter) writeInterface() {
+	if w.opts.GoDoc {
+		w.writeLine(0, "// "+w.name+" is the typed interface for the API operations (see Bind"+w.name+")", false)
+	}
+	w.writeLine(0, "type "+w.name+" interface {", false)
+	for _, op := range w.operations {
+		if w.opts.GoDoc {
+			w.writeLine(1, "// "+op.name+" "+op.method+" "+op.docPath, false)
+		}
+		w.writeLine(1, op.name+"("+strings.Join(op.args, ", ")+") "+op.returns, false)
+	}
+	w.writeLine(0, "}", true)
+}
+
+func (w *typedStubsWriter) writeBinding() {
+	if w.opts.GoDoc {
+		w.writeLine(0, "// Bind"+w.name+" returns a copy of def with the handler of each operation bound to the corresponding method of impl", false)
+		w.writeLine(0, "//", false)
+		w.writeLine(0, "// handlers are built using typed.NewTypedMethodsHandlerBuilder (with any options passed)", false)
+	}
+	w.writeLine(0, "func Bind"+w.name+"(def chioas.Definition, impl "+w.name+", options ...any) chioas.Definition {", false)
+	w.writeLine(1, "handlers := map[string]map[string]any{", false)
+	byPath := map[string][]typedOperation{}
+	for _, op := range w.operations {
+		byPath[op.path] = append(byPath[op.path], op)
+	}
+	for _, path := range sortedKeys(byPath) {
+		w.writeLine(2, strconv.Quote(path)+": {", false)
+		for _, op := range byPath[path] {
+			w.writeLine(3, strconv.Quote(op.method)+": impl."+op.name+",", false)
+		}
+		w.writeLine(2, "},", false)
+	}
+	w.writeLine(1, "}", false)
+	w.writeLine(1, "def.MethodHandlerBuilder = typed.NewTypedMethodsHandlerBuilder(options...)", false)
+	w.writeLine(1, `def.Methods = bindTypedMethods(def.Methods, handlers[""])`, false)
+	w.writeLine(1, `def.Paths = bindTypedPaths("", def.Paths, handlers)`, false)
+	w.writeLine(1, "return def", false)
+	w.writeLine(0, "}", true)
+	for _, line := range strings.Split(typedBindingStatics, "\n") {
+		w.writeLine(0, line, false)
+	}
+}
+
+var typedNameMethods = map[string]string{
+	values.Path:   "PathParamName",
+	values.Query:  "QueryParamName",
+	values.Header: "HeaderName",
+	values.Cookie: "CookieName",
+}
+
+// typedUnmarshalers is the UnmarshalText body for named param types whose underlying type is not a string
+var typedUnmarshalers = map[string]string{
+	"int":     "strconv.Atoi(string(text))",
+	"float64": "strconv.ParseFloat(string(text), 64)",
+	"bool":    "strconv.ParseBool(string(text))",
+}
+
+func (w *typedStubsWriter) writeParamTypes() {
+	pts := make([]*typedParamType, 0, len(w.paramTypes))
+	for _, pt := range w.paramTypes {
+		pts = append(pts, pt)
+	}
+	sort.Slice(pts, func(i, j int) bool {
+		return pts[i].name < pts[j].name
+	})
+	for _, pt := range pts {
+		if w.opts.GoDoc {
+			w.writeLine(0, "// "+pt.name+" is the "+strconv.Quote(pt.param)+" "+pt.in+" param", false)
+		}
+		w.writeLine(0, "type "+pt.name+" "+pt.goType, true)
+		w.writeLine(0, "func ("+pt.name+") "+typedNameMethods[pt.in]+"() string {", false)
+		w.writeLine(1, "return "+strconv.Quote(pt.param), false)
+		w.writeLine(0, "}", true)
+		if unmarshal, ok := typedUnmarshalers[pt.goType]; ok {
+			w.writeLine(0, "func (p *"+pt.name+") UnmarshalText(text []byte) error {", false)
+			w.writeLine(1, "v, err := "+unmarshal, false)
+			w.writeLine(1, "*p = "+pt.name+"(v)", false)
+			w.writeLine(1, "return err", false)
+			w.writeLine(0, "}", true)
+		}
+	}
+}
+
+const typedBindingStatics = `func bindTypedPaths(path string, paths chioas.Paths, handlers map[string]map[string]any) chioas.Paths {
+	if paths == nil {
+		return nil
+	}
+	result := make(chioas.Paths, len(paths))
+	for k, p := range paths {
+		p.Methods = bindTypedMethods(p.Methods, handlers[path+k])
+		p.Paths = bindTypedPaths(path+k, p.Paths, handlers)
+		result[k] = p
+	}
+	return result
+}
+
+func bindTypedMethods(methods chioas.Methods, handlers map[string]any) chioas.Methods {
+	if methods == nil {
+		return nil
+	}
+	result := make(chioas.Methods, len(methods))
+	for m, def := range methods {
+		if h, ok := handlers[m]; ok {
+			def.Handler = h
+		}
+		result[m] = def
+	}
+	return result
+}
+`
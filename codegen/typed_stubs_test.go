@@ -0,0 +1,179 @@
+package codegen
+
+import (
+	"bytes"
+	"github.com/go-andiamo/chioas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGenerateHandlerStubs_Typed(t *testing.T) {
+	var buf bytes.Buffer
+	err := GenerateHandlerStubs(testClientDefinition, &buf, HandlerStubOptions{Typed: true, Format: true, GoDoc: true, TypedInterface: "PetsApi"})
+	require.NoError(t, err)
+	code := buf.String()
+	const expectStart = `package api
+
+import (
+	"context"
+	"github.com/go-andiamo/chioas"
+	"github.com/go-andiamo/chioas/typed"
+	"net/http"
+	"strconv"
+)
+
+// PetsApi is the typed interface for the API operations (see BindPetsApi)
+type PetsApi interface {
+	// GetPets GET /pets
+	GetPets(ctx context.Context, limit *QueryParamLimit, tags []QueryParamTags, xTrace HeaderXTrace, session *CookieSession) ([]GetPetsOkResponse, error)
+	// PostPets POST /pets
+	PostPets(ctx context.Context, body PostPetsRequest) (*PostPetsCreatedResponse, int, error)
+	// DeletePetsPetId DELETE /pets/{petId:[0-9]+}
+	DeletePetsPetId(ctx context.Context, petId PathParamPetId) (int, error)
+	// GetPhoto GET /pets/{petId:[0-9]+}/photo
+	GetPhoto(ctx context.Context, petId PathParamPetId) ([]byte, error)
+	// PutPhoto PUT /pets/{petId:[0-9]+}/photo
+	PutPhoto(ctx context.Context, petId PathParamPetId, body []byte) error
+}
+
+// BindPetsApi returns a copy of def with the handler of each operation bound to the corresponding method of impl
+//
+// handlers are built using typed.NewTypedMethodsHandlerBuilder (with any options passed)
+func BindPetsApi(def chioas.Definition, impl PetsApi, options ...any) chioas.Definition {
+	handlers := map[string]map[string]any{
+		"/pets": {
+			"GET":  impl.GetPets,
+			"POST": impl.PostPets,
+		},
+		"/pets/{petId:[0-9]+}": {
+			"DELETE": impl.DeletePetsPetId,
+		},
+		"/pets/{petId:[0-9]+}/photo": {
+			"GET": impl.GetPhoto,
+			"PUT": impl.PutPhoto,
+		},
+	}
+	def.MethodHandlerBuilder = typed.NewTypedMethodsHandlerBuilder(options...)
+	def.Methods = bindTypedMethods(def.Methods, handlers[""])
+	def.Paths = bindTypedPaths("", def.Paths, handlers)
+	return def
+}
+`
+	assert.True(t, strings.HasPrefix(code, expectStart))
+	const expectParamTypes = `// CookieSession is the "session" cookie param
+type CookieSession http.Cookie
+
+func (CookieSession) CookieName() string {
+	return "session"
+}
+
+// HeaderXTrace is the "X-Trace" header param
+type HeaderXTrace string
+
+func (HeaderXTrace) HeaderName() string {
+	return "X-Trace"
+}
+
+// PathParamPetId is the "petId" path param
+type PathParamPetId int
+
+func (PathParamPetId) PathParamName() string {
+	return "petId"
+}
+
+func (p *PathParamPetId) UnmarshalText(text []byte) error {
+	v, err := strconv.Atoi(string(text))
+	*p = PathParamPetId(v)
+	return err
+}
+
+// QueryParamLimit is the "limit" query param
+type QueryParamLimit int
+`
+	assert.Contains(t, code, expectParamTypes)
+	assert.Contains(t, code, "func bindTypedPaths(path string, paths chioas.Paths, handlers map[string]map[string]any) chioas.Paths {")
+	assert.Contains(t, code, "type PostPetsRequest SchemaPet")
+	assert.Contains(t, code, "type SchemaPet struct {")
+}
+
+func TestGenerateHandlerStubs_TypedRoot(t *testing.T) {
+	var buf bytes.Buffer
+	err := GenerateHandlerStubs(&chioas.Definition{
+		Methods: chioas.Methods{
+			http.MethodGet: {
+				Responses: chioas.Responses{
+					http.StatusOK: {Schema: &chioas.Schema{Type: "object"}},
+				},
+			},
+			http.MethodPut: {
+				Request: &chioas.Request{},
+				Responses: chioas.Responses{
+					http.StatusOK:        {},
+					http.StatusNoContent: {},
+				},
+			},
+		},
+	}, &buf, HandlerStubOptions{Typed: true, Package: "foo", StubNaming: &testNaming{}})
+	require.NoError(t, err)
+	code := buf.String()
+	assert.True(t, strings.HasPrefix(code, `package foo
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-andiamo/chioas"
+	"github.com/go-andiamo/chioas/typed"
+)
+
+type TypedApi interface {
+	Get(ctx context.Context) (*GetRootOkResponse, error)
+	Put(ctx context.Context, body json.RawMessage) (int, error)
+}
+
+func BindTypedApi(def chioas.Definition, impl TypedApi, options ...any) chioas.Definition {
+	handlers := map[string]map[string]any{
+		"": {
+			"GET": impl.Get,
+			"PUT": impl.Put,
+		},
+	}
+`), code)
+}
+
+func TestGenerateHandlerStubs_TypedErrors(t *testing.T) {
+	var buf bytes.Buffer
+	err := GenerateHandlerStubs(chioas.Path{}, &buf, HandlerStubOptions{Typed: true})
+	require.Error(t, err)
+	assert.Equal(t, "typed stubs can only be generated for a chioas.Definition", err.Error())
+
+	err = GenerateHandlerStubs(testClientDefinition, &errorWriter{}, HandlerStubOptions{Typed: true})
+	require.Error(t, err)
+}
+
+type testNaming struct{}
+
+func (t *testNaming) Name(path string, method string, def chioas.Method) string {
+	return strings.ToLower(method)
+}
+
+func TestGenerateHandlerStubs_TypedUntypedSchemas(t *testing.T) {
+	var buf bytes.Buffer
+	err := GenerateHandlerStubs(testUntypedDefinition, &buf, HandlerStubOptions{Typed: true, Format: true, GoDoc: true})
+	require.NoError(t, err)
+	code := buf.String()
+	typeCheck(t, code)
+	assert.Contains(t, code, "\tPostPets(ctx context.Context, body PostPetsRequest) (*PostPetsCreatedResponse, int, error)\n")
+	assert.Contains(t, code, "\tPutPetsPetId(ctx context.Context, petId PathParamPetId, body *PutPetsPetIdRequest) (json.RawMessage, error)\n")
+	assert.Contains(t, code, "type PutPetsPetIdRequest struct {\n")
+	assert.Contains(t, code, "type SchemaPet struct {\n")
+}
+
+func TestGenerateHandlerStubs_TypedTypeChecks(t *testing.T) {
+	var buf bytes.Buffer
+	err := GenerateHandlerStubs(testClientDefinition, &buf, HandlerStubOptions{Typed: true, GoDoc: true})
+	require.NoError(t, err)
+	typeCheck(t, buf.String())
+}
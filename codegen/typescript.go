@@ -0,0 +1,621 @@
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/go-andiamo/chioas"
+	"github.com/go-andiamo/chioas/internal/refs"
+	"github.com/go-andiamo/chioas/internal/tags"
+	"github.com/go-andiamo/chioas/internal/values"
+	"github.com/go-andiamo/urit"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// TypeScriptOptions is the options for GenerateTypeScript
+type TypeScriptOptions struct {
+	Client   bool // if true, also writes a fetch based client function for each operation
+	Comments bool // if true, writes a doc comment for each type, property and client function
+	UseCRLF  bool // true to use \r\n as the line terminator
+}
+
+// GenerateTypeScript writes TypeScript source for the types (and optionally a fetch based client) of the specified
+// definition to w using the supplied TypeScriptOptions.
+//
+// Types are written for each of the Components.Schemas and for each inline (or referenced) request/response schema of
+// each operation (named as per GenerateSchemaStructs). Schema Ofs are written as unions (oneOf/anyOf) or intersections
+// (allOf) - with a Discriminator narrowing each member by its discriminator property value. A schema/property Enum is
+// written as a union of literals.
+//
+// If TypeScriptOptions.Client is set, a function is written for each operation - named from the operationId (or,
+// where there is no operationId, the same naming as handler stubs) - with path params as arguments followed by the
+// request body (if any) and an object of query and header params. Non-success responses are thrown as an ApiError
+// (generated).
+//
+// Errors:
+//   - Returns the first write error encountered. It does not close w.
+func GenerateTypeScript[T ClientItemType](item T, w io.Writer, opts TypeScriptOptions) error {
+	var def chioas.Definition
+	switch it := any(item).(type) {
+	case chioas.Definition:
+		def = it
+	case *chioas.Definition:
+		def = *it
+	}
+	buf := &bytes.Buffer{}
+	tw := &tsWriter{
+		writer:    newWriter(buf, false, false),
+		opts:      opts,
+		sw:        newStructsWriter(io.Discard, SchemaStructOptions{Components: def.Components}),
+		deduper:   newNameDeDuper(),
+		names:     map[string]string{},
+		typeNames: map[string]string{},
+	}
+	for _, r := range tsReservedTypeNames {
+		tw.deduper.take(r)
+	}
+	tw.generateComponents(def.Components)
+	walkMethods(def, func(path string, _ chioas.PathParams, method string, mDef chioas.Method) {
+		tw.generateMethodTypes(defValue(path, "root"), defValue(path, "root"), method, mDef)
+	})
+	if opts.Client {
+		context := strings.Trim(def.DocOptions.Context, "/")
+		if context != "" {
+			context = "/" + context
+		}
+		tw.writeStatics()
+		walkMethods(def, func(path string, _ chioas.PathParams, method string, mDef chioas.Method) {
+			tw.generateFunc(context, defValue(path, "root"), path, method, mDef)
+		})
+	}
+	if tw.err == nil {
+		// every declaration is followed by a blank line - so trim the final one...
+		out := append(bytes.TrimRight(buf.Bytes(), "\n"), lf...)
+		if opts.UseCRLF {
+			out = bytes.ReplaceAll(out, lf, crlf)
+		}
+		_, tw.err = w.Write(out)
+	}
+	return tw.err
+}
+
+type tsWriter struct {
+	*writer
+	opts      TypeScriptOptions
+	sw        *structsWriter // used for resolving $refs
+	deduper   *nameDeDuper
+	names     map[string]string // component schema name -> type name
+	typeNames map[string]string // typeNameKey -> type name (for operation requests/responses)
+}
+
+func (w *tsWriter) writeLines(indent int, text string, extra bool) {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		w.writeLine(indent, line, extra && i == len(lines)-1)
+	}
+}
+
+func (w *tsWriter) writeComment(indent int, lines ...string) {
+	if w.opts.Comments {
+		if cmt := tsComment(indent, lines...); cmt != "" {
+			w.writeLines(0, cmt, false)
+		}
+	}
+}
+
+// tsComment returns a doc comment (or empty string if there are no non-empty lines)
+func tsComment(indent int, lines ...string) string {
+	actual := make([]string, 0, len(lines))
+	for _, l := range lines {
+		if l != "" {
+			actual = append(actual, strings.Split(strings.ReplaceAll(l, "*/", "*\\/"), "\n")...)
+		}
+	}
+	tabs := strings.Repeat("\t", indent)
+	switch len(actual) {
+	case 0:
+		return ""
+	case 1:
+		return tabs + "/** " + actual[0] + " */"
+	}
+	return tabs + "/**\n" + tabs + " * " + strings.Join(actual, "\n"+tabs+" * ") + "\n" + tabs + " */"
+}
+
+func (w *tsWriter) generateComponents(components *chioas.Components) {
+	if components == nil {
+		return
+	}
+	// name all first (so that refs between schemas can be resolved in any order)...
+	for _, s := range components.Schemas {
+		w.names[s.Name] = w.deduper.take(tsTypeName(s.Name, w.deduper))
+	}
+	for _, s := range components.Schemas {
+		w.writeComment(0, s.Description)
+		w.writeNamedType(w.names[s.Name], &s, false)
+	}
+}
+
+func (w *tsWriter) writeNamedType(name string, s *chioas.Schema, isArray bool) {
+	if !isArray && s.SchemaRef == "" && s.Ofs == nil && s.Discriminator == nil && len(s.Enum) == 0 &&
+		s.Type == values.TypeObject && len(s.Properties) > 0 {
+		w.writeLines(0, "export interface "+name+" "+w.objectType(s.Properties, s.RequiredProperties, 0), true)
+		return
+	}
+	t := w.schemaType(s, 0)
+	if isArray {
+		t = tsArrayOf(t)
+	}
+	w.writeLines(0, "export type "+name+" = "+t+";", true)
+}
+
+func (w *tsWriter) generateMethodTypes(namingPath string, key string, m string, def chioas.Method) {
+	name := defaultStubNaming.Name(namingPath, m, def)
+	if def.Request != nil {
+		if r, err := w.sw.resolveRequest(def.Request, nil); err == nil {
+			w.writeOperationType(toPascal(name+" Request"), typeNameKey(m, key, "request"), m+" "+namingPath+" request",
+				r.ContentType, r.Schema, r.SchemaRef, r.IsArray)
+		}
+	}
+	for _, status := range sortedKeys(def.Responses) {
+		rd := def.Responses[status]
+		if r, err := w.sw.resolveResponse(&rd, nil); err == nil && !r.NoContent {
+			w.writeOperationType(toPascal(name+" "+statusCodeName(status)+" Response"), typeNameKey(m, key, strconv.Itoa(status)), m+" "+namingPath+" "+strconv.Itoa(status)+" response",
+				r.ContentType, r.Schema, r.SchemaRef, r.IsArray)
+		}
+	}
+}
+
+func (w *tsWriter) writeOperationType(name string, key string, comment string, contentType string, schema any, schemaRef string, isArray bool) {
+	isJson := isJsonContentType(defValue(contentType, tags.ApplicationJson))
+	var s *chioas.Schema
+	if schemaRef != "" {
+		s = &chioas.Schema{SchemaRef: schemaRef}
+	} else if s = getSchema(schema); s == nil && isJson {
+		return
+	}
+	name = w.deduper.take(name)
+	w.typeNames[key] = name
+	w.writeComment(0, comment)
+	if !isJson {
+		w.writeLine(0, "export type "+name+" = Blob;", true)
+		return
+	}
+	w.writeNamedType(name, s, isArray)
+}
+
+// schemaType returns the TypeScript type expression for a schema
+func (w *tsWriter) schemaType(s *chioas.Schema, indent int) string {
+	if s.SchemaRef != "" {
+		return w.refType(s.SchemaRef)
+	}
+	if len(s.Enum) > 0 {
+		return tsLiterals(s.Enum)
+	}
+	parts := make([]string, 0, 2)
+	switch s.Type {
+	case values.TypeObject:
+		if len(s.Properties) > 0 || (s.Ofs == nil && s.Discriminator == nil) {
+			parts = append(parts, w.objectType(s.Properties, s.RequiredProperties, indent))
+		}
+	case "":
+		if len(s.Properties) > 0 {
+			parts = append(parts, w.objectType(s.Properties, s.RequiredProperties, indent))
+		} else if s.Ofs == nil && s.Discriminator == nil {
+			parts = append(parts, "unknown")
+		}
+	default:
+		parts = append(parts, tsPrimitive(s.Type))
+	}
+	if members, allOf := w.ofMembers(s, indent); len(members) > 0 {
+		if allOf || len(members) == 1 {
+			parts = append(parts, members...)
+		} else if len(parts) > 0 {
+			parts = append(parts, "("+strings.Join(members, " | ")+")")
+		} else {
+			return strings.Join(members, " | ")
+		}
+	}
+	return strings.Join(parts, " & ")
+}
+
+// ofMembers returns the members of the schema Ofs (with discriminator narrowing)
+func (w *tsWriter) ofMembers(s *chioas.Schema, indent int) (members []string, allOf bool) {
+	var d *chioas.Discriminator
+	if s.Discriminator != nil && s.Discriminator.PropertyName != "" {
+		d = s.Discriminator
+	}
+	if s.Ofs != nil && len(s.Ofs.Of) > 0 {
+		allOf = s.Ofs.OfType == chioas.AllOf
+		for _, of := range s.Ofs.Of {
+			if of.IsRef() {
+				m := w.refType(of.Ref())
+				if d != nil && !allOf {
+					m = m + " & " + w.discriminatorNarrowing(d, refs.Normalize(tags.Schemas, of.Ref()))
+				}
+				members = append(members, m)
+			} else if os := of.Schema(); os != nil {
+				m := w.schemaType(os, indent)
+				if strings.Contains(m, " | ") {
+					m = "(" + m + ")"
+				}
+				members = append(members, m)
+			}
+		}
+	} else if d != nil && len(d.Mapping) > 0 {
+		// discriminator without ofs - the union of the mapped schemas...
+		seen := map[string]bool{}
+		for _, k := range sortedKeys(d.Mapping) {
+			ref := refs.Normalize(tags.Schemas, d.Mapping[k])
+			if !seen[ref] {
+				seen[ref] = true
+				members = append(members, w.refType(ref)+" & "+w.discriminatorNarrowing(d, ref))
+			}
+		}
+	}
+	return
+}
+
+func (w *tsWriter) discriminatorNarrowing(d *chioas.Discriminator, ref string) string {
+	vs := make([]any, 0)
+	for _, k := range sortedKeys(d.Mapping) {
+		if refs.Normalize(tags.Schemas, d.Mapping[k]) == ref {
+			vs = append(vs, k)
+		}
+	}
+	if len(vs) == 0 {
+		vs = append(vs, ref)
+	}
+	return "{ " + tsPropertyName(d.PropertyName) + ": " + tsLiterals(vs) + " }"
+}
+
+func (w *tsWriter) refType(ref string) string {
+	if !strings.Contains(ref, "/") || strings.HasPrefix(ref, refs.ComponentsPrefix+tags.Schemas+"/") {
+		if name, ok := w.names[refs.Normalize(tags.Schemas, ref)]; ok {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+// objectType returns the TypeScript object type literal for properties
+func (w *tsWriter) objectType(ptys chioas.Properties, reqdPtys []string, indent int) string {
+	if len(ptys) == 0 {
+		return "Record<string, unknown>"
+	}
+	var sb strings.Builder
+	sb.WriteString("{\n")
+	for _, p := range ptys {
+		if w.opts.Comments {
+			deprecated := ""
+			if p.Deprecated {
+				deprecated = "@deprecated"
+			}
+			if cmt := tsComment(indent+1, p.Description, deprecated); cmt != "" {
+				sb.WriteString(cmt + "\n")
+			}
+		}
+		optional := "?"
+		if p.Required || slices.Contains(reqdPtys, p.Name) {
+			optional = ""
+		}
+		sb.WriteString(strings.Repeat("\t", indent+1) + tsPropertyName(p.Name) + optional + ": " + w.propertyType(p, indent+1) + ";\n")
+	}
+	sb.WriteString(strings.Repeat("\t", indent) + "}")
+	return sb.String()
+}
+
+func (w *tsWriter) propertyType(p chioas.Property, indent int) string {
+	isArray := p.Type == values.TypeArray
+	var t string
+	switch {
+	case p.SchemaRef != "":
+		t = w.refType(p.SchemaRef)
+	case len(p.Enum) > 0:
+		t = tsLiterals(p.Enum)
+	case isArray:
+		isArray = false
+		switch p.ItemType {
+		case values.TypeObject:
+			t = tsArrayOf(w.objectType(p.Properties, nil, indent))
+		case "":
+			t = "string[]"
+		default:
+			t = tsArrayOf(tsPrimitive(p.ItemType))
+		}
+	case p.Type == values.TypeObject:
+		t = w.objectType(p.Properties, nil, indent)
+	case p.Type == "":
+		t = "string"
+	default:
+		t = tsPrimitive(p.Type)
+	}
+	if isArray {
+		t = tsArrayOf(t)
+	}
+	return t
+}
+
+func tsPrimitive(oasType string) string {
+	switch oasType {
+	case values.TypeString:
+		return "string"
+	case values.TypeInteger, values.TypeNumber:
+		return "number"
+	case values.TypeBoolean:
+		return "boolean"
+	case "null":
+		return "null"
+	case values.TypeArray:
+		return "unknown[]"
+	case values.TypeObject:
+		return "Record<string, unknown>"
+	}
+	return "unknown"
+}
+
+func tsArrayOf(t string) string {
+	if strings.Contains(t, " | ") || strings.Contains(t, " & ") {
+		return "(" + t + ")[]"
+	}
+	return t + "[]"
+}
+
+func tsLiterals(vs []any) string {
+	ls := make([]string, 0, len(vs))
+	for _, v := range vs {
+		if data, err := json.Marshal(v); err == nil {
+			ls = append(ls, string(data))
+		}
+	}
+	return strings.Join(ls, " | ")
+}
+
+var tsIdentifierRegex = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+func tsPropertyName(name string) string {
+	if tsIdentifierRegex.MatchString(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
+
+// tsReservedTypeNames is the type names that are not used for generated types (globals and generated client types)
+var tsReservedTypeNames = []string{
+	"Array", "Blob", "Boolean", "Date", "Error", "Function", "Map", "Number", "Object", "Promise", "Record", "RegExp",
+	"Request", "Response", "Set", "String", "Symbol", "URL",
+	"ApiError", "ClientOptions", "RequestArgs",
+}
+
+func tsTypeName(name string, deduper *nameDeDuper) string {
+	result := toPascal(name)
+	if result == "" || (result[0] >= '0' && result[0] <= '9') {
+		result = "T" + result
+	} else if _, used := deduper.used[result]; used && slices.Contains(tsReservedTypeNames, result) {
+		result = "Schema" + result
+	}
+	return result
+}
+
+var tsReserved = map[string]bool{
+	"break": true, "case": true, "catch": true, "class": true, "const": true, "continue": true, "debugger": true,
+	"default": true, "delete": true, "do": true, "else": true, "enum": true, "export": true, "extends": true,
+	"false": true, "finally": true, "for": true, "function": true, "if": true, "import": true, "in": true,
+	"instanceof": true, "new": true, "null": true, "return": true, "super": true, "switch": true, "this": true,
+	"throw": true, "true": true, "try": true, "typeof": true, "var": true, "void": true, "while": true, "with": true,
+	"let": true, "static": true, "yield": true, "await": true, "implements": true, "interface": true,
+	"package": true, "private": true, "protected": true, "public": true,
+}
+
+func tsArgName(name string, argNames *nameDeDuper) string {
+	result := argName(name, argNames)
+	if tsReserved[result] {
+		result = argNames.take("p" + toPascal(result))
+	}
+	return result
+}
+
+func (w *tsWriter) writeStatics() {
+	for _, line := range strings.Split(tsClientStatics, "\n") {
+		w.writeLine(0, line, false)
+	}
+}
+
+var tsReservedArgNames = []string{"options", "params", "body"}
+
+func (w *tsWriter) generateFunc(context string, key string, path string, method string, def chioas.Method) {
+	fullPath := context + path
+	if fullPath == "" {
+		fullPath = "/"
+	}
+	name := def.OperationId
+	if name == "" {
+		name = defaultStubNaming.Name(path, method, def)
+	}
+	name = toPascal(name)
+	name = w.deduper.take(strings.ToLower(name[:1]) + name[1:])
+	argNames := newNameDeDuper()
+	for _, r := range tsReservedArgNames {
+		argNames.take(r)
+	}
+	args := []string{"options: ClientOptions"}
+	// path params...
+	if t, err := urit.NewTemplate(fullPath); err == nil {
+		fullPath = t.Template(true)
+	}
+	pathExpr := clientPathVarRegex.ReplaceAllStringFunc(strings.ReplaceAll(fullPath, "`", "\\`"), func(s string) string {
+		an := tsArgName(s[1:len(s)-1], argNames)
+		args = append(args, an+": string | number")
+		return "${encodeURIComponent(String(" + an + "))}"
+	})
+	// body...
+	reqInit := make([]string, 0)
+	if def.Request != nil {
+		if r, err := w.sw.resolveRequest(def.Request, nil); err == nil {
+			contentType := defValue(r.ContentType, tags.ApplicationJson)
+			bt, ok := w.typeNames[typeNameKey(method, key, "request")]
+			if !ok {
+				bt = "unknown"
+			}
+			if r.Required {
+				args = append(args, "body: "+bt)
+			} else {
+				args = append(args, "body?: "+bt)
+			}
+			reqInit = append(reqInit, "body", "contentType: "+strconv.Quote(contentType))
+		}
+	}
+	// query & header params...
+	ptys := make([]string, 0)
+	query := make([]string, 0)
+	headers := make([]string, 0)
+	anyRequired := false
+	for _, p := range w.sw.params(def.QueryParams, newNameDeDuper()) {
+		if p.in != values.Query && p.in != values.Header {
+			continue
+		}
+		pn := tsPropertyName(p.name)
+		optional := "?"
+		if p.required {
+			optional = ""
+			anyRequired = true
+		}
+		ptys = append(ptys, pn+optional+": "+tsGoType(p.goType))
+		access := "params." + p.name
+		if pn != p.name {
+			access = "params[" + pn + "]"
+		}
+		if p.in == values.Query {
+			query = append(query, pn+": "+access)
+		} else {
+			headers = append(headers, pn+": "+access)
+		}
+	}
+	if len(ptys) > 0 {
+		pt := "{ " + strings.Join(ptys, "; ") + " }"
+		if anyRequired {
+			args = append(args, "params: "+pt)
+		} else {
+			args = append(args, "params: "+pt+" = {}")
+		}
+	}
+	if len(query) > 0 {
+		reqInit = append(reqInit, "query: { "+strings.Join(query, ", ")+" }")
+	}
+	if len(headers) > 0 {
+		reqInit = append(reqInit, "headers: { "+strings.Join(headers, ", ")+" }")
+	}
+	// result...
+	result := "void"
+	statuses := sortedKeys(def.Responses)
+	for _, status := range statuses {
+		if status >= 200 && status < 300 {
+			if rt, ok := w.typeNames[typeNameKey(method, key, strconv.Itoa(status))]; ok {
+				result = rt
+				rd := def.Responses[status]
+				if r, err := w.sw.resolveResponse(&rd, nil); err == nil && !isJsonContentType(defValue(r.ContentType, tags.ApplicationJson)) {
+					reqInit = append(reqInit, "raw: true")
+				}
+				break
+			}
+		}
+	}
+	w.writeComment(0, method+" "+fullPath, def.Description)
+	w.writeLine(0, "export async function "+name+"("+strings.Join(args, ", ")+"): Promise<"+result+"> {", false)
+	call := "return request<" + result + ">(options, " + strconv.Quote(method) + ", `" + pathExpr + "`"
+	if len(reqInit) > 0 {
+		w.writeLine(1, call+", {", false)
+		for _, ri := range reqInit {
+			w.writeLine(2, ri+",", false)
+		}
+		w.writeLine(1, "});", false)
+	} else {
+		w.writeLine(1, call+");", false)
+	}
+	w.writeLine(0, "}", true)
+}
+
+func tsGoType(goType string) string {
+	if strings.HasPrefix(goType, "[]") {
+		return tsGoType(goType[2:]) + "[]"
+	}
+	switch goType {
+	case "int", "float64":
+		return "number"
+	case "bool":
+		return "boolean"
+	}
+	return "string"
+}
+
+const tsClientStatics = `/** ClientOptions is the options passed to each client function */
+export interface ClientOptions {
+	/** baseUrl is the base url of the API (e.g. "https://api.example.com") */
+	baseUrl: string;
+	/** fetch is the fetch implementation to use (defaults to the global fetch) */
+	fetch?: typeof fetch;
+	/** headers are additional headers sent with every request (e.g. for auth) */
+	headers?: Record<string, string> | (() => Record<string, string> | Promise<Record<string, string>>);
+}
+
+/** ApiError is thrown when the API responds with a non-success status code */
+export class ApiError extends Error {
+	constructor(public readonly status: number, public readonly body: unknown) {
+		super("unexpected response status code " + status);
+		this.name = "ApiError";
+	}
+}
+
+interface RequestArgs {
+	query?: Record<string, unknown>;
+	headers?: Record<string, unknown>;
+	body?: unknown;
+	contentType?: string;
+	raw?: boolean;
+}
+
+async function request<T>(options: ClientOptions, method: string, path: string, args: RequestArgs = {}): Promise<T> {
+	let url = options.baseUrl.replace(/\/+$/, "") + path;
+	const qs = new URLSearchParams();
+	for (const [k, v] of Object.entries(args.query ?? {})) {
+		if (Array.isArray(v)) {
+			v.forEach((item) => qs.append(k, String(item)));
+		} else if (v !== undefined && v !== null) {
+			qs.append(k, String(v));
+		}
+	}
+	if (qs.toString() !== "") {
+		url += "?" + qs.toString();
+	}
+	const headers: Record<string, string> = typeof options.headers === "function" ? { ...(await options.headers()) } : { ...options.headers };
+	for (const [k, v] of Object.entries(args.headers ?? {})) {
+		if (v !== undefined && v !== null) {
+			headers[k] = String(v);
+		}
+	}
+	let body: BodyInit | undefined;
+	if (args.body !== undefined) {
+		const contentType = args.contentType ?? "application/json";
+		headers["Content-Type"] = contentType;
+		body = contentType.toLowerCase().includes("json") ? JSON.stringify(args.body) : (args.body as BodyInit);
+	}
+	const res = await (options.fetch ?? fetch)(url, { method, headers, body });
+	if (!res.ok) {
+		const text = await res.text();
+		let value: unknown = text;
+		try {
+			value = text !== "" ? JSON.parse(text) : undefined;
+		} catch {
+			// not json - leave as text
+		}
+		throw new ApiError(res.status, value);
+	}
+	if (args.raw) {
+		return (await res.blob()) as T;
+	}
+	const text = await res.text();
+	return (text !== "" ? JSON.parse(text) : undefined) as T;
+}
+`
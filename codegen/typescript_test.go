@@ -0,0 +1,166 @@
+package codegen
+
+import (
+	"bytes"
+	"github.com/go-andiamo/chioas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+var tsTestDefinition = withTestSchemas(testClientDefinition,
+	chioas.Schema{
+		Name:               "Cat",
+		Type:               "object",
+		RequiredProperties: []string{"petType"},
+		Properties: chioas.Properties{
+			{Name: "petType", Type: "string"},
+			{Name: "lives", Type: "integer", Description: "remaining lives", Deprecated: true},
+		},
+	},
+	chioas.Schema{
+		Name: "Dog",
+		Type: "object",
+		Properties: chioas.Properties{
+			{Name: "petType", Type: "string"},
+			{Name: "tricks", Type: "array", ItemType: "object", Properties: chioas.Properties{{Name: "name", Required: true}}},
+			{Name: "x-size", Enum: []any{"S", "M", "L"}},
+		},
+	},
+	chioas.Schema{
+		Name: "Animal",
+		Discriminator: &chioas.Discriminator{
+			PropertyName: "petType",
+			Mapping:      map[string]string{"cat": "Cat", "kitty": "#/components/schemas/Cat"},
+		},
+		Ofs: &chioas.Ofs{Of: []chioas.OfSchema{&chioas.Of{SchemaRef: "Cat"}, &chioas.Of{SchemaRef: "Dog"}}},
+	},
+	chioas.Schema{
+		Name: "Both",
+		Ofs: &chioas.Ofs{
+			OfType: chioas.AllOf,
+			Of: []chioas.OfSchema{
+				&chioas.Of{SchemaRef: "Cat"},
+				&chioas.Of{SchemaDef: &chioas.Schema{Type: "object", Properties: chioas.Properties{{Name: "extra", Type: "boolean"}}}},
+			},
+		},
+	},
+	chioas.Schema{Name: "Status", Type: "string", Enum: []any{"on", "off", nil}},
+)
+
+func withTestSchemas(def chioas.Definition, schemas ...chioas.Schema) chioas.Definition {
+	c := *def.Components
+	c.Schemas = append(append(chioas.Schemas{}, c.Schemas...), schemas...)
+	def.Components = &c
+	return def
+}
+
+func TestGenerateTypeScript(t *testing.T) {
+	var buf bytes.Buffer
+	err := GenerateTypeScript(tsTestDefinition, &buf, TypeScriptOptions{Client: true, Comments: true})
+	require.NoError(t, err)
+	code := buf.String()
+	const expectTypes = `export interface Pet {
+	id?: number;
+	name: string;
+}
+
+export interface SchemaError {
+	message?: string;
+}
+
+export interface Cat {
+	petType: string;
+	/**
+	 * remaining lives
+	 * @deprecated
+	 */
+	lives?: number;
+}
+
+export interface Dog {
+	petType?: string;
+	tricks?: {
+		name: string;
+	}[];
+	"x-size"?: "S" | "M" | "L";
+}
+
+export type Animal = Cat & { petType: "cat" | "kitty" } | Dog & { petType: "Dog" };
+
+export type Both = Cat & {
+	extra?: boolean;
+};
+
+export type Status = "on" | "off" | null;
+
+/** GET /pets 200 response */
+export type GetPetsOkResponse = Pet[];
+`
+	assert.True(t, strings.HasPrefix(code, expectTypes), code)
+	assert.Contains(t, code, "export type PostPetsConflictResponse = SchemaError;\n")
+	assert.Contains(t, code, "export type GetPhotoOkResponse = Blob;\n")
+	assert.Contains(t, code, "export type PutPhotoRequest = Blob;\n")
+	assert.Contains(t, code, "export class ApiError extends Error {\n")
+	assert.Contains(t, code, `/** GET /api/pets */
+export async function listPets(options: ClientOptions, params: { limit?: number; tags?: string[]; "X-Trace": string }): Promise<GetPetsOkResponse> {
+	return request<GetPetsOkResponse>(options, "GET", `+"`/api/pets`"+`, {
+		query: { limit: params.limit, tags: params.tags },
+		headers: { "X-Trace": params["X-Trace"] },
+	});
+}
+`)
+	assert.Contains(t, code, `export async function postPets(options: ClientOptions, body: PostPetsRequest): Promise<PostPetsCreatedResponse> {
+	return request<PostPetsCreatedResponse>(options, "POST", `+"`/api/pets`"+`, {
+		body,
+		contentType: "application/json",
+	});
+}
+`)
+	assert.Contains(t, code, `export async function deletePet(options: ClientOptions, petId: string | number): Promise<void> {
+	return request<void>(options, "DELETE", `+"`/api/pets/${encodeURIComponent(String(petId))}`"+`);
+}
+`)
+	assert.Contains(t, code, `export async function getPhoto(options: ClientOptions, petId: string | number): Promise<GetPhotoOkResponse> {
+	return request<GetPhotoOkResponse>(options, "GET", `+"`/api/pets/${encodeURIComponent(String(petId))}/photo`"+`, {
+		raw: true,
+	});
+}
+`)
+	assert.Contains(t, code, "export async function putPhoto(options: ClientOptions, petId: string | number, body?: PutPhotoRequest): Promise<void> {\n")
+}
+
+func TestGenerateTypeScript_Defaults(t *testing.T) {
+	var buf bytes.Buffer
+	err := GenerateTypeScript(tsTestDefinition, &buf, TypeScriptOptions{})
+	require.NoError(t, err)
+	code := buf.String()
+	assert.NotContains(t, code, "/**")
+	assert.NotContains(t, code, "ClientOptions")
+	assert.NotContains(t, code, "export async function")
+	assert.Contains(t, code, "export type GetPetsOkResponse = Pet[];\n")
+}
+
+func TestGenerateTypeScript_Root(t *testing.T) {
+	var buf bytes.Buffer
+	err := GenerateTypeScript(chioas.Definition{Methods: chioas.Methods{http.MethodPost: {
+		Request: &chioas.Request{
+			Schema: &chioas.Schema{
+				Type:       "object",
+				Properties: chioas.Properties{{Name: "ids", Type: "array", ItemType: "integer"}},
+			},
+		},
+		Responses: chioas.Responses{
+			http.StatusOK: {Schema: &chioas.Schema{Type: "string", Enum: []any{"a", "b"}}},
+		},
+	}}}, &buf, TypeScriptOptions{UseCRLF: true})
+	require.NoError(t, err)
+	assert.Equal(t, "export interface PostRootRequest {\r\n\tids?: number[];\r\n}\r\n\r\nexport type PostRootOkResponse = \"a\" | \"b\";\r\n", buf.String())
+}
+
+func TestGenerateTypeScript_Errors(t *testing.T) {
+	err := GenerateTypeScript(tsTestDefinition, &errorWriter{}, TypeScriptOptions{Client: true})
+	require.Error(t, err)
+}
@@ -120,7 +120,11 @@ func writeContentType(contentType string, cw contentWritable, w yaml.Writer) {
 		w.WriteTagValue(tags.Type, values.TypeObject)
 	}
 	w.WriteTagEnd()
-	cw.examples().writeYaml(w)
+	if egs := cw.examples(); len(egs) > 0 {
+		egs.writeYaml(w)
+	} else {
+		writeGeneratedExample(cw.schema(), cw.schemaRef(), isArray, w)
+	}
 	writeExtensions(cw.extensions(), w)
 	writeAdditional(cw.additional(), nil, w)
 	w.WriteTagEnd()
@@ -61,12 +61,30 @@ type Definition struct {
 	//
 	// If MethodHandlerBuilder is nil then the default method handler builder is used
 	MethodHandlerBuilder MethodHandlerBuilder
+	// OperationMiddleware is an optional function that is called for each operation (method on a path) when
+	// routes are setup - the returned middleware (if non-nil) is applied to that operation only
+	//
+	// This allows middlewares (e.g. auth, metrics, rate limiting) to be built generically from the spec metadata (see OperationInfo)
+	OperationMiddleware OperationMiddleware
+	routeMatcher        *RouteMatcher
+	operationTemplates  operationTemplates
 }
 
 // SetupRoutes sets up the API routes on the supplied chi.Router
 //
 // Pass the thisApi arg if any of the methods use method by name
 func (d *Definition) SetupRoutes(router chi.Router, thisApi any) error {
+	// validate before anything is setup on the router...
+	rm, err := d.RouteMatcher()
+	if err != nil {
+		return err
+	}
+	ots, err := d.buildOperationTemplates()
+	if err != nil {
+		return err
+	}
+	d.routeMatcher = rm
+	d.operationTemplates = ots
 	if err := d.DocOptions.SetupRoutes(d, router); err != nil {
 		return err
 	}
@@ -76,10 +94,10 @@ func (d *Definition) SetupRoutes(router chi.Router, thisApi any) error {
 		middlewares = append(middlewares, d.ApplyMiddlewares(thisApi)...)
 	}
 	subRoute.Use(middlewares...)
-	if err := d.setupMethods(root, nil, d.Methods, d.RootAutoOptionsMethod, subRoute, thisApi); err != nil {
+	if err := d.setupMethods(root, nil, "", d.Methods, d.RootAutoOptionsMethod, subRoute, thisApi); err != nil {
 		return err
 	}
-	if err := d.setupPaths(nil, d.Paths, subRoute, thisApi); err != nil {
+	if err := d.setupPaths(nil, "", d.Paths, subRoute, thisApi); err != nil {
 		return err
 	}
 	if d.AutoMethodNotAllowed {
@@ -89,7 +107,7 @@ func (d *Definition) SetupRoutes(router chi.Router, thisApi any) error {
 	return nil
 }
 
-func (d *Definition) setupPaths(ancestry []string, paths Paths, route chi.Router, thisApi any) error {
+func (d *Definition) setupPaths(ancestry []string, parentTag string, paths Paths, route chi.Router, thisApi any) error {
 	if paths != nil {
 		for p, pDef := range paths {
 			disabled := false
@@ -98,6 +116,7 @@ func (d *Definition) setupPaths(ancestry []string, paths Paths, route chi.Router
 			}
 			if !disabled {
 				newAncestry := append(ancestry, p)
+				useTag := defaultTag(parentTag, pDef.Tag)
 				subRoute := chi.NewRouter()
 				middlewares := pDef.Middlewares
 				if pDef.ApplyMiddlewares != nil {
@@ -107,10 +126,10 @@ func (d *Definition) setupPaths(ancestry []string, paths Paths, route chi.Router
 					subRoute.MethodNotAllowed(d.methodNotAllowedHandler(pDef.Methods))
 				}
 				subRoute.Use(middlewares...)
-				if err := d.setupMethods(strings.Join(newAncestry, ""), &pDef, pDef.Methods, d.AutoOptionsMethods || pDef.AutoOptionsMethod, subRoute, thisApi); err != nil {
+				if err := d.setupMethods(strings.Join(newAncestry, ""), &pDef, useTag, pDef.Methods, d.AutoOptionsMethods || pDef.AutoOptionsMethod, subRoute, thisApi); err != nil {
 					return err
 				}
-				if err := d.setupPaths(newAncestry, pDef.Paths, subRoute, thisApi); err != nil {
+				if err := d.setupPaths(newAncestry, useTag, pDef.Paths, subRoute, thisApi); err != nil {
 					return err
 				}
 				route.Mount(p, subRoute)
@@ -120,11 +139,11 @@ func (d *Definition) setupPaths(ancestry []string, paths Paths, route chi.Router
 	return nil
 }
 
-func (d *Definition) setupMethods(path string, pathDef *Path, methods Methods, pathAutoOptions bool, route chi.Router, thisApi any) error {
+func (d *Definition) setupMethods(path string, pathDef *Path, parentTag string, methods Methods, pathAutoOptions bool, route chi.Router, thisApi any) error {
 	if methods != nil && len(methods) > 0 {
 		for m, mDef := range methods {
 			if h, err := getMethodHandlerBuilder(d.MethodHandlerBuilder).BuildHandler(path, m, mDef, thisApi); err == nil {
-				route.MethodFunc(m, root, h)
+				route.Method(m, root, d.methodHandler(path, pathDef, m, mDef, parentTag, h))
 			} else {
 				return err
 			}
@@ -135,7 +154,7 @@ func (d *Definition) setupMethods(path string, pathDef *Path, methods Methods, p
 		if d.AutoHeadMethods {
 			if mDef, ok := methods.getWithoutHead(); ok {
 				h, _ := getMethodHandlerBuilder(d.MethodHandlerBuilder).BuildHandler(path, http.MethodHead, mDef, thisApi)
-				route.MethodFunc(http.MethodHead, root, h)
+				route.Method(http.MethodHead, root, d.methodHandler(path, pathDef, http.MethodHead, mDef, parentTag, h))
 			}
 		}
 	} else if pathAutoOptions {
@@ -182,6 +201,13 @@ func (d *Definition) methodNotAllowedHandler(methods Methods) http.HandlerFunc {
 
 // WriteYaml writes the definition as YAML to the provided io.Writer
 func (d *Definition) WriteYaml(w io.Writer) error {
+	if len(d.DocOptions.Overlays) > 0 {
+		data, err := d.AsYaml()
+		if err == nil {
+			_, err = w.Write(data)
+		}
+		return err
+	}
 	yw := yaml.NewWriter(bufio.NewWriter(w))
 	err := d.writeYaml(yw)
 	if err == nil {
@@ -196,8 +222,10 @@ func (d *Definition) WriteJson(writer io.Writer) (err error) {
 	_ = d.writeYaml(w)
 	var data []byte
 	if data, err = w.Bytes(); err == nil {
-		if data, err = yaml2Json(data); err == nil {
-			_, err = writer.Write(data)
+		if data, err = d.applyOverlays(data); err == nil {
+			if data, err = yaml2Json(data); err == nil {
+				_, err = writer.Write(data)
+			}
 		}
 	}
 	return
@@ -211,7 +239,11 @@ func yaml2Json(yamlData []byte) (data []byte, err error) {
 func (d *Definition) AsYaml() ([]byte, error) {
 	w := yaml.NewWriter(nil)
 	_ = d.writeYaml(w)
-	return w.Bytes()
+	data, err := w.Bytes()
+	if err == nil {
+		data, err = d.applyOverlays(data)
+	}
+	return data, err
 }
 
 // AsJson returns the spec as JSON data
@@ -219,7 +251,17 @@ func (d *Definition) AsJson() (data []byte, err error) {
 	w := yaml.NewWriter(nil)
 	_ = d.writeYaml(w)
 	if data, err = w.Bytes(); err == nil {
-		data, err = yaml2Json(data)
+		if data, err = d.applyOverlays(data); err == nil {
+			data, err = yaml2Json(data)
+		}
+	}
+	return
+}
+
+func (d *Definition) applyOverlays(data []byte) (result []byte, err error) {
+	result = data
+	for i := 0; i < len(d.DocOptions.Overlays) && err == nil; i++ {
+		result, err = d.DocOptions.Overlays[i].Apply(result)
 	}
 	return
 }
@@ -228,6 +270,11 @@ func (d *Definition) writeYaml(w yaml.Writer) error {
 	if d.DocOptions.CheckRefs {
 		w.RefChecker(d)
 	}
+	if d.DocOptions.GenerateExamples {
+		if egw, ok := w.(yaml.ExampleGeneratorWriter); ok {
+			egw.ExampleGenerator(&docsExampleGenerator{def: d})
+		}
+	}
 	w.WriteComments(d.Comment)
 	w.WriteTagValue(tags.OpenApi, OasVersion)
 	d.Info.writeYaml(w)
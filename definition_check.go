@@ -9,329 +9,262 @@ import (
 	"strings"
 )
 
+// RefError is an error found by Definition.CheckRefs
 type RefError struct {
-	Msg      string
-	Ref      string
-	Path     string
-	Method   string
-	Item     any
+	Msg string
+	Ref string
+	// Path is the path of the item - or, for items in components, the component (e.g. "#/components/schemas[0]" or "#/components/parameters[name]")
+	Path   string
+	Method string
+	Item   any
+	// ItemName is the name of the item (only set for path params and common parameters)
 	ItemName string
+	// Pointer is the JSON pointer to the item in the OAS spec
+	Pointer string
 }
 
 func (e *RefError) Error() string {
 	return e.Msg
 }
 
+// CheckRefs checks that all $refs in the definition resolve (and that there are no cyclic refs)
 func (d *Definition) CheckRefs() (result []error) {
-	for k, v := range d.Methods {
-		result = append(result, v.checkRefs(root, k, d)...)
-	}
-	d.Paths.refsWalk("", func(path string, pathDef Path) {
-		result = append(result, pathDef.checkRefs(path, d)...)
-		for k, v := range pathDef.Methods {
-			result = append(result, v.checkRefs(path, k, d)...)
-		}
-	})
-	if d.Components != nil {
-		result = append(result, d.Components.checkRefs(d)...)
-	}
-	return result
-}
-
-func (ps Paths) refsWalk(currPath string, fn func(path string, pathDef Path)) {
-	for k, v := range ps {
-		fn(currPath+k, v)
-		v.Paths.refsWalk(currPath+k, fn)
-	}
+	c := &refsChecker{def: d}
+	_ = d.Visit(c.visitor())
+	return c.result
 }
 
-func (m Method) checkRefs(path string, method string, def *Definition) (result []error) {
-	result = append(result, m.QueryParams.checkRefs(path, method, def)...)
-	if m.Request != nil {
-		result = append(result, m.Request.checkRefs(path, method, def)...)
-	}
-	for _, r := range m.Responses {
-		result = append(result, r.checkRefs(path, method, def)...)
-	}
-	return result
+// refsChecker is the Visitor used by Definition.CheckRefs
+type refsChecker struct {
+	def    *Definition
+	result []error
+	// seen is the stack of seen schema refs - each schema starts a new scope (shared by its properties)
+	seen []map[string]bool
+	// seed is the initial seen refs for the next schema scope
+	seed map[string]bool
+	// added is the stack of refs added to the current scope by properties (removed on leaving the property)
+	added []string
 }
 
-func (c *Components) checkRefs(def *Definition) (result []error) {
-	for i, s := range c.Schemas {
-		seen := map[string]bool{
-			refs.Canonical(tags.Schemas, s.Name): true,
-		}
-		result = append(result, s.checkRefs(fmt.Sprintf(refs.ComponentsPrefix+tags.Schemas+"[%d]", i), "", def, seen)...)
-	}
-	for name, r := range c.Requests {
-		result = append(result, r.checkRefs(fmt.Sprintf(refs.ComponentsPrefix+tags.RequestBodies+"[%s]", name), "", def)...)
-	}
-	for name, r := range c.Responses {
-		result = append(result, r.checkRefs(fmt.Sprintf(refs.ComponentsPrefix+tags.Responses+"[%s]", name), "", def)...)
-	}
-	for name, p := range c.Parameters {
-		result = append(result, p.checkRefs(fmt.Sprintf(refs.ComponentsPrefix+tags.Parameters+"[%s]", name), name, def)...)
-	}
-	for i, eg := range c.Examples {
-		result = append(result, eg.checkRefs(fmt.Sprintf(refs.ComponentsPrefix+tags.Examples+"[%d]", i), "", def)...)
+func (c *refsChecker) visitor() *Visitor {
+	return &Visitor{
+		PathParam: func(ctx *VisitContext, param *PathParam) (VisitAction, error) {
+			c.checkRef(ctx, param.Ref, tags.Parameters, *param)
+			c.checkRef(ctx, param.SchemaRef, tags.Schemas, *param)
+			if param.Schema != nil {
+				c.checkHasSchemaRefWithSchema(ctx, param.SchemaRef, *param)
+			}
+			return VisitContinue, nil
+		},
+		QueryParam: func(ctx *VisitContext, param *QueryParam) (VisitAction, error) {
+			c.checkRef(ctx, param.Ref, tags.Parameters, *param)
+			c.checkRef(ctx, param.SchemaRef, tags.Schemas, *param)
+			if param.Schema != nil {
+				c.checkHasSchemaRefWithSchema(ctx, param.SchemaRef, *param)
+			}
+			return VisitContinue, nil
+		},
+		CommonParameter: func(ctx *VisitContext, param *CommonParameter) (VisitAction, error) {
+			c.checkRef(ctx, param.SchemaRef, tags.Schemas, *param)
+			if param.Schema != nil {
+				c.checkHasSchemaRefWithSchema(ctx, param.SchemaRef, *param)
+			}
+			return VisitContinue, nil
+		},
+		Request: func(ctx *VisitContext, request *Request) (VisitAction, error) {
+			c.checkRef(ctx, request.Ref, tags.RequestBodies, request)
+			c.checkRef(ctx, request.SchemaRef, tags.Schemas, request)
+			c.checkVaryingSchema(ctx, request.Schema, request.SchemaRef, request)
+			return VisitContinue, nil
+		},
+		Response: func(ctx *VisitContext, response *Response) (VisitAction, error) {
+			c.checkRef(ctx, response.Ref, tags.Responses, *response)
+			c.checkRef(ctx, response.SchemaRef, tags.Schemas, *response)
+			c.checkVaryingSchema(ctx, response.Schema, response.SchemaRef, *response)
+			return VisitContinue, nil
+		},
+		ContentType: func(ctx *VisitContext, contentType *ContentType) (VisitAction, error) {
+			c.checkRef(ctx, contentType.SchemaRef, tags.Schemas, *contentType)
+			if contentType.Schema != nil {
+				c.checkHasSchemaRefWithSchema(ctx, contentType.SchemaRef, *contentType)
+			}
+			return VisitContinue, nil
+		},
+		Example: func(ctx *VisitContext, example *Example) (VisitAction, error) {
+			c.checkRef(ctx, example.ExampleRef, tags.Examples, *example)
+			return VisitContinue, nil
+		},
+		Schema:   c.schema,
+		Property: c.property,
+		Leave: func(ctx *VisitContext, node any) error {
+			switch node.(type) {
+			case *Schema:
+				c.seen = c.seen[:len(c.seen)-1]
+			case *Property:
+				if cRef := c.added[len(c.added)-1]; cRef != "" {
+					delete(c.seen[len(c.seen)-1], cRef)
+				}
+				c.added = c.added[:len(c.added)-1]
+			}
+			return nil
+		},
 	}
-	return result
 }
 
-func (p CommonParameter) checkRefs(path string, name string, def *Definition) (result []error) {
-	ref, area, ok, err := isInternalRef(p.SchemaRef, tags.Schemas)
-	if ok {
-		err = def.RefCheck(area, ref)
-	}
-	if err != nil {
-		result = append(result, &RefError{
-			Msg:      err.Error(),
-			Ref:      p.SchemaRef,
-			Path:     path,
-			ItemName: name,
-			Item:     p,
-		})
-	}
-	if p.Schema != nil {
-		result = append(result, checkHasSchemaRefWithSchema(p.SchemaRef, path, "", name, p)...)
-		result = append(result, p.Schema.checkRefs(path, "", def, nil)...)
-	}
-	return result
+func (c *refsChecker) addError(ctx *VisitContext, msg string, ref string, item any) {
+	itemName := ""
+	switch item.(type) {
+	case PathParam, CommonParameter:
+		itemName = ctx.Name
+	}
+	c.result = append(c.result, &RefError{
+		Msg:      msg,
+		Ref:      ref,
+		Path:     c.errorPath(ctx),
+		Method:   ctx.Method,
+		Item:     item,
+		ItemName: itemName,
+		Pointer:  ctx.Pointer,
+	})
 }
 
-func (p Path) checkRefs(path string, def *Definition) (result []error) {
-	for name, pp := range p.PathParams {
-		ref, area, ok, err := isInternalRef(pp.Ref, tags.Parameters)
-		if ok {
-			err = def.RefCheck(area, ref)
-		}
-		if err != nil {
-			result = append(result, &RefError{
-				Msg:      err.Error(),
-				Ref:      pp.Ref,
-				Path:     path,
-				ItemName: name,
-				Item:     pp,
-			})
-		}
-		ref, area, ok, err = isInternalRef(pp.SchemaRef, tags.Schemas)
-		if ok {
-			err = def.RefCheck(area, ref)
-		}
-		if err != nil {
-			result = append(result, &RefError{
-				Msg:      err.Error(),
-				Ref:      pp.SchemaRef,
-				Path:     path,
-				ItemName: name,
-				Item:     pp,
-			})
-		}
-		if pp.Schema != nil {
-			result = append(result, checkHasSchemaRefWithSchema(pp.SchemaRef, path, "", name, pp)...)
-			result = append(result, pp.Schema.checkRefs(path, "", def, nil)...)
+// errorPath returns the RefError.Path for the context - for items in components, this is the component
+// (e.g. "#/components/schemas[0]")
+func (c *refsChecker) errorPath(ctx *VisitContext) string {
+	if len(ctx.frames) < 2 {
+		return ctx.Path
+	} else if _, ok := ctx.frames[0].node.(*Components); !ok {
+		return ctx.Path
+	}
+	top := ctx.frames[1]
+	switch node := top.node.(type) {
+	case *Schema:
+		for i := range c.def.Components.Schemas {
+			if &c.def.Components.Schemas[i] == node {
+				return fmt.Sprintf(refs.ComponentsPrefix+tags.Schemas+"[%d]", i)
+			}
 		}
-	}
-	return result
-}
-
-func checkHasSchemaRefWithSchema(ref string, path string, method string, name string, item any) (result []error) {
-	if ref != "" {
-		result = []error{&RefError{
-			Msg:      "has both schema and schemaRef",
-			Path:     path,
-			Method:   method,
-			ItemName: name,
-			Item:     item,
-		}}
-	}
-	return result
-}
-
-func isInternalRef(r string, defArea string) (ref string, area string, ok bool, err error) {
-	if r == "" {
-		return "", "", false, nil
-	}
-	if strings.HasPrefix(r, refs.ComponentsPrefix) {
-		if parts := strings.Split(r[len(refs.ComponentsPrefix):], "/"); len(parts) == 2 {
-			if parts[0] != defArea {
-				return "", "", false, fmt.Errorf("incorrect ref path %q - expected %q", r, refs.ComponentsPrefix+defArea+"/"+parts[1])
+	case *Request:
+		return fmt.Sprintf(refs.ComponentsPrefix+tags.RequestBodies+"[%s]", top.name)
+	case *Response:
+		return fmt.Sprintf(refs.ComponentsPrefix+tags.Responses+"[%s]", top.name)
+	case *CommonParameter:
+		return fmt.Sprintf(refs.ComponentsPrefix+tags.Parameters+"[%s]", top.name)
+	case *Example:
+		for i := range c.def.Components.Examples {
+			if &c.def.Components.Examples[i] == node {
+				return fmt.Sprintf(refs.ComponentsPrefix+tags.Examples+"[%d]", i)
 			}
-			return parts[1], parts[0], true, nil
-		} else {
-			return "", "", false, fmt.Errorf("invalid ref path %q", r)
 		}
-	} else if a := strings.IndexRune(r, '/'); a == -1 {
-		return r, defArea, true, nil
 	}
-	return "", "", false, nil
+	return ctx.Path
 }
 
-func (r *Request) checkRefs(path string, method string, def *Definition) (result []error) {
-	ref, area, ok, err := isInternalRef(r.Ref, tags.RequestBodies)
+func (c *refsChecker) checkRef(ctx *VisitContext, r string, defArea string, item any) {
+	ref, area, ok, err := isInternalRef(r, defArea)
 	if ok {
-		err = def.RefCheck(area, ref)
+		err = c.def.RefCheck(area, ref)
 	}
 	if err != nil {
-		result = append(result, &RefError{
-			Msg:    err.Error(),
-			Ref:    r.Ref,
-			Path:   path,
-			Method: method,
-			Item:   r,
-		})
-	}
-	ref, area, ok, err = isInternalRef(r.SchemaRef, tags.Schemas)
-	if ok {
-		err = def.RefCheck(area, ref)
+		c.addError(ctx, err.Error(), r, item)
 	}
-	if err != nil {
-		result = append(result, &RefError{
-			Msg:    err.Error(),
-			Ref:    r.SchemaRef,
-			Path:   path,
-			Method: method,
-			Item:   r,
-		})
-	}
-	result = append(result, checkVaryingSchema(r.Schema, r, r.SchemaRef, path, method, def)...)
-	result = append(result, r.Examples.checkRefs(path, method, def)...)
-	result = append(result, r.AlternativeContentTypes.checkRefs(path, method, def)...)
-	return result
 }
 
-func (r Response) checkRefs(path string, method string, def *Definition) (result []error) {
-	ref, area, ok, err := isInternalRef(r.Ref, tags.Responses)
-	if ok {
-		err = def.RefCheck(area, ref)
-	}
-	if err != nil {
-		result = append(result, &RefError{
-			Msg:    err.Error(),
-			Ref:    r.Ref,
-			Path:   path,
-			Method: method,
-			Item:   r,
-		})
-	}
-	ref, area, ok, err = isInternalRef(r.SchemaRef, tags.Schemas)
-	if ok {
-		err = def.RefCheck(area, ref)
-	}
-	if err != nil {
-		result = append(result, &RefError{
-			Msg:    err.Error(),
-			Ref:    r.SchemaRef,
-			Path:   path,
-			Method: method,
-			Item:   r,
-		})
+func (c *refsChecker) checkHasSchemaRefWithSchema(ctx *VisitContext, ref string, item any) {
+	if ref != "" {
+		c.addError(ctx, "has both schema and schemaRef", "", item)
 	}
-	result = append(result, checkVaryingSchema(r.Schema, r, r.SchemaRef, path, method, def)...)
-	result = append(result, r.Examples.checkRefs(path, method, def)...)
-	result = append(result, r.AlternativeContentTypes.checkRefs(path, method, def)...)
-	return result
 }
 
-func checkVaryingSchema(s any, item any, schemaRef string, path string, method string, def *Definition) (result []error) {
+func (c *refsChecker) checkVaryingSchema(ctx *VisitContext, s any, schemaRef string, item any) {
 	switch schema := s.(type) {
 	case Schema:
-		result = append(result, checkHasSchemaRefWithSchema(schemaRef, path, method, "", item)...)
-		result = append(result, schema.checkRefs(path, method, def, nil)...)
+		c.checkHasSchemaRefWithSchema(ctx, schemaRef, item)
 	case *Schema:
 		if schema != nil {
-			result = append(result, checkHasSchemaRefWithSchema(schemaRef, path, method, "", item)...)
-			result = append(result, schema.checkRefs(path, method, def, nil)...)
+			c.checkHasSchemaRefWithSchema(ctx, schemaRef, item)
 		}
 	}
-	return result
 }
 
-func (c ContentTypes) checkRefs(path string, method string, def *Definition) (result []error) {
-	for _, ct := range c {
-		ref, area, ok, err := isInternalRef(ct.SchemaRef, tags.Schemas)
-		if ok {
-			err = def.RefCheck(area, ref)
+func (c *refsChecker) schema(ctx *VisitContext, s *Schema) (VisitAction, error) {
+	seen := c.seed
+	c.seed = nil
+	if seen == nil {
+		seen = make(map[string]bool)
+	}
+	if _, ok := ctx.Parent().(*Components); ok {
+		seen[refs.Canonical(tags.Schemas, s.Name)] = true
+	}
+	c.seen = append(c.seen, seen)
+	ref, area, ok, err := isInternalRef(s.SchemaRef, tags.Schemas)
+	if ok {
+		err = c.def.RefCheck(area, ref)
+		if cRef := refs.Canonical(area, ref); seen[cRef] {
+			c.addError(ctx, "cyclic ref", s.SchemaRef, s)
+		} else {
+			err = recurseSchemaRefs(ref, c.def, map[string]bool{ref: true})
+			seen[cRef] = true
 		}
-		if err != nil {
-			result = append(result, &RefError{
-				Msg:    err.Error(),
-				Ref:    ct.SchemaRef,
-				Path:   path,
-				Method: method,
-				Item:   ct,
-			})
+	}
+	if err != nil {
+		c.addError(ctx, err.Error(), s.SchemaRef, s)
+	}
+	if s.Discriminator != nil {
+		for _, r := range s.Discriminator.Mapping {
+			c.checkRef(ctx, r, tags.Schemas, s.Discriminator)
 		}
-		if ct.Schema != nil {
-			result = append(result, checkHasSchemaRefWithSchema(ct.SchemaRef, path, method, "", ct)...)
-			switch schema := ct.Schema.(type) {
-			case *Schema:
-				result = append(result, schema.checkRefs(path, method, def, nil)...)
-			case Schema:
-				result = append(result, schema.checkRefs(path, method, def, nil)...)
+	}
+	if s.Ofs != nil {
+		for _, of := range s.Ofs.Of {
+			if of.IsRef() {
+				c.checkRef(ctx, of.Ref(), tags.Schemas, of)
 			}
 		}
-		result = append(result, ct.Examples.checkRefs(path, method, def)...)
 	}
-	return result
+	return VisitContinue, nil
 }
 
-func (egs Examples) checkRefs(path string, method string, def *Definition) (result []error) {
-	for _, eg := range egs {
-		result = append(result, eg.checkRefs(path, method, def)...)
+func (c *refsChecker) property(ctx *VisitContext, p *Property) (VisitAction, error) {
+	if len(c.seen) == 0 {
+		c.seen = append(c.seen, make(map[string]bool))
 	}
-	return result
-}
-
-func (eg Example) checkRefs(path string, method string, def *Definition) (result []error) {
-	ref, area, ok, err := isInternalRef(eg.ExampleRef, tags.Examples)
+	seen := c.seen[len(c.seen)-1]
+	added := ""
+	ref, area, ok, err := isInternalRef(p.SchemaRef, tags.Schemas)
 	if ok {
-		err = def.RefCheck(area, ref)
+		err = c.def.RefCheck(area, ref)
+		if cRef := refs.Canonical(area, ref); seen[cRef] {
+			c.addError(ctx, "cyclic ref", p.SchemaRef, *p)
+		} else {
+			seen[cRef] = true
+			added = cRef
+		}
 	}
 	if err != nil {
-		result = append(result, &RefError{
-			Msg:    err.Error(),
-			Ref:    eg.ExampleRef,
-			Path:   path,
-			Method: method,
-			Item:   eg,
-		})
+		c.addError(ctx, err.Error(), p.SchemaRef, *p)
 	}
-	return result
+	c.added = append(c.added, added)
+	return VisitContinue, nil
 }
 
-func (qp QueryParams) checkRefs(path string, method string, def *Definition) (result []error) {
-	for _, p := range qp {
-		ref, area, ok, err := isInternalRef(p.Ref, tags.Parameters)
-		if ok {
-			err = def.RefCheck(area, ref)
-		}
-		if err != nil {
-			result = append(result, &RefError{
-				Msg:  err.Error(),
-				Ref:  p.Ref,
-				Path: path,
-				Item: p,
-			})
-		}
-		ref, area, ok, err = isInternalRef(p.SchemaRef, tags.Schemas)
-		if ok {
-			err = def.RefCheck(area, ref)
-		}
-		if err != nil {
-			result = append(result, &RefError{
-				Msg:  err.Error(),
-				Ref:  p.SchemaRef,
-				Path: path,
-				Item: p,
-			})
-		}
-		if p.Schema != nil {
-			result = append(result, checkHasSchemaRefWithSchema(p.SchemaRef, path, method, "", p)...)
-			result = append(result, p.Schema.checkRefs(path, method, def, nil)...)
+func isInternalRef(r string, defArea string) (ref string, area string, ok bool, err error) {
+	if r == "" {
+		return "", "", false, nil
+	}
+	if strings.HasPrefix(r, refs.ComponentsPrefix) {
+		if parts := strings.Split(r[len(refs.ComponentsPrefix):], "/"); len(parts) == 2 {
+			if parts[0] != defArea {
+				return "", "", false, fmt.Errorf("incorrect ref path %q - expected %q", r, refs.ComponentsPrefix+defArea+"/"+parts[1])
+			}
+			return parts[1], parts[0], true, nil
+		} else {
+			return "", "", false, fmt.Errorf("invalid ref path %q", r)
 		}
+	} else if a := strings.IndexRune(r, '/'); a == -1 {
+		return r, defArea, true, nil
 	}
-	return result
+	return "", "", false, nil
 }
 
 func recurseSchemaRefs(ref string, def *Definition, seen map[string]bool) (err error) {
@@ -356,121 +289,3 @@ func recurseSchemaRefs(ref string, def *Definition, seen map[string]bool) (err e
 	}
 	return err
 }
-
-func (s *Schema) checkRefs(path string, method string, def *Definition, seen map[string]bool) (result []error) {
-	if seen == nil {
-		seen = make(map[string]bool)
-	}
-	ref, area, ok, err := isInternalRef(s.SchemaRef, tags.Schemas)
-	if ok {
-		err = def.RefCheck(area, ref)
-		cRef := refs.Canonical(area, ref)
-		if seen[cRef] {
-			result = append(result, &RefError{
-				Msg:    "cyclic ref",
-				Ref:    s.SchemaRef,
-				Path:   path,
-				Method: method,
-				Item:   s,
-			})
-		} else {
-			err = recurseSchemaRefs(ref, def, map[string]bool{ref: true})
-			seen[cRef] = true
-			defer delete(seen, cRef)
-		}
-	}
-	if err != nil {
-		result = append(result, &RefError{
-			Msg:    err.Error(),
-			Ref:    s.SchemaRef,
-			Path:   path,
-			Method: method,
-			Item:   s,
-		})
-	}
-	for _, p := range s.Properties {
-		result = append(result, p.checkRefs(path, method, def, seen)...)
-	}
-	if s.Discriminator != nil {
-		result = append(result, s.Discriminator.checkRefs(path, method, def)...)
-	}
-	if s.Ofs != nil {
-		result = append(result, s.Ofs.checkRefs(path, method, def)...)
-	}
-	return result
-}
-
-func (p Property) checkRefs(path string, method string, def *Definition, seen map[string]bool) (result []error) {
-	ref, area, ok, err := isInternalRef(p.SchemaRef, tags.Schemas)
-	if ok {
-		err = def.RefCheck(area, ref)
-		cRef := refs.Canonical(area, ref)
-		if seen[cRef] {
-			result = append(result, &RefError{
-				Msg:    "cyclic ref",
-				Ref:    p.SchemaRef,
-				Path:   path,
-				Method: method,
-				Item:   p,
-			})
-		} else {
-			seen[cRef] = true
-			defer delete(seen, cRef)
-		}
-	}
-	if err != nil {
-		result = append(result, &RefError{
-			Msg:    err.Error(),
-			Ref:    p.SchemaRef,
-			Path:   path,
-			Method: method,
-			Item:   p,
-		})
-	}
-	for _, ps := range p.Properties {
-		result = append(result, ps.checkRefs(path, method, def, seen)...)
-	}
-	return result
-}
-
-func (d *Discriminator) checkRefs(path string, method string, def *Definition) (result []error) {
-	for _, r := range d.Mapping {
-		ref, area, ok, err := isInternalRef(r, tags.Schemas)
-		if ok {
-			err = def.RefCheck(area, ref)
-		}
-		if err != nil {
-			result = append(result, &RefError{
-				Msg:    err.Error(),
-				Ref:    r,
-				Path:   path,
-				Method: method,
-				Item:   d,
-			})
-		}
-	}
-	return result
-}
-
-func (ofs *Ofs) checkRefs(path string, method string, def *Definition) (result []error) {
-	for _, of := range ofs.Of {
-		if of.IsRef() {
-			ref, area, ok, err := isInternalRef(of.Ref(), tags.Schemas)
-			if ok {
-				err = def.RefCheck(area, ref)
-			}
-			if err != nil {
-				result = append(result, &RefError{
-					Msg:    err.Error(),
-					Ref:    of.Ref(),
-					Path:   path,
-					Method: method,
-					Item:   of,
-				})
-			}
-		} else if s := of.Schema(); s != nil {
-			result = append(result, s.checkRefs(path, method, def, nil)...)
-		}
-	}
-	return result
-}
@@ -208,7 +208,10 @@ func TestMethod_checkRefs(t *testing.T) {
 	}
 	for i, tc := range testCases {
 		t.Run(fmt.Sprintf("[%d]", i+1), func(t *testing.T) {
-			errs := tc.method.checkRefs("", "", d)
+			errs := testCheckRefs(d, nil, func(w *visitWalker) error {
+				_, err := w.method("", "", "", 0, &tc.method)
+				return err
+			})
 			assert.Equal(t, tc.expectedErrs, len(errs))
 		})
 	}
@@ -344,12 +347,58 @@ func TestComponents_checkRefs(t *testing.T) {
 	}
 	for i, tc := range testCases {
 		t.Run(fmt.Sprintf("[%d]", i+1), func(t *testing.T) {
-			errs := tc.components.checkRefs(&Definition{Components: tc.components})
+			errs := testCheckRefs(&Definition{Components: tc.components}, nil, func(w *visitWalker) error {
+				return w.components(tc.components)
+			})
 			assert.Equal(t, tc.expectedErrs, len(errs))
 		})
 	}
 }
 
+func TestComponents_checkRefs_ErrorPaths(t *testing.T) {
+	d := &Definition{
+		Components: &Components{
+			Schemas: Schemas{
+				{Name: "ok"},
+				{Name: "foo", SchemaRef: "unknown"},
+			},
+			Requests: CommonRequests{
+				"req": {SchemaRef: "unknown"},
+			},
+			Responses: CommonResponses{
+				"resp": {SchemaRef: "unknown"},
+			},
+			Parameters: CommonParameters{
+				"param": {SchemaRef: "unknown"},
+			},
+			Examples: Examples{
+				{Name: "ok"},
+				{Name: "eg", ExampleRef: "unknown"},
+			},
+		},
+	}
+	errs := d.CheckRefs()
+	require.Len(t, errs, 5)
+	expect := []struct {
+		path     string
+		itemName string
+		pointer  string
+	}{
+		{path: "#/components/schemas[1]", pointer: "/components/schemas/foo"},
+		{path: "#/components/requestBodies[req]", pointer: "/components/requestBodies/req"},
+		{path: "#/components/responses[resp]", pointer: "/components/responses/resp"},
+		{path: "#/components/parameters[param]", itemName: "param", pointer: "/components/parameters/param"},
+		{path: "#/components/examples[1]", pointer: "/components/examples/eg"},
+	}
+	for i, err := range errs {
+		refErr := err.(*RefError)
+		assert.Equal(t, expect[i].path, refErr.Path)
+		assert.Equal(t, expect[i].itemName, refErr.ItemName)
+		assert.Equal(t, expect[i].pointer, refErr.Pointer)
+		assert.Equal(t, "", refErr.Method)
+	}
+}
+
 func TestPath_checkRefs(t *testing.T) {
 	d := &Definition{
 		Components: &Components{
@@ -492,7 +541,9 @@ func TestPath_checkRefs(t *testing.T) {
 	}
 	for i, tc := range testCases {
 		t.Run(fmt.Sprintf("[%d]", i+1), func(t *testing.T) {
-			errs := tc.path.checkRefs("", d)
+			errs := testCheckRefs(d, nil, func(w *visitWalker) error {
+				return w.path("", "", nil, &tc.path)
+			})
 			assert.Equal(t, tc.expectedErrs, len(errs))
 		})
 	}
@@ -560,7 +611,10 @@ func TestCommonParameter_checkRefs(t *testing.T) {
 	}
 	for i, tc := range testCases {
 		t.Run(fmt.Sprintf("[%d]", i+1), func(t *testing.T) {
-			errs := tc.param.checkRefs("", "", d)
+			errs := testCheckRefs(d, nil, func(w *visitWalker) error {
+				_, err := w.commonParameter("", "", &tc.param)
+				return err
+			})
 			assert.Equal(t, tc.expectedErrs, len(errs))
 		})
 	}
@@ -686,7 +740,9 @@ func TestQueryParams_checkRefs(t *testing.T) {
 	}
 	for i, tc := range testCases {
 		t.Run(fmt.Sprintf("[%d]", i+1), func(t *testing.T) {
-			errs := tc.params.checkRefs("", "", d)
+			errs := testCheckRefs(d, nil, func(w *visitWalker) error {
+				return w.queryParams("", 0, tc.params)
+			})
 			assert.Equal(t, tc.expectedErrs, len(errs))
 		})
 	}
@@ -841,7 +897,10 @@ func TestRequest_checkRefs(t *testing.T) {
 	}
 	for i, tc := range testCases {
 		t.Run(fmt.Sprintf("[%d]", i+1), func(t *testing.T) {
-			errs := tc.request.checkRefs("", "", d)
+			errs := testCheckRefs(d, nil, func(w *visitWalker) error {
+				_, err := w.request("", "", tc.request)
+				return err
+			})
 			assert.Equal(t, tc.expectedErrs, len(errs))
 		})
 	}
@@ -996,7 +1055,10 @@ func TestResponse_checkRefs(t *testing.T) {
 	}
 	for i, tc := range testCases {
 		t.Run(fmt.Sprintf("[%d]", i+1), func(t *testing.T) {
-			errs := tc.response.checkRefs("", "", d)
+			errs := testCheckRefs(d, nil, func(w *visitWalker) error {
+				_, err := w.response("", "", &tc.response)
+				return err
+			})
 			assert.Equal(t, tc.expectedErrs, len(errs))
 		})
 	}
@@ -1066,7 +1128,9 @@ func TestExamples_checkRefs(t *testing.T) {
 	}
 	for i, tc := range testCases {
 		t.Run(fmt.Sprintf("[%d]", i+1), func(t *testing.T) {
-			errs := tc.examples.checkRefs("", "", d)
+			errs := testCheckRefs(d, nil, func(w *visitWalker) error {
+				return w.examples("", tc.examples)
+			})
 			assert.Equal(t, tc.expectedErrs, len(errs))
 		})
 	}
@@ -1177,7 +1241,9 @@ func TestContentTypes_checkRefs(t *testing.T) {
 	}
 	for i, tc := range testCases {
 		t.Run(fmt.Sprintf("[%d]", i+1), func(t *testing.T) {
-			errs := tc.contentTypes.checkRefs("", "", d)
+			errs := testCheckRefs(d, nil, func(w *visitWalker) error {
+				return w.contentTypes("", tc.contentTypes)
+			})
 			assert.Equal(t, tc.expectedErrs, len(errs))
 		})
 	}
@@ -1329,7 +1395,9 @@ func TestSchema_checkRefs(t *testing.T) {
 			if seen == nil {
 				seen = make(map[string]bool)
 			}
-			errs := tc.schema.checkRefs("", "", d, seen)
+			errs := testCheckRefs(d, seen, func(w *visitWalker) error {
+				return w.schema("", tc.schema)
+			})
 			assert.Equal(t, tc.expectedErrs, len(errs))
 		})
 	}
@@ -1425,7 +1493,9 @@ func TestProperty_checkRefs(t *testing.T) {
 			if seen == nil {
 				seen = make(map[string]bool)
 			}
-			errs := tc.property.checkRefs("", "", d, seen)
+			errs := testCheckRefs(d, seen, func(w *visitWalker) error {
+				return w.property("", &tc.property)
+			})
 			assert.Equal(t, tc.expectedErrs, len(errs))
 		})
 	}
@@ -1495,7 +1565,9 @@ func TestDiscriminator_checkRefs(t *testing.T) {
 	}
 	for i, tc := range testCases {
 		t.Run(fmt.Sprintf("[%d]", i+1), func(t *testing.T) {
-			errs := tc.discriminator.checkRefs("", "", d)
+			errs := testCheckRefs(d, nil, func(w *visitWalker) error {
+				return w.schema("", &Schema{Discriminator: tc.discriminator})
+			})
 			assert.Equal(t, tc.expectedErrs, len(errs))
 		})
 	}
@@ -1600,7 +1672,9 @@ func TestOfs_checkRefs(t *testing.T) {
 	}
 	for i, tc := range testCases {
 		t.Run(fmt.Sprintf("[%d]", i+1), func(t *testing.T) {
-			errs := tc.ofs.checkRefs("", "", d)
+			errs := testCheckRefs(d, nil, func(w *visitWalker) error {
+				return w.schema("", &Schema{Ofs: tc.ofs})
+			})
 			assert.Equal(t, tc.expectedErrs, len(errs))
 		})
 	}
@@ -1660,6 +1734,16 @@ func Test_isInternalRef(t *testing.T) {
 	}
 }
 
+// testCheckRefs runs the refs checker over the nodes visited by fn
+func testCheckRefs(d *Definition, seen map[string]bool, fn func(w *visitWalker) error) []error {
+	c := &refsChecker{def: d, seed: seen}
+	if seen != nil {
+		c.seen = []map[string]bool{seen}
+	}
+	_ = fn(newVisitWalker(d, c.visitor()))
+	return c.result
+}
+
 func TestRefError_Error(t *testing.T) {
 	err := &RefError{
 		Msg: "foo",
@@ -3,6 +3,7 @@ package chioas
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"github.com/go-andiamo/chioas/internal/values"
 	"github.com/go-andiamo/chioas/yaml"
 	"github.com/go-chi/chi/v5"
@@ -942,3 +943,40 @@ func testUnAuthPostMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+type testSpecOverlay struct {
+	err error
+}
+
+func (o *testSpecOverlay) Apply(spec []byte) ([]byte, error) {
+	return append(spec, []byte("x-overlaid: true\n")...), o.err
+}
+
+func TestDefinition_Overlays(t *testing.T) {
+	d := Definition{
+		DocOptions: DocOptions{
+			Overlays: []SpecOverlay{&testSpecOverlay{}},
+		},
+	}
+	data, err := d.AsYaml()
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "\nx-overlaid: true\n")
+	var buf bytes.Buffer
+	require.NoError(t, d.WriteYaml(&buf))
+	assert.Equal(t, string(data), buf.String())
+	data, err = d.AsJson()
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"x-overlaid":true`)
+	buf.Reset()
+	require.NoError(t, d.WriteJson(&buf))
+	assert.Equal(t, string(data), buf.String())
+
+	d.DocOptions.Overlays = append(d.DocOptions.Overlays, &testSpecOverlay{err: errors.New("fooey")})
+	_, err = d.AsYaml()
+	require.Error(t, err)
+	assert.Equal(t, "fooey", err.Error())
+	_, err = d.AsJson()
+	require.Error(t, err)
+	require.Error(t, d.WriteYaml(&buf))
+	require.Error(t, d.WriteJson(&buf))
+}
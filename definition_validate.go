@@ -0,0 +1,483 @@
+package chioas
+
+import (
+	"fmt"
+	"github.com/go-andiamo/chioas/internal/refs"
+	"github.com/go-andiamo/chioas/internal/tags"
+	"github.com/go-andiamo/chioas/internal/values"
+	"github.com/go-andiamo/urit"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Severity is the severity of a Diagnostic
+type Severity int
+
+const (
+	// SeverityError indicates a problem that will result in an invalid spec or broken routing
+	SeverityError Severity = iota
+	// SeverityWarning indicates a probable mistake (or an inconsistency in the spec)
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Diagnostic codes (see Diagnostic.Code)
+const (
+	DiagDuplicateOperationId    = "duplicate-operation-id"
+	DiagUndeclaredPathParam     = "undeclared-path-param"
+	DiagUnknownPathParam        = "unknown-path-param"
+	DiagMissingHandler          = "missing-handler"
+	DiagRequiredNotInProperties = "required-not-in-properties"
+	DiagEnumTypeMismatch        = "enum-type-mismatch"
+	DiagExampleMismatch         = "example-mismatch"
+	DiagUnusedComponent         = "unused-component"
+	DiagRequestBodyNotAllowed   = "request-body-not-allowed"
+	DiagInvalidPathTemplate     = "invalid-path-template"
+)
+
+const (
+	jsonPointerPathsPrefix       = "/" + tags.Paths + "/"
+	jsonPointerComponentsPrefix  = "/" + tags.Components + "/"
+	jsonPointerPropertiesSegment = "/" + tags.Properties + "/"
+)
+
+// Diagnostic is a single problem found by Definition.Validate
+type Diagnostic struct {
+	// Severity is the severity of the problem
+	Severity Severity `json:"severity"`
+	// Code is the diagnostic code (e.g. DiagDuplicateOperationId)
+	Code string `json:"code"`
+	// Location is the JSON pointer to the location of the problem in the OAS spec (e.g. "/paths/~1people~1{id}/get")
+	Location string `json:"location"`
+	// Message is the human-readable description of the problem
+	Message string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	return d.Severity.String() + " [" + d.Code + "] " + d.Location + ": " + d.Message
+}
+
+// Diagnostics is a collection of Diagnostic (as returned by Definition.Validate)
+type Diagnostics []Diagnostic
+
+// HasErrors returns true if any of the diagnostics is SeverityError
+func (ds Diagnostics) HasErrors() bool {
+	return slices.ContainsFunc(ds, func(d Diagnostic) bool {
+		return d.Severity == SeverityError
+	})
+}
+
+// Validate performs semantic checks on the definition and returns any problems found
+//
+// Unlike CheckRefs (which only checks that $refs resolve), Validate checks for:
+//   - duplicate operationIds
+//   - path vars in path templates without a matching PathParams entry (and PathParams entries without a matching path var)
+//   - methods without a handler (only checked where no MethodHandlerBuilder is set)
+//   - schema RequiredProperties that are not in Properties
+//   - enum values that don't match the schema/property type
+//   - examples that don't match their schemas
+//   - unused components (components not reachable, directly or via other components, from any path or method)
+//   - GET or HEAD methods with a request body
+//
+// Each Diagnostic has a JSON pointer location (relative to the OAS spec) and a Severity.  Disabled paths are not validated
+// and diagnostics are always returned in a deterministic order
+func (d *Definition) Validate() Diagnostics {
+	v := &validator{
+		def:     d,
+		opIds:   map[string]string{},
+		used:    map[string]bool{},
+		deps:    map[string][]string{},
+		context: strings.Trim(d.DocOptions.Context, "/"),
+	}
+	v.methods(root, root, nil, nil, "", d.Methods)
+	v.paths(nil, nil, "", d.Paths)
+	if d.Components != nil {
+		v.components(d.Components)
+	}
+	return v.result
+}
+
+type validator struct {
+	def     *Definition
+	result  Diagnostics
+	opIds   map[string]string
+	used    map[string]bool     // refs used by paths & methods (and, after components, refs reachable from those)
+	deps    map[string][]string // refs used by each component (keyed by the component canonical ref)
+	owner   string              // the component currently being validated (canonical ref) - empty when not in components
+	context string
+}
+
+func (v *validator) add(severity Severity, code string, location string, msg string, args ...any) {
+	v.result = append(v.result, Diagnostic{
+		Severity: severity,
+		Code:     code,
+		Location: location,
+		Message:  fmt.Sprintf(msg, args...),
+	})
+}
+
+func (v *validator) pathPointer(path string) string {
+	return jsonPointerPath(v.context, path)
+}
+
+func (v *validator) paths(ancestry []string, ancestors []Path, parentTag string, paths Paths) {
+	for _, p := range sortedKeys(paths) {
+		pDef := paths[p]
+		if pDef.Disabled != nil && pDef.Disabled() {
+			continue
+		}
+		newAncestry := append(slices.Clone(ancestry), p)
+		path := strings.Join(newAncestry, "")
+		useTag := defaultTag(parentTag, pDef.Tag)
+		if template, err := urit.NewTemplate(path); err != nil {
+			v.add(SeverityError, DiagInvalidPathTemplate, v.pathPointer(path), "invalid path template - %s", err.Error())
+		} else {
+			specPath := template.Template(true)
+			pathVars := template.Vars()
+			v.pathParams(specPath, pathVars, ancestry, ancestors, pDef)
+			v.methods(path, specPath, pathVars, (flatPath{ancestry: ancestors, def: pDef}).getPathParams(), useTag, pDef.Methods)
+		}
+		v.paths(newAncestry, append(slices.Clone(ancestors), pDef), useTag, pDef.Paths)
+	}
+}
+
+func (v *validator) pathParams(specPath string, pathVars []urit.PathVar, ancestry []string, ancestors []Path, pDef Path) {
+	ptr := v.pathPointer(specPath)
+	names := make(map[string]bool, len(pathVars))
+	for _, pv := range pathVars {
+		names[pv.Name] = true
+	}
+	for _, name := range sortedKeys(pDef.PathParams) {
+		if !names[name] {
+			v.add(SeverityError, DiagUnknownPathParam, ptr, "path param '%s' is not a path var in path template", name)
+		}
+		pp := pDef.PathParams[name]
+		v.ref(tags.Parameters, pp.Ref)
+		v.ref(tags.Schemas, pp.SchemaRef)
+	}
+	// only report undeclared vars on the path that introduces them...
+	inherited := map[string]bool{}
+	if len(ancestry) > 0 {
+		if pt, err := urit.NewTemplate(strings.Join(ancestry, "")); err == nil {
+			for _, pv := range pt.Vars() {
+				inherited[pv.Name] = true
+			}
+		}
+	}
+	declared := (flatPath{ancestry: ancestors, def: pDef}).getPathParams()
+	for _, pv := range pathVars {
+		if _, ok := declared[pv.Name]; !ok && !inherited[pv.Name] {
+			v.add(SeverityWarning, DiagUndeclaredPathParam, ptr, "path var '%s' has no PathParams entry", pv.Name)
+		}
+	}
+}
+
+func (v *validator) methods(path string, specPath string, pathVars []urit.PathVar, pathParams PathParams, parentTag string, methods Methods) {
+	pathPtr := v.pathPointer(specPath)
+	for _, m := range sortedKeys(methods) {
+		mDef := methods[m]
+		ptr := pathPtr + "/" + strings.ToLower(m)
+		if opId := mDef.getOperationIdForPath(&v.def.DocOptions, m, path, parentTag); opId != "" {
+			if existing, ok := v.opIds[opId]; ok {
+				v.add(SeverityError, DiagDuplicateOperationId, ptr, "duplicate operationId '%s' (also used at %s)", opId, existing)
+			} else {
+				v.opIds[opId] = ptr
+			}
+		}
+		if mDef.Handler == nil && v.def.MethodHandlerBuilder == nil {
+			v.add(SeverityError, DiagMissingHandler, ptr, "no handler set for method")
+		}
+		paramsPtr := ptr + "/" + tags.Parameters + "/"
+		for i, pv := range pathVars {
+			if pp, ok := pathParams[pv.Name]; ok {
+				v.paramValue(paramsPtr+strconv.Itoa(i), pp.Schema, pp.SchemaRef, pp.Example)
+			}
+		}
+		for i, qp := range mDef.QueryParams {
+			v.ref(tags.Parameters, qp.Ref)
+			v.ref(tags.Schemas, qp.SchemaRef)
+			v.paramValue(paramsPtr+strconv.Itoa(len(pathVars)+i), qp.Schema, qp.SchemaRef, qp.Example)
+		}
+		if mDef.Request != nil {
+			if m == http.MethodGet || m == http.MethodHead {
+				v.add(SeverityWarning, DiagRequestBodyNotAllowed, ptr+"/"+tags.RequestBody, "%s method should not have a request body", m)
+			}
+			v.request(ptr+"/"+tags.RequestBody, mDef.Request)
+		}
+		for _, sc := range sortedKeys(mDef.Responses) {
+			r := mDef.Responses[sc]
+			v.response(ptr+"/"+tags.Responses+"/"+strconv.Itoa(sc), r)
+		}
+	}
+}
+
+func (v *validator) paramValue(ptr string, schema *Schema, schemaRef string, example any) {
+	if schema != nil {
+		v.schema(ptr+"/"+tags.Schema, schema)
+	} else if schemaRef != "" {
+		schema = &Schema{SchemaRef: schemaRef}
+	}
+	if schema != nil && example != nil {
+		v.exampleMismatches(ptr+"/"+tags.Example, "", v.def.CheckValue(example, schema))
+	}
+}
+
+// exampleMismatches adds diagnostics for example mismatches - where the example is a $ref, the location is the
+// referencing example (and the message includes the path within the referenced value)
+func (v *validator) exampleMismatches(ptr string, ref string, mms []ValueMismatch) {
+	for _, mm := range mms {
+		if ref != "" {
+			v.add(SeverityWarning, DiagExampleMismatch, ptr, "example $ref '%s' does not match schema - %s", ref, mm.String())
+		} else {
+			v.add(SeverityWarning, DiagExampleMismatch, ptr+mm.Path, "example does not match schema - %s", mm.Message)
+		}
+	}
+}
+
+func (v *validator) request(ptr string, r *Request) {
+	v.ref(tags.RequestBodies, r.Ref)
+	if r.Ref == "" {
+		v.content(ptr+"/"+tags.Content+"/"+jsonPointerEscape(defValue(r.ContentType, tags.ApplicationJson)), r)
+		for _, ct := range sortedKeys(r.AlternativeContentTypes) {
+			v.content(ptr+"/"+tags.Content+"/"+jsonPointerEscape(ct), r.AlternativeContentTypes[ct])
+		}
+	}
+}
+
+func (v *validator) response(ptr string, r Response) {
+	v.ref(tags.Responses, r.Ref)
+	if r.Ref == "" && !r.NoContent {
+		v.content(ptr+"/"+tags.Content+"/"+jsonPointerEscape(defValue(r.ContentType, tags.ApplicationJson)), r)
+		for _, ct := range sortedKeys(r.AlternativeContentTypes) {
+			v.content(ptr+"/"+tags.Content+"/"+jsonPointerEscape(ct), r.AlternativeContentTypes[ct])
+		}
+	}
+}
+
+func (v *validator) content(ptr string, cw contentWritable) {
+	v.ref(tags.Schemas, cw.schemaRef())
+	var schema *Schema
+	if s := cw.schema(); s != nil {
+		switch st := s.(type) {
+		case Schema:
+			schema = &st
+		case *Schema:
+			schema = st
+		}
+		if schema != nil {
+			schemaPtr := ptr + "/" + tags.Schema
+			if cw.isArray() {
+				schemaPtr += "/" + tags.Items
+			}
+			v.schema(schemaPtr, schema)
+		}
+	} else if ref := cw.schemaRef(); ref != "" {
+		schema = &Schema{SchemaRef: ref}
+	}
+	for _, eg := range cw.examples() {
+		v.ref(tags.Examples, eg.ExampleRef)
+		if schema == nil {
+			continue
+		}
+		egPtr := ptr + "/" + tags.Examples + "/" + jsonPointerEscape(eg.Name)
+		value := eg.Value
+		if eg.ExampleRef != "" {
+			name, _, ok, _ := isInternalRef(eg.ExampleRef, tags.Examples)
+			if !ok {
+				continue
+			}
+			refEg, found := v.def.componentExample(name)
+			if !found {
+				continue
+			}
+			value = refEg.Value
+		} else {
+			egPtr += "/" + tags.Value
+		}
+		vc := &valueChecker{def: v.def}
+		if cw.isArray() {
+			vc.items(normalizeValue(value), schema, "")
+		} else {
+			vc.schema(normalizeValue(value), schema, "", nil)
+		}
+		v.exampleMismatches(egPtr, eg.ExampleRef, vc.result)
+	}
+}
+
+func (v *validator) schema(ptr string, s *Schema) {
+	v.ref(tags.Schemas, s.SchemaRef)
+	if s.SchemaRef != "" {
+		return
+	}
+	for i, rp := range s.RequiredProperties {
+		if !slices.ContainsFunc(s.Properties, func(p Property) bool {
+			return p.Name == rp
+		}) {
+			v.add(SeverityError, DiagRequiredNotInProperties, ptr+"/"+tags.Required+"/"+strconv.Itoa(i), "required property '%s' is not in properties", rp)
+		}
+	}
+	typ := defValue(s.Type, values.TypeObject)
+	for i, e := range s.Enum {
+		if !valueMatchesType(normalizeValue(e), typ) {
+			v.add(SeverityError, DiagEnumTypeMismatch, ptr+"/"+tags.Enum+"/"+strconv.Itoa(i), "enum value %v does not match type '%s'", e, typ)
+		}
+	}
+	if s.Example != nil {
+		v.exampleMismatches(ptr+"/"+tags.Example, "", v.def.CheckValue(s.Example, s))
+	}
+	for _, p := range s.Properties {
+		v.property(ptr+jsonPointerPropertiesSegment+jsonPointerEscape(p.Name), p)
+	}
+	if s.Discriminator != nil {
+		for _, r := range s.Discriminator.Mapping {
+			v.ref(tags.Schemas, r)
+		}
+	}
+	if s.Ofs != nil {
+		for i, of := range s.Ofs.Of {
+			if of.IsRef() {
+				v.ref(tags.Schemas, of.Ref())
+			} else if os := of.Schema(); os != nil {
+				v.schema(ptr+"/"+s.Ofs.OfType.TagName()+"/"+strconv.Itoa(i), os)
+			}
+		}
+	}
+}
+
+func (v *validator) property(ptr string, p Property) {
+	v.ref(tags.Schemas, p.SchemaRef)
+	if p.SchemaRef != "" {
+		return
+	}
+	typ := defValue(p.Type, values.TypeString)
+	if typ == values.TypeArray {
+		ptr += "/" + tags.Items
+		typ = defValue(p.ItemType, values.TypeString)
+	}
+	for i, e := range p.Enum {
+		if !valueMatchesType(normalizeValue(e), typ) {
+			v.add(SeverityError, DiagEnumTypeMismatch, ptr+"/"+tags.Enum+"/"+strconv.Itoa(i), "enum value %v does not match type '%s'", e, typ)
+		}
+	}
+	if p.Example != nil {
+		vc := &valueChecker{def: v.def}
+		vc.propertyItem(normalizeValue(p.Example), p, typ, "")
+		v.exampleMismatches(ptr+"/"+tags.Example, "", vc.result)
+	}
+	for _, sub := range p.Properties {
+		v.property(ptr+jsonPointerPropertiesSegment+jsonPointerEscape(sub.Name), sub)
+	}
+}
+
+func (v *validator) ref(area string, r string) {
+	if ref, refArea, ok, _ := isInternalRef(r, area); ok {
+		if key := refs.Canonical(refArea, ref); v.owner == "" {
+			v.used[key] = true
+		} else {
+			v.deps[v.owner] = append(v.deps[v.owner], key)
+		}
+	}
+}
+
+func (v *validator) components(c *Components) {
+	ptr := jsonPointerComponentsPrefix
+	for _, s := range c.Schemas {
+		v.owner = refs.Canonical(tags.Schemas, s.Name)
+		v.schema(ptr+tags.Schemas+"/"+jsonPointerEscape(s.Name), &s)
+	}
+	for _, name := range sortedKeys(c.Requests) {
+		r := c.Requests[name]
+		v.owner = refs.Canonical(tags.RequestBodies, name)
+		v.request(ptr+tags.RequestBodies+"/"+jsonPointerEscape(name), &r)
+	}
+	for _, name := range sortedKeys(c.Responses) {
+		v.owner = refs.Canonical(tags.Responses, name)
+		v.response(ptr+tags.Responses+"/"+jsonPointerEscape(name), c.Responses[name])
+	}
+	for _, name := range sortedKeys(c.Parameters) {
+		p := c.Parameters[name]
+		v.owner = refs.Canonical(tags.Parameters, name)
+		v.ref(tags.Schemas, p.SchemaRef)
+		v.paramValue(ptr+tags.Parameters+"/"+jsonPointerEscape(name), p.Schema, p.SchemaRef, p.Example)
+	}
+	for _, eg := range c.Examples {
+		v.owner = refs.Canonical(tags.Examples, eg.Name)
+		v.ref(tags.Examples, eg.ExampleRef)
+	}
+	v.owner = ""
+	v.reachable()
+	// unused...
+	v.unused(tags.Schemas, sliceNames(c.Schemas, func(s Schema) string { return s.Name }))
+	v.unused(tags.RequestBodies, sortedKeys(c.Requests))
+	v.unused(tags.Responses, sortedKeys(c.Responses))
+	v.unused(tags.Parameters, sortedKeys(c.Parameters))
+	v.unused(tags.Examples, sliceNames(c.Examples, func(eg Example) string { return eg.Name }))
+}
+
+// reachable marks as used all components transitively referenced by the components used by paths & methods
+// (so that components only referenced by themselves, or by each other, are still unused)
+func (v *validator) reachable() {
+	pending := sortedKeys(v.used)
+	for len(pending) > 0 {
+		key := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		for _, dep := range v.deps[key] {
+			if !v.used[dep] {
+				v.used[dep] = true
+				pending = append(pending, dep)
+			}
+		}
+	}
+}
+
+func (v *validator) unused(area string, names []string) {
+	for _, name := range names {
+		if !v.used[refs.Canonical(area, name)] {
+			v.add(SeverityWarning, DiagUnusedComponent, jsonPointerComponentsPrefix+area+"/"+jsonPointerEscape(name), "component is not referenced")
+		}
+	}
+}
+
+func sliceNames[T any](items []T, name func(T) string) []string {
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		result = append(result, name(item))
+	}
+	return result
+}
+
+func sortedKeys[K string | int, V any](m map[K]V) []K {
+	result := make([]K, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i] < result[j]
+	})
+	return result
+}
+
+// jsonPointerPath returns the JSON pointer to a path in the OAS spec (where context is the trimmed DocOptions.Context)
+func jsonPointerPath(context string, path string) string {
+	if context != "" {
+		path = "/" + context + path
+	}
+	return jsonPointerPathsPrefix + jsonPointerEscape(path)
+}
+
+func jsonPointerEscape(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,272 @@
+package chioas
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+var testValidateHandler = func(writer http.ResponseWriter, request *http.Request) {}
+
+func TestDefinition_Validate(t *testing.T) {
+	testCases := []struct {
+		definition *Definition
+		expect     Diagnostics
+	}{
+		{
+			definition: &Definition{},
+		},
+		{
+			definition: &Definition{
+				Methods: Methods{
+					http.MethodGet: {OperationId: "dup", Handler: testValidateHandler},
+				},
+				Paths: Paths{
+					"/people": {
+						Methods: Methods{
+							http.MethodGet:  {OperationId: "dup", Handler: testValidateHandler},
+							http.MethodPost: {OperationId: "addPerson"},
+						},
+					},
+				},
+			},
+			expect: Diagnostics{
+				{Severity: SeverityError, Code: DiagDuplicateOperationId, Location: "/paths/~1people/get", Message: "duplicate operationId 'dup' (also used at /paths/~1/get)"},
+				{Severity: SeverityError, Code: DiagMissingHandler, Location: "/paths/~1people/post", Message: "no handler set for method"},
+			},
+		},
+		{
+			definition: &Definition{
+				DocOptions: DocOptions{Context: "api"},
+				Paths: Paths{
+					"/people/{id:[0-9]+}": {
+						PathParams: PathParams{
+							"personId": {},
+						},
+						Methods: Methods{
+							http.MethodGet: {Handler: testValidateHandler},
+						},
+						Paths: Paths{
+							"/addresses/{addressId}": {
+								PathParams: PathParams{
+									"addressId": {},
+								},
+								Methods: Methods{
+									http.MethodGet: {Handler: testValidateHandler},
+								},
+							},
+						},
+					},
+				},
+			},
+			expect: Diagnostics{
+				{Severity: SeverityError, Code: DiagUnknownPathParam, Location: "/paths/~1api~1people~1{id}", Message: "path param 'personId' is not a path var in path template"},
+				{Severity: SeverityWarning, Code: DiagUndeclaredPathParam, Location: "/paths/~1api~1people~1{id}", Message: "path var 'id' has no PathParams entry"},
+			},
+		},
+		{
+			definition: &Definition{
+				Paths: Paths{
+					"/{id": {},
+					"/disabled/{id}": {
+						Disabled: func() bool {
+							return true
+						},
+					},
+				},
+			},
+			expect: Diagnostics{
+				{Severity: SeverityError, Code: DiagInvalidPathTemplate, Location: "/paths/~1{id", Message: "invalid path template - unclosed '{' at position 1"},
+			},
+		},
+		{
+			definition: &Definition{
+				MethodHandlerBuilder: &methodHandlerBuilder{},
+				Methods: Methods{
+					http.MethodGet: {
+						Request: &Request{
+							Schema: Schema{
+								RequiredProperties: []string{"foo", "bar"},
+								Properties: Properties{
+									{Name: "foo"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expect: Diagnostics{
+				{Severity: SeverityWarning, Code: DiagRequestBodyNotAllowed, Location: "/paths/~1/get/requestBody", Message: "GET method should not have a request body"},
+				{Severity: SeverityError, Code: DiagRequiredNotInProperties, Location: "/paths/~1/get/requestBody/content/application~1json/schema/required/1", Message: "required property 'bar' is not in properties"},
+			},
+		},
+		{
+			definition: &Definition{
+				Methods: Methods{
+					http.MethodPost: {
+						Handler: testValidateHandler,
+						Request: &Request{
+							IsArray: true,
+							Schema: &Schema{
+								Type: "string",
+								Enum: []any{"a", 1},
+							},
+							Examples: Examples{
+								{Name: "ok", Value: []string{"a"}},
+								{Name: "bad", Value: []any{"a", true}},
+								{Name: "not-array", Value: "a"},
+							},
+						},
+						Responses: Responses{
+							http.StatusOK: {
+								Schema: &Schema{
+									RequiredProperties: []string{"name"},
+									Properties: Properties{
+										{Name: "name"},
+										{Name: "age", Type: "integer", Enum: []any{1, 2.5}},
+										{Name: "tags", Type: "array", ItemType: "string", Example: []any{"a", 1}},
+										{
+											Name: "address",
+											Type: "object",
+											Properties: Properties{
+												{Name: "postcode", Required: true},
+											},
+										},
+									},
+								},
+								Examples: Examples{
+									{Name: "ok", Value: map[string]any{"name": "bilbo", "age": 1, "address": map[string]any{"postcode": "x"}}},
+									{Name: "missing", Value: map[string]any{"age": 1}},
+									{Name: "nested", Value: map[string]any{"name": "bilbo", "address": map[string]any{}}},
+									{Name: "wrong-item", Value: map[string]any{"name": "bilbo", "tags": []any{"a", 2}}},
+									{Name: "struct", Value: struct {
+										Name string `json:"name"`
+										Age  int    `json:"age"`
+									}{Name: "bilbo", Age: 3}},
+								},
+							},
+							http.StatusNoContent: {NoContent: true},
+						},
+					},
+				},
+			},
+			expect: Diagnostics{
+				{Severity: SeverityError, Code: DiagEnumTypeMismatch, Location: "/paths/~1/post/requestBody/content/application~1json/schema/items/enum/1", Message: "enum value 1 does not match type 'string'"},
+				{Severity: SeverityWarning, Code: DiagExampleMismatch, Location: "/paths/~1/post/requestBody/content/application~1json/examples/bad/value/1", Message: "example does not match schema - expected type string, got boolean"},
+				{Severity: SeverityWarning, Code: DiagExampleMismatch, Location: "/paths/~1/post/requestBody/content/application~1json/examples/not-array/value", Message: "example does not match schema - expected type array, got string"},
+				{Severity: SeverityError, Code: DiagEnumTypeMismatch, Location: "/paths/~1/post/responses/200/content/application~1json/schema/properties/age/enum/1", Message: "enum value 2.5 does not match type 'integer'"},
+				{Severity: SeverityWarning, Code: DiagExampleMismatch, Location: "/paths/~1/post/responses/200/content/application~1json/schema/properties/tags/items/example", Message: "example does not match schema - expected type string, got array"},
+				{Severity: SeverityWarning, Code: DiagExampleMismatch, Location: "/paths/~1/post/responses/200/content/application~1json/examples/missing/value", Message: "example does not match schema - missing required property 'name'"},
+				{Severity: SeverityWarning, Code: DiagExampleMismatch, Location: "/paths/~1/post/responses/200/content/application~1json/examples/nested/value/address", Message: "example does not match schema - missing required property 'postcode'"},
+				{Severity: SeverityWarning, Code: DiagExampleMismatch, Location: "/paths/~1/post/responses/200/content/application~1json/examples/wrong-item/value/tags/1", Message: "example does not match schema - expected type string, got integer"},
+				{Severity: SeverityWarning, Code: DiagExampleMismatch, Location: "/paths/~1/post/responses/200/content/application~1json/examples/struct/value/age", Message: "example does not match schema - value 3 not in enum [1,2.5]"},
+			},
+		},
+		{
+			definition: &Definition{
+				Methods: Methods{
+					http.MethodGet: {
+						Handler: testValidateHandler,
+						QueryParams: QueryParams{
+							{Name: "a", Ref: "a"},
+							{Name: "b", Schema: &Schema{Type: "integer"}, Example: "x"},
+						},
+						Responses: Responses{
+							http.StatusOK: {
+								Ref: "ok",
+							},
+						},
+					},
+				},
+				Components: &Components{
+					Schemas: Schemas{
+						{Name: "used", Example: map[string]any{}},
+						{Name: "unused", Properties: Properties{{Name: "used", SchemaRef: "used"}}},
+						{Name: "bad-example", Type: "string", Example: 1},
+					},
+					Requests: CommonRequests{
+						"unused": {},
+					},
+					Responses: CommonResponses{
+						"ok": {
+							Examples: Examples{{Name: "eg", ExampleRef: "eg"}},
+						},
+					},
+					Parameters: CommonParameters{
+						"a": {SchemaRef: "used"},
+						"b": {},
+					},
+					Examples: Examples{
+						{Name: "eg"},
+						{Name: "unused"},
+					},
+				},
+			},
+			expect: Diagnostics{
+				{Severity: SeverityWarning, Code: DiagExampleMismatch, Location: "/paths/~1/get/parameters/1/example", Message: "example does not match schema - expected type integer, got string"},
+				{Severity: SeverityWarning, Code: DiagExampleMismatch, Location: "/components/schemas/bad-example/example", Message: "example does not match schema - expected type string, got integer"},
+				{Severity: SeverityWarning, Code: DiagUnusedComponent, Location: "/components/schemas/unused", Message: "component is not referenced"},
+				{Severity: SeverityWarning, Code: DiagUnusedComponent, Location: "/components/schemas/bad-example", Message: "component is not referenced"},
+				{Severity: SeverityWarning, Code: DiagUnusedComponent, Location: "/components/requestBodies/unused", Message: "component is not referenced"},
+				{Severity: SeverityWarning, Code: DiagUnusedComponent, Location: "/components/parameters/b", Message: "component is not referenced"},
+				{Severity: SeverityWarning, Code: DiagUnusedComponent, Location: "/components/examples/unused", Message: "component is not referenced"},
+			},
+		},
+		{
+			definition: &Definition{
+				Methods: Methods{
+					http.MethodGet: {
+						Handler: testValidateHandler,
+						Responses: Responses{
+							http.StatusOK: {SchemaRef: "tree"},
+						},
+					},
+				},
+				Components: &Components{
+					Schemas: Schemas{
+						{Name: "tree", Properties: Properties{{Name: "children", Type: "array", SchemaRef: "tree"}}},
+						{Name: "self", Properties: Properties{{Name: "parent", SchemaRef: "self"}}},
+						{Name: "ping", Properties: Properties{{Name: "pong", SchemaRef: "pong"}}},
+						{Name: "pong", Properties: Properties{{Name: "ping", SchemaRef: "ping"}}},
+					},
+					Responses: CommonResponses{
+						"self": {SchemaRef: "self"},
+					},
+					Examples: Examples{
+						{Name: "eg", ExampleRef: "eg"},
+					},
+				},
+			},
+			expect: Diagnostics{
+				{Severity: SeverityWarning, Code: DiagUnusedComponent, Location: "/components/schemas/self", Message: "component is not referenced"},
+				{Severity: SeverityWarning, Code: DiagUnusedComponent, Location: "/components/schemas/ping", Message: "component is not referenced"},
+				{Severity: SeverityWarning, Code: DiagUnusedComponent, Location: "/components/schemas/pong", Message: "component is not referenced"},
+				{Severity: SeverityWarning, Code: DiagUnusedComponent, Location: "/components/responses/self", Message: "component is not referenced"},
+				{Severity: SeverityWarning, Code: DiagUnusedComponent, Location: "/components/examples/eg", Message: "component is not referenced"},
+			},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]", i+1), func(t *testing.T) {
+			result := tc.definition.Validate()
+			assert.Equal(t, tc.expect, result)
+		})
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	ds := Diagnostics{
+		{Severity: SeverityWarning, Code: DiagUnusedComponent, Location: "/components/schemas/foo", Message: "component is not referenced"},
+	}
+	assert.False(t, ds.HasErrors())
+	assert.Equal(t, "warning [unused-component] /components/schemas/foo: component is not referenced", ds[0].String())
+	ds = append(ds, Diagnostic{Severity: SeverityError})
+	assert.True(t, ds.HasErrors())
+
+	data, err := json.Marshal(ds[0])
+	require.NoError(t, err)
+	assert.Equal(t, `{"severity":"warning","code":"unused-component","location":"/components/schemas/foo","message":"component is not referenced"}`, string(data))
+}
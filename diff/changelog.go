@@ -0,0 +1,286 @@
+package diff
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Changelog is a human-readable changelog of changes between two API versions - grouped by tag
+//
+// A Changelog can be served on the API docs path (see chioas.DocOptions.Changelog)
+type Changelog struct {
+	// Title is the title of the changelog (defaults to "API Changelog")
+	Title string
+	// Tags is the changes to operations, grouped by tag (in tag order)
+	Tags []ChangelogTag
+	// Schemas is the changes to component schemas (in schema name order)
+	Schemas []ChangelogEntry
+}
+
+// ChangelogTag is the changes to operations for a single tag
+type ChangelogTag struct {
+	// Tag is the name of the tag (empty for untagged operations)
+	Tag string
+	// Added is the operations added
+	Added []ChangelogEntry
+	// Changed is the operations changed
+	Changed []ChangelogEntry
+	// Deprecated is the operations deprecated
+	Deprecated []ChangelogEntry
+	// Removed is the operations removed
+	Removed []ChangelogEntry
+}
+
+// ChangelogEntry is a changelog entry for an operation or a component schema
+type ChangelogEntry struct {
+	// Name is the operation (e.g. "GET /pets") or the component schema name
+	Name string
+	// Breaking indicates whether any of the changes are breaking
+	Breaking bool
+	// Changes is the individual changes (only for changed operations and schemas)
+	Changes []ChangelogChange
+}
+
+// ChangelogChange is an individual change in a ChangelogEntry
+type ChangelogChange struct {
+	Breaking bool
+	Message  string
+}
+
+const (
+	defaultChangelogTitle = "API Changelog"
+	untaggedTitle         = "Untagged"
+	schemasPrefix         = "/components/schemas/"
+)
+
+// NewChangelog creates a new Changelog from changes (see Compare)
+func NewChangelog(title string, changes Changes) *Changelog {
+	result := &Changelog{
+		Title:   title,
+		Tags:    make([]ChangelogTag, 0),
+		Schemas: make([]ChangelogEntry, 0),
+	}
+	tags := map[string]*ChangelogTag{}
+	changed := map[string]int{}
+	schemas := map[string]*ChangelogEntry{}
+	tagOrder := make([]string, 0)
+	schemaOrder := make([]string, 0)
+	for _, c := range changes {
+		if c.Operation == "" {
+			name, ok := schemaName(c.Location)
+			if !ok {
+				continue
+			}
+			entry, exists := schemas[name]
+			if !exists {
+				entry = &ChangelogEntry{Name: name}
+				schemas[name] = entry
+				schemaOrder = append(schemaOrder, name)
+			}
+			entry.add(c)
+			continue
+		}
+		tag, exists := tags[c.Tag]
+		if !exists {
+			tag = &ChangelogTag{Tag: c.Tag}
+			tags[c.Tag] = tag
+			tagOrder = append(tagOrder, c.Tag)
+		}
+		switch c.Kind {
+		case KindOperationAdded:
+			tag.Added = append(tag.Added, ChangelogEntry{Name: c.Operation, Breaking: c.Breaking})
+		case KindOperationRemoved:
+			tag.Removed = append(tag.Removed, ChangelogEntry{Name: c.Operation, Breaking: c.Breaking})
+		case KindOperationDeprecated:
+			tag.Deprecated = append(tag.Deprecated, ChangelogEntry{Name: c.Operation, Breaking: c.Breaking})
+		default:
+			key := c.Tag + "|" + c.Operation
+			i, exists := changed[key]
+			if !exists {
+				i = len(tag.Changed)
+				changed[key] = i
+				tag.Changed = append(tag.Changed, ChangelogEntry{Name: c.Operation})
+			}
+			tag.Changed[i].add(c)
+		}
+	}
+	sort.Strings(tagOrder)
+	for _, t := range tagOrder {
+		result.Tags = append(result.Tags, *tags[t])
+	}
+	sort.Strings(schemaOrder)
+	for _, s := range schemaOrder {
+		result.Schemas = append(result.Schemas, *schemas[s])
+	}
+	return result
+}
+
+func (e *ChangelogEntry) add(c Change) {
+	e.Breaking = e.Breaking || c.Breaking
+	e.Changes = append(e.Changes, ChangelogChange{Breaking: c.Breaking, Message: c.Message})
+}
+
+func schemaName(location string) (string, bool) {
+	if strings.HasPrefix(location, schemasPrefix) {
+		name, _, _ := strings.Cut(location[len(schemasPrefix):], "/")
+		return strings.ReplaceAll(strings.ReplaceAll(name, "~1", "/"), "~0", "~"), true
+	}
+	return "", false
+}
+
+// IsEmpty returns true if the changelog has no entries
+func (c *Changelog) IsEmpty() bool {
+	return len(c.Tags) == 0 && len(c.Schemas) == 0
+}
+
+func (c *Changelog) title() string {
+	if c.Title != "" {
+		return c.Title
+	}
+	return defaultChangelogTitle
+}
+
+// WriteMarkdown writes the changelog as markdown
+func (c *Changelog) WriteMarkdown(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("# " + c.title() + "\n")
+	if c.IsEmpty() {
+		sb.WriteString("\nNo changes.\n")
+	}
+	for _, t := range c.Tags {
+		sb.WriteString("\n## " + tagTitle(t.Tag) + "\n")
+		writeMarkdownSections(&sb, tagSections(t))
+	}
+	if len(c.Schemas) > 0 {
+		sb.WriteString("\n## Schemas\n")
+		writeMarkdownSections(&sb, schemaSections(c.Schemas))
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeMarkdownSections(sb *strings.Builder, sections []changelogSection) {
+	for _, section := range sections {
+		sb.WriteString("\n### " + section.Title + "\n\n")
+		for _, e := range section.Entries {
+			sb.WriteString("- `" + e.Name + "`" + breakingMarker(e.Breaking) + "\n")
+			for _, c := range e.Changes {
+				sb.WriteString("  - " + c.Message + breakingMarker(c.Breaking) + "\n")
+			}
+		}
+	}
+}
+
+func breakingMarker(breaking bool) string {
+	if breaking {
+		return " **(breaking)**"
+	}
+	return ""
+}
+
+func tagTitle(tag string) string {
+	if tag == "" {
+		return untaggedTitle
+	}
+	return tag
+}
+
+// changelogSection is a titled list of entries (used by the html template)
+type changelogSection struct {
+	Title   string
+	Entries []ChangelogEntry
+}
+
+func tagSections(t ChangelogTag) []changelogSection {
+	return nonEmptySections(
+		changelogSection{Title: "Added", Entries: t.Added},
+		changelogSection{Title: "Changed", Entries: t.Changed},
+		changelogSection{Title: "Deprecated", Entries: t.Deprecated},
+		changelogSection{Title: "Removed", Entries: t.Removed},
+	)
+}
+
+func schemaSections(entries []ChangelogEntry) []changelogSection {
+	return nonEmptySections(changelogSection{Title: "Changed", Entries: entries})
+}
+
+func nonEmptySections(sections ...changelogSection) []changelogSection {
+	result := make([]changelogSection, 0, len(sections))
+	for _, s := range sections {
+		if len(s.Entries) > 0 {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+var changelogHtml = template.Must(template.New("changelog").Funcs(template.FuncMap{
+	"tagTitle":       tagTitle,
+	"tagSections":    tagSections,
+	"schemaSections": schemaSections,
+}).Parse(changelogTemplate))
+
+// WriteHtml writes the changelog as an HTML page
+func (c *Changelog) WriteHtml(w io.Writer) error {
+	var buffer bytes.Buffer
+	if err := changelogHtml.Execute(&buffer, map[string]any{
+		"title":     c.title(),
+		"changelog": c,
+	}); err != nil {
+		return err
+	}
+	_, err := w.Write(buffer.Bytes())
+	return err
+}
+
+const changelogTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="utf-8"/>
+    <meta name="viewport" content="width=device-width, initial-scale=1">
+    <title>{{.title}}</title>
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, Helvetica, Arial, sans-serif; margin: 2em auto; max-width: 960px; padding: 0 1em; color: #333; }
+        h1 { border-bottom: 1px solid #ddd; padding-bottom: 0.3em; }
+        h2 { margin-top: 1.5em; }
+        h3 { color: #555; font-size: 1em; text-transform: uppercase; }
+        code { background: #f4f4f4; border-radius: 3px; padding: 0.1em 0.3em; }
+        .breaking { background: #d9534f; border-radius: 3px; color: #fff; font-size: 0.8em; margin-left: 0.5em; padding: 0.1em 0.4em; }
+    </style>
+</head>
+<body>
+<h1>{{.title}}</h1>
+{{- with .changelog}}
+{{- if .IsEmpty}}
+<p>No changes.</p>
+{{- end}}
+{{- range .Tags}}
+<h2>{{tagTitle .Tag}}</h2>
+{{- range tagSections .}}{{template "section" .}}{{end}}
+{{- end}}
+{{- if .Schemas}}
+<h2>Schemas</h2>
+{{- range schemaSections .Schemas}}{{template "section" .}}{{end}}
+{{- end}}
+{{- end}}
+</body>
+</html>
+{{- define "section"}}
+<h3>{{.Title}}</h3>
+<ul>
+{{- range .Entries}}
+    <li><code>{{.Name}}</code>{{if .Breaking}}<span class="breaking">breaking</span>{{end}}
+    {{- if .Changes}}
+        <ul>
+        {{- range .Changes}}
+            <li>{{.Message}}{{if .Breaking}}<span class="breaking">breaking</span>{{end}}</li>
+        {{- end}}
+        </ul>
+    {{- end}}
+    </li>
+{{- end}}
+</ul>
+{{- end}}`
@@ -0,0 +1,116 @@
+package diff
+
+import (
+	"github.com/go-andiamo/chioas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+var testChangelogChanges = Changes{
+	{Kind: KindOperationAdded, Location: "/paths/~1pets/post", Operation: "POST /pets", Tag: "Pets", Message: "operation added"},
+	{Kind: KindParamAdded, Breaking: true, Location: "/paths/~1pets/get/parameters/query/owner", Operation: "GET /pets", Tag: "Pets", Message: "required query param 'owner' added"},
+	{Kind: KindPropertyRemoved, Breaking: true, Location: "/components/schemas/Pet/properties/age", Message: "property 'age' removed"},
+	{Kind: KindParamAdded, Location: "/paths/~1pets/get/parameters/query/limit", Operation: "GET /pets", Tag: "Pets", Message: "optional query param 'limit' added"},
+	{Kind: KindOperationDeprecated, Location: "/paths/~1owners/get", Operation: "GET /owners", Tag: "Owners", Message: "operation deprecated"},
+	{Kind: KindOperationRemoved, Breaking: true, Location: "/paths/~1status/get", Operation: "GET /status", Message: "operation removed"},
+	{Kind: KindPropertyAdded, Location: "/components/schemas/Pet/properties/colour", Message: "optional property 'colour' added"},
+	{Kind: KindSchemaAdded, Location: "/components/schemas/Owner", Message: "schema 'Owner' added"},
+}
+
+func TestNewChangelog(t *testing.T) {
+	cl := NewChangelog("", testChangelogChanges)
+	assert.False(t, cl.IsEmpty())
+	assert.Equal(t, []ChangelogTag{
+		{
+			Tag:     "",
+			Removed: []ChangelogEntry{{Name: "GET /status", Breaking: true}},
+		},
+		{
+			Tag:        "Owners",
+			Deprecated: []ChangelogEntry{{Name: "GET /owners"}},
+		},
+		{
+			Tag:   "Pets",
+			Added: []ChangelogEntry{{Name: "POST /pets"}},
+			Changed: []ChangelogEntry{
+				{
+					Name:     "GET /pets",
+					Breaking: true,
+					Changes: []ChangelogChange{
+						{Breaking: true, Message: "required query param 'owner' added"},
+						{Message: "optional query param 'limit' added"},
+					},
+				},
+			},
+		},
+	}, cl.Tags)
+	assert.Equal(t, []ChangelogEntry{
+		{Name: "Owner", Changes: []ChangelogChange{{Message: "schema 'Owner' added"}}},
+		{
+			Name:     "Pet",
+			Breaking: true,
+			Changes: []ChangelogChange{
+				{Breaking: true, Message: "property 'age' removed"},
+				{Message: "optional property 'colour' added"},
+			},
+		},
+	}, cl.Schemas)
+
+	assert.True(t, NewChangelog("", nil).IsEmpty())
+}
+
+func TestChangelog_WriteMarkdown(t *testing.T) {
+	var sb strings.Builder
+	require.NoError(t, NewChangelog("", testChangelogChanges).WriteMarkdown(&sb))
+	const expect = "# API Changelog\n" +
+		"\n## Untagged\n" +
+		"\n### Removed\n\n" +
+		"- `GET /status` **(breaking)**\n" +
+		"\n## Owners\n" +
+		"\n### Deprecated\n\n" +
+		"- `GET /owners`\n" +
+		"\n## Pets\n" +
+		"\n### Added\n\n" +
+		"- `POST /pets`\n" +
+		"\n### Changed\n\n" +
+		"- `GET /pets` **(breaking)**\n" +
+		"  - required query param 'owner' added **(breaking)**\n" +
+		"  - optional query param 'limit' added\n" +
+		"\n## Schemas\n" +
+		"\n### Changed\n\n" +
+		"- `Owner`\n" +
+		"  - schema 'Owner' added\n" +
+		"- `Pet` **(breaking)**\n" +
+		"  - property 'age' removed **(breaking)**\n" +
+		"  - optional property 'colour' added\n"
+	assert.Equal(t, expect, sb.String())
+
+	sb.Reset()
+	require.NoError(t, NewChangelog("v2 Changes", nil).WriteMarkdown(&sb))
+	assert.Equal(t, "# v2 Changes\n\nNo changes.\n", sb.String())
+}
+
+func TestChangelog_WriteHtml(t *testing.T) {
+	var sb strings.Builder
+	require.NoError(t, NewChangelog("v2 <Changes>", testChangelogChanges).WriteHtml(&sb))
+	html := sb.String()
+	assert.Contains(t, html, "<title>v2 &lt;Changes&gt;</title>")
+	assert.Contains(t, html, "<h2>Untagged</h2>")
+	assert.Contains(t, html, "<h2>Pets</h2>")
+	assert.Contains(t, html, "<h2>Schemas</h2>")
+	assert.Contains(t, html, "<h3>Deprecated</h3>")
+	assert.Contains(t, html, `<li><code>GET /status</code><span class="breaking">breaking</span>`)
+	assert.Contains(t, html, "<li>required query param &#39;owner&#39; added<span class=\"breaking\">breaking</span></li>")
+	assert.NotContains(t, html, "No changes.")
+
+	sb.Reset()
+	require.NoError(t, NewChangelog("", nil).WriteHtml(&sb))
+	assert.Contains(t, sb.String(), "<p>No changes.</p>")
+}
+
+func TestChangelog_IsChioasChangelog(t *testing.T) {
+	var cl chioas.Changelog = NewChangelog("", nil)
+	assert.NotNil(t, cl)
+}
@@ -0,0 +1,921 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-andiamo/chioas"
+	"github.com/go-andiamo/chioas/internal/refs"
+	"github.com/go-andiamo/chioas/internal/tags"
+	"github.com/go-andiamo/chioas/internal/values"
+	"github.com/go-andiamo/urit"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type direction int
+
+const (
+	inRequest direction = iota
+	inResponse
+)
+
+// scope is the operation (and tag) that changes are reported against
+type scope struct {
+	operation string
+	tag       string
+}
+
+type comparer struct {
+	base       *side
+	head       *side
+	changes    Changes
+	index      map[string]int
+	components map[string]bool
+}
+
+func newComparer(base *chioas.Definition, head *chioas.Definition) *comparer {
+	return &comparer{
+		base:       collect(base),
+		head:       collect(head),
+		changes:    Changes{},
+		index:      map[string]int{},
+		components: map[string]bool{},
+	}
+}
+
+// add adds a change - where the same change is found more than once (e.g. a change in a component schema used by
+// both requests and responses) it is only reported once (and is breaking if any occurrence is breaking)
+func (c *comparer) add(sc scope, kind Kind, breaking bool, ptr string, msg string, args ...any) {
+	change := Change{
+		Kind:      kind,
+		Breaking:  breaking,
+		Location:  ptr,
+		Operation: sc.operation,
+		Tag:       sc.tag,
+		Message:   fmt.Sprintf(msg, args...),
+	}
+	key := change.Location + "|" + string(change.Kind) + "|" + change.Message
+	if i, ok := c.index[key]; ok {
+		c.changes[i].Breaking = c.changes[i].Breaking || breaking
+		return
+	}
+	c.index[key] = len(c.changes)
+	c.changes = append(c.changes, change)
+}
+
+func (c *comparer) compare() {
+	keys := slices.Clone(c.base.keys)
+	for _, k := range c.head.keys {
+		if _, ok := c.base.operations[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return lessOperation(c.operation(keys[i]), c.operation(keys[j]))
+	})
+	for _, k := range keys {
+		b, inBase := c.base.operations[k]
+		h, inHead := c.head.operations[k]
+		switch {
+		case inBase && !inHead:
+			c.add(b.scope(), KindOperationRemoved, true, b.ptr, "operation removed")
+		case inHead && !inBase:
+			c.add(h.scope(), KindOperationAdded, false, h.ptr, "operation added")
+		default:
+			c.compareOperation(b, h)
+		}
+	}
+	c.compareComponentNames()
+}
+
+func (c *comparer) operation(key string) *operation {
+	if op, ok := c.head.operations[key]; ok {
+		return op
+	}
+	return c.base.operations[key]
+}
+
+func lessOperation(a, b *operation) bool {
+	if a.normalized != b.normalized {
+		return a.normalized < b.normalized
+	}
+	ai, bi := slices.Index(chioas.MethodsOrder, a.method), slices.Index(chioas.MethodsOrder, b.method)
+	if ai == bi {
+		return a.method < b.method
+	}
+	return ai != -1 && (bi == -1 || ai < bi)
+}
+
+func (c *comparer) compareComponentNames() {
+	baseNames, headNames := c.base.schemaNames(), c.head.schemaNames()
+	for _, name := range baseNames {
+		if !slices.Contains(headNames, name) {
+			c.add(scope{}, KindSchemaRemoved, false, componentPtr(name), "schema '%s' removed", name)
+		}
+	}
+	for _, name := range headNames {
+		if !slices.Contains(baseNames, name) {
+			c.add(scope{}, KindSchemaAdded, false, componentPtr(name), "schema '%s' added", name)
+		}
+	}
+}
+
+func componentPtr(name string) string {
+	return "/" + tags.Components + "/" + tags.Schemas + "/" + pointerEscape(name)
+}
+
+func (c *comparer) compareOperation(b *operation, h *operation) {
+	sc := h.scope()
+	if !b.def.Deprecated && h.def.Deprecated {
+		c.add(sc, KindOperationDeprecated, false, h.ptr, "operation deprecated")
+	}
+	c.compareSecurity(sc, h.ptr+"/"+tags.Security, b, h)
+	c.compareParams(sc, h.ptr+"/"+tags.Parameters, b.params(c.base), h.params(c.head))
+	c.compareRequest(sc, h.ptr+"/"+tags.RequestBody, c.base.request(b.def.Request), c.head.request(h.def.Request))
+	c.compareResponses(sc, h.ptr+"/"+tags.Responses, c.base.responses(b.def.Responses), c.head.responses(h.def.Responses))
+}
+
+func (c *comparer) compareSecurity(sc scope, ptr string, b *operation, h *operation) {
+	bAlts, hAlts := securityAlternatives(b.security), securityAlternatives(h.security)
+	for _, alt := range bAlts {
+		if !slices.Contains(hAlts, alt) {
+			c.add(sc, KindSecurityRemoved, true, ptr, "security alternative '%s' removed", alt)
+		}
+	}
+	for _, alt := range hAlts {
+		if !slices.Contains(bAlts, alt) {
+			// adding security where there was none is breaking...
+			c.add(sc, KindSecurityAdded, len(bAlts) == 0 && !h.def.OptionalSecurity, ptr, "security alternative '%s' added", alt)
+		}
+	}
+	if b.def.OptionalSecurity && !h.def.OptionalSecurity {
+		c.add(sc, KindSecurityRemoved, true, ptr, "optional (anonymous) security removed")
+	} else if !b.def.OptionalSecurity && h.def.OptionalSecurity && len(bAlts) > 0 {
+		c.add(sc, KindSecurityAdded, false, ptr, "optional (anonymous) security added")
+	}
+}
+
+func securityAlternatives(ss chioas.SecuritySchemes) []string {
+	result := make([]string, 0, len(ss))
+	for _, s := range ss {
+		alt := s.Name
+		if len(s.Scopes) > 0 {
+			scopes := slices.Clone(s.Scopes)
+			sort.Strings(scopes)
+			alt += " [" + strings.Join(scopes, ", ") + "]"
+		}
+		result = append(result, alt)
+	}
+	return result
+}
+
+// param is a resolved path, query, header or cookie param
+type param struct {
+	key      string
+	in       string
+	name     string
+	required bool
+	schema   *chioas.Schema
+}
+
+func (c *comparer) compareParams(sc scope, ptr string, bps []param, hps []param) {
+	for _, bp := range bps {
+		if !slices.ContainsFunc(hps, func(hp param) bool { return hp.key == bp.key }) {
+			c.add(sc, KindParamRemoved, false, ptr+"/"+bp.in+"/"+pointerEscape(bp.name), "%s param '%s' removed", bp.in, bp.name)
+		}
+	}
+	for _, hp := range hps {
+		pPtr := ptr + "/" + hp.in + "/" + pointerEscape(hp.name)
+		i := slices.IndexFunc(bps, func(bp param) bool { return bp.key == hp.key })
+		if i == -1 {
+			if hp.required {
+				c.add(sc, KindParamAdded, true, pPtr, "required %s param '%s' added", hp.in, hp.name)
+			} else {
+				c.add(sc, KindParamAdded, false, pPtr, "optional %s param '%s' added", hp.in, hp.name)
+			}
+			continue
+		}
+		bp := bps[i]
+		if !bp.required && hp.required {
+			c.add(sc, KindParamRequired, true, pPtr, "%s param '%s' became required", hp.in, hp.name)
+		} else if bp.required && !hp.required {
+			c.add(sc, KindParamOptional, false, pPtr, "%s param '%s' became optional", hp.in, hp.name)
+		}
+		bs, hs := bp.schema, hp.schema
+		if bs == nil {
+			bs = &chioas.Schema{Type: values.TypeString}
+		}
+		if hs == nil {
+			hs = &chioas.Schema{Type: values.TypeString}
+		}
+		c.compareSchemas(sc, pPtr+"/"+tags.Schema, bs, hs, inRequest)
+	}
+}
+
+func (c *comparer) compareRequest(sc scope, ptr string, b *chioas.Request, h *chioas.Request) {
+	switch {
+	case b == nil && h == nil:
+		return
+	case b == nil:
+		if h.Required {
+			c.add(sc, KindRequestBodyAdded, true, ptr, "required request body added")
+		} else {
+			c.add(sc, KindRequestBodyAdded, false, ptr, "optional request body added")
+		}
+		return
+	case h == nil:
+		c.add(sc, KindRequestBodyRemoved, false, ptr, "request body removed")
+		return
+	}
+	if !b.Required && h.Required {
+		c.add(sc, KindRequestBodyRequired, true, ptr, "request body became required")
+	}
+	c.compareContents(sc, ptr+"/"+tags.Content, requestContents(b), requestContents(h), inRequest)
+}
+
+func (c *comparer) compareResponses(sc scope, ptr string, b chioas.Responses, h chioas.Responses) {
+	for _, code := range sortedCodes(b) {
+		if _, ok := h[code]; !ok {
+			c.add(sc, KindResponseRemoved, true, ptr+"/"+strconv.Itoa(code), "response %d removed", code)
+		}
+	}
+	for _, code := range sortedCodes(h) {
+		rPtr := ptr + "/" + strconv.Itoa(code)
+		if br, ok := b[code]; !ok {
+			c.add(sc, KindResponseAdded, false, rPtr, "response %d added", code)
+		} else {
+			c.compareContents(sc, rPtr+"/"+tags.Content, responseContents(code, br), responseContents(code, h[code]), inResponse)
+		}
+	}
+}
+
+func sortedCodes(rs chioas.Responses) []int {
+	result := make([]int, 0, len(rs))
+	for code := range rs {
+		result = append(result, code)
+	}
+	sort.Ints(result)
+	return result
+}
+
+// content is a resolved request/response content type
+type content struct {
+	schema  *chioas.Schema
+	isArray bool
+}
+
+func requestContents(r *chioas.Request) map[string]content {
+	result := map[string]content{
+		contentTypeOrDefault(r.ContentType): {schema: contentSchema(r.Schema, r.SchemaRef), isArray: r.IsArray},
+	}
+	for ct, alt := range r.AlternativeContentTypes {
+		result[ct] = content{schema: contentSchema(alt.Schema, alt.SchemaRef), isArray: alt.IsArray}
+	}
+	return result
+}
+
+func responseContents(code int, r chioas.Response) map[string]content {
+	if r.NoContent || code == http.StatusNoContent {
+		return map[string]content{}
+	}
+	result := map[string]content{
+		contentTypeOrDefault(r.ContentType): {schema: contentSchema(r.Schema, r.SchemaRef), isArray: r.IsArray},
+	}
+	for ct, alt := range r.AlternativeContentTypes {
+		result[ct] = content{schema: contentSchema(alt.Schema, alt.SchemaRef), isArray: alt.IsArray}
+	}
+	return result
+}
+
+func contentTypeOrDefault(ct string) string {
+	if ct == "" {
+		return tags.ApplicationJson
+	}
+	return ct
+}
+
+// contentSchema determines the schema of a content (the same as would be written to the OAS spec)
+func contentSchema(schema any, schemaRef string) *chioas.Schema {
+	if schema != nil {
+		switch st := schema.(type) {
+		case chioas.Schema:
+			return &st
+		case *chioas.Schema:
+			return st
+		case chioas.SchemaConverter:
+			return st.ToSchema()
+		case chioas.SchemaWriter:
+			return nil
+		}
+		if s, err := (&chioas.Schema{}).From(schema); err == nil {
+			return s
+		}
+		return nil
+	} else if schemaRef != "" {
+		return &chioas.Schema{SchemaRef: schemaRef}
+	}
+	// no schema is written as an empty object...
+	return &chioas.Schema{Type: values.TypeObject}
+}
+
+func (c *comparer) compareContents(sc scope, ptr string, b map[string]content, h map[string]content, dir direction) {
+	for _, ct := range sortedKeys(b) {
+		if _, ok := h[ct]; !ok {
+			c.add(sc, KindContentTypeRemoved, true, ptr+"/"+pointerEscape(ct), "content type '%s' removed", ct)
+		}
+	}
+	for _, ct := range sortedKeys(h) {
+		cPtr := ptr + "/" + pointerEscape(ct)
+		bc, ok := b[ct]
+		if !ok {
+			c.add(sc, KindContentTypeAdded, false, cPtr, "content type '%s' added", ct)
+			continue
+		}
+		hc := h[ct]
+		sPtr := cPtr + "/" + tags.Schema
+		if bc.isArray != hc.isArray {
+			c.add(sc, KindTypeChanged, true, sPtr, "type changed from %s to %s", arrayTypeName(bc.isArray), arrayTypeName(hc.isArray))
+			continue
+		}
+		if hc.isArray {
+			sPtr += "/" + tags.Items
+		}
+		if bc.schema != nil && hc.schema != nil {
+			c.compareSchemas(sc, sPtr, bc.schema, hc.schema, dir)
+		}
+	}
+}
+
+func arrayTypeName(isArray bool) string {
+	if isArray {
+		return values.TypeArray
+	}
+	return "non-array"
+}
+
+// compareSchemas compares two schemas - where both reference the same named component schema, the component schemas
+// are compared (once per direction) and changes reported at the component location
+func (c *comparer) compareSchemas(sc scope, ptr string, b *chioas.Schema, h *chioas.Schema, dir direction) {
+	if b.SchemaRef != "" && h.SchemaRef != "" {
+		bName, bOk := schemaRefName(b.SchemaRef)
+		hName, hOk := schemaRefName(h.SchemaRef)
+		if bOk && hOk && bName == hName {
+			key := fmt.Sprintf("%s|%d", hName, dir)
+			if !c.components[key] {
+				c.components[key] = true
+				bs, bFound := c.base.schema(bName, nil)
+				hs, hFound := c.head.schema(hName, nil)
+				if bFound && hFound {
+					c.compareResolvedSchemas(scope{}, componentPtr(hName), bs, hs, dir)
+				}
+			}
+			return
+		}
+	}
+	bs, bOk := c.base.resolve(b)
+	hs, hOk := c.head.resolve(h)
+	if bOk && hOk {
+		c.compareResolvedSchemas(sc, ptr, bs, hs, dir)
+	}
+}
+
+func (c *comparer) compareResolvedSchemas(sc scope, ptr string, b *chioas.Schema, h *chioas.Schema, dir direction) {
+	bt, ht := schemaType(b), schemaType(h)
+	if bt != ht {
+		c.add(sc, KindTypeChanged, true, ptr, "type changed from %s to %s", typeName(bt), typeName(ht))
+		return
+	}
+	if b.Format != h.Format {
+		c.add(sc, KindFormatChanged, true, ptr, "format changed from %s to %s", typeName(b.Format), typeName(h.Format))
+	}
+	c.compareEnums(sc, ptr, b.Enum, h.Enum, dir)
+	c.compareProperties(sc, ptr, b.Properties, requiredProperties(b), h.Properties, requiredProperties(h), dir)
+	c.compareOfs(sc, ptr, b.Ofs, h.Ofs, dir)
+}
+
+func schemaType(s *chioas.Schema) string {
+	if s.Type == "" && s.Ofs == nil {
+		return values.TypeObject
+	}
+	return s.Type
+}
+
+func typeName(t string) string {
+	if t == "" {
+		return "none"
+	}
+	return t
+}
+
+func requiredProperties(s *chioas.Schema) []string {
+	result := slices.Clone(s.RequiredProperties)
+	for _, p := range s.Properties {
+		if p.Required && !slices.Contains(result, p.Name) {
+			result = append(result, p.Name)
+		}
+	}
+	return result
+}
+
+func (c *comparer) compareOfs(sc scope, ptr string, b *chioas.Ofs, h *chioas.Ofs, dir direction) {
+	switch {
+	case b == nil && h == nil:
+		return
+	case b == nil || h == nil || b.OfType != h.OfType:
+		c.add(sc, KindTypeChanged, true, ptr, "composition changed from %s to %s", ofTypeName(b), ofTypeName(h))
+		return
+	}
+	ofPtr := ptr + "/" + h.OfType.TagName()
+	n := min(len(b.Of), len(h.Of))
+	for i := 0; i < n; i++ {
+		c.compareSchemas(sc, ofPtr+"/"+strconv.Itoa(i), ofSchema(b.Of[i]), ofSchema(h.Of[i]), dir)
+	}
+	if h.OfType == chioas.AllOf {
+		// allOf - fewer schemas is relaxing, more is tightening...
+		for i := n; i < len(b.Of); i++ {
+			c.add(sc, KindConstraintRelaxed, dir == inResponse, ofPtr+"/"+strconv.Itoa(i), "%s schema removed", h.OfType.TagName())
+		}
+		for i := n; i < len(h.Of); i++ {
+			c.add(sc, KindConstraintTightened, dir == inRequest, ofPtr+"/"+strconv.Itoa(i), "%s schema added", h.OfType.TagName())
+		}
+	} else {
+		for i := n; i < len(b.Of); i++ {
+			c.add(sc, KindConstraintTightened, dir == inRequest, ofPtr+"/"+strconv.Itoa(i), "%s alternative removed", h.OfType.TagName())
+		}
+		for i := n; i < len(h.Of); i++ {
+			c.add(sc, KindConstraintRelaxed, dir == inResponse, ofPtr+"/"+strconv.Itoa(i), "%s alternative added", h.OfType.TagName())
+		}
+	}
+}
+
+func ofTypeName(ofs *chioas.Ofs) string {
+	if ofs == nil {
+		return "none"
+	}
+	return ofs.OfType.TagName()
+}
+
+func ofSchema(of chioas.OfSchema) *chioas.Schema {
+	if of.IsRef() {
+		return &chioas.Schema{SchemaRef: of.Ref()}
+	} else if s := of.Schema(); s != nil {
+		return s
+	}
+	return &chioas.Schema{}
+}
+
+func (c *comparer) compareEnums(sc scope, ptr string, b []any, h []any, dir direction) {
+	switch {
+	case len(b) == 0 && len(h) == 0:
+		return
+	case len(b) == 0:
+		c.add(sc, KindConstraintTightened, dir == inRequest, ptr+"/"+tags.Enum, "enum added %s", enumString(h))
+		return
+	case len(h) == 0:
+		c.add(sc, KindConstraintRelaxed, dir == inResponse, ptr+"/"+tags.Enum, "enum removed (was %s)", enumString(b))
+		return
+	}
+	bvs, hvs := enumValues(b), enumValues(h)
+	for _, v := range bvs {
+		if !slices.Contains(hvs, v) {
+			c.add(sc, KindEnumValueRemoved, dir == inRequest, ptr+"/"+tags.Enum, "enum value %s removed", v)
+		}
+	}
+	for _, v := range hvs {
+		if !slices.Contains(bvs, v) {
+			c.add(sc, KindEnumValueAdded, dir == inResponse, ptr+"/"+tags.Enum, "enum value %s added", v)
+		}
+	}
+}
+
+func enumValues(enum []any) []string {
+	result := make([]string, 0, len(enum))
+	for _, v := range enum {
+		data, _ := json.Marshal(v)
+		result = append(result, string(data))
+	}
+	return result
+}
+
+func enumString(enum []any) string {
+	return "[" + strings.Join(enumValues(enum), ",") + "]"
+}
+
+func (c *comparer) compareProperties(sc scope, ptr string, b chioas.Properties, bReqd []string, h chioas.Properties, hReqd []string, dir direction) {
+	pPtr := ptr + "/" + tags.Properties + "/"
+	for _, bp := range b {
+		if !slices.ContainsFunc(h, func(hp chioas.Property) bool { return hp.Name == bp.Name }) {
+			c.add(sc, KindPropertyRemoved, dir == inResponse, pPtr+pointerEscape(bp.Name), "property '%s' removed", bp.Name)
+		}
+	}
+	for _, hp := range h {
+		ptyPtr := pPtr + pointerEscape(hp.Name)
+		hRequired := slices.Contains(hReqd, hp.Name)
+		i := slices.IndexFunc(b, func(bp chioas.Property) bool { return bp.Name == hp.Name })
+		if i == -1 {
+			if hRequired {
+				c.add(sc, KindPropertyAdded, dir == inRequest, ptyPtr, "required property '%s' added", hp.Name)
+			} else {
+				c.add(sc, KindPropertyAdded, false, ptyPtr, "optional property '%s' added", hp.Name)
+			}
+			continue
+		}
+		bp := b[i]
+		bRequired := slices.Contains(bReqd, bp.Name)
+		if !bRequired && hRequired {
+			c.add(sc, KindPropertyRequired, dir == inRequest, ptyPtr, "property '%s' became required", hp.Name)
+		} else if bRequired && !hRequired {
+			c.add(sc, KindPropertyOptional, dir == inResponse, ptyPtr, "property '%s' became optional", hp.Name)
+		}
+		c.compareProperty(sc, ptyPtr, bp, hp, dir)
+	}
+}
+
+func propertyType(p chioas.Property) string {
+	if p.Type == "" && p.SchemaRef != "" {
+		return "$ref"
+	} else if p.Type == "" {
+		return values.TypeString
+	}
+	return p.Type
+}
+
+func propertyItemType(p chioas.Property) string {
+	if p.SchemaRef != "" {
+		return "$ref"
+	} else if p.ItemType == "" {
+		return values.TypeString
+	}
+	return p.ItemType
+}
+
+func (c *comparer) compareProperty(sc scope, ptr string, b chioas.Property, h chioas.Property, dir direction) {
+	bt, ht := propertyType(b), propertyType(h)
+	if bt != ht {
+		c.add(sc, KindTypeChanged, true, ptr, "type changed from %s to %s", bt, ht)
+		return
+	}
+	c.compareConstraints(sc, ptr, b.Constraints, h.Constraints, dir)
+	itemPtr := ptr
+	if ht == values.TypeArray {
+		itemPtr += "/" + tags.Items
+		bit, hit := propertyItemType(b), propertyItemType(h)
+		if bit != hit {
+			c.add(sc, KindTypeChanged, true, itemPtr, "type changed from %s to %s", bit, hit)
+			return
+		}
+	}
+	if b.SchemaRef != "" && h.SchemaRef != "" {
+		c.compareSchemas(sc, itemPtr, &chioas.Schema{SchemaRef: b.SchemaRef}, &chioas.Schema{SchemaRef: h.SchemaRef}, dir)
+		return
+	}
+	if b.Format != h.Format {
+		c.add(sc, KindFormatChanged, true, itemPtr, "format changed from %s to %s", typeName(b.Format), typeName(h.Format))
+	}
+	c.compareEnums(sc, itemPtr, b.Enum, h.Enum, dir)
+	c.compareProperties(sc, itemPtr, b.Properties, subRequired(b.Properties), h.Properties, subRequired(h.Properties), dir)
+}
+
+func subRequired(ps chioas.Properties) []string {
+	result := make([]string, 0)
+	for _, p := range ps {
+		if p.Required {
+			result = append(result, p.Name)
+		}
+	}
+	return result
+}
+
+// constraintChange is a change to a single constraint
+type constraintChange struct {
+	name      string
+	from      string
+	to        string
+	tightened bool
+}
+
+func (c *comparer) compareConstraints(sc scope, ptr string, b chioas.Constraints, h chioas.Constraints, dir direction) {
+	changes := make([]constraintChange, 0)
+	add := func(name string, from string, to string, tightened bool) {
+		changes = append(changes, constraintChange{name: name, from: from, to: to, tightened: tightened})
+	}
+	upperUint := func(name string, bv uint, hv uint) {
+		if bv != hv {
+			add(name, uintString(bv), uintString(hv), hv != 0 && (bv == 0 || hv < bv))
+		}
+	}
+	lowerUint := func(name string, bv uint, hv uint) {
+		if bv != hv {
+			add(name, uintString(bv), uintString(hv), hv > bv)
+		}
+	}
+	upperNumber := func(name string, bv json.Number, hv json.Number) {
+		if bv != hv {
+			bf, bErr := bv.Float64()
+			hf, hErr := hv.Float64()
+			add(name, string(bv), string(hv), hv != "" && hErr == nil && (bv == "" || bErr != nil || hf < bf))
+		}
+	}
+	lowerNumber := func(name string, bv json.Number, hv json.Number) {
+		if bv != hv {
+			bf, bErr := bv.Float64()
+			hf, hErr := hv.Float64()
+			add(name, string(bv), string(hv), hv != "" && hErr == nil && (bv == "" || bErr != nil || hf > bf))
+		}
+	}
+	flag := func(name string, bv bool, hv bool) {
+		if bv != hv {
+			add(name, strconv.FormatBool(bv), strconv.FormatBool(hv), hv)
+		}
+	}
+	if b.Pattern != h.Pattern {
+		add("pattern", b.Pattern, h.Pattern, h.Pattern != "")
+	}
+	upperNumber("maximum", b.Maximum, h.Maximum)
+	lowerNumber("minimum", b.Minimum, h.Minimum)
+	flag("exclusiveMaximum", b.ExclusiveMaximum, h.ExclusiveMaximum)
+	flag("exclusiveMinimum", b.ExclusiveMinimum, h.ExclusiveMinimum)
+	if b.MultipleOf != h.MultipleOf {
+		add("multipleOf", uintString(b.MultipleOf), uintString(h.MultipleOf), h.MultipleOf != 0 && (b.MultipleOf == 0 || b.MultipleOf%h.MultipleOf != 0))
+	}
+	upperUint("maxLength", b.MaxLength, h.MaxLength)
+	lowerUint("minLength", b.MinLength, h.MinLength)
+	upperUint("maxItems", b.MaxItems, h.MaxItems)
+	lowerUint("minItems", b.MinItems, h.MinItems)
+	flag("uniqueItems", b.UniqueItems, h.UniqueItems)
+	upperUint("maxProperties", b.MaxProperties, h.MaxProperties)
+	lowerUint("minProperties", b.MinProperties, h.MinProperties)
+	if b.Nullable != h.Nullable {
+		// becoming non-nullable is a tightening...
+		add("nullable", strconv.FormatBool(b.Nullable), strconv.FormatBool(h.Nullable), !h.Nullable)
+	}
+	for _, cc := range changes {
+		msg := constraintMessage(cc)
+		if cc.tightened {
+			c.add(sc, KindConstraintTightened, dir == inRequest, ptr, "%s", msg)
+		} else {
+			// in responses, only becoming nullable is breaking...
+			c.add(sc, KindConstraintRelaxed, dir == inResponse && cc.name == "nullable", ptr, "%s", msg)
+		}
+	}
+}
+
+func uintString(v uint) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.FormatUint(uint64(v), 10)
+}
+
+func constraintMessage(cc constraintChange) string {
+	switch {
+	case cc.from == "":
+		return fmt.Sprintf("%s %s added", cc.name, cc.to)
+	case cc.to == "":
+		return fmt.Sprintf("%s %s removed", cc.name, cc.from)
+	}
+	return fmt.Sprintf("%s changed from %s to %s", cc.name, cc.from, cc.to)
+}
+
+// side is a collected definition (i.e. base or head)
+type side struct {
+	def        *chioas.Definition
+	context    string
+	operations map[string]*operation
+	keys       []string
+}
+
+// operation is a collected method on a path
+type operation struct {
+	path       string
+	normalized string
+	method     string
+	ptr        string
+	def        chioas.Method
+	pathVars   []urit.PathVar
+	pathParams chioas.PathParams
+	tag        string
+	security   chioas.SecuritySchemes
+}
+
+func (op *operation) scope() scope {
+	return scope{
+		operation: op.method + " " + op.path,
+		tag:       op.tag,
+	}
+}
+
+func (op *operation) params(s *side) []param {
+	result := make([]param, 0, len(op.pathVars)+len(op.def.QueryParams))
+	for i, pv := range op.pathVars {
+		p := param{key: values.Path + ":" + strconv.Itoa(i), in: values.Path, name: pv.Name, required: true}
+		if pp, ok := op.pathParams[pv.Name]; ok {
+			if pp.Ref != "" {
+				if cp, ok := s.parameter(pp.Ref); ok {
+					p.schema = paramSchema(cp.Schema, cp.SchemaRef)
+				}
+			} else {
+				p.schema = paramSchema(pp.Schema, pp.SchemaRef)
+			}
+		}
+		result = append(result, p)
+	}
+	for _, qp := range op.def.QueryParams {
+		p := param{in: qp.In, name: qp.Name, required: qp.Required, schema: paramSchema(qp.Schema, qp.SchemaRef)}
+		if qp.Ref != "" {
+			cp, ok := s.parameter(qp.Ref)
+			if !ok {
+				continue
+			}
+			p = param{in: cp.In, name: cp.Name, required: cp.Required, schema: paramSchema(cp.Schema, cp.SchemaRef)}
+		}
+		if p.in == "" {
+			p.in = values.Query
+		}
+		p.key = p.in + ":" + p.name
+		result = append(result, p)
+	}
+	return result
+}
+
+func paramSchema(schema *chioas.Schema, schemaRef string) *chioas.Schema {
+	if schema != nil {
+		return schema
+	} else if schemaRef != "" {
+		return &chioas.Schema{SchemaRef: schemaRef}
+	}
+	return nil
+}
+
+var pathVarsRegex = regexp.MustCompile(`\{[^}]*}`)
+
+func collect(d *chioas.Definition) *side {
+	result := &side{
+		def:        d,
+		context:    strings.Trim(d.DocOptions.Context, "/"),
+		operations: map[string]*operation{},
+	}
+	result.methods("/", nil, nil, "", d.Methods)
+	result.paths(nil, nil, "", d.Paths)
+	return result
+}
+
+func (s *side) paths(ancestry []string, parentParams chioas.PathParams, parentTag string, paths chioas.Paths) {
+	for _, p := range sortedKeys(paths) {
+		pDef := paths[p]
+		if pDef.HideDocs || (pDef.Disabled != nil && pDef.Disabled()) {
+			continue
+		}
+		newAncestry := append(slices.Clone(ancestry), p)
+		pps := chioas.PathParams{}
+		for k, pp := range parentParams {
+			pps[k] = pp
+		}
+		for k, pp := range pDef.PathParams {
+			pps[k] = pp
+		}
+		useTag := parentTag
+		if pDef.Tag != "" {
+			useTag = pDef.Tag
+		}
+		if template, err := urit.NewTemplate(strings.Join(newAncestry, "")); err == nil {
+			s.methods(template.Template(true), template.Vars(), pps, useTag, pDef.Methods)
+		}
+		s.paths(newAncestry, pps, useTag, pDef.Paths)
+	}
+}
+
+func (s *side) methods(path string, pathVars []urit.PathVar, pathParams chioas.PathParams, parentTag string, methods chioas.Methods) {
+	if s.context != "" {
+		path = "/" + s.context + path
+	}
+	normalized := pathVarsRegex.ReplaceAllString(path, "{}")
+	for _, m := range sortedKeys(methods) {
+		mDef := methods[m]
+		if mDef.HideDocs {
+			continue
+		}
+		op := &operation{
+			path:       path,
+			normalized: normalized,
+			method:     m,
+			ptr:        "/" + tags.Paths + "/" + pointerEscape(path) + "/" + strings.ToLower(m),
+			def:        mDef,
+			pathVars:   pathVars,
+			pathParams: pathParams,
+			tag:        parentTag,
+			security:   mDef.Security,
+		}
+		if mDef.Tag != "" {
+			op.tag = mDef.Tag
+		}
+		if len(op.security) == 0 {
+			op.security = s.def.Security
+		}
+		key := m + " " + normalized
+		s.operations[key] = op
+		s.keys = append(s.keys, key)
+	}
+}
+
+func (s *side) schemaNames() []string {
+	result := make([]string, 0)
+	if s.def.Components != nil {
+		for _, cs := range s.def.Components.Schemas {
+			result = append(result, cs.Name)
+		}
+	}
+	return result
+}
+
+func schemaRefName(ref string) (string, bool) {
+	name := refs.Normalize(tags.Schemas, ref)
+	return name, !strings.Contains(name, "/")
+}
+
+func (s *side) schema(name string, seen map[string]bool) (*chioas.Schema, bool) {
+	if s.def.Components != nil && !seen[name] {
+		for i := range s.def.Components.Schemas {
+			if s.def.Components.Schemas[i].Name == name {
+				return &s.def.Components.Schemas[i], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// resolve follows any SchemaRef (returns false if the ref cannot be resolved, is external or is cyclic)
+func (s *side) resolve(schema *chioas.Schema) (*chioas.Schema, bool) {
+	seen := map[string]bool{}
+	for schema.SchemaRef != "" {
+		name, ok := schemaRefName(schema.SchemaRef)
+		if !ok {
+			return nil, false
+		}
+		if schema, ok = s.schema(name, seen); !ok {
+			return nil, false
+		}
+		seen[name] = true
+	}
+	return schema, true
+}
+
+func (s *side) parameter(ref string) (chioas.CommonParameter, bool) {
+	if s.def.Components != nil {
+		cp, ok := s.def.Components.Parameters[refs.Normalize(tags.Parameters, ref)]
+		return cp, ok
+	}
+	return chioas.CommonParameter{}, false
+}
+
+func (s *side) request(r *chioas.Request) *chioas.Request {
+	if r != nil && r.Ref != "" {
+		if s.def.Components != nil {
+			if cr, ok := s.def.Components.Requests[refs.Normalize(tags.RequestBodies, r.Ref)]; ok {
+				return &cr
+			}
+		}
+		return nil
+	}
+	return r
+}
+
+func (s *side) responses(rs chioas.Responses) chioas.Responses {
+	if len(rs) == 0 {
+		if len(s.def.DocOptions.DefaultResponses) > 0 {
+			rs = s.def.DocOptions.DefaultResponses
+		} else {
+			rs = chioas.Responses{http.StatusOK: {}}
+		}
+	}
+	result := make(chioas.Responses, len(rs))
+	for code, r := range rs {
+		if r.Ref != "" {
+			if s.def.Components != nil {
+				if cr, ok := s.def.Components.Responses[refs.Normalize(tags.Responses, r.Ref)]; ok {
+					result[code] = cr
+				}
+			}
+		} else {
+			result[code] = r
+		}
+	}
+	return result
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func pointerEscape(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
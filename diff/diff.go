@@ -0,0 +1,113 @@
+package diff
+
+import (
+	"github.com/go-andiamo/chioas"
+)
+
+// Kind is the kind of change
+type Kind string
+
+const (
+	KindOperationAdded      Kind = "operation-added"
+	KindOperationRemoved    Kind = "operation-removed"
+	KindOperationDeprecated Kind = "operation-deprecated"
+	KindParamAdded          Kind = "param-added"
+	KindParamRemoved        Kind = "param-removed"
+	KindParamRequired       Kind = "param-required"
+	KindParamOptional       Kind = "param-optional"
+	KindRequestBodyAdded    Kind = "request-body-added"
+	KindRequestBodyRemoved  Kind = "request-body-removed"
+	KindRequestBodyRequired Kind = "request-body-required"
+	KindContentTypeAdded    Kind = "content-type-added"
+	KindContentTypeRemoved  Kind = "content-type-removed"
+	KindResponseAdded       Kind = "response-added"
+	KindResponseRemoved     Kind = "response-removed"
+	KindTypeChanged         Kind = "type-changed"
+	KindFormatChanged       Kind = "format-changed"
+	KindEnumValueAdded      Kind = "enum-value-added"
+	KindEnumValueRemoved    Kind = "enum-value-removed"
+	KindPropertyAdded       Kind = "property-added"
+	KindPropertyRemoved     Kind = "property-removed"
+	KindPropertyRequired    Kind = "property-required"
+	KindPropertyOptional    Kind = "property-optional"
+	KindConstraintTightened Kind = "constraint-tightened"
+	KindConstraintRelaxed   Kind = "constraint-relaxed"
+	KindSecurityAdded       Kind = "security-added"
+	KindSecurityRemoved     Kind = "security-removed"
+	KindSchemaAdded         Kind = "schema-added"
+	KindSchemaRemoved       Kind = "schema-removed"
+)
+
+// Change is a single semantic change between two definitions
+type Change struct {
+	// Kind is the kind of change
+	Kind Kind `json:"kind"`
+	// Breaking indicates whether the change is breaking (i.e. may break existing clients)
+	Breaking bool `json:"breaking"`
+	// Location is the JSON pointer style location of the change in the OAS spec (of the head definition or, for
+	// removals, the base definition) - params are identified by their "in" and name (e.g. ".../get/parameters/query/limit")
+	Location string `json:"location"`
+	// Operation is the operation affected by the change (e.g. "GET /pets/{id}") - empty for changes to component schemas
+	Operation string `json:"operation,omitempty"`
+	// Tag is the tag of the operation affected by the change
+	Tag string `json:"tag,omitempty"`
+	// Message is the description of the change
+	Message string `json:"message"`
+}
+
+func (c Change) String() string {
+	result := "non-breaking"
+	if c.Breaking {
+		result = "breaking"
+	}
+	result += " [" + string(c.Kind) + "] "
+	if c.Operation != "" {
+		result += c.Operation + ": "
+	}
+	return result + c.Message + " (at " + c.Location + ")"
+}
+
+// Changes is a list of Change
+type Changes []Change
+
+// HasBreaking returns true if any of the changes are breaking
+func (cs Changes) HasBreaking() bool {
+	for _, c := range cs {
+		if c.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// Breaking returns only the breaking changes
+func (cs Changes) Breaking() Changes {
+	result := make(Changes, 0, len(cs))
+	for _, c := range cs {
+		if c.Breaking {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// Compare semantically compares two definitions (base being the old version and head the new version) and returns
+// the changes found
+//
+// Operations are matched by method and path template (ignoring path var names and patterns).  Schemas are compared with
+// refs resolved against each definition's components - where both base and head reference the same named component
+// schema, changes within that schema are reported once at the component location (with no Operation).  The breaking
+// classification of schema changes depends on whether the schema is used in requests or responses, e.g.
+//
+// * a new required request property is breaking, whereas a new response property is not
+//
+// * a removed request enum value is breaking, whereas an added response enum value is breaking
+//
+// * tightened request constraints are breaking, whereas changed response constraints are not (except newly nullable)
+//
+// Hidden (see chioas.Path.HideDocs and chioas.Method.HideDocs) and disabled paths and methods are not compared
+func Compare(base *chioas.Definition, head *chioas.Definition) Changes {
+	c := newComparer(base, head)
+	c.compare()
+	return c.changes
+}
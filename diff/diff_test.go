@@ -0,0 +1,335 @@
+package diff
+
+import (
+	"github.com/go-andiamo/chioas"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+// testBaseDefinition is shared by tests that only read the definition (tests that alter it use newTestBaseDefinition)
+var testBaseDefinition = newTestBaseDefinition()
+
+func newTestBaseDefinition() *chioas.Definition {
+	return &chioas.Definition{
+		Security: chioas.SecuritySchemes{{Name: "bearer"}, {Name: "apiKey"}},
+		Paths: chioas.Paths{
+			"/pets": {
+				Tag: "Pets",
+				Methods: chioas.Methods{
+					http.MethodGet: {
+						QueryParams: chioas.QueryParams{
+							{Name: "limit", Schema: &chioas.Schema{Type: "integer"}},
+							{Name: "status", Schema: &chioas.Schema{Type: "string", Enum: []any{"available", "sold"}}},
+						},
+						Responses: chioas.Responses{
+							http.StatusOK: {IsArray: true, SchemaRef: "Pet"},
+						},
+					},
+					http.MethodPost: {
+						Request: &chioas.Request{SchemaRef: "Pet"},
+						Responses: chioas.Responses{
+							http.StatusCreated:  {SchemaRef: "Pet"},
+							http.StatusConflict: {},
+						},
+					},
+				},
+				Paths: chioas.Paths{
+					"/{petId}": {
+						Methods: chioas.Methods{
+							http.MethodGet: {
+								Responses: chioas.Responses{
+									http.StatusOK: {SchemaRef: "Pet"},
+								},
+							},
+							http.MethodDelete: {},
+						},
+					},
+				},
+			},
+		},
+		Components: &chioas.Components{
+			Schemas: chioas.Schemas{
+				{
+					Name:               "Pet",
+					RequiredProperties: []string{"name"},
+					Properties: chioas.Properties{
+						{Name: "id", Type: "integer"},
+						{Name: "name", Constraints: chioas.Constraints{MaxLength: 50}},
+						{Name: "age", Type: "integer"},
+					},
+				},
+				{Name: "Old"},
+			},
+		},
+	}
+}
+
+func TestCompare_NoChanges(t *testing.T) {
+	changes := Compare(testBaseDefinition, testBaseDefinition)
+	assert.Empty(t, changes)
+	assert.False(t, changes.HasBreaking())
+}
+
+func TestCompare(t *testing.T) {
+	head := newTestBaseDefinition()
+	head.Security = chioas.SecuritySchemes{{Name: "bearer"}}
+	pets := head.Paths["/pets"]
+	pets.Methods[http.MethodGet] = chioas.Method{
+		QueryParams: chioas.QueryParams{
+			{Name: "limit", Schema: &chioas.Schema{Type: "string"}},
+			{Name: "status", Schema: &chioas.Schema{Type: "string", Enum: []any{"available"}}},
+			{Name: "owner", Required: true},
+		},
+		Responses: chioas.Responses{
+			http.StatusOK: {IsArray: true, SchemaRef: "Pet"},
+		},
+	}
+	pets.Methods[http.MethodPost] = chioas.Method{
+		Deprecated: true,
+		Request:    &chioas.Request{SchemaRef: "Pet"},
+		Responses: chioas.Responses{
+			http.StatusCreated: {SchemaRef: "Pet"},
+		},
+	}
+	// path var name changed - the same operation...
+	pets.Paths = chioas.Paths{
+		"/{id}": {
+			Methods: chioas.Methods{
+				http.MethodGet: {
+					Responses: chioas.Responses{
+						http.StatusOK: {SchemaRef: "Pet"},
+					},
+				},
+				http.MethodPut: {},
+			},
+		},
+	}
+	head.Paths["/pets"] = pets
+	head.Components.Schemas = chioas.Schemas{
+		{
+			Name:               "Pet",
+			RequiredProperties: []string{"name"},
+			Properties: chioas.Properties{
+				{Name: "id", Type: "integer"},
+				{Name: "name", Constraints: chioas.Constraints{MaxLength: 20}},
+				{Name: "colour", Required: true},
+			},
+		},
+		{Name: "New"},
+	}
+
+	changes := Compare(testBaseDefinition, head)
+	assert.True(t, changes.HasBreaking())
+	type expect struct {
+		kind      Kind
+		breaking  bool
+		location  string
+		operation string
+		message   string
+	}
+	expected := []expect{
+		{KindSecurityRemoved, true, "/paths/~1pets/get/security", "GET /pets", "security alternative 'apiKey' removed"},
+		{KindTypeChanged, true, "/paths/~1pets/get/parameters/query/limit/schema", "GET /pets", "type changed from integer to string"},
+		{KindEnumValueRemoved, true, "/paths/~1pets/get/parameters/query/status/schema/enum", "GET /pets", "enum value \"sold\" removed"},
+		{KindParamAdded, true, "/paths/~1pets/get/parameters/query/owner", "GET /pets", "required query param 'owner' added"},
+		// Pet is used in both responses and requests - so both directions apply...
+		{KindPropertyRemoved, true, "/components/schemas/Pet/properties/age", "", "property 'age' removed"},
+		{KindConstraintTightened, true, "/components/schemas/Pet/properties/name", "", "maxLength changed from 50 to 20"},
+		{KindPropertyAdded, true, "/components/schemas/Pet/properties/colour", "", "required property 'colour' added"},
+		{KindOperationDeprecated, false, "/paths/~1pets/post", "POST /pets", "operation deprecated"},
+		{KindSecurityRemoved, true, "/paths/~1pets/post/security", "POST /pets", "security alternative 'apiKey' removed"},
+		{KindResponseRemoved, true, "/paths/~1pets/post/responses/409", "POST /pets", "response 409 removed"},
+		{KindSecurityRemoved, true, "/paths/~1pets~1{id}/get/security", "GET /pets/{id}", "security alternative 'apiKey' removed"},
+		{KindOperationAdded, false, "/paths/~1pets~1{id}/put", "PUT /pets/{id}", "operation added"},
+		{KindOperationRemoved, true, "/paths/~1pets~1{petId}/delete", "DELETE /pets/{petId}", "operation removed"},
+		{KindSchemaRemoved, false, "/components/schemas/Old", "", "schema 'Old' removed"},
+		{KindSchemaAdded, false, "/components/schemas/New", "", "schema 'New' added"},
+	}
+	actual := make([]expect, 0, len(changes))
+	for _, c := range changes {
+		actual = append(actual, expect{c.Kind, c.Breaking, c.Location, c.Operation, c.Message})
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func TestCompare_RequestDirection(t *testing.T) {
+	base := &chioas.Definition{
+		Paths: chioas.Paths{
+			"/pets": {
+				Methods: chioas.Methods{
+					http.MethodPost: {
+						Request: &chioas.Request{
+							Schema: chioas.Schema{
+								Properties: chioas.Properties{
+									{Name: "name", Constraints: chioas.Constraints{Pattern: "^[a-z]+$"}},
+									{Name: "kind", Enum: []any{"cat", "dog"}},
+									{Name: "weight", Type: "number", Constraints: chioas.Constraints{Maximum: "100"}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	head := &chioas.Definition{
+		Paths: chioas.Paths{
+			"/pets": {
+				Methods: chioas.Methods{
+					http.MethodPost: {
+						Request: &chioas.Request{
+							Required: true,
+							Schema: chioas.Schema{
+								RequiredProperties: []string{"name"},
+								Properties: chioas.Properties{
+									{Name: "name"},
+									{Name: "kind", Enum: []any{"cat", "dog", "bird"}},
+									{Name: "weight", Type: "number", Constraints: chioas.Constraints{Maximum: "50"}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	changes := Compare(base, head)
+	msgs := make(map[string]bool, len(changes))
+	for _, c := range changes {
+		msgs[c.Message] = c.Breaking
+	}
+	assert.Equal(t, map[string]bool{
+		"request body became required":    true,
+		"property 'name' became required": true,
+		"pattern ^[a-z]+$ removed":        false,
+		"enum value \"bird\" added":       false,
+		"maximum changed from 100 to 50":  true,
+	}, msgs)
+}
+
+func TestCompare_ResponseDirection(t *testing.T) {
+	base := &chioas.Definition{
+		Paths: chioas.Paths{
+			"/pets": {
+				Methods: chioas.Methods{
+					http.MethodGet: {
+						Responses: chioas.Responses{
+							http.StatusOK: {
+								Schema: chioas.Schema{
+									RequiredProperties: []string{"name"},
+									Properties: chioas.Properties{
+										{Name: "name"},
+										{Name: "kind", Enum: []any{"cat", "dog"}},
+										{Name: "age", Type: "integer"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	head := &chioas.Definition{
+		Paths: chioas.Paths{
+			"/pets": {
+				Methods: chioas.Methods{
+					http.MethodGet: {
+						Responses: chioas.Responses{
+							http.StatusOK: {
+								Schema: chioas.Schema{
+									Properties: chioas.Properties{
+										{Name: "name"},
+										{Name: "kind", Enum: []any{"cat", "dog", "bird"}},
+										{Name: "age", Type: "integer", Constraints: chioas.Constraints{Nullable: true}},
+										{Name: "colour"},
+									},
+								},
+								AlternativeContentTypes: chioas.ContentTypes{
+									"text/csv": {},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	changes := Compare(base, head)
+	msgs := make(map[string]bool, len(changes))
+	for _, c := range changes {
+		msgs[c.Message] = c.Breaking
+	}
+	assert.Equal(t, map[string]bool{
+		"content type 'text/csv' added":       false,
+		"property 'name' became optional":     true,
+		"enum value \"bird\" added":           true,
+		"nullable changed from false to true": true,
+		"optional property 'colour' added":    false,
+	}, msgs)
+}
+
+func TestCompare_ComponentCycles(t *testing.T) {
+	def := func(maxItems uint) *chioas.Definition {
+		return &chioas.Definition{
+			Paths: chioas.Paths{
+				"/nodes": {
+					Methods: chioas.Methods{
+						http.MethodGet: {
+							Responses: chioas.Responses{
+								http.StatusOK: {SchemaRef: "Node"},
+							},
+						},
+					},
+				},
+			},
+			Components: &chioas.Components{
+				Schemas: chioas.Schemas{
+					{
+						Name: "Node",
+						Properties: chioas.Properties{
+							{Name: "children", Type: "array", SchemaRef: "Node", Constraints: chioas.Constraints{MaxItems: maxItems}},
+						},
+					},
+				},
+			},
+		}
+	}
+	changes := Compare(def(10), def(5))
+	assert.Equal(t, Changes{
+		{
+			Kind:     KindConstraintTightened,
+			Location: "/components/schemas/Node/properties/children",
+			Message:  "maxItems changed from 10 to 5",
+		},
+	}, changes)
+}
+
+func TestChange_String(t *testing.T) {
+	c := Change{
+		Kind:      KindOperationRemoved,
+		Breaking:  true,
+		Location:  "/paths/~1pets/get",
+		Operation: "GET /pets",
+		Message:   "operation removed",
+	}
+	assert.Equal(t, "breaking [operation-removed] GET /pets: operation removed (at /paths/~1pets/get)", c.String())
+	c = Change{
+		Kind:     KindSchemaAdded,
+		Location: "/components/schemas/Pet",
+		Message:  "schema 'Pet' added",
+	}
+	assert.Equal(t, "non-breaking [schema-added] schema 'Pet' added (at /components/schemas/Pet)", c.String())
+}
+
+func TestChanges_Breaking(t *testing.T) {
+	cs := Changes{
+		{Kind: KindOperationAdded},
+		{Kind: KindOperationRemoved, Breaking: true},
+	}
+	assert.True(t, cs.HasBreaking())
+	assert.Equal(t, Changes{{Kind: KindOperationRemoved, Breaking: true}}, cs.Breaking())
+	assert.False(t, Changes{}.HasBreaking())
+	assert.Empty(t, Changes{}.Breaking())
+}
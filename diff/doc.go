@@ -0,0 +1,3 @@
+// Package diff provides semantic comparison of chioas definitions - classifying changes between two versions of an API as
+// breaking or non-breaking (e.g. for blocking breaking API changes in CI)
+package diff
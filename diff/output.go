@@ -0,0 +1,63 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteText writes the changes as plain text - one line per change followed by a summary line
+func (cs Changes) WriteText(w io.Writer) error {
+	var sb strings.Builder
+	for _, c := range cs {
+		sb.WriteString(c.String() + "\n")
+	}
+	sb.WriteString(fmt.Sprintf("%d changes (%d breaking)\n", len(cs), len(cs.Breaking())))
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// WriteJson writes the changes as a JSON array
+func (cs Changes) WriteJson(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if cs == nil {
+		return enc.Encode(Changes{})
+	}
+	return enc.Encode(cs)
+}
+
+// WriteMarkdown writes the changes as a markdown changelog - with breaking and non-breaking changes in separate sections
+func (cs Changes) WriteMarkdown(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("# API Changelog\n")
+	if len(cs) == 0 {
+		sb.WriteString("\nNo changes.\n")
+	} else {
+		writeMarkdownSection(&sb, "Breaking changes", cs, true)
+		writeMarkdownSection(&sb, "Non-breaking changes", cs, false)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeMarkdownSection(sb *strings.Builder, title string, cs Changes, breaking bool) {
+	started := false
+	for _, c := range cs {
+		if c.Breaking == breaking {
+			if !started {
+				sb.WriteString("\n## " + title + "\n\n")
+				started = true
+			}
+			sb.WriteString(markdownLine(c))
+		}
+	}
+}
+
+func markdownLine(c Change) string {
+	if c.Operation != "" {
+		return "- `" + c.Operation + "` - " + c.Message + "\n"
+	}
+	return "- `" + c.Location + "` - " + c.Message + "\n"
+}
@@ -0,0 +1,68 @@
+package diff
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+var testChanges = Changes{
+	{Kind: KindOperationAdded, Location: "/paths/~1pets/post", Operation: "POST /pets", Tag: "Pets", Message: "operation added"},
+	{Kind: KindOperationRemoved, Breaking: true, Location: "/paths/~1pets/get", Operation: "GET /pets", Tag: "Pets", Message: "operation removed"},
+	{Kind: KindSchemaAdded, Location: "/components/schemas/Pet", Message: "schema 'Pet' added"},
+}
+
+func TestChanges_WriteText(t *testing.T) {
+	var sb strings.Builder
+	require.NoError(t, testChanges.WriteText(&sb))
+	const expect = `non-breaking [operation-added] POST /pets: operation added (at /paths/~1pets/post)
+breaking [operation-removed] GET /pets: operation removed (at /paths/~1pets/get)
+non-breaking [schema-added] schema 'Pet' added (at /components/schemas/Pet)
+3 changes (1 breaking)
+`
+	assert.Equal(t, expect, sb.String())
+}
+
+func TestChanges_WriteJson(t *testing.T) {
+	var sb strings.Builder
+	require.NoError(t, testChanges[1:].WriteJson(&sb))
+	const expect = `[
+  {
+    "kind": "operation-removed",
+    "breaking": true,
+    "location": "/paths/~1pets/get",
+    "operation": "GET /pets",
+    "tag": "Pets",
+    "message": "operation removed"
+  },
+  {
+    "kind": "schema-added",
+    "breaking": false,
+    "location": "/components/schemas/Pet",
+    "message": "schema 'Pet' added"
+  }
+]
+`
+	assert.Equal(t, expect, sb.String())
+
+	sb.Reset()
+	require.NoError(t, Changes(nil).WriteJson(&sb))
+	assert.Equal(t, "[]\n", sb.String())
+}
+
+func TestChanges_WriteMarkdown(t *testing.T) {
+	var sb strings.Builder
+	require.NoError(t, testChanges.WriteMarkdown(&sb))
+	const expect = "# API Changelog\n" +
+		"\n## Breaking changes\n\n" +
+		"- `GET /pets` - operation removed\n" +
+		"\n## Non-breaking changes\n\n" +
+		"- `POST /pets` - operation added\n" +
+		"- `/components/schemas/Pet` - schema 'Pet' added\n"
+	assert.Equal(t, expect, sb.String())
+
+	sb.Reset()
+	require.NoError(t, Changes{}.WriteMarkdown(&sb))
+	assert.Equal(t, "# API Changelog\n\nNo changes.\n", sb.String())
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/go-andiamo/chioas/swagger_ui"
 	"github.com/go-chi/chi/v5"
 	"html/template"
+	"io"
 	"mime"
 	"net/http"
 	"path/filepath"
@@ -102,6 +103,20 @@ type DocOptions struct {
 	Middlewares chi.Middlewares
 	// CheckRefs when set to true, all internal $ref's are checked
 	CheckRefs bool
+	// GenerateExamples when set to true, generated examples (see Definition.GenerateExample) are written into the spec
+	// for request/response content and parameters that have no explicit examples
+	GenerateExamples bool
+	// Changelog is an optional changelog to be served on the docs path (e.g. a *diff.Changelog - see diff.NewChangelog)
+	//
+	// the changelog is served as HTML on "/docs/changelog" and as markdown on "/docs/changelog.md" (see ChangelogPath)
+	Changelog Changelog
+	// ChangelogPath is the path (under the docs path) on which to serve the Changelog (defaults to "changelog")
+	ChangelogPath string
+	// Overlays is optional overlays (e.g. *overlay.Overlay - see overlay.Parse) applied, in order, to the spec
+	//
+	// overlays are applied to the spec produced by Definition.AsYaml, Definition.AsJson, Definition.WriteYaml and
+	// Definition.WriteJson (and therefore to the served spec) - they do not affect routing
+	Overlays []SpecOverlay
 	// specData is used internally where api has been generated from spec (see FromJson and FromYaml)
 	specData []byte
 }
@@ -110,6 +125,18 @@ type SupportFiles interface {
 	ServeHTTP(http.ResponseWriter, *http.Request)
 }
 
+// Changelog is the interface for a changelog that can be served on the docs path (see DocOptions.Changelog)
+type Changelog interface {
+	WriteHtml(w io.Writer) error
+	WriteMarkdown(w io.Writer) error
+}
+
+// SpecOverlay is the interface for an overlay applied to the produced spec (see DocOptions.Overlays)
+type SpecOverlay interface {
+	// Apply applies the overlay to the supplied spec data (yaml or json) - returning the spec data in the same format
+	Apply(spec []byte) ([]byte, error)
+}
+
 // OperationIdentifier is a function that can be provided to DocOptions
 type OperationIdentifier func(method Method, methodName string, path string, parentTag string) string
 
@@ -118,6 +145,7 @@ const (
 	defaultIndexName    = "index.html"
 	defaultSpecName     = "spec.yaml"
 	defaultSpecNameJson = "spec.json"
+	defaultChangelog    = "changelog"
 	defaultTitle        = "API Documentation"
 	defaultRedocJsUrl   = "https://cdn.jsdelivr.net/npm/redoc@2.0.0-rc.77/bundles/redoc.standalone.min.js"
 	defaultTryJsUrl     = "https://cdn.jsdelivr.net/gh/wll8/redoc-try@1.4.7/dist/try.js"
@@ -143,6 +171,9 @@ func (d *DocOptions) SetupRoutes(def *Definition, route chi.Router) error {
 		} else {
 			setupNoCachedRoutes(def, d.AsJson, docsRoute, tmp, data, indexPage, specName)
 		}
+		if err = d.setupChangelogRoutes(docsRoute); err != nil {
+			return err
+		}
 		setupSupportFiles(defValue(d.Path, defaultDocsPath), d.UIStyle, d.SupportFiles, d.SupportFilesStripPrefix, docsRoute)
 		route.Mount(path, docsRoute)
 		for altPath, alt := range d.AlternateUIDocs {
@@ -243,7 +274,9 @@ func setupCachedRoutes(def *Definition, asJson bool, specData []byte, docsRoute
 	var data []byte
 	contentType := contentTypeYaml
 	if specData != nil {
-		data = specData
+		if data, err = def.applyOverlays(specData); err != nil {
+			return err
+		}
 	} else if asJson {
 		contentType = contentTypeJson
 		if data, err = def.AsJson(); err != nil {
@@ -279,6 +312,39 @@ func setupNoCachedRoutes(def *Definition, asJson bool, docsRoute *chi.Mux, tmp *
 	}
 }
 
+func (d *DocOptions) setupChangelogRoutes(docsRoute *chi.Mux) error {
+	if d.Changelog != nil {
+		path := root + strings.Trim(defValue(d.ChangelogPath, defaultChangelog), "/")
+		if d.NoCache {
+			docsRoute.Get(path, func(writer http.ResponseWriter, request *http.Request) {
+				writer.Header().Set(hdrContentType, contentTypeHtml)
+				_ = d.Changelog.WriteHtml(writer)
+			})
+			docsRoute.Get(path+extMarkdown, func(writer http.ResponseWriter, request *http.Request) {
+				writer.Header().Set(hdrContentType, contentTypeMarkdown)
+				_ = d.Changelog.WriteMarkdown(writer)
+			})
+			return nil
+		}
+		var html, md bytes.Buffer
+		if err := d.Changelog.WriteHtml(&html); err != nil {
+			return err
+		}
+		if err := d.Changelog.WriteMarkdown(&md); err != nil {
+			return err
+		}
+		docsRoute.Get(path, func(writer http.ResponseWriter, request *http.Request) {
+			writer.Header().Set(hdrContentType, contentTypeHtml)
+			_, _ = writer.Write(html.Bytes())
+		})
+		docsRoute.Get(path+extMarkdown, func(writer http.ResponseWriter, request *http.Request) {
+			writer.Header().Set(hdrContentType, contentTypeMarkdown)
+			_, _ = writer.Write(md.Bytes())
+		})
+	}
+	return nil
+}
+
 func setupSupportFiles(path string, uiStyle UIStyle, supportFiles http.Handler, stripPrefix bool, docsRoute *chi.Mux) {
 	sf := getSupportFiles(supportFiles, stripPrefix, path)
 	switch uiStyle {
@@ -391,6 +457,8 @@ const (
 	contentTypeHtml       = "text/html; charset=utf-8"
 	contentTypeJson       = "application/json"
 	contentTypeYaml       = "application/yaml"
+	contentTypeMarkdown   = "text/markdown; charset=utf-8"
+	extMarkdown           = ".md"
 	hdrContentType        = "Content-Type"
 	htmlTagTitle          = "title"
 	htmlTagStylesOverride = "stylesOverride"
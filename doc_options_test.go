@@ -2,6 +2,7 @@ package chioas
 
 import (
 	"errors"
+	"fmt"
 	"github.com/go-andiamo/chioas/yaml"
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
@@ -386,6 +387,87 @@ func TestDocOptions_ErrorsWithCache_BadJson(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestDocOptions_Changelog(t *testing.T) {
+	testCases := []struct {
+		noCache bool
+		path    string
+		expect  string
+	}{
+		{
+			expect: "/docs/changelog",
+		},
+		{
+			noCache: true,
+			expect:  "/docs/changelog",
+		},
+		{
+			path:   "/whats-new/",
+			expect: "/docs/whats-new",
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]", i+1), func(t *testing.T) {
+			def := &Definition{
+				DocOptions: DocOptions{
+					ServeDocs:     true,
+					NoCache:       tc.noCache,
+					Changelog:     &testChangelog{},
+					ChangelogPath: tc.path,
+				},
+			}
+			router := chi.NewRouter()
+			require.NoError(t, def.SetupRoutes(router, nil))
+
+			req, _ := http.NewRequest(http.MethodGet, tc.expect, nil)
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			assert.Equal(t, http.StatusOK, res.Code)
+			assert.Equal(t, contentTypeHtml, res.Header().Get(hdrContentType))
+			assert.Equal(t, "<h1>Changelog</h1>", res.Body.String())
+
+			req, _ = http.NewRequest(http.MethodGet, tc.expect+".md", nil)
+			res = httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			assert.Equal(t, http.StatusOK, res.Code)
+			assert.Equal(t, contentTypeMarkdown, res.Header().Get(hdrContentType))
+			assert.Equal(t, "# Changelog", res.Body.String())
+		})
+	}
+}
+
+func TestDocOptions_Changelog_Errors(t *testing.T) {
+	d := DocOptions{
+		ServeDocs: true,
+		Changelog: &testChangelog{htmlErr: errors.New("fooey")},
+	}
+	router := chi.NewRouter()
+	assert.Error(t, d.SetupRoutes(&Definition{}, router))
+	d.Changelog = &testChangelog{mdErr: errors.New("fooey")}
+	router = chi.NewRouter()
+	assert.Error(t, d.SetupRoutes(&Definition{}, router))
+}
+
+type testChangelog struct {
+	htmlErr error
+	mdErr   error
+}
+
+func (c *testChangelog) WriteHtml(w io.Writer) error {
+	if c.htmlErr != nil {
+		return c.htmlErr
+	}
+	_, err := w.Write([]byte("<h1>Changelog</h1>"))
+	return err
+}
+
+func (c *testChangelog) WriteMarkdown(w io.Writer) error {
+	if c.mdErr != nil {
+		return c.mdErr
+	}
+	_, err := w.Write([]byte("# Changelog"))
+	return err
+}
+
 type errorAdditional struct {
 }
 
@@ -0,0 +1,408 @@
+package chioas
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-andiamo/chioas/internal/tags"
+	"github.com/go-andiamo/chioas/internal/values"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"unicode/utf8"
+)
+
+// ValueMismatch is a mismatch found when checking a value against a schema (see Definition.CheckValue)
+type ValueMismatch struct {
+	// Path is the JSON pointer to the mismatched item within the value (empty string means the value itself)
+	Path string
+	// Message is the description of the mismatch
+	Message string
+}
+
+func (m ValueMismatch) String() string {
+	if m.Path != "" {
+		return m.Message + " (at '" + m.Path + "')"
+	}
+	return m.Message
+}
+
+// CheckValue checks a value against a schema and returns any mismatches found
+//
+// Any SchemaRef, Ofs and Discriminator are followed (with refs resolved against the definition Components) - refs that
+// cannot be resolved (or are external) are not checked
+//
+// The value is checked as its JSON equivalent - so structs, maps etc. can be passed as the value
+func (d *Definition) CheckValue(value any, schema *Schema) []ValueMismatch {
+	vc := &valueChecker{def: d}
+	vc.schema(normalizeValue(value), schema, "", nil)
+	return vc.result
+}
+
+// CheckExamples checks every example in the definition against its resolved schema
+//
+// This checks Example.Value (for requests, responses and content types - including examples referenced from components),
+// Schema.Example, Property.Example and the examples of query, path and common params.  Each Diagnostic location is
+// the JSON pointer to the mismatched value in the OAS spec
+//
+// (CheckExamples returns the DiagExampleMismatch diagnostics of Validate)
+func (d *Definition) CheckExamples() Diagnostics {
+	result := make(Diagnostics, 0)
+	for _, diag := range d.Validate() {
+		if diag.Code == DiagExampleMismatch {
+			result = append(result, diag)
+		}
+	}
+	return result
+}
+
+type valueChecker struct {
+	def    *Definition
+	result []ValueMismatch
+}
+
+func (vc *valueChecker) add(at string, msg string, args ...any) {
+	vc.result = append(vc.result, ValueMismatch{
+		Path:    at,
+		Message: fmt.Sprintf(msg, args...),
+	})
+}
+
+func (vc *valueChecker) sub() *valueChecker {
+	return &valueChecker{def: vc.def}
+}
+
+// items checks that the value is an array and checks each item against the schema
+func (vc *valueChecker) items(value any, s *Schema, at string) {
+	if items, ok := value.([]any); ok {
+		for i, item := range items {
+			vc.schema(item, s, at+"/"+strconv.Itoa(i), nil)
+		}
+	} else {
+		vc.add(at, "expected type %s, got %s", values.TypeArray, jsonTypeName(value))
+	}
+}
+
+func (vc *valueChecker) schema(value any, s *Schema, at string, seen map[string]bool) {
+	if s.SchemaRef != "" {
+		vc.ref(value, s.SchemaRef, at, seen)
+		return
+	}
+	dispatched := s.Discriminator != nil && vc.discriminator(value, s.Discriminator, at, seen)
+	typ := s.Type
+	if typ == "" && s.Ofs == nil {
+		typ = values.TypeObject
+	}
+	if !vc.typed(value, typ, s.Enum, at) {
+		return
+	}
+	if obj, ok := value.(map[string]any); ok {
+		reqs, _ := s.getRequiredProperties()
+		vc.properties(obj, s.Properties, reqs, at)
+	}
+	if s.Ofs != nil && !dispatched {
+		vc.ofs(value, s.Ofs, at, seen)
+	}
+}
+
+func (vc *valueChecker) ref(value any, ref string, at string, seen map[string]bool) {
+	if name, _, ok, _ := isInternalRef(ref, tags.Schemas); ok && !seen[name] {
+		if s, found := vc.def.componentSchema(name); found {
+			vc.schema(value, s, at, withSeen(seen, name))
+		}
+	}
+}
+
+func withSeen(seen map[string]bool, name string) map[string]bool {
+	result := make(map[string]bool, len(seen)+1)
+	for k := range seen {
+		result[k] = true
+	}
+	result[name] = true
+	return result
+}
+
+func (vc *valueChecker) discriminator(value any, d *Discriminator, at string, seen map[string]bool) bool {
+	obj, ok := value.(map[string]any)
+	if !ok {
+		return false
+	}
+	dv, ok := obj[d.PropertyName]
+	if !ok {
+		vc.add(at, "missing discriminator property '%s'", d.PropertyName)
+		return true
+	}
+	pAt := at + "/" + jsonPointerEscape(d.PropertyName)
+	sv, ok := dv.(string)
+	if !ok {
+		vc.add(pAt, "discriminator value must be a string, got %s", jsonTypeName(dv))
+		return true
+	}
+	ref, mapped := d.Mapping[sv]
+	if !mapped {
+		ref = sv
+	}
+	if name, _, ok, _ := isInternalRef(ref, tags.Schemas); ok {
+		if s, found := vc.def.componentSchema(name); found {
+			if !seen[name] {
+				vc.schema(value, s, at, withSeen(seen, name))
+			}
+			return true
+		}
+	}
+	if !mapped {
+		vc.add(pAt, "unknown discriminator value '%s'", sv)
+	}
+	return true
+}
+
+func (vc *valueChecker) ofs(value any, ofs *Ofs, at string, seen map[string]bool) {
+	if len(ofs.Of) == 0 {
+		return
+	}
+	if ofs.OfType == AllOf {
+		for _, of := range ofs.Of {
+			vc.of(value, of, at, seen)
+		}
+		return
+	}
+	matches := 0
+	for _, of := range ofs.Of {
+		sub := vc.sub()
+		sub.of(value, of, at, seen)
+		if len(sub.result) == 0 {
+			matches++
+		}
+	}
+	if matches == 0 {
+		vc.add(at, "does not match any %s schema", ofs.OfType.TagName())
+	} else if matches > 1 && ofs.OfType == OneOf {
+		vc.add(at, "matches %d %s schemas (expected exactly one)", matches, ofs.OfType.TagName())
+	}
+}
+
+func (vc *valueChecker) of(value any, of OfSchema, at string, seen map[string]bool) {
+	if of.IsRef() {
+		vc.ref(value, of.Ref(), at, seen)
+	} else if s := of.Schema(); s != nil {
+		vc.schema(value, s, at, seen)
+	}
+}
+
+func (vc *valueChecker) properties(obj map[string]any, properties Properties, required []string, at string) {
+	for _, rp := range required {
+		if _, ok := obj[rp]; !ok {
+			vc.add(at, "missing required property '%s'", rp)
+		}
+	}
+	for _, p := range properties {
+		if pv, ok := obj[p.Name]; ok {
+			vc.property(pv, p, at+"/"+jsonPointerEscape(p.Name))
+		}
+	}
+}
+
+func (vc *valueChecker) property(value any, p Property, at string) {
+	if p.Type != values.TypeArray {
+		vc.propertyItem(value, p, defValue(p.Type, values.TypeString), at)
+		return
+	}
+	if value == nil && p.Constraints.Nullable {
+		return
+	}
+	items, ok := value.([]any)
+	if !ok {
+		vc.add(at, "expected type %s, got %s", values.TypeArray, jsonTypeName(value))
+		return
+	}
+	if c := p.Constraints; c.MinItems > 0 && uint(len(items)) < c.MinItems {
+		vc.add(at, "expected at least %d items, got %d", c.MinItems, len(items))
+	} else if c.MaxItems > 0 && uint(len(items)) > c.MaxItems {
+		vc.add(at, "expected at most %d items, got %d", c.MaxItems, len(items))
+	}
+	if p.Constraints.UniqueItems {
+		for i := 1; i < len(items); i++ {
+			if slices.IndexFunc(items[:i], func(item any) bool {
+				return reflect.DeepEqual(item, items[i])
+			}) != -1 {
+				vc.add(at+"/"+strconv.Itoa(i), "duplicate item (items must be unique)")
+			}
+		}
+	}
+	itemType := defValue(p.ItemType, values.TypeString)
+	for i, item := range items {
+		vc.propertyItem(item, p, itemType, at+"/"+strconv.Itoa(i))
+	}
+}
+
+// propertyItem checks a value against a property (for array properties, the value is an item and typ is the item type)
+func (vc *valueChecker) propertyItem(value any, p Property, typ string, at string) {
+	if p.SchemaRef != "" {
+		vc.ref(value, p.SchemaRef, at, nil)
+		return
+	}
+	if value == nil && p.Constraints.Nullable {
+		return
+	}
+	if !vc.typed(value, typ, p.Enum, at) {
+		return
+	}
+	vc.constraints(value, p.Constraints, at)
+	if obj, ok := value.(map[string]any); ok {
+		required := make([]string, 0)
+		for _, sp := range p.Properties {
+			if sp.Required {
+				required = append(required, sp.Name)
+			}
+		}
+		vc.properties(obj, p.Properties, required, at)
+	}
+}
+
+func (vc *valueChecker) typed(value any, typ string, enum []any, at string) bool {
+	if !valueMatchesType(value, typ) {
+		vc.add(at, "expected type %s, got %s", typ, jsonTypeName(value))
+		return false
+	}
+	if len(enum) > 0 && !valueMatchesEnum(value, enum) {
+		vc.add(at, "value %s not in enum %s", jsonString(value), jsonString(enum))
+	}
+	return true
+}
+
+func (vc *valueChecker) constraints(value any, c Constraints, at string) {
+	switch tv := value.(type) {
+	case string:
+		if c.Pattern != "" {
+			if rx, err := regexp.Compile(c.Pattern); err == nil && !rx.MatchString(tv) {
+				vc.add(at, "value %s does not match pattern '%s'", jsonString(tv), c.Pattern)
+			}
+		}
+		if l := uint(utf8.RuneCountInString(tv)); c.MinLength > 0 && l < c.MinLength {
+			vc.add(at, "length %d is less than minLength %d", l, c.MinLength)
+		} else if c.MaxLength > 0 && l > c.MaxLength {
+			vc.add(at, "length %d is greater than maxLength %d", l, c.MaxLength)
+		}
+	case float64:
+		if minimum, err := c.Minimum.Float64(); err == nil && c.Minimum != "" {
+			if tv < minimum || (c.ExclusiveMinimum && tv == minimum) {
+				vc.add(at, "value %s is less than minimum %s", jsonString(tv), c.Minimum)
+			}
+		}
+		if maximum, err := c.Maximum.Float64(); err == nil && c.Maximum != "" {
+			if tv > maximum || (c.ExclusiveMaximum && tv == maximum) {
+				vc.add(at, "value %s is greater than maximum %s", jsonString(tv), c.Maximum)
+			}
+		}
+		if c.MultipleOf > 0 && math.Mod(tv, float64(c.MultipleOf)) != 0 {
+			vc.add(at, "value %s is not a multiple of %d", jsonString(tv), c.MultipleOf)
+		}
+	case map[string]any:
+		if c.MinProperties > 0 && uint(len(tv)) < c.MinProperties {
+			vc.add(at, "expected at least %d properties, got %d", c.MinProperties, len(tv))
+		} else if c.MaxProperties > 0 && uint(len(tv)) > c.MaxProperties {
+			vc.add(at, "expected at most %d properties, got %d", c.MaxProperties, len(tv))
+		}
+	}
+}
+
+func (d *Definition) componentSchema(name string) (*Schema, bool) {
+	if d.Components != nil {
+		if i := slices.IndexFunc(d.Components.Schemas, func(s Schema) bool {
+			return s.Name == name
+		}); i != -1 {
+			return &d.Components.Schemas[i], true
+		}
+	}
+	return nil, false
+}
+
+func (d *Definition) componentExample(name string) (*Example, bool) {
+	if d.Components != nil {
+		if i := slices.IndexFunc(d.Components.Examples, func(eg Example) bool {
+			return eg.Name == name
+		}); i != -1 {
+			return &d.Components.Examples[i], true
+		}
+	}
+	return nil, false
+}
+
+// normalizeValue normalizes a value to its JSON equivalent (e.g. structs to map[string]any, ints to float64)
+func normalizeValue(value any) any {
+	switch value.(type) {
+	case nil, string, bool, float64:
+		return value
+	}
+	if data, err := json.Marshal(value); err == nil {
+		var result any
+		if err = json.Unmarshal(data, &result); err == nil {
+			return result
+		}
+	}
+	return value
+}
+
+func valueMatchesType(value any, typ string) bool {
+	switch typ {
+	case values.TypeString:
+		_, ok := value.(string)
+		return ok
+	case values.TypeInteger:
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case values.TypeNumber:
+		_, ok := value.(float64)
+		return ok
+	case values.TypeBoolean:
+		_, ok := value.(bool)
+		return ok
+	case values.TypeNull:
+		return value == nil
+	case values.TypeObject:
+		_, ok := value.(map[string]any)
+		return ok
+	case values.TypeArray:
+		_, ok := value.([]any)
+		return ok
+	}
+	return true
+}
+
+func valueMatchesEnum(value any, enum []any) bool {
+	for _, e := range enum {
+		if reflect.DeepEqual(value, normalizeValue(e)) {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonTypeName(value any) string {
+	switch tv := value.(type) {
+	case nil:
+		return values.TypeNull
+	case string:
+		return values.TypeString
+	case bool:
+		return values.TypeBoolean
+	case float64:
+		if tv == math.Trunc(tv) {
+			return values.TypeInteger
+		}
+		return values.TypeNumber
+	case map[string]any:
+		return values.TypeObject
+	case []any:
+		return values.TypeArray
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func jsonString(value any) string {
+	if data, err := json.Marshal(value); err == nil {
+		return string(data)
+	}
+	return fmt.Sprintf("%v", value)
+}
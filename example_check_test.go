@@ -0,0 +1,340 @@
+package chioas
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+var testCheckValueDefinition = &Definition{
+	Components: &Components{
+		Schemas: Schemas{
+			{
+				Name:               "Pet",
+				RequiredProperties: []string{"petType"},
+				Properties: Properties{
+					{Name: "petType"},
+					{Name: "name", Constraints: Constraints{MinLength: 2, MaxLength: 5}},
+				},
+				Discriminator: &Discriminator{
+					PropertyName: "petType",
+					Mapping: map[string]string{
+						"cat": "Cat",
+						"dog": "#/components/schemas/Dog",
+					},
+				},
+			},
+			{
+				Name: "Cat",
+				Ofs: &Ofs{
+					OfType: AllOf,
+					Of: []OfSchema{
+						OfRef("Pet"),
+						&Schema{
+							Properties: Properties{
+								{Name: "lives", Type: "integer", Constraints: Constraints{Minimum: "1", Maximum: "9"}},
+							},
+						},
+					},
+				},
+			},
+			{
+				Name: "Dog",
+				Ofs: &Ofs{
+					OfType: AllOf,
+					Of: []OfSchema{
+						OfRef("Pet"),
+						&Schema{
+							RequiredProperties: []string{"breed"},
+							Properties: Properties{
+								{Name: "breed", Enum: []any{"collie", "poodle"}},
+							},
+						},
+					},
+				},
+			},
+			{
+				Name:      "PetRef",
+				SchemaRef: "Pet",
+			},
+			{
+				Name:      "Cyclic",
+				SchemaRef: "Cyclic2",
+			},
+			{
+				Name:      "Cyclic2",
+				SchemaRef: "Cyclic",
+			},
+			{
+				Name: "StringOrNumber",
+				Ofs: &Ofs{
+					Of: []OfSchema{
+						&Schema{Type: "string"},
+						&Schema{Type: "number"},
+					},
+				},
+			},
+			{
+				Name: "Numbers",
+				Ofs: &Ofs{
+					Of: []OfSchema{
+						&Schema{Type: "number"},
+						&Schema{Type: "integer"},
+					},
+				},
+			},
+			{
+				Name: "AnyNumbers",
+				Ofs: &Ofs{
+					OfType: AnyOf,
+					Of: []OfSchema{
+						&Schema{Type: "number"},
+						&Schema{Type: "integer"},
+					},
+				},
+			},
+			{
+				Name: "Constrained",
+				Properties: Properties{
+					{Name: "code", Constraints: Constraints{Pattern: "^[A-Z]{3}$"}},
+					{Name: "qty", Type: "integer", Constraints: Constraints{Minimum: "0", ExclusiveMinimum: true, MultipleOf: 5}},
+					{Name: "tags", Type: "array", Constraints: Constraints{MinItems: 1, MaxItems: 3, UniqueItems: true}},
+					{Name: "refs", Type: "array", SchemaRef: "StringOrNumber"},
+					{Name: "opt", Constraints: Constraints{Nullable: true}},
+					{Name: "meta", Type: "object", Constraints: Constraints{MaxProperties: 1}},
+				},
+			},
+		},
+	},
+}
+
+func TestDefinition_CheckValue(t *testing.T) {
+	testCases := []struct {
+		schema *Schema
+		value  any
+		expect []ValueMismatch
+	}{
+		{
+			schema: &Schema{SchemaRef: "Pet"},
+			value:  map[string]any{"petType": "cat", "name": "tom", "lives": 9},
+		},
+		{
+			schema: &Schema{SchemaRef: "Pet"},
+			value:  map[string]any{"petType": "cat", "name": "t", "lives": 10},
+			expect: []ValueMismatch{
+				{Path: "/lives", Message: "value 10 is greater than maximum 9"},
+				{Path: "/name", Message: "length 1 is less than minLength 2"},
+			},
+		},
+		{
+			schema: &Schema{SchemaRef: "#/components/schemas/PetRef"},
+			value:  map[string]any{"petType": "dog", "name": "rover"},
+			expect: []ValueMismatch{
+				{Path: "", Message: "missing required property 'breed'"},
+			},
+		},
+		{
+			schema: &Schema{SchemaRef: "Pet"},
+			value:  map[string]any{"petType": "dog", "breed": "labrador"},
+			expect: []ValueMismatch{
+				{Path: "/breed", Message: `value "labrador" not in enum ["collie","poodle"]`},
+			},
+		},
+		{
+			schema: &Schema{SchemaRef: "Pet"},
+			value:  map[string]any{"petType": "fish"},
+			expect: []ValueMismatch{
+				{Path: "/petType", Message: "unknown discriminator value 'fish'"},
+			},
+		},
+		{
+			schema: &Schema{SchemaRef: "Pet"},
+			value:  map[string]any{"petType": 1},
+			expect: []ValueMismatch{
+				{Path: "/petType", Message: "discriminator value must be a string, got integer"},
+				{Path: "/petType", Message: "expected type string, got integer"},
+			},
+		},
+		{
+			schema: &Schema{SchemaRef: "Pet"},
+			value:  map[string]any{"name": "tom"},
+			expect: []ValueMismatch{
+				{Path: "", Message: "missing discriminator property 'petType'"},
+				{Path: "", Message: "missing required property 'petType'"},
+			},
+		},
+		{
+			schema: &Schema{SchemaRef: "Pet"},
+			value:  "not an object",
+			expect: []ValueMismatch{
+				{Path: "", Message: "expected type object, got string"},
+			},
+		},
+		{
+			schema: &Schema{SchemaRef: "Cyclic"},
+			value:  "anything",
+		},
+		{
+			schema: &Schema{SchemaRef: "unknown"},
+			value:  "anything",
+		},
+		{
+			schema: &Schema{SchemaRef: "StringOrNumber"},
+			value:  "foo",
+		},
+		{
+			schema: &Schema{SchemaRef: "StringOrNumber"},
+			value:  true,
+			expect: []ValueMismatch{
+				{Path: "", Message: "does not match any oneOf schema"},
+			},
+		},
+		{
+			schema: &Schema{SchemaRef: "Numbers"},
+			value:  1,
+			expect: []ValueMismatch{
+				{Path: "", Message: "matches 2 oneOf schemas (expected exactly one)"},
+			},
+		},
+		{
+			schema: &Schema{SchemaRef: "Numbers"},
+			value:  1.5,
+		},
+		{
+			schema: &Schema{SchemaRef: "AnyNumbers"},
+			value:  1,
+		},
+		{
+			schema: &Schema{SchemaRef: "AnyNumbers"},
+			value:  "1",
+			expect: []ValueMismatch{
+				{Path: "", Message: "does not match any anyOf schema"},
+			},
+		},
+		{
+			schema: &Schema{SchemaRef: "Constrained"},
+			value: map[string]any{
+				"code": "ABC",
+				"qty":  10,
+				"tags": []string{"a", "b"},
+				"refs": []any{"a", 1},
+				"opt":  nil,
+				"meta": map[string]any{"a": 1},
+			},
+		},
+		{
+			schema: &Schema{SchemaRef: "Constrained"},
+			value: map[string]any{
+				"code": "abc",
+				"qty":  0,
+				"tags": []string{"a", "a"},
+				"refs": []any{"a", true},
+				"meta": map[string]any{"a": 1, "b": 2},
+			},
+			expect: []ValueMismatch{
+				{Path: "/code", Message: `value "abc" does not match pattern '^[A-Z]{3}$'`},
+				{Path: "/qty", Message: "value 0 is less than minimum 0"},
+				{Path: "/tags/1", Message: "duplicate item (items must be unique)"},
+				{Path: "/refs/1", Message: "does not match any oneOf schema"},
+				{Path: "/meta", Message: "expected at most 1 properties, got 2"},
+			},
+		},
+		{
+			schema: &Schema{SchemaRef: "Constrained"},
+			value: map[string]any{
+				"qty":  7,
+				"tags": []string{},
+				"opt":  1,
+			},
+			expect: []ValueMismatch{
+				{Path: "/qty", Message: "value 7 is not a multiple of 5"},
+				{Path: "/tags", Message: "expected at least 1 items, got 0"},
+				{Path: "/opt", Message: "expected type string, got integer"},
+			},
+		},
+		{
+			schema: &Schema{SchemaRef: "Constrained"},
+			value: map[string]any{
+				"tags": []string{"a", "b", "c", "d"},
+				"refs": "a",
+			},
+			expect: []ValueMismatch{
+				{Path: "/tags", Message: "expected at most 3 items, got 4"},
+				{Path: "/refs", Message: "expected type array, got string"},
+			},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]", i+1), func(t *testing.T) {
+			result := testCheckValueDefinition.CheckValue(tc.value, tc.schema)
+			assert.Equal(t, tc.expect, result)
+		})
+	}
+}
+
+func TestValueMismatch_String(t *testing.T) {
+	assert.Equal(t, "foo", ValueMismatch{Message: "foo"}.String())
+	assert.Equal(t, "foo (at '/bar')", ValueMismatch{Path: "/bar", Message: "foo"}.String())
+}
+
+func TestDefinition_CheckExamples(t *testing.T) {
+	d := &Definition{
+		Methods: Methods{
+			http.MethodPost: {
+				Handler: testValidateHandler,
+				QueryParams: QueryParams{
+					{Name: "q", SchemaRef: "Code", Example: "abc"},
+				},
+				Request: &Request{
+					SchemaRef: "Person",
+					Examples: Examples{
+						{Name: "inline", Value: map[string]any{"name": 1}},
+						{Name: "ref", ExampleRef: "person"},
+					},
+				},
+				Responses: Responses{
+					http.StatusOK: {
+						IsArray:   true,
+						SchemaRef: "Person",
+						Examples: Examples{
+							{Name: "list", Value: []any{map[string]any{"name": "bilbo"}, map[string]any{}}},
+						},
+					},
+				},
+			},
+		},
+		Components: &Components{
+			Schemas: Schemas{
+				{
+					Name:               "Person",
+					RequiredProperties: []string{"name"},
+					Properties: Properties{
+						{Name: "name", Example: true},
+					},
+				},
+				{
+					Name: "Code",
+					Type: "string",
+					Enum: []any{"ABC"},
+				},
+			},
+			Examples: Examples{
+				{Name: "person", Value: map[string]any{"name": "bilbo", "age": 111}},
+				{Name: "unused", Value: "whatever"},
+			},
+		},
+	}
+	result := d.CheckExamples()
+	assert.Equal(t, Diagnostics{
+		{Severity: SeverityWarning, Code: DiagExampleMismatch, Location: "/paths/~1/post/parameters/0/example", Message: `example does not match schema - value "abc" not in enum ["ABC"]`},
+		{Severity: SeverityWarning, Code: DiagExampleMismatch, Location: "/paths/~1/post/requestBody/content/application~1json/examples/inline/value/name", Message: "example does not match schema - expected type string, got integer"},
+		{Severity: SeverityWarning, Code: DiagExampleMismatch, Location: "/paths/~1/post/responses/200/content/application~1json/examples/list/value/1", Message: "example does not match schema - missing required property 'name'"},
+		{Severity: SeverityWarning, Code: DiagExampleMismatch, Location: "/components/schemas/Person/properties/name/example", Message: "example does not match schema - expected type string, got boolean"},
+	}, result)
+
+	d.Components.Examples[0].Value = map[string]any{"name": false}
+	result = d.CheckExamples()
+	assert.Len(t, result, 5)
+	assert.Equal(t, Diagnostic{Severity: SeverityWarning, Code: DiagExampleMismatch, Location: "/paths/~1/post/requestBody/content/application~1json/examples/ref", Message: "example $ref 'person' does not match schema - expected type string, got boolean (at '/name')"}, result[2])
+}
@@ -0,0 +1,328 @@
+package chioas
+
+import (
+	"github.com/go-andiamo/chioas/internal/tags"
+	"github.com/go-andiamo/chioas/internal/values"
+	"github.com/go-andiamo/chioas/yaml"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// GenerateExample generates a sample value for a schema
+//
+// The generated value is deterministic (the same schema always generates the same value) and takes into account
+// any Example, Default, Enum, Format, SchemaRef (resolved against the definition Components), Ofs and Discriminator - and,
+// for properties, any Constraints (except Pattern)
+//
+// The generated value is its JSON equivalent (i.e. objects are map[string]any and arrays are []any)
+//
+// See also DocOptions.GenerateExamples
+func (d *Definition) GenerateExample(schema *Schema) any {
+	g := &exampleGenerator{def: d}
+	return g.schema(schema, "")
+}
+
+// GeneratePropertyExample generates a sample value for a property (see GenerateExample)
+func (d *Definition) GeneratePropertyExample(property Property) any {
+	g := &exampleGenerator{def: d}
+	return g.property(property)
+}
+
+// FormatExamples is the sample string values used by Definition.GenerateExample for string formats
+var FormatExamples = map[string]string{
+	"date":      "2024-01-01",
+	"date-time": "2024-01-01T12:00:00Z",
+	"time":      "12:00:00",
+	"duration":  "P1D",
+	"email":     "user@example.com",
+	"uuid":      "3fa85f64-5717-4562-b3fc-2c963f66afa6",
+	"uri":       "https://example.com",
+	"url":       "https://example.com",
+	"hostname":  "example.com",
+	"ipv4":      "192.0.2.1",
+	"ipv6":      "2001:db8::1",
+	"byte":      "c3RyaW5n",
+	"binary":    "string",
+	"password":  "password",
+}
+
+type exampleGenerator struct {
+	def   *Definition
+	stack []string
+}
+
+func (g *exampleGenerator) ref(ref string) (any, bool) {
+	if name, _, ok, _ := isInternalRef(ref, tags.Schemas); ok && !slices.Contains(g.stack, name) {
+		if s, found := g.def.componentSchema(name); found {
+			g.stack = append(g.stack, name)
+			defer func() {
+				g.stack = g.stack[:len(g.stack)-1]
+			}()
+			return g.schema(s, name), true
+		}
+	}
+	return nil, false
+}
+
+func (g *exampleGenerator) schema(s *Schema, name string) any {
+	if s.SchemaRef != "" {
+		v, _ := g.ref(s.SchemaRef)
+		return v
+	}
+	if s.Example != nil {
+		return normalizeValue(s.Example)
+	} else if s.Default != nil {
+		return normalizeValue(s.Default)
+	} else if len(s.Enum) > 0 {
+		return normalizeValue(s.Enum[0])
+	}
+	var result any
+	chosen := ""
+	if s.Ofs != nil && len(s.Ofs.Of) > 0 {
+		result, chosen = g.ofs(s.Ofs)
+	}
+	typ := s.Type
+	if typ == "" {
+		if _, isObj := result.(map[string]any); s.Ofs != nil && !isObj {
+			return result
+		}
+		typ = values.TypeObject
+	}
+	if typ != values.TypeObject {
+		return g.primitive(typ, s.Format, Constraints{}, 0)
+	}
+	obj, ok := result.(map[string]any)
+	if !ok {
+		obj = map[string]any{}
+	}
+	g.properties(obj, s.Properties)
+	if s.Discriminator != nil && s.Discriminator.PropertyName != "" {
+		if dv, ok := g.discriminatorValue(s.Discriminator, chosen, name); ok {
+			obj[s.Discriminator.PropertyName] = dv
+		}
+	}
+	return obj
+}
+
+func (g *exampleGenerator) ofs(ofs *Ofs) (result any, chosen string) {
+	if ofs.OfType != AllOf {
+		of := ofs.Of[0]
+		if of.IsRef() {
+			if name, _, ok, _ := isInternalRef(of.Ref(), tags.Schemas); ok {
+				chosen = name
+			}
+		}
+		return g.of(of), chosen
+	}
+	var obj map[string]any
+	for _, of := range ofs.Of {
+		v := g.of(of)
+		if m, ok := v.(map[string]any); ok {
+			if obj == nil {
+				obj = map[string]any{}
+			}
+			for k, pv := range m {
+				obj[k] = pv
+			}
+		} else if v != nil && obj == nil {
+			result = v
+		}
+	}
+	if obj != nil {
+		return obj, ""
+	}
+	return result, ""
+}
+
+func (g *exampleGenerator) of(of OfSchema) any {
+	if of.IsRef() {
+		v, _ := g.ref(of.Ref())
+		return v
+	} else if s := of.Schema(); s != nil {
+		return g.schema(s, "")
+	}
+	return nil
+}
+
+// discriminatorValue determines the discriminator value - preferring the mapping key of the chosen oneOf/anyOf schema,
+// then the mapping key of the outermost schema being generated
+func (g *exampleGenerator) discriminatorValue(d *Discriminator, chosen string, name string) (string, bool) {
+	candidates := make([]string, 0, len(g.stack)+2)
+	if chosen != "" {
+		candidates = append(candidates, chosen)
+	}
+	candidates = append(candidates, g.stack...)
+	if name != "" {
+		candidates = append(candidates, name)
+	}
+	keys := sortedKeys(d.Mapping)
+	for _, candidate := range candidates {
+		for _, k := range keys {
+			if ref, _, ok, _ := isInternalRef(d.Mapping[k], tags.Schemas); ok && ref == candidate {
+				return k, true
+			}
+		}
+	}
+	if len(candidates) > 0 {
+		return candidates[0], true
+	} else if len(keys) > 0 {
+		return keys[0], true
+	}
+	return "", false
+}
+
+func (g *exampleGenerator) properties(obj map[string]any, properties Properties) {
+	for _, p := range properties {
+		if _, exists := obj[p.Name]; !exists {
+			if v := g.property(p); v != nil {
+				obj[p.Name] = v
+			}
+		}
+	}
+}
+
+func (g *exampleGenerator) property(p Property) any {
+	if p.Type != values.TypeArray {
+		return g.propertyItem(p, defValue(p.Type, values.TypeString), 0)
+	}
+	count := max(1, int(p.Constraints.MinItems))
+	items := make([]any, 0, count)
+	for i := 0; i < count; i++ {
+		if v := g.propertyItem(p, defValue(p.ItemType, values.TypeString), i); v != nil {
+			items = append(items, v)
+		}
+	}
+	return items
+}
+
+// propertyItem generates a value for a property (for array properties, the value is an item and typ is the item type)
+func (g *exampleGenerator) propertyItem(p Property, typ string, i int) any {
+	if p.SchemaRef != "" {
+		v, _ := g.ref(p.SchemaRef)
+		return v
+	}
+	if p.Example != nil {
+		return normalizeValue(p.Example)
+	} else if len(p.Enum) > 0 {
+		return normalizeValue(p.Enum[i%len(p.Enum)])
+	}
+	if typ == values.TypeObject {
+		obj := map[string]any{}
+		g.properties(obj, p.Properties)
+		return obj
+	}
+	return g.primitive(typ, p.Format, p.Constraints, i)
+}
+
+func (g *exampleGenerator) primitive(typ string, format string, c Constraints, i int) any {
+	switch typ {
+	case values.TypeString:
+		return sampleString(format, c, i)
+	case values.TypeInteger:
+		return int64(sampleNumber(float64(1+i), true, c))
+	case values.TypeNumber:
+		return sampleNumber(1.5+float64(i), false, c)
+	case values.TypeBoolean:
+		return true
+	case values.TypeArray:
+		return []any{}
+	case values.TypeObject:
+		return map[string]any{}
+	}
+	return nil
+}
+
+func sampleString(format string, c Constraints, i int) string {
+	result, ok := FormatExamples[format]
+	if !ok {
+		result = values.TypeString
+		if i > 0 {
+			result += strconv.Itoa(i + 1)
+		}
+	}
+	if l := utf8.RuneCountInString(result); c.MinLength > 0 && uint(l) < c.MinLength {
+		result += strings.Repeat("x", int(c.MinLength)-l)
+	} else if c.MaxLength > 0 && uint(l) > c.MaxLength {
+		result = string([]rune(result)[:c.MaxLength])
+	}
+	return result
+}
+
+func sampleNumber(v float64, integer bool, c Constraints) float64 {
+	if minimum, err := c.Minimum.Float64(); err == nil && c.Minimum != "" {
+		if c.ExclusiveMinimum {
+			if integer {
+				minimum = math.Floor(minimum) + 1
+			} else {
+				minimum += 0.5
+			}
+		} else if integer {
+			minimum = math.Ceil(minimum)
+		}
+		v = math.Max(v, minimum)
+	}
+	if c.MultipleOf > 0 {
+		m := float64(c.MultipleOf)
+		v = math.Ceil(v/m) * m
+	}
+	if maximum, err := c.Maximum.Float64(); err == nil && c.Maximum != "" {
+		if c.ExclusiveMaximum {
+			if integer {
+				maximum = math.Ceil(maximum) - 1
+			} else {
+				maximum -= 0.5
+			}
+		} else if integer {
+			maximum = math.Floor(maximum)
+		}
+		if v > maximum {
+			v = maximum
+			if c.MultipleOf > 0 {
+				m := float64(c.MultipleOf)
+				v = math.Floor(v/m) * m
+			}
+		}
+	}
+	return v
+}
+
+// docsExampleGenerator is the yaml.ExampleGenerator used when DocOptions.GenerateExamples is set
+type docsExampleGenerator struct {
+	def *Definition
+}
+
+func (eg *docsExampleGenerator) GenerateExample(schema any) (any, bool) {
+	if s, ok := schema.(*Schema); ok && s != nil {
+		if v := eg.def.GenerateExample(s); v != nil {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// writeGeneratedExample writes a generated example (if the writer has an example generator - see DocOptions.GenerateExamples)
+func writeGeneratedExample(schema any, schemaRef string, isArray bool, w yaml.Writer) {
+	egw, ok := w.(yaml.ExampleGeneratorWriter)
+	if !ok {
+		return
+	}
+	var s *Schema
+	if schema != nil {
+		if actual, _ := isActualSchema(schema); actual != nil {
+			s = actual
+		}
+	} else if schemaRef != "" {
+		s = &Schema{SchemaRef: schemaRef}
+	}
+	if s != nil {
+		if v, ok := egw.ExampleGenerator(nil).GenerateExample(s); ok {
+			if isArray {
+				v = []any{v}
+			}
+			w.WriteTagValue(tags.Example, v)
+		}
+	}
+}
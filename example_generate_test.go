@@ -0,0 +1,272 @@
+package chioas
+
+import (
+	"fmt"
+	"github.com/go-andiamo/chioas/yaml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func TestDefinition_GenerateExample(t *testing.T) {
+	testCases := []struct {
+		schema *Schema
+		expect any
+	}{
+		{
+			schema: &Schema{},
+			expect: map[string]any{},
+		},
+		{
+			schema: &Schema{Type: "string", Example: "foo", Default: "bar"},
+			expect: "foo",
+		},
+		{
+			schema: &Schema{Type: "string", Default: "bar", Enum: []any{"baz"}},
+			expect: "bar",
+		},
+		{
+			schema: &Schema{Type: "integer", Enum: []any{2, 3}},
+			expect: float64(2),
+		},
+		{
+			schema: &Schema{Type: "string", Format: "date-time"},
+			expect: "2024-01-01T12:00:00Z",
+		},
+		{
+			schema: &Schema{
+				Properties: Properties{
+					{Name: "id", Format: "uuid"},
+					{Name: "age", Type: "integer", Constraints: Constraints{Minimum: "18", Maximum: "65"}},
+					{Name: "score", Type: "number", Constraints: Constraints{Maximum: "1", ExclusiveMaximum: true}},
+					{Name: "code", Constraints: Constraints{MinLength: 8}},
+					{Name: "short", Constraints: Constraints{MaxLength: 3}},
+					{Name: "active", Type: "boolean"},
+					{Name: "tags", Type: "array", Constraints: Constraints{MinItems: 2}},
+					{Name: "colours", Type: "array", Enum: []any{"red", "green"}, Constraints: Constraints{MinItems: 3}},
+					{Name: "address", Type: "object", Properties: Properties{{Name: "postcode", Example: "AB1 2CD"}}},
+				},
+			},
+			expect: map[string]any{
+				"id":      "3fa85f64-5717-4562-b3fc-2c963f66afa6",
+				"age":     int64(18),
+				"score":   0.5,
+				"code":    "stringxx",
+				"short":   "str",
+				"active":  true,
+				"tags":    []any{"string", "string2"},
+				"colours": []any{"red", "green", "red"},
+				"address": map[string]any{"postcode": "AB1 2CD"},
+			},
+		},
+		{
+			schema: &Schema{SchemaRef: "Pet"},
+			expect: map[string]any{"petType": "Pet", "name": "strin"},
+		},
+		{
+			schema: &Schema{SchemaRef: "Cat"},
+			expect: map[string]any{"petType": "cat", "name": "strin", "lives": int64(1)},
+		},
+		{
+			schema: &Schema{SchemaRef: "#/components/schemas/Dog"},
+			expect: map[string]any{"petType": "dog", "name": "strin", "breed": "collie"},
+		},
+		{
+			schema: &Schema{
+				Ofs: &Ofs{
+					OfType: OneOf,
+					Of:     []OfSchema{OfRef("Dog"), OfRef("Cat")},
+				},
+			},
+			expect: map[string]any{"petType": "dog", "name": "strin", "breed": "collie"},
+		},
+		{
+			schema: &Schema{SchemaRef: "StringOrNumber"},
+			expect: "string",
+		},
+		{
+			schema: &Schema{SchemaRef: "Constrained"},
+			expect: map[string]any{
+				"code": "string",
+				"qty":  int64(5),
+				"tags": []any{"string"},
+				"refs": []any{"string"},
+				"opt":  "string",
+				"meta": map[string]any{},
+			},
+		},
+		{
+			schema: &Schema{SchemaRef: "Cyclic"},
+		},
+		{
+			schema: &Schema{SchemaRef: "unknown"},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]", i+1), func(t *testing.T) {
+			result := testCheckValueDefinition.GenerateExample(tc.schema)
+			assert.Equal(t, tc.expect, result)
+			// generating again must produce the same...
+			assert.Equal(t, result, testCheckValueDefinition.GenerateExample(tc.schema))
+		})
+	}
+}
+
+func TestDefinition_GenerateExample_PassesCheckValue(t *testing.T) {
+	for _, s := range testCheckValueDefinition.Components.Schemas {
+		if s.Name == "Constrained" || s.Name == "Cyclic" || s.Name == "Cyclic2" {
+			// patterns are not generated
+			continue
+		}
+		t.Run(s.Name, func(t *testing.T) {
+			schema := &Schema{SchemaRef: s.Name}
+			v := testCheckValueDefinition.GenerateExample(schema)
+			require.NotNil(t, v)
+			assert.Empty(t, testCheckValueDefinition.CheckValue(v, schema))
+		})
+	}
+}
+
+func TestDefinition_GeneratePropertyExample(t *testing.T) {
+	d := &Definition{}
+	assert.Equal(t, "string", d.GeneratePropertyExample(Property{}))
+	assert.Equal(t, []any{int64(1)}, d.GeneratePropertyExample(Property{Type: "array", ItemType: "integer"}))
+	assert.Equal(t, int64(10), d.GeneratePropertyExample(Property{Type: "integer", Constraints: Constraints{MultipleOf: 10}}))
+	assert.Equal(t, int64(4), d.GeneratePropertyExample(Property{Type: "integer", Constraints: Constraints{Minimum: "3", ExclusiveMinimum: true}}))
+	assert.Equal(t, int64(0), d.GeneratePropertyExample(Property{Type: "integer", Constraints: Constraints{Maximum: "1", ExclusiveMaximum: true}}))
+}
+
+func TestDefinition_writeYaml_GenerateExamples(t *testing.T) {
+	d := &Definition{
+		DocOptions: DocOptions{GenerateExamples: true},
+		Methods: Methods{
+			http.MethodPost: {
+				QueryParams: QueryParams{
+					{Name: "q", Schema: &Schema{Type: "integer"}},
+					{Name: "e", Schema: &Schema{Type: "integer"}, Example: 2},
+				},
+				Request: &Request{
+					SchemaRef: "Person",
+				},
+				Responses: Responses{
+					http.StatusOK: {
+						IsArray:   true,
+						SchemaRef: "Person",
+					},
+					http.StatusCreated: {
+						SchemaRef: "Person",
+						Examples:  Examples{{Name: "eg", Value: map[string]any{"name": "bilbo"}}},
+					},
+				},
+			},
+		},
+		Components: &Components{
+			Schemas: Schemas{
+				{
+					Name: "Person",
+					Properties: Properties{
+						{Name: "name"},
+					},
+				},
+			},
+		},
+	}
+	w := yaml.NewWriter(nil)
+	d.writeYaml(w)
+	data, err := w.Bytes()
+	require.NoError(t, err)
+	const expect = `openapi: "3.0.3"
+info:
+  title: "API Documentation"
+  version: "1.0.0"
+paths:
+  "/":
+    post:
+      parameters:
+        - name: q
+          in: query
+          required: false
+          example: 1
+          schema:
+            type: integer
+        - name: e
+          in: query
+          required: false
+          example: 2
+          schema:
+            type: integer
+      requestBody:
+        required: false
+        content:
+          "application/json":
+            schema:
+              $ref: "#/components/schemas/Person"
+            example:
+              name: string
+      responses:
+        200:
+          description: OK
+          content:
+            "application/json":
+              schema:
+                type: array
+                items:
+                  $ref: "#/components/schemas/Person"
+              example:
+                - name: string
+        201:
+          description: Created
+          content:
+            "application/json":
+              schema:
+                $ref: "#/components/schemas/Person"
+              examples:
+                eg:
+                  value:
+                    name: bilbo
+components:
+  schemas:
+    "Person":
+      type: object
+      properties:
+        "name":
+          type: string
+`
+	assert.Equal(t, expect, string(data))
+
+	d.DocOptions.GenerateExamples = false
+	w = yaml.NewWriter(nil)
+	d.writeYaml(w)
+	data, err = w.Bytes()
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "example: 1")
+	assert.NotContains(t, string(data), "name: string")
+}
+
+func TestDefinition_writeYaml_GenerateExamples_WriterWithoutExampleGenerator(t *testing.T) {
+	d := &Definition{
+		DocOptions: DocOptions{GenerateExamples: true},
+		Methods: Methods{
+			http.MethodGet: {
+				QueryParams: QueryParams{
+					{Name: "q", Schema: &Schema{Type: "integer"}},
+				},
+			},
+		},
+	}
+	w := &writerOnly{Writer: yaml.NewWriter(nil)}
+	_, ok := any(w).(yaml.ExampleGeneratorWriter)
+	require.False(t, ok)
+	err := d.writeYaml(w)
+	require.NoError(t, err)
+	data, err := w.Bytes()
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "name: q")
+	assert.NotContains(t, string(data), "example: 1")
+}
+
+// writerOnly is a yaml.Writer that does not implement yaml.ExampleGeneratorWriter
+type writerOnly struct {
+	yaml.Writer
+}
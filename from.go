@@ -33,6 +33,8 @@ import (
 //	      ...
 //
 // where the "GetRoot" must be a http.HandlerFunc method on the supplied api arg
+//
+// Methods without a `x-handler` can be served as mocks by setting FromOptions.MockFallback
 func FromJson(r io.Reader, opts *FromOptions) (result *Definition, err error) {
 	useOptions := defaultedFromOptions(opts, true)
 	result = &Definition{
@@ -59,6 +61,9 @@ func FromJson(r io.Reader, opts *FromOptions) (result *Definition, err error) {
 				err = useOptions.setMethodHandler(path, method, methodDef)
 				return true, err
 			})
+			if useOptions.MockFallback {
+				result.MethodHandlerBuilder = NewMockMethodHandlerBuilder(result, nil)
+			}
 		}
 	}
 	return
@@ -83,6 +88,8 @@ func FromJson(r io.Reader, opts *FromOptions) (result *Definition, err error) {
 //	      x-handler: ".GetRoot"
 //
 // where the "GetRoot" must be a http.HandlerFunc method on the supplied api arg
+//
+// Methods without a `x-handler` can be served as mocks by setting FromOptions.MockFallback
 func FromYaml(r io.Reader, opts *FromOptions) (result *Definition, err error) {
 	useOptions := defaultedFromOptions(opts, false)
 	result = &Definition{
@@ -108,6 +115,9 @@ func FromYaml(r io.Reader, opts *FromOptions) (result *Definition, err error) {
 				err = useOptions.setMethodHandler(path, method, methodDef)
 				return true, err
 			})
+			if useOptions.MockFallback {
+				result.MethodHandlerBuilder = NewMockMethodHandlerBuilder(result, nil)
+			}
 		}
 	}
 	return
@@ -123,7 +133,7 @@ func (f *FromOptions) setMethodHandler(path string, method string, methodDef *Me
 		} else {
 			err = fmt.Errorf("path '%s', method '%s' - 'x-handler' tag not a string", path, method)
 		}
-	} else if f.Strict {
+	} else if f.Strict && !f.MockFallback {
 		err = fmt.Errorf("path '%s', method '%s' - missing 'x-handler' tag", path, method)
 	}
 	return err
@@ -162,7 +172,11 @@ type FromOptions struct {
 	// Handlers is the optional look for handlers specified by `x-handler`
 	Handlers Handlers
 	// Strict when set, causes the FromJson / FromYaml to error if no `x-handler` tag is specified
+	//
+	// Strict is ignored when MockFallback is set
 	Strict bool
+	// MockFallback when set, methods that have no `x-handler` tag are served as mocks (see NewMockMethodHandlerBuilder)
+	MockFallback bool
 	// PathMiddlewares is an optional func that sets middlewares on paths found in the from spec
 	PathMiddlewares PathMiddlewares
 }
@@ -174,6 +188,7 @@ func defaultedFromOptions(opts *FromOptions, asJson bool) *FromOptions {
 			Api:             opts.Api,
 			Handlers:        opts.Handlers,
 			Strict:          opts.Strict,
+			MockFallback:    opts.MockFallback,
 			PathMiddlewares: opts.PathMiddlewares,
 		}
 	}
@@ -0,0 +1,170 @@
+package chioas
+
+import (
+	"github.com/go-andiamo/urit"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// FromRouterOptions is the options for FromRouter
+type FromRouterOptions struct {
+	// DocOptions is the optional doc options for the generated Definition
+	DocOptions *DocOptions
+	// KeepHandlers when set, each Method.Handler is the handler registered on the router
+	//
+	// Otherwise, each Method.Handler is the handler name (see HandlerName) - or nil if the name cannot be inferred
+	KeepHandlers bool
+	// ExcludeMethods is an optional list of http methods not to be included in the Definition (e.g. http.MethodOptions)
+	ExcludeMethods []string
+}
+
+// FromRouter builds a skeleton Definition from an existing chi router (using chi.Walk)
+//
+// Each route is broken down into nested Paths (one per path segment), with PathParams for any
+// path vars in the route (e.g. "/pets/{petId}") and a Method for each http method registered on the route
+//
+// The resulting Definition can be written out as Go code using codegen.GenerateCode
+func FromRouter(router chi.Routes, opts *FromRouterOptions) (*Definition, error) {
+	if opts == nil {
+		opts = &FromRouterOptions{}
+	}
+	result := &Definition{}
+	if opts.DocOptions != nil {
+		result.DocOptions = *opts.DocOptions
+	}
+	err := chi.Walk(router, func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		for _, xm := range opts.ExcludeMethods {
+			if strings.EqualFold(xm, method) {
+				return nil
+			}
+		}
+		mDef := Method{}
+		if opts.KeepHandlers {
+			mDef.Handler = http.HandlerFunc(handler.ServeHTTP)
+		} else if name := HandlerName(handler); name != "" {
+			mDef.Handler = name
+		}
+		return result.addRoute(method, route, mDef)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (d *Definition) addRoute(method string, route string, mDef Method) error {
+	segments := splitRoute(route)
+	if len(segments) == 0 {
+		if d.Methods == nil {
+			d.Methods = Methods{}
+		}
+		d.Methods[method] = mDef
+		return nil
+	}
+	if d.Paths == nil {
+		d.Paths = Paths{}
+	}
+	paths := d.Paths
+	for i, seg := range segments {
+		pDef, ok := paths[seg]
+		if !ok {
+			pps, err := segmentPathParams(seg)
+			if err != nil {
+				return err
+			}
+			pDef = Path{PathParams: pps}
+		}
+		if i == len(segments)-1 {
+			if pDef.Methods == nil {
+				pDef.Methods = Methods{}
+			}
+			pDef.Methods[method] = mDef
+		} else if pDef.Paths == nil {
+			pDef.Paths = Paths{}
+		}
+		paths[seg] = pDef
+		paths = pDef.Paths
+	}
+	return nil
+}
+
+// splitRoute splits a route into path segments (e.g. "/api/pets/{petId}" -> "/api", "/pets", "/{petId}")
+//
+// path var patterns (which may contain "/") are not split
+func splitRoute(route string) []string {
+	result := make([]string, 0)
+	depth := 0
+	start := -1
+	for i, ch := range route {
+		switch {
+		case ch == '{':
+			depth++
+		case ch == '}':
+			depth--
+		case ch == '/' && depth == 0:
+			if start != -1 && i > start+1 {
+				result = append(result, route[start:i])
+			}
+			start = i
+		}
+	}
+	if start != -1 && start < len(route)-1 {
+		result = append(result, route[start:])
+	}
+	return result
+}
+
+func segmentPathParams(seg string) (PathParams, error) {
+	if !strings.Contains(seg, "{") {
+		return nil, nil
+	}
+	t, err := urit.NewTemplate(seg)
+	if err != nil {
+		return nil, err
+	}
+	vars := t.Vars()
+	if len(vars) == 0 {
+		return nil, nil
+	}
+	result := make(PathParams, len(vars))
+	for _, v := range vars {
+		result[v.Name] = PathParam{}
+	}
+	return result, nil
+}
+
+var anonFuncRegex = regexp.MustCompile(`^func\d+$|^\d+$`)
+
+// HandlerName returns the name of a handler - as inferred from the func name (using runtime.FuncForPC)
+//
+// For method values (e.g. http.HandlerFunc(api.GetPets)) the name is the method name (e.g. "GetPets"), for funcs
+// the name is the func name (without package) and for non-func http.Handler the name is the type name
+//
+// Returns an empty string if the name cannot be inferred (e.g. anonymous funcs)
+func HandlerName(handler any) string {
+	if handler == nil {
+		return ""
+	}
+	v := reflect.ValueOf(handler)
+	if v.Kind() == reflect.Func {
+		if v.IsNil() {
+			return ""
+		}
+		if fn := runtime.FuncForPC(v.Pointer()); fn != nil {
+			name := parseMethodName(strings.TrimSuffix(fn.Name(), "-fm"))
+			if !anonFuncRegex.MatchString(name) {
+				return name
+			}
+		}
+		return ""
+	}
+	t := v.Type()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Name()
+}
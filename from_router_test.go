@@ -0,0 +1,153 @@
+package chioas
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testRouterApi struct{}
+
+func (a *testRouterApi) GetPets(w http.ResponseWriter, r *http.Request) {}
+
+func (a *testRouterApi) GetPet(w http.ResponseWriter, r *http.Request) {}
+
+func testRouterHandler(w http.ResponseWriter, r *http.Request) {}
+
+type testRouterHttpHandler struct{}
+
+func (h *testRouterHttpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {}
+
+func TestFromRouter(t *testing.T) {
+	api := &testRouterApi{}
+	router := chi.NewRouter()
+	router.Get("/", testRouterHandler)
+	router.Route("/api", func(r chi.Router) {
+		r.Get("/", api.GetPet)
+		r.Route("/pets", func(r chi.Router) {
+			r.Get("/", api.GetPets)
+			r.Post("/", func(w http.ResponseWriter, r *http.Request) {})
+			r.Get("/{petId:[0-9]+}", api.GetPet)
+			r.Options("/{petId:[0-9]+}", api.GetPet)
+		})
+	})
+	sub := chi.NewRouter()
+	sub.Method(http.MethodPut, "/{x}/{y}", &testRouterHttpHandler{})
+	router.Mount("/sub", sub)
+
+	def, err := FromRouter(router, &FromRouterOptions{
+		DocOptions:     &DocOptions{ServeDocs: true},
+		ExcludeMethods: []string{"options"},
+	})
+	require.NoError(t, err)
+	assert.True(t, def.DocOptions.ServeDocs)
+	assert.Equal(t, "testRouterHandler", def.Methods[http.MethodGet].Handler)
+	require.Len(t, def.Paths, 2)
+	apiPath := def.Paths["/api"]
+	assert.Equal(t, "GetPet", apiPath.Methods[http.MethodGet].Handler)
+	petsPath := apiPath.Paths["/pets"]
+	require.Len(t, petsPath.Methods, 2)
+	assert.Equal(t, "GetPets", petsPath.Methods[http.MethodGet].Handler)
+	assert.Nil(t, petsPath.Methods[http.MethodPost].Handler)
+	petPath := petsPath.Paths["/{petId:[0-9]+}"]
+	require.Len(t, petPath.Methods, 1)
+	assert.Equal(t, "GetPet", petPath.Methods[http.MethodGet].Handler)
+	require.Len(t, petPath.PathParams, 1)
+	_, ok := petPath.PathParams["petId"]
+	assert.True(t, ok)
+	subPath := def.Paths["/sub"]
+	assert.Empty(t, subPath.Methods)
+	xyPath := subPath.Paths["/{x}"].Paths["/{y}"]
+	assert.Equal(t, "testRouterHttpHandler", xyPath.Methods[http.MethodPut].Handler)
+	assert.Len(t, subPath.Paths["/{x}"].PathParams, 1)
+	assert.Len(t, xyPath.PathParams, 1)
+}
+
+func TestFromRouter_KeepHandlers(t *testing.T) {
+	router := chi.NewRouter()
+	called := false
+	router.Get("/foo", func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+	def, err := FromRouter(router, &FromRouterOptions{KeepHandlers: true})
+	require.NoError(t, err)
+	h, ok := def.Paths["/foo"].Methods[http.MethodGet].Handler.(http.HandlerFunc)
+	require.True(t, ok)
+	h(nil, nil)
+	assert.True(t, called)
+}
+
+func TestFromRouter_RoundTrip(t *testing.T) {
+	api := &testRouterApi{}
+	router := chi.NewRouter()
+	router.Get("/pets", api.GetPets)
+	router.Get("/pets/{petId}", api.GetPet)
+	def, err := FromRouter(router, nil)
+	require.NoError(t, err)
+
+	// the string handlers resolve against the api...
+	rebuilt := chi.NewRouter()
+	err = def.SetupRoutes(rebuilt, api)
+	require.NoError(t, err)
+	for _, path := range []string{"/pets", "/pets/1"} {
+		res := httptest.NewRecorder()
+		rebuilt.ServeHTTP(res, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusOK, res.Code)
+	}
+}
+
+func TestSegmentPathParams(t *testing.T) {
+	pps, err := segmentPathParams("/foo")
+	require.NoError(t, err)
+	assert.Nil(t, pps)
+	pps, err = segmentPathParams("/{id}")
+	require.NoError(t, err)
+	assert.Len(t, pps, 1)
+	_, err = segmentPathParams("/{bad")
+	require.Error(t, err)
+}
+
+func TestSplitRoute(t *testing.T) {
+	testCases := []struct {
+		route  string
+		expect []string
+	}{
+		{route: "", expect: []string{}},
+		{route: "/", expect: []string{}},
+		{route: "/api/", expect: []string{"/api"}},
+		{route: "/api/pets/{petId}", expect: []string{"/api", "/pets", "/{petId}"}},
+		{route: "/files/{path:a/b}/x", expect: []string{"/files", "/{path:a/b}", "/x"}},
+		{route: "//foo", expect: []string{"/foo"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.route, func(t *testing.T) {
+			assert.Equal(t, tc.expect, splitRoute(tc.route))
+		})
+	}
+}
+
+func TestHandlerName(t *testing.T) {
+	api := &testRouterApi{}
+	var nilFn http.HandlerFunc
+	testCases := []struct {
+		handler any
+		expect  string
+	}{
+		{handler: nil, expect: ""},
+		{handler: nilFn, expect: ""},
+		{handler: testRouterHandler, expect: "testRouterHandler"},
+		{handler: http.HandlerFunc(testRouterHandler), expect: "testRouterHandler"},
+		{handler: api.GetPets, expect: "GetPets"},
+		{handler: http.HandlerFunc(api.GetPet), expect: "GetPet"},
+		{handler: func() {}, expect: ""},
+		{handler: &testRouterHttpHandler{}, expect: "testRouterHttpHandler"},
+		{handler: errors.New("not a handler"), expect: "errorString"},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expect, HandlerName(tc.handler))
+	}
+}
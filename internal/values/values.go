@@ -2,10 +2,13 @@ package values
 
 const (
 	HdrAllow        = "Allow"
+	HdrPrefer       = "Prefer"
 	ContentTypeJson = "application/json"
 	ContentTypeYaml = "application/yaml"
 	Path            = "path"
 	Query           = "query"
+	Header          = "header"
+	Cookie          = "cookie"
 	TypeObject      = "object"
 	TypeArray       = "array"
 	TypeString      = "string"
@@ -1,65 +1,346 @@
 package chioas
 
 import (
+	"fmt"
 	"net/http"
 	"regexp"
+	"sort"
 	"strings"
 )
 
-func (d *Definition) MapRequest(r *http.Request) (path *Path, method *Method) {
-	if path = d.MapPath(r.URL.Path); path != nil {
-		if m, ok := d.Methods[r.Method]; ok {
-			method = &m
+// RouteMatch is the result of matching a request (method and path) against a Definition - see RouteMatcher
+type RouteMatch struct {
+	// Path is the matched Path (nil if the match is on the api root)
+	Path *Path
+	// Method is the matched Method (nil if the path matched but there is no method for the request method)
+	//
+	// Note: if Definition.AutoHeadMethods is set, a HEAD request will match the GET method (if there is no explicit HEAD method)
+	Method *Method
+	// Template is the flattened path template of the matched path (e.g. "/people/{id}")
+	Template string
+	// PathParams is the path params extracted from the request path (keyed by path param name)
+	PathParams map[string]string
+}
+
+// RouteMatcher is a precompiled matcher for matching requests against the paths and methods of a Definition
+//
+// Use Definition.RouteMatcher to create a RouteMatcher (Definition.SetupRoutes also creates one which is
+// then used by Definition.MapRequest and Definition.MapPath)
+//
+// A RouteMatcher is safe for concurrent use - but reflects the Definition at the time it was created
+type RouteMatcher struct {
+	root      *routeNode
+	autoHeads bool
+}
+
+type routeNode struct {
+	statics   map[string]*routeNode
+	params    []*routeParam
+	catchAll  *routeEntry
+	entry     *routeEntry
+	hasStatic bool
+}
+
+type routeParam struct {
+	names   []string
+	groups  []int
+	rx      *regexp.Regexp
+	node    *routeNode
+	segment string
+}
+
+type routeEntry struct {
+	path     *Path
+	methods  Methods
+	template string
+}
+
+// RouteMatcher creates a new precompiled RouteMatcher for the Definition
+//
+// Disabled paths (see Path.Disabled) are not matched.  An error is returned if any path var regex is invalid
+func (d *Definition) RouteMatcher() (*RouteMatcher, error) {
+	result := &RouteMatcher{
+		root:      newRouteNode(),
+		autoHeads: d.AutoHeadMethods,
+	}
+	result.root.entry = &routeEntry{
+		methods:  d.Methods,
+		template: root,
+	}
+	if err := result.addPaths(nil, d.Paths); err != nil {
+		return nil, err
+	}
+	result.root.sort()
+	return result, nil
+}
+
+func (rm *RouteMatcher) addPaths(ancestry []string, paths Paths) error {
+	for p, pDef := range paths {
+		if pDef.Disabled == nil || !pDef.Disabled() {
+			newAncestry := append(ancestry, p)
+			template := strings.Join(newAncestry, "")
+			if err := rm.add(template, &pDef); err != nil {
+				return err
+			}
+			if err := rm.addPaths(newAncestry, pDef.Paths); err != nil {
+				return err
+			}
 		}
 	}
-	return
+	return nil
 }
 
-func (d *Definition) MapPath(url string) (path *Path) {
-	parts, err := pathSplitter.Split(url)
+func (rm *RouteMatcher) add(template string, pDef *Path) error {
+	segments, err := pathSplitter.Split(template)
 	if err != nil {
-		return nil
+		return err
 	}
-	l := len(parts)
-	currPaths := d.Paths
-	for i, part := range parts {
-		if p, ok := currPaths["/"+part]; ok {
-			if i == l {
-				path = &p
-				break
-			} else {
-				currPaths = p.Paths
+	entry := &routeEntry{
+		path:     pDef,
+		methods:  pDef.Methods,
+		template: template,
+	}
+	node := rm.root
+	for i, segment := range segments {
+		if segment == "*" && i == len(segments)-1 {
+			node.catchAll = entry
+			return nil
+		} else if !strings.Contains(segment, "{") {
+			node.hasStatic = true
+			child, ok := node.statics[segment]
+			if !ok {
+				child = newRouteNode()
+				node.statics[segment] = child
+			}
+			node = child
+		} else if param, err := node.getParam(segment); err == nil {
+			node = param.node
+		} else {
+			return fmt.Errorf("invalid path template '%s' - %s", template, err.Error())
+		}
+	}
+	node.entry = entry
+	return nil
+}
+
+func newRouteNode() *routeNode {
+	return &routeNode{
+		statics: map[string]*routeNode{},
+	}
+}
+
+func (n *routeNode) getParam(segment string) (*routeParam, error) {
+	for _, p := range n.params {
+		if p.segment == segment {
+			return p, nil
+		}
+	}
+	names, groups, rx, err := compileSegment(segment)
+	if err != nil {
+		return nil, err
+	}
+	result := &routeParam{
+		names:   names,
+		groups:  groups,
+		rx:      rx,
+		node:    newRouteNode(),
+		segment: segment,
+	}
+	n.params = append(n.params, result)
+	return result, nil
+}
+
+// sort sorts param children so that those with regexes are tried before plain (match anything) params
+func (n *routeNode) sort() {
+	sort.SliceStable(n.params, func(i, j int) bool {
+		return n.params[i].rx != nil && n.params[j].rx == nil
+	})
+	for _, child := range n.statics {
+		child.sort()
+	}
+	for _, p := range n.params {
+		p.node.sort()
+	}
+}
+
+// compileSegment compiles a path segment containing path vars
+//
+// if the segment is just a single path var without a regex (e.g. "{id}") then the returned regexp is nil
+//
+// groups are the submatch indexes of each of the names (a path var regex may contain its own capture groups)
+func compileSegment(segment string) (names []string, groups []int, rx *regexp.Regexp, err error) {
+	names = make([]string, 0, 1)
+	groups = make([]int, 0, 1)
+	group := 1
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	plain := true
+	for pos := 0; pos < len(segment); {
+		if segment[pos] == '{' {
+			end := closingBrace(segment, pos)
+			if end == -1 {
+				return nil, nil, nil, fmt.Errorf("unclosed path var in '%s'", segment)
 			}
-		} else if varPath := findPathVarMatch(part, currPaths); varPath != nil {
-			if i == l {
-				path = varPath
-				break
+			name, varRx, hasRx := strings.Cut(segment[pos+1:end], ":")
+			names = append(names, strings.TrimSpace(name))
+			groups = append(groups, group)
+			group++
+			if hasRx {
+				plain = false
+				varRx = strings.TrimSpace(varRx)
+				vrx, vErr := regexp.Compile(varRx)
+				if vErr != nil {
+					return nil, nil, nil, vErr
+				}
+				group += vrx.NumSubexp()
+				pattern.WriteString("(" + varRx + ")")
 			} else {
-				currPaths = varPath.Paths
+				pattern.WriteString("([^/]+)")
 			}
+			pos = end + 1
 		} else {
-			break
+			plain = false
+			next := strings.IndexByte(segment[pos:], '{')
+			if next == -1 {
+				next = len(segment)
+			} else {
+				next += pos
+			}
+			pattern.WriteString(regexp.QuoteMeta(segment[pos:next]))
+			pos = next
 		}
 	}
-	return path
+	if plain && len(names) == 1 {
+		return names, groups, nil, nil
+	}
+	pattern.WriteString("$")
+	rx, err = regexp.Compile(pattern.String())
+	return
 }
 
-func findPathVarMatch(path string, curr Paths) (found *Path) {
-	for k, rp := range curr {
-		if strings.HasPrefix(k, "/{") && strings.HasSuffix(k, "}") {
-			pth := strings.TrimSuffix(strings.TrimPrefix(k, `/{`), `}`)
-			matches := !strings.Contains(pth, ":")
-			if !matches {
-				paramRx := strings.SplitN(pth, ":", 2)
-				if rx, err := regexp.Compile(paramRx[1]); err == nil {
-					matches = rx.MatchString(path)
-				}
+func closingBrace(s string, start int) int {
+	depth := 0
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Match matches the given http method and url path
+//
+// returns false if no path matches - if a path matches, but there is no method for the http method, the
+// result is still returned (with RouteMatch.Method nil)
+func (rm *RouteMatcher) Match(method string, urlPath string) (*RouteMatch, bool) {
+	var segments []string
+	if trimmed := strings.TrimPrefix(urlPath, "/"); trimmed != "" {
+		segments = strings.Split(trimmed, "/")
+	}
+	params := map[string]string{}
+	if entry := rm.root.match(segments, params); entry != nil {
+		result := &RouteMatch{
+			Path:       entry.path,
+			Template:   entry.template,
+			PathParams: params,
+		}
+		if m, ok := entry.methods[method]; ok {
+			result.Method = &m
+		} else if method == http.MethodHead && rm.autoHeads {
+			if m, ok := entry.methods.getWithoutHead(); ok {
+				result.Method = &m
+			}
+		}
+		return result, true
+	}
+	return nil, false
+}
+
+// MatchRequest matches the given *http.Request (see Match)
+func (rm *RouteMatcher) MatchRequest(r *http.Request) (*RouteMatch, bool) {
+	return rm.Match(r.Method, r.URL.Path)
+}
+
+func (n *routeNode) match(segments []string, params map[string]string) *routeEntry {
+	if len(segments) == 0 {
+		if n.entry != nil {
+			return n.entry
+		} else if n.catchAll != nil {
+			params["*"] = ""
+			return n.catchAll
+		}
+		return nil
+	}
+	segment, rest := segments[0], segments[1:]
+	if n.hasStatic {
+		if child, ok := n.statics[segment]; ok {
+			if entry := child.match(rest, params); entry != nil {
+				return entry
 			}
-			if matches {
-				found = &rp
-				break
+		}
+	}
+	if segment != "" {
+		for _, p := range n.params {
+			if p.rx == nil {
+				if entry := p.node.match(rest, params); entry != nil {
+					params[p.names[0]] = segment
+					return entry
+				}
+			} else if sms := p.rx.FindStringSubmatch(segment); sms != nil {
+				if entry := p.node.match(rest, params); entry != nil {
+					for i, name := range p.names {
+						params[name] = sms[p.groups[i]]
+					}
+					return entry
+				}
 			}
 		}
 	}
+	if n.catchAll != nil {
+		params["*"] = strings.Join(segments, "/")
+		return n.catchAll
+	}
+	return nil
+}
+
+// MapRequest maps the *http.Request to the Path and Method in the definition
+//
+// returns nil path if no path matched (or the request is for the api root) and nil method if no method matched
+//
+// Note: if Definition.SetupRoutes has been called, the RouteMatcher created by that is used - otherwise a
+// new RouteMatcher is created on each call (see Definition.RouteMatcher to create a reusable RouteMatcher)
+func (d *Definition) MapRequest(r *http.Request) (path *Path, method *Method) {
+	if rm := d.getRouteMatcher(); rm != nil {
+		if match, ok := rm.MatchRequest(r); ok {
+			path, method = match.Path, match.Method
+		}
+	}
+	return
+}
+
+// MapPath maps the url path to a Path in the definition
+//
+// returns nil if no path matched (or the url is for the api root)
+//
+// (see also MapRequest)
+func (d *Definition) MapPath(url string) (path *Path) {
+	if rm := d.getRouteMatcher(); rm != nil {
+		if match, ok := rm.Match("", url); ok {
+			path = match.Path
+		}
+	}
 	return
 }
+
+func (d *Definition) getRouteMatcher() *RouteMatcher {
+	if d.routeMatcher != nil {
+		return d.routeMatcher
+	}
+	rm, _ := d.RouteMatcher()
+	return rm
+}
@@ -0,0 +1,387 @@
+package chioas
+
+import (
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+var testMappingDefinition = Definition{
+	AutoHeadMethods: true,
+	Methods: Methods{
+		http.MethodGet: {OperationId: "getRoot"},
+	},
+	Paths: Paths{
+		"/people": {
+			Methods: Methods{
+				http.MethodGet:  {OperationId: "getPeople"},
+				http.MethodPost: {OperationId: "addPerson"},
+			},
+			Paths: Paths{
+				"/me": {
+					Methods: Methods{
+						http.MethodGet: {OperationId: "getMe"},
+					},
+				},
+				"/{id:[0-9]+}": {
+					Methods: Methods{
+						http.MethodGet: {OperationId: "getPersonById"},
+					},
+					Paths: Paths{
+						"/addresses/{addressId}": {
+							Methods: Methods{
+								http.MethodGet:  {OperationId: "getAddress"},
+								http.MethodHead: {OperationId: "headAddress"},
+							},
+						},
+					},
+				},
+				"/{name}": {
+					Methods: Methods{
+						http.MethodGet: {OperationId: "getPersonByName"},
+					},
+				},
+			},
+		},
+		"/files": {
+			Paths: Paths{
+				"/{name}.{ext:[a-z]+}": {
+					Methods: Methods{
+						http.MethodGet: {OperationId: "getFile"},
+					},
+				},
+				"/*": {
+					Methods: Methods{
+						http.MethodGet: {OperationId: "getAnyFile"},
+					},
+				},
+			},
+		},
+		"/disabled": {
+			Disabled: func() bool {
+				return true
+			},
+			Methods: Methods{
+				http.MethodGet: {OperationId: "getDisabled"},
+			},
+		},
+		"/hidden": {
+			HideDocs: true,
+			Methods: Methods{
+				http.MethodGet: {OperationId: "getHidden"},
+			},
+		},
+	},
+}
+
+func TestDefinition_RouteMatcher(t *testing.T) {
+	rm, err := testMappingDefinition.RouteMatcher()
+	require.NoError(t, err)
+	testCases := []struct {
+		method            string
+		path              string
+		expectMatch       bool
+		expectOperationId string
+		expectTemplate    string
+		expectParams      map[string]string
+		expectNilPath     bool
+	}{
+		{
+			method:            http.MethodGet,
+			path:              "/",
+			expectMatch:       true,
+			expectOperationId: "getRoot",
+			expectTemplate:    "/",
+			expectParams:      map[string]string{},
+			expectNilPath:     true,
+		},
+		{
+			method:            http.MethodGet,
+			path:              "/people",
+			expectMatch:       true,
+			expectOperationId: "getPeople",
+			expectTemplate:    "/people",
+			expectParams:      map[string]string{},
+		},
+		{
+			method:            http.MethodPost,
+			path:              "/people",
+			expectMatch:       true,
+			expectOperationId: "addPerson",
+			expectTemplate:    "/people",
+			expectParams:      map[string]string{},
+		},
+		{
+			method:         http.MethodDelete,
+			path:           "/people",
+			expectMatch:    true,
+			expectTemplate: "/people",
+			expectParams:   map[string]string{},
+		},
+		{
+			method:            http.MethodHead,
+			path:              "/people",
+			expectMatch:       true,
+			expectOperationId: "getPeople",
+			expectTemplate:    "/people",
+			expectParams:      map[string]string{},
+		},
+		{
+			method:            http.MethodGet,
+			path:              "/people/me",
+			expectMatch:       true,
+			expectOperationId: "getMe",
+			expectTemplate:    "/people/me",
+			expectParams:      map[string]string{},
+		},
+		{
+			method:            http.MethodGet,
+			path:              "/people/123",
+			expectMatch:       true,
+			expectOperationId: "getPersonById",
+			expectTemplate:    "/people/{id:[0-9]+}",
+			expectParams:      map[string]string{"id": "123"},
+		},
+		{
+			method:            http.MethodGet,
+			path:              "/people/bilbo",
+			expectMatch:       true,
+			expectOperationId: "getPersonByName",
+			expectTemplate:    "/people/{name}",
+			expectParams:      map[string]string{"name": "bilbo"},
+		},
+		{
+			method:            http.MethodGet,
+			path:              "/people/123/addresses/abc",
+			expectMatch:       true,
+			expectOperationId: "getAddress",
+			expectTemplate:    "/people/{id:[0-9]+}/addresses/{addressId}",
+			expectParams:      map[string]string{"id": "123", "addressId": "abc"},
+		},
+		{
+			method:            http.MethodHead,
+			path:              "/people/123/addresses/abc",
+			expectMatch:       true,
+			expectOperationId: "headAddress",
+			expectTemplate:    "/people/{id:[0-9]+}/addresses/{addressId}",
+			expectParams:      map[string]string{"id": "123", "addressId": "abc"},
+		},
+		{
+			method: http.MethodGet,
+			path:   "/people/bilbo/addresses/abc",
+		},
+		{
+			method: http.MethodGet,
+			path:   "/people/123/addresses",
+		},
+		{
+			method: http.MethodGet,
+			path:   "/people/",
+		},
+		{
+			method:            http.MethodGet,
+			path:              "/files/test.txt",
+			expectMatch:       true,
+			expectOperationId: "getFile",
+			expectTemplate:    "/files/{name}.{ext:[a-z]+}",
+			expectParams:      map[string]string{"name": "test", "ext": "txt"},
+		},
+		{
+			method:            http.MethodGet,
+			path:              "/files/test.123",
+			expectMatch:       true,
+			expectOperationId: "getAnyFile",
+			expectTemplate:    "/files/*",
+			expectParams:      map[string]string{"*": "test.123"},
+		},
+		{
+			method:            http.MethodGet,
+			path:              "/files/sub/dir/test.txt",
+			expectMatch:       true,
+			expectOperationId: "getAnyFile",
+			expectTemplate:    "/files/*",
+			expectParams:      map[string]string{"*": "sub/dir/test.txt"},
+		},
+		{
+			method:         http.MethodGet,
+			path:           "/files",
+			expectMatch:    true,
+			expectTemplate: "/files",
+			expectParams:   map[string]string{},
+		},
+		{
+			method: http.MethodGet,
+			path:   "/disabled",
+		},
+		{
+			method:            http.MethodGet,
+			path:              "/hidden",
+			expectMatch:       true,
+			expectOperationId: "getHidden",
+			expectTemplate:    "/hidden",
+			expectParams:      map[string]string{},
+		},
+		{
+			method: http.MethodGet,
+			path:   "/unknown",
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s %s", i, tc.method, tc.path), func(t *testing.T) {
+			match, ok := rm.Match(tc.method, tc.path)
+			if !tc.expectMatch {
+				assert.False(t, ok)
+				assert.Nil(t, match)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, tc.expectTemplate, match.Template)
+			assert.Equal(t, tc.expectParams, match.PathParams)
+			assert.Equal(t, tc.expectNilPath, match.Path == nil)
+			if tc.expectOperationId != "" {
+				require.NotNil(t, match.Method)
+				assert.Equal(t, tc.expectOperationId, match.Method.OperationId)
+			} else {
+				assert.Nil(t, match.Method)
+			}
+		})
+	}
+}
+
+func TestDefinition_RouteMatcher_RegexGroups(t *testing.T) {
+	d := Definition{
+		Paths: Paths{
+			"/pairs/{a:(x|y)}-{b}": {
+				Methods: Methods{http.MethodGet: {}},
+			},
+			"/nested/{a:((x)|(y))z}.{b:[0-9]+}.{c}": {
+				Methods: Methods{http.MethodGet: {}},
+			},
+			"/spaced/{ id : [0-9]+ }": {
+				Methods: Methods{http.MethodGet: {}},
+			},
+		},
+	}
+	rm, err := d.RouteMatcher()
+	require.NoError(t, err)
+	testCases := []struct {
+		path         string
+		expectParams map[string]string
+	}{
+		{
+			path:         "/pairs/x-foo",
+			expectParams: map[string]string{"a": "x", "b": "foo"},
+		},
+		{
+			path:         "/nested/yz.12.bar",
+			expectParams: map[string]string{"a": "yz", "b": "12", "c": "bar"},
+		},
+		{
+			path:         "/spaced/123",
+			expectParams: map[string]string{"id": "123"},
+		},
+		{
+			path: "/spaced/abc",
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i, tc.path), func(t *testing.T) {
+			match, ok := rm.Match(http.MethodGet, tc.path)
+			if tc.expectParams == nil {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, tc.expectParams, match.PathParams)
+		})
+	}
+}
+
+func TestDefinition_RouteMatcher_Errors(t *testing.T) {
+	d := Definition{
+		Paths: Paths{
+			"/people": {
+				Paths: Paths{
+					"/{id:[0-9+}": {},
+				},
+			},
+		},
+	}
+	_, err := d.RouteMatcher()
+	assert.Error(t, err)
+
+	d = Definition{
+		Paths: Paths{
+			"/{id": {},
+		},
+	}
+	_, err = d.RouteMatcher()
+	assert.Error(t, err)
+	err = d.SetupRoutes(chi.NewRouter(), nil)
+	assert.Error(t, err)
+}
+
+func TestDefinition_MapRequest(t *testing.T) {
+	d := testMappingDefinition
+	req, _ := http.NewRequest(http.MethodGet, "/people/123/addresses/abc", nil)
+	path, method := d.MapRequest(req)
+	require.NotNil(t, path)
+	require.NotNil(t, method)
+	assert.Equal(t, "getAddress", method.OperationId)
+
+	req, _ = http.NewRequest(http.MethodPut, "/people/123", nil)
+	path, method = d.MapRequest(req)
+	require.NotNil(t, path)
+	assert.Nil(t, method)
+
+	req, _ = http.NewRequest(http.MethodGet, "/unknown", nil)
+	path, method = d.MapRequest(req)
+	assert.Nil(t, path)
+	assert.Nil(t, method)
+
+	path = d.MapPath("/people/me")
+	require.NotNil(t, path)
+	assert.Equal(t, "getMe", path.Methods[http.MethodGet].OperationId)
+	path = d.MapPath("/people/123/addresses/abc")
+	require.NotNil(t, path)
+	assert.Equal(t, "getAddress", path.Methods[http.MethodGet].OperationId)
+	assert.Nil(t, d.MapPath("/"))
+	assert.Nil(t, d.MapPath("/unknown"))
+}
+
+func TestDefinition_MapRequest_AfterSetupRoutes(t *testing.T) {
+	d := Definition{
+		Paths: Paths{
+			"/people": {
+				Paths: Paths{
+					"/{id}": {
+						Methods: Methods{
+							http.MethodGet: {
+								OperationId: "getPerson",
+								Handler:     func(writer http.ResponseWriter, request *http.Request) {},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	require.NoError(t, d.SetupRoutes(chi.NewRouter(), nil))
+	require.NotNil(t, d.routeMatcher)
+	req, _ := http.NewRequest(http.MethodGet, "/people/123", nil)
+	path, method := d.MapRequest(req)
+	require.NotNil(t, path)
+	require.NotNil(t, method)
+	assert.Equal(t, "getPerson", method.OperationId)
+}
+
+func BenchmarkRouteMatcher_Match(b *testing.B) {
+	rm, _ := testMappingDefinition.RouteMatcher()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = rm.Match(http.MethodGet, "/people/123/addresses/abc")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/go-andiamo/chioas/internal/tags"
 	"github.com/go-andiamo/chioas/yaml"
 	"github.com/go-andiamo/urit"
+	"github.com/go-chi/chi/v5"
 	"golang.org/x/exp/slices"
 	"net/http"
 	"sort"
@@ -69,6 +70,10 @@ type Method struct {
 	Comment string
 	// HideDocs if set to true, hides this method from the OAS docs
 	HideDocs bool
+	// Middlewares is any chi.Middlewares to be applied to this method only
+	//
+	// (these are applied after any path middlewares and any Definition.OperationMiddleware)
+	Middlewares chi.Middlewares
 }
 
 // MethodsOrder defines the order in which methods appear in docs
@@ -221,11 +226,15 @@ func (m Method) writeYaml(opts *DocOptions, template urit.Template, pathVars []u
 }
 
 func (m Method) getOperationId(opts *DocOptions, method string, template urit.Template, parentTag string) string {
+	path := "/"
+	if template != nil {
+		path = template.OriginalTemplate()
+	}
+	return m.getOperationIdForPath(opts, method, path, parentTag)
+}
+
+func (m Method) getOperationIdForPath(opts *DocOptions, method string, path string, parentTag string) string {
 	if opts.OperationIdentifier != nil {
-		path := "/"
-		if template != nil {
-			path = template.OriginalTemplate()
-		}
 		return defValue(opts.OperationIdentifier(m, method, path, parentTag), m.OperationId)
 	} else {
 		return m.OperationId
@@ -0,0 +1,516 @@
+package chioas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/go-andiamo/chioas/internal/tags"
+	"github.com/go-andiamo/chioas/internal/values"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MockOptions is the options for NewMockMethodHandlerBuilder
+type MockOptions struct {
+	// HandlerBuilder is the MethodHandlerBuilder used for methods that have a Method.Handler set
+	//
+	// If nil, the default MethodHandlerBuilder is used
+	HandlerBuilder MethodHandlerBuilder
+	// MockAll when set, all methods are mocked (even those that have a Method.Handler set)
+	MockAll bool
+	// NoValidation when set, incoming requests are not validated
+	NoValidation bool
+	// Stateful when set, resource collections are inferred from path shapes (e.g. "/pets" and "/pets/{id}") and are served
+	// from an in-memory store - POST on the collection creates an item, GET on the collection lists items, GET on the item
+	// fetches it, PUT/PATCH update it and DELETE removes it
+	//
+	// New item ids are generated according to the item path param schema (integer sequence, uuid or string)
+	//
+	// Requests with a `Prefer` header selecting a code or example are served statically (as if Stateful were not set)
+	Stateful bool
+	// Store is the store used when Stateful is set (if nil, a new store is used)
+	Store *MockStore
+}
+
+// NewMockMethodHandlerBuilder creates a MethodHandlerBuilder (for use as Definition.MethodHandlerBuilder) that serves
+// mock responses for the operations of the definition - useful for working against an API before the handlers exist
+//
+// Methods that have a Method.Handler set use the MockOptions.HandlerBuilder (unless MockOptions.MockAll is set) - so the
+// mock acts as a fallback for methods without handlers
+//
+// Each mocked operation responds with the lowest 2xx response (or, if there are none, the lowest response) of the
+// method - using the first example of the response or, where the response has no examples, a value generated from
+// the response schema (see Definition.GenerateExample)
+//
+// The response and example can be selected by the request `Prefer` header, e.g.
+//
+//	Prefer: code=404
+//	Prefer: example=my-example
+//	Prefer: code=200, dynamic=true
+//
+// where "dynamic=true" uses a generated value even if the response has examples
+//
+// Unless MockOptions.NoValidation is set, incoming requests are validated (path, query and header params and the JSON request body
+// are checked against their schemas - see Definition.CheckValue) - invalid requests receive a http.StatusBadRequest response
+// with a JSON body describing the errors
+func NewMockMethodHandlerBuilder(d *Definition, opts *MockOptions) MethodHandlerBuilder {
+	result := &mockBuilder{
+		def:         d,
+		pathParams:  map[string]PathParams{},
+		pathMethods: map[string]Methods{},
+	}
+	if opts != nil {
+		result.opts = *opts
+	}
+	result.collectPathParams(nil, nil, d.Paths)
+	if result.opts.Stateful {
+		if result.store = result.opts.Store; result.store == nil {
+			result.store = NewMockStore()
+		}
+		result.inferCollections()
+	}
+	return result
+}
+
+type mockBuilder struct {
+	def             *Definition
+	opts            MockOptions
+	pathParams      map[string]PathParams
+	pathMethods     map[string]Methods
+	store           *MockStore
+	crudCollections map[string]*mockCrud
+	crudItems       map[string]*mockCrud
+}
+
+func (b *mockBuilder) collectPathParams(ancestry []string, parentParams PathParams, paths Paths) {
+	for p, pDef := range paths {
+		newAncestry := append(ancestry, p)
+		pps := PathParams{}
+		for k, pp := range parentParams {
+			pps[k] = pp
+		}
+		for k, pp := range pDef.PathParams {
+			pps[k] = pp
+		}
+		template := strings.Join(newAncestry, "")
+		b.pathParams[template] = pps
+		b.pathMethods[template] = pDef.Methods
+		b.collectPathParams(newAncestry, pps, pDef.Paths)
+	}
+}
+
+func (b *mockBuilder) BuildHandler(path string, method string, mdef Method, thisApi any) (http.HandlerFunc, error) {
+	if mdef.Handler != nil && !b.opts.MockAll {
+		return getMethodHandlerBuilder(b.opts.HandlerBuilder).BuildHandler(path, method, mdef, thisApi)
+	}
+	op := &mockOperation{
+		builder:           b,
+		path:              path,
+		method:            method,
+		params:            b.params(path, mdef),
+		request:           b.request(mdef.Request),
+		responses:         b.responses(mdef.Responses),
+		declaredResponses: len(mdef.Responses) > 0,
+		collection:        b.crudCollections[path],
+		item:              b.crudItems[path],
+	}
+	return op.serve, nil
+}
+
+// mockParam is the resolved path, query, header or cookie param of a mocked operation
+type mockParam struct {
+	name     string
+	in       string
+	required bool
+	schema   *Schema
+}
+
+func (b *mockBuilder) params(path string, mdef Method) []mockParam {
+	result := make([]mockParam, 0, len(mdef.QueryParams))
+	pps := b.pathParams[path]
+	for _, name := range sortedKeys(pps) {
+		pp := pps[name]
+		if pp.Ref != "" {
+			if cp, ok := b.commonParameter(pp.Ref); ok {
+				result = append(result, mockParam{name: name, in: values.Path, required: true, schema: paramSchema(cp.Schema, cp.SchemaRef)})
+			}
+		} else {
+			result = append(result, mockParam{name: name, in: values.Path, required: true, schema: paramSchema(pp.Schema, pp.SchemaRef)})
+		}
+	}
+	for _, qp := range mdef.QueryParams {
+		if qp.Ref != "" {
+			if cp, ok := b.commonParameter(qp.Ref); ok {
+				result = append(result, mockParam{name: cp.Name, in: defValue(cp.In, values.Query), required: cp.Required, schema: paramSchema(cp.Schema, cp.SchemaRef)})
+			}
+		} else {
+			result = append(result, mockParam{name: qp.Name, in: defValue(qp.In, values.Query), required: qp.Required, schema: paramSchema(qp.Schema, qp.SchemaRef)})
+		}
+	}
+	return result
+}
+
+func paramSchema(schema *Schema, schemaRef string) *Schema {
+	if schema != nil {
+		return schema
+	} else if schemaRef != "" {
+		return &Schema{SchemaRef: schemaRef}
+	}
+	return nil
+}
+
+func (b *mockBuilder) commonParameter(ref string) (CommonParameter, bool) {
+	if name, _, ok, _ := isInternalRef(ref, tags.Parameters); ok && b.def.Components != nil {
+		cp, found := b.def.Components.Parameters[name]
+		return cp, found
+	}
+	return CommonParameter{}, false
+}
+
+func (b *mockBuilder) request(r *Request) *Request {
+	if r != nil && r.Ref != "" {
+		if name, _, ok, _ := isInternalRef(r.Ref, tags.RequestBodies); ok && b.def.Components != nil {
+			if cr, found := b.def.Components.Requests[name]; found {
+				return &cr
+			}
+		}
+		return nil
+	}
+	return r
+}
+
+func (b *mockBuilder) responses(responses Responses) Responses {
+	if len(responses) == 0 {
+		if len(b.def.DocOptions.DefaultResponses) > 0 {
+			responses = b.def.DocOptions.DefaultResponses
+		} else {
+			responses = defaultResponses
+		}
+	}
+	result := make(Responses, len(responses))
+	for sc, r := range responses {
+		if r.Ref != "" {
+			if name, _, ok, _ := isInternalRef(r.Ref, tags.Responses); ok && b.def.Components != nil {
+				if cr, found := b.def.Components.Responses[name]; found {
+					result[sc] = cr
+				}
+			}
+		} else {
+			result[sc] = r
+		}
+	}
+	return result
+}
+
+// mockSchema determines the schema of a request/response content
+func mockSchema(cw contentWritable) *Schema {
+	if s := cw.schema(); s != nil {
+		if actual, _ := isActualSchema(s); actual != nil {
+			return actual
+		} else if fs, err := (&Schema{}).From(s); err == nil {
+			return fs
+		}
+	} else if ref := cw.schemaRef(); ref != "" {
+		return &Schema{SchemaRef: ref}
+	}
+	return nil
+}
+
+type mockOperation struct {
+	builder           *mockBuilder
+	path              string
+	method            string
+	params            []mockParam
+	request           *Request
+	responses         Responses
+	declaredResponses bool
+	collection        *mockCrud
+	item              *mockCrud
+}
+
+func (op *mockOperation) serve(w http.ResponseWriter, r *http.Request) {
+	if !op.builder.opts.NoValidation {
+		if errs := op.validate(r); len(errs) > 0 {
+			writeMockJson(w, http.StatusBadRequest, mockErrorBody{
+				Status:  http.StatusBadRequest,
+				Message: "request validation failed",
+				Errors:  errs,
+			})
+			return
+		}
+	}
+	prefs := parseMockPreferences(r)
+	if prefs.code == 0 && prefs.example == "" {
+		if op.collection != nil && op.collection.serveCollection(op, w, r) {
+			return
+		} else if op.item != nil && op.item.serveItem(op, w, r) {
+			return
+		}
+	}
+	sc, response, err := op.response(prefs)
+	if err == nil {
+		err = op.writeResponse(w, sc, response, prefs)
+	}
+	if err != nil {
+		writeMockJson(w, http.StatusInternalServerError, mockErrorBody{
+			Status:  http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+	}
+}
+
+const inBody = "body"
+
+// mockRequestError is an error found when validating a mock request
+type mockRequestError struct {
+	In      string `json:"in"`
+	Name    string `json:"name,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+type mockErrorBody struct {
+	Status  int                `json:"status"`
+	Message string             `json:"message"`
+	Errors  []mockRequestError `json:"errors,omitempty"`
+}
+
+func (op *mockOperation) validate(r *http.Request) []mockRequestError {
+	result := make([]mockRequestError, 0)
+	for _, p := range op.params {
+		raws := mockRawParam(r, p)
+		if len(raws) == 0 {
+			if p.required {
+				result = append(result, mockRequestError{In: p.in, Name: p.name, Message: "missing required param"})
+			}
+			continue
+		}
+		if p.schema != nil {
+			for _, mm := range op.builder.def.CheckValue(op.paramValue(raws, p.schema), p.schema) {
+				result = append(result, mockRequestError{In: p.in, Name: p.name, Path: mm.Path, Message: mm.Message})
+			}
+		}
+	}
+	if op.request != nil {
+		result = append(result, op.validateBody(r)...)
+	}
+	return result
+}
+
+func mockRawParam(r *http.Request, p mockParam) []string {
+	switch p.in {
+	case values.Path:
+		if v := PathValue(r, p.name); v != "" {
+			return []string{v}
+		}
+	case values.Header:
+		return r.Header.Values(p.name)
+	case values.Cookie:
+		if c, err := r.Cookie(p.name); err == nil {
+			return []string{c.Value}
+		}
+	default:
+		return r.URL.Query()[p.name]
+	}
+	return nil
+}
+
+// paramValue converts raw param string(s) to the type of the param schema (strings that cannot be converted are left as strings)
+func (op *mockOperation) paramValue(raws []string, s *Schema) any {
+	switch op.builder.def.schemaType(s) {
+	case values.TypeArray:
+		result := make([]any, 0, len(raws))
+		for _, raw := range raws {
+			for _, v := range strings.Split(raw, ",") {
+				result = append(result, v)
+			}
+		}
+		return result
+	case values.TypeInteger:
+		if i, err := strconv.ParseInt(raws[0], 10, 64); err == nil {
+			return i
+		}
+	case values.TypeNumber:
+		if f, err := strconv.ParseFloat(raws[0], 64); err == nil {
+			return f
+		}
+	case values.TypeBoolean:
+		if b, err := strconv.ParseBool(raws[0]); err == nil {
+			return b
+		}
+	}
+	return raws[0]
+}
+
+// schemaType determines the type of a schema (following any SchemaRef)
+func (d *Definition) schemaType(s *Schema) string {
+	if s = d.resolvedSchema(s, map[string]bool{}); s != nil {
+		return s.Type
+	}
+	return ""
+}
+
+func (op *mockOperation) validateBody(r *http.Request) []mockRequestError {
+	if !strings.Contains(defValue(op.request.ContentType, tags.ApplicationJson), "json") || r.Body == nil {
+		return nil
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return []mockRequestError{{In: inBody, Message: "unable to read request body - " + err.Error()}}
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		if op.request.Required {
+			return []mockRequestError{{In: inBody, Message: "missing required request body"}}
+		}
+		return nil
+	}
+	// the body may be read again by a stateful mock...
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	var body any
+	if err = json.Unmarshal(data, &body); err != nil {
+		return []mockRequestError{{In: inBody, Message: "invalid JSON request body - " + err.Error()}}
+	}
+	result := make([]mockRequestError, 0)
+	if schema := mockSchema(op.request); schema != nil {
+		vc := &valueChecker{def: op.builder.def}
+		if op.request.IsArray {
+			vc.items(body, schema, "")
+		} else {
+			vc.schema(body, schema, "", nil)
+		}
+		for _, mm := range vc.result {
+			result = append(result, mockRequestError{In: inBody, Path: mm.Path, Message: mm.Message})
+		}
+	}
+	return result
+}
+
+// mockPreferences is the preferences parsed from the request `Prefer` header
+type mockPreferences struct {
+	code    int
+	example string
+	dynamic bool
+}
+
+func parseMockPreferences(r *http.Request) (result mockPreferences) {
+	for _, hdr := range r.Header.Values(values.HdrPrefer) {
+		for _, pref := range strings.FieldsFunc(hdr, func(r rune) bool {
+			return r == ',' || r == ';'
+		}) {
+			k, v, _ := strings.Cut(strings.TrimSpace(pref), "=")
+			v = strings.Trim(strings.TrimSpace(v), `"`)
+			switch strings.ToLower(strings.TrimSpace(k)) {
+			case "code":
+				result.code, _ = strconv.Atoi(v)
+			case "example":
+				result.example = v
+			case "dynamic":
+				result.dynamic, _ = strconv.ParseBool(v)
+			}
+		}
+	}
+	return
+}
+
+func (op *mockOperation) response(prefs mockPreferences) (int, Response, error) {
+	if prefs.code != 0 {
+		if response, ok := op.responses[prefs.code]; ok {
+			return prefs.code, response, nil
+		}
+		return 0, Response{}, fmt.Errorf("no response defined for status code %d", prefs.code)
+	}
+	codes := make([]int, 0, len(op.responses))
+	for sc := range op.responses {
+		codes = append(codes, sc)
+	}
+	sort.Ints(codes)
+	if prefs.example != "" {
+		// find the first response that has the preferred example...
+		for _, sc := range codes {
+			if _, ok := op.builder.example(op.responses[sc].Examples, prefs.example); ok {
+				return sc, op.responses[sc], nil
+			}
+		}
+	}
+	for _, sc := range codes {
+		if sc >= http.StatusOK && sc < http.StatusMultipleChoices {
+			return sc, op.responses[sc], nil
+		}
+	}
+	if len(codes) > 0 {
+		return codes[0], op.responses[codes[0]], nil
+	}
+	return http.StatusOK, Response{}, nil
+}
+
+func (op *mockOperation) writeResponse(w http.ResponseWriter, sc int, response Response, prefs mockPreferences) error {
+	if response.NoContent || sc == http.StatusNoContent {
+		w.WriteHeader(sc)
+		return nil
+	}
+	var body any
+	hasBody := false
+	if prefs.example != "" {
+		if body, hasBody = op.builder.example(response.Examples, prefs.example); !hasBody {
+			return fmt.Errorf("no example '%s' defined for status code %d", prefs.example, sc)
+		}
+	} else if !prefs.dynamic && len(response.Examples) > 0 {
+		body, hasBody = op.builder.example(response.Examples, "")
+	}
+	if !hasBody {
+		if schema := mockSchema(response); schema != nil {
+			body = op.builder.def.GenerateExample(schema)
+			if response.IsArray {
+				body = []any{body}
+			}
+			hasBody = true
+		}
+	}
+	if !hasBody {
+		w.WriteHeader(sc)
+		return nil
+	}
+	contentType := defValue(response.ContentType, tags.ApplicationJson)
+	if str, ok := body.(string); ok && !strings.Contains(contentType, "json") {
+		w.Header().Set(hdrContentType, contentType)
+		w.WriteHeader(sc)
+		_, _ = w.Write([]byte(str))
+		return nil
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	w.Header().Set(hdrContentType, contentType)
+	w.WriteHeader(sc)
+	_, _ = w.Write(data)
+	return nil
+}
+
+// example finds the named example (or the first example, if name is empty) - resolving any Example.ExampleRef
+func (b *mockBuilder) example(examples Examples, name string) (any, bool) {
+	for _, eg := range examples {
+		if name == "" || eg.Name == name {
+			if eg.ExampleRef != "" {
+				if refName, _, ok, _ := isInternalRef(eg.ExampleRef, tags.Examples); ok {
+					if refEg, found := b.def.componentExample(refName); found {
+						return refEg.Value, true
+					}
+				}
+				return nil, false
+			}
+			return eg.Value, true
+		}
+	}
+	return nil, false
+}
+
+func writeMockJson(w http.ResponseWriter, sc int, body any) {
+	data, _ := json.Marshal(body)
+	w.Header().Set(hdrContentType, tags.ApplicationJson)
+	w.WriteHeader(sc)
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,362 @@
+package chioas
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-andiamo/chioas/internal/tags"
+	"github.com/go-andiamo/chioas/internal/values"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MockStore is the in-memory store used by stateful mocks (see MockOptions.Stateful)
+//
+// Items are stored by collection (the actual request path of the collection - e.g. "/pets" or "/owners/1/pets") and id
+//
+// A MockStore is safe for concurrent use - and can be used by tests to seed, inspect or reset the mock data
+type MockStore struct {
+	mutex       sync.Mutex
+	collections map[string]*mockCollection
+}
+
+type mockCollection struct {
+	ids   []string
+	items map[string]map[string]any
+	seq   int64
+}
+
+// NewMockStore creates a new empty MockStore
+func NewMockStore() *MockStore {
+	return &MockStore{
+		collections: map[string]*mockCollection{},
+	}
+}
+
+func (s *MockStore) collection(collection string) *mockCollection {
+	collection = normalizeCollectionPath(collection)
+	c, ok := s.collections[collection]
+	if !ok {
+		c = &mockCollection{items: map[string]map[string]any{}}
+		s.collections[collection] = c
+	}
+	return c
+}
+
+func normalizeCollectionPath(collection string) string {
+	if l := len(collection); l > 1 && strings.HasSuffix(collection, "/") {
+		return collection[:l-1]
+	}
+	return collection
+}
+
+// List returns the items in a collection (in the order they were added)
+func (s *MockStore) List(collection string) []map[string]any {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	c := s.collection(collection)
+	result := make([]map[string]any, 0, len(c.ids))
+	for _, id := range c.ids {
+		result = append(result, c.items[id])
+	}
+	return result
+}
+
+// Get returns an item in a collection
+func (s *MockStore) Get(collection string, id string) (map[string]any, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	item, ok := s.collection(collection).items[id]
+	return item, ok
+}
+
+// Put adds (or replaces) an item in a collection
+func (s *MockStore) Put(collection string, id string, item map[string]any) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.put(s.collection(collection), id, item)
+}
+
+func (s *MockStore) put(c *mockCollection, id string, item map[string]any) {
+	if _, exists := c.items[id]; !exists {
+		c.ids = append(c.ids, id)
+	}
+	c.items[id] = item
+}
+
+// Delete removes an item from a collection - returning false if the item did not exist
+func (s *MockStore) Delete(collection string, id string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	c := s.collection(collection)
+	if _, exists := c.items[id]; !exists {
+		return false
+	}
+	delete(c.items, id)
+	for i, cid := range c.ids {
+		if cid == id {
+			c.ids = append(c.ids[:i], c.ids[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// Reset removes all items from all collections
+func (s *MockStore) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.collections = map[string]*mockCollection{}
+}
+
+// mockCrud is an inferred resource collection - i.e. a path whose last segment is a single path var (e.g. "/pets/{id}")
+// and its parent collection path (e.g. "/pets")
+type mockCrud struct {
+	builder    *mockBuilder
+	idParam    string
+	idProperty string
+	idSchema   *Schema
+}
+
+// inferCollections infers the resource collections from the path shapes
+func (b *mockBuilder) inferCollections() {
+	b.crudCollections = map[string]*mockCrud{}
+	b.crudItems = map[string]*mockCrud{}
+	for template, pps := range b.pathParams {
+		segments, err := pathSplitter.Split(template)
+		if err != nil || len(segments) < 2 {
+			continue
+		}
+		last := segments[len(segments)-1]
+		if !strings.HasPrefix(last, "{") || closingBrace(last, 0) != len(last)-1 {
+			continue
+		}
+		names, _, _, err := compileSegment(last)
+		if err != nil || len(names) != 1 {
+			continue
+		}
+		crud := &mockCrud{
+			builder:  b,
+			idParam:  names[0],
+			idSchema: paramSchema(pps[names[0]].Schema, pps[names[0]].SchemaRef),
+		}
+		collectionTemplate := "/" + strings.Join(segments[:len(segments)-1], "/")
+		crud.idProperty = b.idProperty(crud.idParam, b.pathMethods[collectionTemplate], b.pathMethods[template])
+		b.crudItems[template] = crud
+		b.crudCollections[collectionTemplate] = crud
+	}
+}
+
+// idProperty determines the item property that holds the id - the first of the id param name or "id" that is a property of the
+// item schema (as determined by the collection POST request or the item GET response)
+func (b *mockBuilder) idProperty(idParam string, collectionMethods Methods, itemMethods Methods) string {
+	schemas := make([]*Schema, 0, 2)
+	if m, ok := collectionMethods[http.MethodPost]; ok {
+		if r := b.request(m.Request); r != nil {
+			schemas = append(schemas, mockSchema(r))
+		}
+	}
+	if m, ok := itemMethods[http.MethodGet]; ok {
+		if r, ok := b.responses(m.Responses)[http.StatusOK]; ok {
+			schemas = append(schemas, mockSchema(r))
+		}
+	}
+	for _, candidate := range []string{idParam, "id"} {
+		for _, s := range schemas {
+			for _, pty := range b.def.schemaProperties(s) {
+				if pty.Name == candidate {
+					return candidate
+				}
+			}
+		}
+	}
+	return "id"
+}
+
+// schemaProperties determines the properties of a schema (following any SchemaRef and merging allOf)
+func (d *Definition) schemaProperties(s *Schema) Properties {
+	result := Properties{}
+	seen := map[string]bool{}
+	var collect func(s *Schema)
+	collect = func(s *Schema) {
+		if s = d.resolvedSchema(s, seen); s != nil {
+			result = append(result, s.Properties...)
+			if s.Ofs != nil && s.Ofs.OfType == AllOf {
+				for _, of := range s.Ofs.Of {
+					if of.IsRef() {
+						collect(&Schema{SchemaRef: of.Ref()})
+					} else {
+						collect(of.Schema())
+					}
+				}
+			}
+		}
+	}
+	collect(s)
+	return result
+}
+
+// resolvedSchema follows any SchemaRef of a schema (returns nil if the ref cannot be resolved or is cyclic)
+func (d *Definition) resolvedSchema(s *Schema, seen map[string]bool) *Schema {
+	for s != nil && s.SchemaRef != "" {
+		name, _, ok, _ := isInternalRef(s.SchemaRef, tags.Schemas)
+		if !ok || seen[name] {
+			return nil
+		}
+		seen[name] = true
+		s, _ = d.componentSchema(name)
+	}
+	return s
+}
+
+// serveCollection serves GET (list) and POST (create) on a collection path
+func (c *mockCrud) serveCollection(op *mockOperation, w http.ResponseWriter, r *http.Request) bool {
+	store := c.builder.store
+	collection := normalizeCollectionPath(r.URL.Path)
+	switch op.method {
+	case http.MethodGet:
+		writeMockJson(w, op.successStatus(http.StatusOK), store.List(collection))
+	case http.MethodPost:
+		item, ok := readMockItem(w, r)
+		if !ok {
+			return true
+		}
+		store.mutex.Lock()
+		col := store.collection(collection)
+		var id string
+		if v, has := item[c.idProperty]; has && v != nil {
+			id = fmt.Sprint(v)
+			if _, exists := col.items[id]; exists {
+				store.mutex.Unlock()
+				writeMockError(w, http.StatusConflict, fmt.Sprintf("item with id '%s' already exists", id))
+				return true
+			}
+		} else {
+			var idValue any
+			for exists := true; exists; _, exists = col.items[id] {
+				col.seq++
+				idValue, id = c.newId(col.seq)
+			}
+			item[c.idProperty] = idValue
+		}
+		store.put(col, id, item)
+		store.mutex.Unlock()
+		writeMockJson(w, op.successStatus(http.StatusCreated), item)
+	default:
+		return false
+	}
+	return true
+}
+
+// serveItem serves GET, PUT, PATCH and DELETE on an item path
+func (c *mockCrud) serveItem(op *mockOperation, w http.ResponseWriter, r *http.Request) bool {
+	store := c.builder.store
+	id := PathValue(r, c.idParam)
+	collection := normalizeCollectionPath(r.URL.Path)
+	collection = collection[:strings.LastIndex(collection, "/")]
+	existing, exists := store.Get(collection, id)
+	switch op.method {
+	case http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		if !exists {
+			writeMockError(w, http.StatusNotFound, fmt.Sprintf("item with id '%s' not found", id))
+			return true
+		}
+	default:
+		return false
+	}
+	switch op.method {
+	case http.MethodGet:
+		writeMockJson(w, op.successStatus(http.StatusOK), existing)
+	case http.MethodPut, http.MethodPatch:
+		item, ok := readMockItem(w, r)
+		if !ok {
+			return true
+		}
+		if op.method == http.MethodPatch {
+			item = mergePatch(existing, item)
+		}
+		item[c.idProperty] = op.paramValue([]string{id}, c.idSchema)
+		store.Put(collection, id, item)
+		writeMockJson(w, op.successStatus(http.StatusOK), item)
+	case http.MethodDelete:
+		store.Delete(collection, id)
+		if sc := op.successStatus(http.StatusNoContent); sc != http.StatusNoContent {
+			writeMockJson(w, sc, existing)
+		} else {
+			w.WriteHeader(sc)
+		}
+	}
+	return true
+}
+
+// newId generates a new id (according to the id path param schema) - returning the typed id and its string form
+func (c *mockCrud) newId(seq int64) (any, string) {
+	s := c.builder.def.resolvedSchema(c.idSchema, map[string]bool{})
+	if s != nil && s.Type == values.TypeInteger {
+		return seq, strconv.FormatInt(seq, 10)
+	} else if s != nil && s.Format == "uuid" {
+		id := fmt.Sprintf("00000000-0000-4000-8000-%012d", seq)
+		return id, id
+	}
+	id := strconv.FormatInt(seq, 10)
+	return id, id
+}
+
+// successStatus determines the success status for a stateful operation - the lowest 2xx response declared by
+// the method (or the default status if the method declares no 2xx responses)
+func (op *mockOperation) successStatus(def int) int {
+	result := 0
+	for sc := range op.responses {
+		if sc >= http.StatusOK && sc < http.StatusMultipleChoices && (result == 0 || sc < result) {
+			result = sc
+		}
+	}
+	if result == 0 || !op.declaredResponses {
+		return def
+	}
+	return result
+}
+
+func readMockItem(w http.ResponseWriter, r *http.Request) (map[string]any, bool) {
+	var item map[string]any
+	if r.Body != nil {
+		if data, err := io.ReadAll(r.Body); err == nil && len(strings.TrimSpace(string(data))) > 0 {
+			if err = json.Unmarshal(data, &item); err != nil {
+				writeMockError(w, http.StatusBadRequest, "request body must be a JSON object")
+				return nil, false
+			}
+		}
+	}
+	if item == nil {
+		item = map[string]any{}
+	}
+	return item, true
+}
+
+// mergePatch applies a JSON merge patch (RFC 7386)
+func mergePatch(target map[string]any, patch map[string]any) map[string]any {
+	result := make(map[string]any, len(target)+len(patch))
+	for k, v := range target {
+		result[k] = v
+	}
+	for k, v := range patch {
+		if v == nil {
+			delete(result, k)
+		} else if pm, ok := v.(map[string]any); ok {
+			tm, _ := result[k].(map[string]any)
+			result[k] = mergePatch(tm, pm)
+		} else {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+func writeMockError(w http.ResponseWriter, sc int, msg string) {
+	writeMockJson(w, sc, mockErrorBody{
+		Status:  sc,
+		Message: msg,
+	})
+}
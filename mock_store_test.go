@@ -0,0 +1,261 @@
+package chioas
+
+import (
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var testStatefulMockDefinition = &Definition{
+	Paths: Paths{
+		"/pets": {
+			Methods: Methods{
+				http.MethodGet: {
+					Responses: Responses{
+						http.StatusOK: {IsArray: true, SchemaRef: "Pet"},
+					},
+				},
+				http.MethodPost: {
+					Request: &Request{SchemaRef: "Pet"},
+					Responses: Responses{
+						http.StatusCreated: {SchemaRef: "Pet"},
+					},
+				},
+			},
+			Paths: Paths{
+				"/{petId}": {
+					PathParams: PathParams{
+						"petId": {Schema: &Schema{Type: "integer"}},
+					},
+					Methods: Methods{
+						http.MethodGet: {
+							Responses: Responses{
+								http.StatusOK: {SchemaRef: "Pet"},
+							},
+						},
+						http.MethodPut: {
+							Request: &Request{SchemaRef: "Pet"},
+						},
+						http.MethodPatch: {},
+						http.MethodDelete: {
+							Responses: Responses{
+								http.StatusNoContent: {},
+							},
+						},
+					},
+				},
+			},
+		},
+		"/owners/{ownerId}/tags": {
+			Methods: Methods{
+				http.MethodGet:  {},
+				http.MethodPost: {},
+			},
+			Paths: Paths{
+				"/{tagId}": {
+					PathParams: PathParams{
+						"tagId": {Schema: &Schema{Type: "string", Format: "uuid"}},
+					},
+					Methods: Methods{
+						http.MethodGet: {},
+					},
+				},
+			},
+		},
+	},
+	Components: &Components{
+		Schemas: Schemas{
+			{
+				Name:               "Pet",
+				RequiredProperties: []string{"name"},
+				Properties: Properties{
+					{Name: "id", Type: "integer"},
+					{Name: "name"},
+					{Name: "tags", Type: "array", ItemType: "string"},
+				},
+			},
+		},
+	},
+}
+
+func TestNewMockMethodHandlerBuilder_Stateful(t *testing.T) {
+	d := *testStatefulMockDefinition
+	store := NewMockStore()
+	d.MethodHandlerBuilder = NewMockMethodHandlerBuilder(&d, &MockOptions{Stateful: true, Store: store})
+	router := chi.NewRouter()
+	require.NoError(t, d.SetupRoutes(router, nil))
+
+	steps := []struct {
+		method       string
+		path         string
+		body         string
+		headers      map[string]string
+		expectStatus int
+		expectBody   string
+	}{
+		{
+			method:       http.MethodGet,
+			path:         "/pets",
+			expectStatus: http.StatusOK,
+			expectBody:   `[]`,
+		},
+		{
+			method:       http.MethodPost,
+			path:         "/pets",
+			body:         `{"name":"felix"}`,
+			expectStatus: http.StatusCreated,
+			expectBody:   `{"id":1,"name":"felix"}`,
+		},
+		{
+			method:       http.MethodPost,
+			path:         "/pets",
+			body:         `{"id":3,"name":"tom","tags":["cat"]}`,
+			expectStatus: http.StatusCreated,
+			expectBody:   `{"id":3,"name":"tom","tags":["cat"]}`,
+		},
+		{
+			method:       http.MethodPost,
+			path:         "/pets",
+			body:         `{"id":3,"name":"tom"}`,
+			expectStatus: http.StatusConflict,
+			expectBody:   `{"status":409,"message":"item with id '3' already exists"}`,
+		},
+		{
+			method:       http.MethodPost,
+			path:         "/pets",
+			body:         `{"name":"garfield"}`,
+			expectStatus: http.StatusCreated,
+			expectBody:   `{"id":2,"name":"garfield"}`,
+		},
+		{
+			method:       http.MethodPost,
+			path:         "/pets",
+			body:         `{"name":"sylvester"}`,
+			expectStatus: http.StatusCreated,
+			expectBody:   `{"id":4,"name":"sylvester"}`,
+		},
+		{
+			method:       http.MethodPost,
+			path:         "/pets",
+			body:         `{}`,
+			expectStatus: http.StatusBadRequest,
+			expectBody:   `{"status":400,"message":"request validation failed","errors":[{"in":"body","message":"missing required property 'name'"}]}`,
+		},
+		{
+			method:       http.MethodGet,
+			path:         "/pets",
+			expectStatus: http.StatusOK,
+			expectBody:   `[{"id":1,"name":"felix"},{"id":3,"name":"tom","tags":["cat"]},{"id":2,"name":"garfield"},{"id":4,"name":"sylvester"}]`,
+		},
+		{
+			method:       http.MethodGet,
+			path:         "/pets/3",
+			expectStatus: http.StatusOK,
+			expectBody:   `{"id":3,"name":"tom","tags":["cat"]}`,
+		},
+		{
+			method:       http.MethodGet,
+			path:         "/pets/99",
+			expectStatus: http.StatusNotFound,
+			expectBody:   `{"status":404,"message":"item with id '99' not found"}`,
+		},
+		{
+			method:       http.MethodPatch,
+			path:         "/pets/3",
+			body:         `{"tags":null,"age":2}`,
+			expectStatus: http.StatusOK,
+			expectBody:   `{"age":2,"id":3,"name":"tom"}`,
+		},
+		{
+			method:       http.MethodPut,
+			path:         "/pets/1",
+			body:         `{"id":7,"name":"felix the cat"}`,
+			expectStatus: http.StatusOK,
+			expectBody:   `{"id":1,"name":"felix the cat"}`,
+		},
+		{
+			method:       http.MethodDelete,
+			path:         "/pets/2",
+			expectStatus: http.StatusNoContent,
+		},
+		{
+			method:       http.MethodDelete,
+			path:         "/pets/2",
+			expectStatus: http.StatusNotFound,
+			expectBody:   `{"status":404,"message":"item with id '2' not found"}`,
+		},
+		{
+			method:       http.MethodGet,
+			path:         "/pets/2",
+			headers:      map[string]string{"Prefer": "code=200"},
+			expectStatus: http.StatusOK,
+			expectBody:   `{"id":1,"name":"string","tags":["string"]}`,
+		},
+		{
+			method:       http.MethodPost,
+			path:         "/owners/1/tags",
+			body:         `{"label":"foo"}`,
+			expectStatus: http.StatusCreated,
+			expectBody:   `{"id":"00000000-0000-4000-8000-000000000001","label":"foo"}`,
+		},
+		{
+			method:       http.MethodGet,
+			path:         "/owners/1/tags/00000000-0000-4000-8000-000000000001",
+			expectStatus: http.StatusOK,
+			expectBody:   `{"id":"00000000-0000-4000-8000-000000000001","label":"foo"}`,
+		},
+		{
+			method:       http.MethodGet,
+			path:         "/owners/2/tags",
+			expectStatus: http.StatusOK,
+			expectBody:   `[]`,
+		},
+	}
+	for i, step := range steps {
+		t.Run(fmt.Sprintf("[%d]%s %s", i+1, step.method, step.path), func(t *testing.T) {
+			req, err := http.NewRequest(step.method, step.path, strings.NewReader(step.body))
+			require.NoError(t, err)
+			for k, v := range step.headers {
+				req.Header.Set(k, v)
+			}
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			assert.Equal(t, step.expectStatus, res.Code)
+			assert.Equal(t, step.expectBody, res.Body.String())
+		})
+	}
+
+	assert.Len(t, store.List("/pets"), 3)
+	item, ok := store.Get("/pets/", "1")
+	require.True(t, ok)
+	assert.Equal(t, "felix the cat", item["name"])
+	store.Reset()
+	assert.Len(t, store.List("/pets"), 0)
+}
+
+func TestMockStore(t *testing.T) {
+	store := NewMockStore()
+	store.Put("/pets", "1", map[string]any{"name": "felix"})
+	store.Put("/pets", "2", map[string]any{"name": "tom"})
+	store.Put("/pets", "1", map[string]any{"name": "felix the cat"})
+	assert.Equal(t, []map[string]any{{"name": "felix the cat"}, {"name": "tom"}}, store.List("/pets"))
+	assert.True(t, store.Delete("/pets", "1"))
+	assert.False(t, store.Delete("/pets", "1"))
+	_, ok := store.Get("/pets", "1")
+	assert.False(t, ok)
+	assert.Equal(t, []map[string]any{{"name": "tom"}}, store.List("/pets"))
+	assert.Empty(t, store.List("/other"))
+}
+
+func TestMergePatch(t *testing.T) {
+	result := mergePatch(
+		map[string]any{"a": 1, "b": map[string]any{"c": 2, "d": 3}, "e": 4},
+		map[string]any{"b": map[string]any{"c": nil, "f": 5}, "e": nil, "g": 6},
+	)
+	assert.Equal(t, map[string]any{"a": 1, "b": map[string]any{"d": 3, "f": 5}, "g": 6}, result)
+}
@@ -0,0 +1,302 @@
+package chioas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var testMockDefinition = &Definition{
+	Methods: Methods{
+		http.MethodGet: {
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("real handler"))
+			},
+		},
+	},
+	Paths: Paths{
+		"/pets": {
+			Methods: Methods{
+				http.MethodGet: {
+					QueryParams: QueryParams{
+						{Name: "limit", Schema: &Schema{Type: "integer"}},
+						{Name: "X-Tenant", In: "header", Required: true},
+					},
+					Responses: Responses{
+						http.StatusOK: {
+							IsArray:   true,
+							SchemaRef: "Pet",
+						},
+					},
+				},
+				http.MethodPost: {
+					Request: &Request{
+						Ref: "pet",
+					},
+					Responses: Responses{
+						http.StatusCreated: {
+							SchemaRef: "Pet",
+							Examples: Examples{
+								{Name: "tom", Value: map[string]any{"id": 1, "name": "tom"}},
+								{Name: "jerry", ExampleRef: "jerry"},
+							},
+						},
+						http.StatusConflict: {
+							Ref: "conflict",
+						},
+					},
+				},
+			},
+			Paths: Paths{
+				"/{petId:[0-9]+}": {
+					PathParams: PathParams{
+						"petId": {Schema: &Schema{Type: "integer", Example: 1}},
+					},
+					Methods: Methods{
+						http.MethodGet: {
+							Responses: Responses{
+								http.StatusOK: {
+									SchemaRef: "Pet",
+								},
+								http.StatusNotFound: {
+									ContentType: "text/plain",
+									Schema:      &Schema{Type: "string", Example: "not found"},
+								},
+							},
+						},
+						http.MethodDelete: {},
+					},
+				},
+			},
+		},
+	},
+	Components: &Components{
+		Schemas: Schemas{
+			{
+				Name:               "Pet",
+				RequiredProperties: []string{"name"},
+				Properties: Properties{
+					{Name: "id", Type: "integer"},
+					{Name: "name", Example: "felix"},
+				},
+			},
+		},
+		Requests: CommonRequests{
+			"pet": {
+				Required:  true,
+				SchemaRef: "Pet",
+			},
+		},
+		Responses: CommonResponses{
+			"conflict": {
+				Schema: &Schema{
+					Properties: Properties{
+						{Name: "message", Example: "already exists"},
+					},
+				},
+			},
+		},
+		Examples: Examples{
+			{Name: "jerry", Value: map[string]any{"id": 2, "name": "jerry"}},
+		},
+	},
+}
+
+func TestNewMockMethodHandlerBuilder(t *testing.T) {
+	d := *testMockDefinition
+	d.MethodHandlerBuilder = NewMockMethodHandlerBuilder(&d, nil)
+	router := chi.NewRouter()
+	require.NoError(t, d.SetupRoutes(router, nil))
+
+	testCases := []struct {
+		method       string
+		path         string
+		headers      map[string]string
+		body         string
+		expectStatus int
+		expectBody   string
+	}{
+		{
+			method:       http.MethodGet,
+			path:         "/",
+			expectStatus: http.StatusOK,
+			expectBody:   `real handler`,
+		},
+		{
+			method:       http.MethodGet,
+			path:         "/pets?limit=10",
+			headers:      map[string]string{"X-Tenant": "foo"},
+			expectStatus: http.StatusOK,
+			expectBody:   `[{"id":1,"name":"felix"}]`,
+		},
+		{
+			method:       http.MethodGet,
+			path:         "/pets?limit=ten",
+			expectStatus: http.StatusBadRequest,
+			expectBody:   `{"status":400,"message":"request validation failed","errors":[{"in":"query","name":"limit","message":"expected type integer, got string"},{"in":"header","name":"X-Tenant","message":"missing required param"}]}`,
+		},
+		{
+			method:       http.MethodPost,
+			path:         "/pets",
+			body:         `{"name":"tom"}`,
+			expectStatus: http.StatusCreated,
+			expectBody:   `{"id":1,"name":"tom"}`,
+		},
+		{
+			method:       http.MethodPost,
+			path:         "/pets",
+			headers:      map[string]string{"Prefer": "example=jerry"},
+			body:         `{"name":"jerry"}`,
+			expectStatus: http.StatusCreated,
+			expectBody:   `{"id":2,"name":"jerry"}`,
+		},
+		{
+			method:       http.MethodPost,
+			path:         "/pets",
+			headers:      map[string]string{"Prefer": "dynamic=true"},
+			body:         `{"name":"jerry"}`,
+			expectStatus: http.StatusCreated,
+			expectBody:   `{"id":1,"name":"felix"}`,
+		},
+		{
+			method:       http.MethodPost,
+			path:         "/pets",
+			headers:      map[string]string{"Prefer": `code=409`},
+			body:         `{"name":"jerry"}`,
+			expectStatus: http.StatusConflict,
+			expectBody:   `{"message":"already exists"}`,
+		},
+		{
+			method:       http.MethodPost,
+			path:         "/pets",
+			headers:      map[string]string{"Prefer": "code=418"},
+			body:         `{"name":"jerry"}`,
+			expectStatus: http.StatusInternalServerError,
+			expectBody:   `{"status":500,"message":"no response defined for status code 418"}`,
+		},
+		{
+			method:       http.MethodPost,
+			path:         "/pets",
+			headers:      map[string]string{"Prefer": "code=201; example=unknown"},
+			body:         `{"name":"jerry"}`,
+			expectStatus: http.StatusInternalServerError,
+			expectBody:   `{"status":500,"message":"no example 'unknown' defined for status code 201"}`,
+		},
+		{
+			method:       http.MethodPost,
+			path:         "/pets",
+			expectStatus: http.StatusBadRequest,
+			expectBody:   `{"status":400,"message":"request validation failed","errors":[{"in":"body","message":"missing required request body"}]}`,
+		},
+		{
+			method:       http.MethodPost,
+			path:         "/pets",
+			body:         `{"id":"1"}`,
+			expectStatus: http.StatusBadRequest,
+			expectBody:   `{"status":400,"message":"request validation failed","errors":[{"in":"body","message":"missing required property 'name'"},{"in":"body","path":"/id","message":"expected type integer, got string"}]}`,
+		},
+		{
+			method:       http.MethodPost,
+			path:         "/pets",
+			body:         `{`,
+			expectStatus: http.StatusBadRequest,
+			expectBody:   `{"status":400,"message":"request validation failed","errors":[{"in":"body","message":"invalid JSON request body - unexpected end of JSON input"}]}`,
+		},
+		{
+			method:       http.MethodGet,
+			path:         "/pets/1",
+			expectStatus: http.StatusOK,
+			expectBody:   `{"id":1,"name":"felix"}`,
+		},
+		{
+			method:       http.MethodGet,
+			path:         "/pets/1",
+			headers:      map[string]string{"Prefer": "code=404"},
+			expectStatus: http.StatusNotFound,
+			expectBody:   `not found`,
+		},
+		{
+			method:       http.MethodDelete,
+			path:         "/pets/1",
+			expectStatus: http.StatusOK,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s %s", i+1, tc.method, tc.path), func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			require.NoError(t, err)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			assert.Equal(t, tc.expectStatus, res.Code)
+			assert.Equal(t, tc.expectBody, res.Body.String())
+		})
+	}
+}
+
+func TestNewMockMethodHandlerBuilder_Options(t *testing.T) {
+	d := *testMockDefinition
+	d.MethodHandlerBuilder = NewMockMethodHandlerBuilder(&d, &MockOptions{MockAll: true, NoValidation: true})
+	router := chi.NewRouter()
+	require.NoError(t, d.SetupRoutes(router, nil))
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "", res.Body.String())
+
+	req, _ = http.NewRequest(http.MethodGet, "/pets?limit=ten", nil)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	var body []map[string]any
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &body))
+	assert.Len(t, body, 1)
+}
+
+func TestFromYaml_MockFallback(t *testing.T) {
+	const spec = `openapi: "3.0.3"
+paths:
+  "/":
+    get:
+      x-handler: "getRoot"
+  "/pets":
+    get:
+      responses:
+        200:
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  name:
+                    type: string
+              examples:
+                felix:
+                  value:
+                    name: felix
+`
+	_, err := FromYaml(bytes.NewReader([]byte(spec)), &FromOptions{Handlers: Handlers{"getRoot": testGetRoot}, Strict: true})
+	require.Error(t, err)
+
+	d, err := FromYaml(bytes.NewReader([]byte(spec)), &FromOptions{Handlers: Handlers{"getRoot": testGetRoot}, Strict: true, MockFallback: true})
+	require.NoError(t, err)
+	router := chi.NewRouter()
+	require.NoError(t, d.SetupRoutes(router, nil))
+
+	req, _ := http.NewRequest(http.MethodGet, "/pets", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, `{"name":"felix"}`, res.Body.String())
+}
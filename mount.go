@@ -0,0 +1,536 @@
+package chioas
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-andiamo/chioas/internal/refs"
+	"github.com/go-andiamo/chioas/internal/tags"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Mount merges the paths, methods, components, tags and security of a sub-definition into the definition - with the
+// sub-definition's paths (and root methods) under the prefix path (e.g. "/pets")
+//
+// Because the sub-definition is merged (rather than referenced) the result is the same at both routing time (see SetupRoutes)
+// and spec time.  The sub-definition itself is not altered
+//
+// Components of the sub-definition are de-duplicated - a component with the same name (and same content) as an existing
+// component is shared, but a component with the same name and different content is a conflict (see MountNamespaced to
+// avoid component name conflicts)
+//
+// Conflicts (e.g. a method already defined on a path, conflicting components or tags) are returned as an error - in which
+// case the definition is not altered
+//
+// Notes:
+//   - the sub-definition's Middlewares and ApplyMiddlewares are applied to the prefix path
+//   - if the sub-definition has Security (that differs from the definition's Security), it is applied to each of
+//     the sub-definition's methods that do not specify their own Security
+//   - the definition's settings (e.g. DocOptions, MethodHandlerBuilder, AutoHeadMethods etc.) apply to the mounted paths
+func (d *Definition) Mount(prefix string, sub *Definition) error {
+	return d.mount(prefix, "", sub)
+}
+
+// MountNamespaced is the same as Mount - except that the components of the sub-definition are namespaced, i.e. renamed
+// to "<namespace>.<name>" (e.g. component schema "Pet" with namespace "pets" becomes "pets.Pet"), and all $refs
+// to those components within the sub-definition are rewritten accordingly
+func (d *Definition) MountNamespaced(prefix string, namespace string, sub *Definition) error {
+	if namespace == "" {
+		return errors.New("mount namespace must not be empty")
+	}
+	return d.mount(prefix, namespace, sub)
+}
+
+func (d *Definition) mount(prefix string, namespace string, sub *Definition) error {
+	if sub == nil {
+		return errors.New("mount sub-definition must not be nil")
+	}
+	rn := newRefRenamer(namespace, sub.Components)
+	var errs []error
+	components, cErrs := mergeComponents(d.Components, rn.components(sub.Components))
+	errs = append(errs, cErrs...)
+	tgs, tErrs := mergeTags(d.Tags, sub.Tags)
+	errs = append(errs, tErrs...)
+	subSecurity := rn.securitySchemes(sub.Security)
+	applySecurity := len(subSecurity) > 0 && !reflect.DeepEqual(subSecurity, d.Security)
+	mountPath := Path{
+		Methods:          rn.methods(sub.Methods, subSecurity, applySecurity),
+		Paths:            rn.paths(sub.Paths, subSecurity, applySecurity),
+		Middlewares:      sub.Middlewares,
+		ApplyMiddlewares: sub.ApplyMiddlewares,
+	}
+	var methods Methods
+	var paths Paths
+	if segments := splitRoute(prefix); len(segments) == 0 {
+		if len(mountPath.Middlewares) > 0 || mountPath.ApplyMiddlewares != nil {
+			errs = append(errs, errors.New("cannot mount sub-definition with middlewares on api root"))
+		}
+		var err error
+		if methods, err = mergeMethods(root, d.Methods, mountPath.Methods); err != nil {
+			errs = append(errs, err)
+		}
+		paths, cErrs = mergePaths("", d.Paths, mountPath.Paths)
+		errs = append(errs, cErrs...)
+	} else {
+		methods = d.Methods
+		paths, cErrs = mergePaths("", d.Paths, prefixPaths(segments, mountPath))
+		errs = append(errs, cErrs...)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	d.Methods = methods
+	d.Paths = paths
+	d.Components = components
+	d.Tags = tgs
+	return nil
+}
+
+// prefixPaths builds the nested paths (one per prefix segment) with the mount path as the last
+func prefixPaths(segments []string, mountPath Path) Paths {
+	result := Paths{}
+	curr := result
+	for i, seg := range segments {
+		pDef := Path{}
+		if i == len(segments)-1 {
+			pDef = mountPath
+		}
+		pDef.PathParams, _ = segmentPathParams(seg)
+		if i < len(segments)-1 {
+			pDef.Paths = Paths{}
+		}
+		curr[seg] = pDef
+		curr = pDef.Paths
+	}
+	return result
+}
+
+func mergePaths(parent string, existing Paths, incoming Paths) (Paths, []error) {
+	if len(incoming) == 0 {
+		return existing, nil
+	}
+	result := maps.Clone(existing)
+	if result == nil {
+		result = Paths{}
+	}
+	var errs []error
+	for _, p := range sortedKeys(incoming) {
+		in := incoming[p]
+		if ex, ok := result[p]; ok {
+			merged, mErrs := mergePath(parent+p, ex, in)
+			errs = append(errs, mErrs...)
+			result[p] = merged
+		} else {
+			result[p] = in
+		}
+	}
+	return result, errs
+}
+
+func mergePath(path string, existing Path, incoming Path) (Path, []error) {
+	var errs []error
+	if len(incoming.Middlewares) > 0 || incoming.ApplyMiddlewares != nil {
+		errs = append(errs, fmt.Errorf("cannot mount middlewares on existing path '%s'", path))
+	}
+	if incoming.Disabled != nil {
+		errs = append(errs, fmt.Errorf("cannot mount disabled path on existing path '%s'", path))
+	}
+	if incoming.Tag != "" {
+		if existing.Tag != "" && existing.Tag != incoming.Tag {
+			errs = append(errs, fmt.Errorf("conflicting tag on path '%s' - '%s' and '%s'", path, existing.Tag, incoming.Tag))
+		}
+		existing.Tag = incoming.Tag
+	}
+	var err error
+	if existing.Methods, err = mergeMethods(path, existing.Methods, incoming.Methods); err != nil {
+		errs = append(errs, err)
+	}
+	if len(incoming.PathParams) > 0 {
+		pps := maps.Clone(existing.PathParams)
+		if pps == nil {
+			pps = PathParams{}
+		}
+		for _, name := range sortedKeys(incoming.PathParams) {
+			if pp, ok := pps[name]; ok && !reflect.DeepEqual(pp, incoming.PathParams[name]) {
+				errs = append(errs, fmt.Errorf("conflicting path param '%s' on path '%s'", name, path))
+			}
+			pps[name] = incoming.PathParams[name]
+		}
+		existing.PathParams = pps
+	}
+	var pErrs []error
+	existing.Paths, pErrs = mergePaths(path, existing.Paths, incoming.Paths)
+	return existing, append(errs, pErrs...)
+}
+
+func mergeMethods(path string, existing Methods, incoming Methods) (Methods, error) {
+	if len(incoming) == 0 {
+		return existing, nil
+	}
+	result := maps.Clone(existing)
+	if result == nil {
+		result = Methods{}
+	}
+	var errs []error
+	for _, m := range sortedKeys(incoming) {
+		if _, ok := result[m]; ok {
+			errs = append(errs, fmt.Errorf("method %s already defined on path '%s'", m, path))
+		}
+		result[m] = incoming[m]
+	}
+	return result, errors.Join(errs...)
+}
+
+func mergeTags(existing Tags, incoming Tags) (Tags, []error) {
+	result := slices.Clone(existing)
+	var errs []error
+	for _, t := range incoming {
+		if i := slices.IndexFunc(result, func(et Tag) bool {
+			return et.Name == t.Name
+		}); i == -1 {
+			result = append(result, t)
+		} else if !reflect.DeepEqual(result[i], t) {
+			errs = append(errs, fmt.Errorf("conflicting tag '%s'", t.Name))
+		}
+	}
+	return result, errs
+}
+
+func mergeComponents(existing *Components, incoming *Components) (*Components, []error) {
+	if incoming == nil {
+		return existing, nil
+	}
+	result := &Components{}
+	if existing != nil {
+		cp := *existing
+		result = &cp
+	}
+	var errs []error
+	result.Schemas, errs = mergeNamed(tags.Schemas, result.Schemas, incoming.Schemas, func(s Schema) string {
+		return s.Name
+	}, errs)
+	result.Examples, errs = mergeNamed(tags.Examples, result.Examples, incoming.Examples, func(eg Example) string {
+		return eg.Name
+	}, errs)
+	result.SecuritySchemes, errs = mergeNamed(tags.SecuritySchemes, result.SecuritySchemes, incoming.SecuritySchemes, func(ss SecurityScheme) string {
+		return ss.Name
+	}, errs)
+	result.Requests, errs = mergeNamedMap(tags.RequestBodies, result.Requests, incoming.Requests, errs)
+	result.Responses, errs = mergeNamedMap(tags.Responses, result.Responses, incoming.Responses, errs)
+	result.Parameters, errs = mergeNamedMap(tags.Parameters, result.Parameters, incoming.Parameters, errs)
+	return result, errs
+}
+
+func mergeNamed[S ~[]E, E any](area string, existing S, incoming S, name func(E) string, errs []error) (S, []error) {
+	result := slices.Clone(existing)
+	for _, in := range incoming {
+		if i := slices.IndexFunc(result, func(e E) bool {
+			return name(e) == name(in)
+		}); i == -1 {
+			result = append(result, in)
+		} else if !reflect.DeepEqual(result[i], in) {
+			errs = append(errs, fmt.Errorf("conflicting component %s '%s'", area, name(in)))
+		}
+	}
+	return result, errs
+}
+
+func mergeNamedMap[M ~map[string]E, E any](area string, existing M, incoming M, errs []error) (M, []error) {
+	if len(incoming) == 0 {
+		return existing, errs
+	}
+	result := maps.Clone(existing)
+	if result == nil {
+		result = M{}
+	}
+	for _, name := range sortedKeys(incoming) {
+		if e, ok := result[name]; ok && !reflect.DeepEqual(e, incoming[name]) {
+			errs = append(errs, fmt.Errorf("conflicting component %s '%s'", area, name))
+		} else if !ok {
+			result[name] = incoming[name]
+		}
+	}
+	return result, errs
+}
+
+// refRenamer copies parts of a definition - renaming components (and $refs to them) in the process
+type refRenamer struct {
+	renames map[string]map[string]string // area -> old name -> new name
+}
+
+func newRefRenamer(namespace string, components *Components) *refRenamer {
+	result := &refRenamer{renames: map[string]map[string]string{}}
+	if namespace != "" && components != nil {
+		add := func(area string, name string) {
+			if result.renames[area] == nil {
+				result.renames[area] = map[string]string{}
+			}
+			result.renames[area][name] = namespace + "." + name
+		}
+		for _, s := range components.Schemas {
+			add(tags.Schemas, s.Name)
+		}
+		for _, eg := range components.Examples {
+			add(tags.Examples, eg.Name)
+		}
+		for _, ss := range components.SecuritySchemes {
+			add(tags.SecuritySchemes, ss.Name)
+		}
+		for name := range components.Requests {
+			add(tags.RequestBodies, name)
+		}
+		for name := range components.Responses {
+			add(tags.Responses, name)
+		}
+		for name := range components.Parameters {
+			add(tags.Parameters, name)
+		}
+	}
+	return result
+}
+
+func (rn *refRenamer) name(area string, name string) string {
+	if nn, ok := rn.renames[area][name]; ok {
+		return nn
+	}
+	return name
+}
+
+func (rn *refRenamer) ref(area string, ref string) string {
+	if name, _, ok, err := isInternalRef(ref, area); ok && err == nil {
+		if nn, ok := rn.renames[area][name]; ok {
+			if strings.HasPrefix(ref, refs.ComponentsPrefix) {
+				return refs.Canonical(area, nn)
+			}
+			return nn
+		}
+	}
+	return ref
+}
+
+func (rn *refRenamer) components(c *Components) *Components {
+	if c == nil {
+		return nil
+	}
+	result := *c
+	result.Schemas = make(Schemas, 0, len(c.Schemas))
+	for _, s := range c.Schemas {
+		s = rn.schema(s)
+		s.Name = rn.name(tags.Schemas, s.Name)
+		result.Schemas = append(result.Schemas, s)
+	}
+	result.Examples = make(Examples, 0, len(c.Examples))
+	for _, eg := range c.Examples {
+		eg = rn.example(eg)
+		eg.Name = rn.name(tags.Examples, eg.Name)
+		result.Examples = append(result.Examples, eg)
+	}
+	result.SecuritySchemes = rn.securitySchemes(c.SecuritySchemes)
+	if c.Requests != nil {
+		result.Requests = make(CommonRequests, len(c.Requests))
+		for name, r := range c.Requests {
+			result.Requests[rn.name(tags.RequestBodies, name)] = *rn.request(&r)
+		}
+	}
+	if c.Responses != nil {
+		result.Responses = make(CommonResponses, len(c.Responses))
+		for name, r := range c.Responses {
+			result.Responses[rn.name(tags.Responses, name)] = rn.response(r)
+		}
+	}
+	if c.Parameters != nil {
+		result.Parameters = make(CommonParameters, len(c.Parameters))
+		for name, p := range c.Parameters {
+			p.SchemaRef = rn.ref(tags.Schemas, p.SchemaRef)
+			p.Schema = rn.schemaPtr(p.Schema)
+			result.Parameters[rn.name(tags.Parameters, name)] = p
+		}
+	}
+	return &result
+}
+
+func (rn *refRenamer) securitySchemes(ss SecuritySchemes) SecuritySchemes {
+	if ss == nil {
+		return nil
+	}
+	result := make(SecuritySchemes, 0, len(ss))
+	for _, s := range ss {
+		s.Name = rn.name(tags.SecuritySchemes, s.Name)
+		result = append(result, s)
+	}
+	return result
+}
+
+func (rn *refRenamer) paths(ps Paths, security SecuritySchemes, applySecurity bool) Paths {
+	if ps == nil {
+		return nil
+	}
+	result := make(Paths, len(ps))
+	for p, pDef := range ps {
+		pDef.Methods = rn.methods(pDef.Methods, security, applySecurity)
+		pDef.Paths = rn.paths(pDef.Paths, security, applySecurity)
+		if pDef.PathParams != nil {
+			pps := make(PathParams, len(pDef.PathParams))
+			for name, pp := range pDef.PathParams {
+				pp.Ref = rn.ref(tags.Parameters, pp.Ref)
+				pp.SchemaRef = rn.ref(tags.Schemas, pp.SchemaRef)
+				pp.Schema = rn.schemaPtr(pp.Schema)
+				pps[name] = pp
+			}
+			pDef.PathParams = pps
+		}
+		result[p] = pDef
+	}
+	return result
+}
+
+func (rn *refRenamer) methods(ms Methods, security SecuritySchemes, applySecurity bool) Methods {
+	if ms == nil {
+		return nil
+	}
+	result := make(Methods, len(ms))
+	for m, mDef := range ms {
+		if len(mDef.Security) > 0 {
+			mDef.Security = rn.securitySchemes(mDef.Security)
+		} else if applySecurity {
+			mDef.Security = security
+		}
+		if mDef.QueryParams != nil {
+			qps := make(QueryParams, 0, len(mDef.QueryParams))
+			for _, qp := range mDef.QueryParams {
+				qp.Ref = rn.ref(tags.Parameters, qp.Ref)
+				qp.SchemaRef = rn.ref(tags.Schemas, qp.SchemaRef)
+				qp.Schema = rn.schemaPtr(qp.Schema)
+				qps = append(qps, qp)
+			}
+			mDef.QueryParams = qps
+		}
+		mDef.Request = rn.request(mDef.Request)
+		if mDef.Responses != nil {
+			rs := make(Responses, len(mDef.Responses))
+			for sc, r := range mDef.Responses {
+				rs[sc] = rn.response(r)
+			}
+			mDef.Responses = rs
+		}
+		result[m] = mDef
+	}
+	return result
+}
+
+func (rn *refRenamer) request(r *Request) *Request {
+	if r == nil {
+		return nil
+	}
+	result := *r
+	result.Ref = rn.ref(tags.RequestBodies, r.Ref)
+	result.SchemaRef = rn.ref(tags.Schemas, r.SchemaRef)
+	result.Schema = rn.anySchema(r.Schema)
+	result.Examples = rn.examples(r.Examples)
+	result.AlternativeContentTypes = rn.contentTypes(r.AlternativeContentTypes)
+	return &result
+}
+
+func (rn *refRenamer) response(r Response) Response {
+	r.Ref = rn.ref(tags.Responses, r.Ref)
+	r.SchemaRef = rn.ref(tags.Schemas, r.SchemaRef)
+	r.Schema = rn.anySchema(r.Schema)
+	r.Examples = rn.examples(r.Examples)
+	r.AlternativeContentTypes = rn.contentTypes(r.AlternativeContentTypes)
+	return r
+}
+
+func (rn *refRenamer) contentTypes(cts ContentTypes) ContentTypes {
+	if cts == nil {
+		return nil
+	}
+	result := make(ContentTypes, len(cts))
+	for k, ct := range cts {
+		ct.SchemaRef = rn.ref(tags.Schemas, ct.SchemaRef)
+		ct.Schema = rn.anySchema(ct.Schema)
+		ct.Examples = rn.examples(ct.Examples)
+		result[k] = ct
+	}
+	return result
+}
+
+func (rn *refRenamer) examples(egs Examples) Examples {
+	if egs == nil {
+		return nil
+	}
+	result := make(Examples, 0, len(egs))
+	for _, eg := range egs {
+		result = append(result, rn.example(eg))
+	}
+	return result
+}
+
+func (rn *refRenamer) example(eg Example) Example {
+	eg.ExampleRef = rn.ref(tags.Examples, eg.ExampleRef)
+	return eg
+}
+
+func (rn *refRenamer) anySchema(s any) any {
+	switch st := s.(type) {
+	case Schema:
+		return rn.schema(st)
+	case *Schema:
+		return rn.schemaPtr(st)
+	}
+	return s
+}
+
+func (rn *refRenamer) schemaPtr(s *Schema) *Schema {
+	if s == nil {
+		return nil
+	}
+	result := rn.schema(*s)
+	return &result
+}
+
+func (rn *refRenamer) schema(s Schema) Schema {
+	s.SchemaRef = rn.ref(tags.Schemas, s.SchemaRef)
+	s.Properties = rn.properties(s.Properties)
+	if s.Discriminator != nil {
+		d := *s.Discriminator
+		if d.Mapping != nil {
+			d.Mapping = make(map[string]string, len(s.Discriminator.Mapping))
+			for k, v := range s.Discriminator.Mapping {
+				d.Mapping[k] = rn.ref(tags.Schemas, v)
+			}
+		}
+		s.Discriminator = &d
+	}
+	if s.Ofs != nil {
+		ofs := *s.Ofs
+		ofs.Of = make([]OfSchema, 0, len(s.Ofs.Of))
+		for _, of := range s.Ofs.Of {
+			switch ot := of.(type) {
+			case OfRef:
+				of = OfRef(rn.ref(tags.Schemas, string(ot)))
+			case *Of:
+				of = &Of{SchemaRef: rn.ref(tags.Schemas, ot.SchemaRef), SchemaDef: rn.schemaPtr(ot.SchemaDef)}
+			case *Schema:
+				of = rn.schemaPtr(ot)
+			}
+			ofs.Of = append(ofs.Of, of)
+		}
+		s.Ofs = &ofs
+	}
+	return s
+}
+
+func (rn *refRenamer) properties(ptys Properties) Properties {
+	if ptys == nil {
+		return nil
+	}
+	result := make(Properties, 0, len(ptys))
+	for _, pty := range ptys {
+		pty.SchemaRef = rn.ref(tags.Schemas, pty.SchemaRef)
+		pty.Properties = rn.properties(pty.Properties)
+		result = append(result, pty)
+	}
+	return result
+}
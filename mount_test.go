@@ -0,0 +1,361 @@
+package chioas
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func testMountHandler(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body + PathValue(r, "petId")))
+	}
+}
+
+var testMountSubDefinition = Definition{
+	Tags: Tags{{Name: "pets", Description: "Pets"}},
+	Security: SecuritySchemes{
+		{Name: "bearer"},
+	},
+	Middlewares: chi.Middlewares{
+		func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Sub", "true")
+				next.ServeHTTP(w, r)
+			})
+		},
+	},
+	Methods: Methods{
+		http.MethodGet: {
+			Tag:     "pets",
+			Handler: testMountHandler("pets"),
+			Responses: Responses{
+				http.StatusOK: {SchemaRef: "Pet", IsArray: true},
+			},
+		},
+		http.MethodPost: {
+			Tag:      "pets",
+			Handler:  testMountHandler("post"),
+			Security: SecuritySchemes{{Name: "apiKey"}},
+			Request:  &Request{Ref: "PetRequest"},
+			Responses: Responses{
+				http.StatusCreated: {Ref: "PetResponse"},
+			},
+		},
+	},
+	Paths: Paths{
+		"/{petId}": {
+			PathParams: PathParams{
+				"petId": {Ref: "PetId"},
+			},
+			Methods: Methods{
+				http.MethodGet: {
+					Tag:     "pets",
+					Handler: testMountHandler("pet "),
+					QueryParams: QueryParams{
+						{Name: "fields", SchemaRef: "#/components/schemas/Fields"},
+					},
+					Responses: Responses{
+						http.StatusOK: {
+							SchemaRef: "#/components/schemas/Pet",
+							Examples:  Examples{{Name: "cat", ExampleRef: "Cat"}},
+							AlternativeContentTypes: ContentTypes{
+								"application/xml": {SchemaRef: "Pet"},
+							},
+						},
+						http.StatusNotFound: {SchemaRef: "Error"},
+					},
+				},
+			},
+		},
+	},
+	Components: &Components{
+		Schemas: Schemas{
+			{
+				Name: "Pet",
+				Type: "object",
+				Properties: Properties{
+					{Name: "name", Type: "string"},
+					{Name: "owner", SchemaRef: "Owner"},
+				},
+				Discriminator: &Discriminator{
+					PropertyName: "type",
+					Mapping:      map[string]string{"cat": "#/components/schemas/Owner"},
+				},
+				Ofs: &Ofs{
+					OfType: OneOf,
+					Of:     []OfSchema{OfRef("Owner"), &Of{SchemaRef: "Owner"}, &Schema{SchemaRef: "Owner"}},
+				},
+			},
+			{Name: "Owner", Type: "object"},
+			{Name: "Fields", Type: "string"},
+			{Name: "Error", Type: "object"},
+		},
+		Requests: CommonRequests{
+			"PetRequest": {SchemaRef: "Pet"},
+		},
+		Responses: CommonResponses{
+			"PetResponse": {Schema: &Schema{SchemaRef: "Pet"}},
+		},
+		Parameters: CommonParameters{
+			"PetId": {Name: "petId", In: "path", SchemaRef: "Fields"},
+		},
+		Examples: Examples{
+			{Name: "Cat", Value: map[string]any{"name": "Felix"}},
+		},
+		SecuritySchemes: SecuritySchemes{
+			{Name: "bearer", Scheme: "bearer"},
+			{Name: "apiKey", Type: "apiKey", In: "header", ParamName: "X-Key"},
+		},
+	},
+}
+
+func testMountDefinition() *Definition {
+	return &Definition{
+		Tags: Tags{{Name: "health"}},
+		Paths: Paths{
+			"/health": {
+				Methods: Methods{
+					http.MethodGet: {Handler: testMountHandler("ok")},
+				},
+			},
+		},
+		Components: &Components{
+			Schemas: Schemas{
+				{Name: "Error", Type: "object"},
+			},
+		},
+	}
+}
+
+func TestDefinition_Mount(t *testing.T) {
+	def := testMountDefinition()
+	sub := &testMountSubDefinition
+	err := def.Mount("/pets", sub)
+	require.NoError(t, err)
+
+	require.Len(t, def.Paths, 2)
+	pets := def.Paths["/pets"]
+	assert.Len(t, pets.Methods, 2)
+	assert.Len(t, pets.Middlewares, 1)
+	assert.Len(t, pets.Paths["/{petId}"].Methods, 1)
+	// sub security applied to methods without security...
+	assert.Equal(t, "bearer", pets.Methods[http.MethodGet].Security[0].Name)
+	assert.Equal(t, "apiKey", pets.Methods[http.MethodPost].Security[0].Name)
+	// components de-duplicated...
+	assert.Len(t, def.Components.Schemas, 4)
+	assert.Len(t, def.Tags, 2)
+	assert.Empty(t, def.CheckRefs())
+	// sub not altered...
+	assert.Empty(t, sub.Methods[http.MethodGet].Security)
+
+	router := chi.NewRouter()
+	require.NoError(t, def.SetupRoutes(router, nil))
+	testCases := []struct {
+		path       string
+		expectBody string
+		expectSub  bool
+	}{
+		{path: "/health", expectBody: "ok"},
+		{path: "/pets", expectBody: "pets", expectSub: true},
+		{path: "/pets/123", expectBody: "pet 123", expectSub: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			assert.Equal(t, http.StatusOK, res.Code)
+			assert.Equal(t, tc.expectBody, res.Body.String())
+			assert.Equal(t, tc.expectSub, res.Header().Get("X-Sub") == "true")
+		})
+	}
+
+	data, err := def.AsYaml()
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "  \"/pets/{petId}\":\n")
+}
+
+func TestDefinition_MountNamespaced(t *testing.T) {
+	def := testMountDefinition()
+	sub := &testMountSubDefinition
+	err := def.MountNamespaced("/pets", "pets", sub)
+	require.NoError(t, err)
+
+	assert.Len(t, def.Components.Schemas, 5)
+	names := make([]string, 0)
+	for _, s := range def.Components.Schemas {
+		names = append(names, s.Name)
+	}
+	assert.Equal(t, []string{"Error", "pets.Pet", "pets.Owner", "pets.Fields", "pets.Error"}, names)
+	assert.Contains(t, def.Components.Requests, "pets.PetRequest")
+	assert.Contains(t, def.Components.Responses, "pets.PetResponse")
+	assert.Contains(t, def.Components.Parameters, "pets.PetId")
+	assert.Equal(t, "pets.Cat", def.Components.Examples[0].Name)
+	assert.Equal(t, "pets.bearer", def.Components.SecuritySchemes[0].Name)
+	assert.Empty(t, def.CheckRefs())
+
+	pet := def.Components.Schemas[1]
+	assert.Equal(t, "pets.Owner", pet.Properties[1].SchemaRef)
+	assert.Equal(t, "#/components/schemas/pets.Owner", pet.Discriminator.Mapping["cat"])
+	assert.Equal(t, OfRef("pets.Owner"), pet.Ofs.Of[0])
+	assert.Equal(t, "pets.Owner", pet.Ofs.Of[1].Ref())
+	assert.Equal(t, "pets.Owner", pet.Ofs.Of[2].(*Schema).SchemaRef)
+	assert.Equal(t, "pets.Pet", def.Components.Requests["pets.PetRequest"].SchemaRef)
+	assert.Equal(t, "pets.Pet", def.Components.Responses["pets.PetResponse"].Schema.(*Schema).SchemaRef)
+	assert.Equal(t, "pets.Fields", def.Components.Parameters["pets.PetId"].SchemaRef)
+
+	pets := def.Paths["/pets"]
+	assert.Equal(t, "pets.Pet", pets.Methods[http.MethodGet].Responses[http.StatusOK].SchemaRef)
+	assert.Equal(t, "pets.bearer", pets.Methods[http.MethodGet].Security[0].Name)
+	assert.Equal(t, "pets.apiKey", pets.Methods[http.MethodPost].Security[0].Name)
+	assert.Equal(t, "pets.PetRequest", pets.Methods[http.MethodPost].Request.Ref)
+	assert.Equal(t, "pets.PetResponse", pets.Methods[http.MethodPost].Responses[http.StatusCreated].Ref)
+	pet2 := pets.Paths["/{petId}"]
+	assert.Equal(t, "pets.PetId", pet2.PathParams["petId"].Ref)
+	get := pet2.Methods[http.MethodGet]
+	assert.Equal(t, "#/components/schemas/pets.Fields", get.QueryParams[0].SchemaRef)
+	assert.Equal(t, "#/components/schemas/pets.Pet", get.Responses[http.StatusOK].SchemaRef)
+	assert.Equal(t, "pets.Cat", get.Responses[http.StatusOK].Examples[0].ExampleRef)
+	assert.Equal(t, "pets.Pet", get.Responses[http.StatusOK].AlternativeContentTypes["application/xml"].SchemaRef)
+	assert.Equal(t, "pets.Error", get.Responses[http.StatusNotFound].SchemaRef)
+
+	// sub not altered...
+	assert.Equal(t, "Pet", sub.Components.Schemas[0].Name)
+	assert.Equal(t, "Owner", sub.Components.Schemas[0].Properties[1].SchemaRef)
+	assert.Equal(t, "Pet", sub.Methods[http.MethodGet].Responses[http.StatusOK].SchemaRef)
+
+	err = def.MountNamespaced("/pets", "", sub)
+	require.Error(t, err)
+	assert.Equal(t, "mount namespace must not be empty", err.Error())
+}
+
+func TestDefinition_Mount_Prefixes(t *testing.T) {
+	t.Run("root", func(t *testing.T) {
+		def := &Definition{}
+		sub := &Definition{
+			Methods: Methods{http.MethodGet: {}},
+			Paths:   Paths{"/foo": {}},
+		}
+		require.NoError(t, def.Mount("", sub))
+		assert.Len(t, def.Methods, 1)
+		assert.Len(t, def.Paths, 1)
+		assert.Nil(t, def.Components)
+
+		sub.Middlewares = chi.Middlewares{func(handler http.Handler) http.Handler {
+			return handler
+		}}
+		err := (&Definition{}).Mount("/", sub)
+		require.Error(t, err)
+		assert.Equal(t, "cannot mount sub-definition with middlewares on api root", err.Error())
+	})
+	t.Run("nested", func(t *testing.T) {
+		def := &Definition{
+			Paths: Paths{
+				"/tenants": {
+					Tag:     "tenants",
+					Methods: Methods{http.MethodGet: {}},
+				},
+			},
+		}
+		sub := &Definition{
+			Methods: Methods{http.MethodGet: {}},
+		}
+		require.NoError(t, def.Mount("/tenants/{tenantId}/pets/", sub))
+		tenants := def.Paths["/tenants"]
+		assert.Equal(t, "tenants", tenants.Tag)
+		assert.Len(t, tenants.Methods, 1)
+		tenant := tenants.Paths["/{tenantId}"]
+		assert.Contains(t, tenant.PathParams, "tenantId")
+		assert.Empty(t, tenant.Methods)
+		assert.Len(t, tenant.Paths["/pets"].Methods, 1)
+	})
+}
+
+func TestDefinition_Mount_Conflicts(t *testing.T) {
+	testCases := []struct {
+		name        string
+		prefix      string
+		sub         *Definition
+		expectError string
+	}{
+		{
+			name:        "nil sub",
+			expectError: "mount sub-definition must not be nil",
+		},
+		{
+			name:   "method",
+			prefix: "/health",
+			sub: &Definition{
+				Methods: Methods{http.MethodGet: {}, http.MethodPost: {}},
+			},
+			expectError: "method GET already defined on path '/health'",
+		},
+		{
+			name: "root method",
+			sub: &Definition{
+				Methods: Methods{http.MethodGet: {}},
+			},
+			expectError: "method GET already defined on path '/'",
+		},
+		{
+			name:   "component",
+			prefix: "/pets",
+			sub: &Definition{
+				Components: &Components{
+					Schemas: Schemas{{Name: "Error", Type: "string"}},
+				},
+			},
+			expectError: "conflicting component schemas 'Error'",
+		},
+		{
+			name:   "tag",
+			prefix: "/pets",
+			sub: &Definition{
+				Tags: Tags{{Name: "health", Description: "different"}},
+			},
+			expectError: "conflicting tag 'health'",
+		},
+		{
+			name:   "path",
+			prefix: "/",
+			sub: &Definition{
+				Paths: Paths{
+					"/health": {
+						Tag:         "other",
+						Middlewares: chi.Middlewares{nil},
+						Disabled: func() bool {
+							return false
+						},
+						PathParams: PathParams{"x": {Description: "x"}},
+						Paths: Paths{
+							"/sub": {},
+						},
+					},
+				},
+			},
+			expectError: "cannot mount middlewares on existing path '/health'\ncannot mount disabled path on existing path '/health'",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			def := testMountDefinition()
+			def.Methods = Methods{http.MethodGet: {}}
+			hp := def.Paths["/health"]
+			hp.Tag = "health"
+			hp.PathParams = PathParams{"x": {}}
+			def.Paths["/health"] = hp
+			err := def.Mount(tc.prefix, tc.sub)
+			require.Error(t, err)
+			assert.True(t, strings.HasPrefix(err.Error(), tc.expectError), err.Error())
+			// definition not altered...
+			assert.Len(t, def.Paths, 1)
+			assert.Len(t, def.Components.Schemas, 1)
+			assert.Len(t, def.Tags, 1)
+			assert.Empty(t, def.Paths["/health"].Paths)
+		})
+	}
+}
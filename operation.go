@@ -0,0 +1,108 @@
+package chioas
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+)
+
+// OperationInfo is the information about an operation (i.e. a method on a path)
+//
+// It is passed to Definition.OperationMiddleware (so that middlewares can be built generically from the spec metadata) and
+// is also injected into the request context by Definition.SetupRoutes - see OperationFromContext and OperationFromRequest
+type OperationInfo struct {
+	// Path is the path template of the operation (e.g. "/people/{id}")
+	Path string
+	// Method is the http method of the operation
+	Method string
+	// OperationId is the OAS operation id (as would appear in the spec - i.e. taking into account any DocOptions.OperationIdentifier)
+	OperationId string
+	// Tags is the OAS tags of the operation (i.e. the Method.Tag or, if empty, the nearest ancestor Path.Tag)
+	Tags []string
+	// Security is the effective security for the operation (i.e. the Method.Security or, if empty, the Definition.Security)
+	Security SecuritySchemes
+	// OptionalSecurity is the Method.OptionalSecurity
+	OptionalSecurity bool
+	// Extensions is the Method.Extensions
+	Extensions Extensions
+	// MethodDef is the Method definition of the operation
+	//
+	// (for automatically added HEAD methods, this is the GET method definition)
+	MethodDef *Method
+	// PathDef is the Path definition of the operation (nil for methods on the api root)
+	PathDef *Path
+}
+
+type operationContextKey struct{}
+
+// OperationFromContext returns the OperationInfo (as injected by Definition.SetupRoutes) for the operation matched by the request
+//
+// Note: the OperationInfo is only available to the handler, Method.Middlewares and Definition.OperationMiddleware - it is not
+// available to path or root middlewares (because these are called before the operation has been matched)
+//
+// The returned OperationInfo is shared across requests and should not be modified
+func OperationFromContext(ctx context.Context) (*OperationInfo, bool) {
+	if ctx != nil {
+		if op, ok := ctx.Value(operationContextKey{}).(*OperationInfo); ok {
+			return op, true
+		}
+	}
+	return nil, false
+}
+
+// OperationFromRequest returns the OperationInfo (as injected by Definition.SetupRoutes) for the operation matched by the request
+//
+// (see OperationFromContext)
+func OperationFromRequest(r *http.Request) (*OperationInfo, bool) {
+	if r != nil {
+		return OperationFromContext(r.Context())
+	}
+	return nil, false
+}
+
+func injectOperation(op *OperationInfo) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), operationContextKey{}, op)))
+		})
+	}
+}
+
+// OperationMiddleware is a function that can be set on Definition.OperationMiddleware
+//
+// It is called (when routes are setup) for each operation - and the returned middleware is applied to that operation only
+// (if the function returns nil, no middleware is applied to that operation)
+type OperationMiddleware func(op OperationInfo) func(http.Handler) http.Handler
+
+func (d *Definition) operationInfo(path string, pathDef *Path, method string, mDef Method, parentTag string) *OperationInfo {
+	result := &OperationInfo{
+		Path:             path,
+		Method:           method,
+		OperationId:      mDef.getOperationIdForPath(&d.DocOptions, method, path, parentTag),
+		Security:         mDef.Security,
+		OptionalSecurity: mDef.OptionalSecurity,
+		Extensions:       mDef.Extensions,
+		MethodDef:        &mDef,
+		PathDef:          pathDef,
+	}
+	if tag := defaultTag(parentTag, mDef.Tag); tag != "" {
+		result.Tags = []string{tag}
+	}
+	if len(result.Security) == 0 {
+		result.Security = d.Security
+	}
+	return result
+}
+
+func (d *Definition) methodHandler(path string, pathDef *Path, method string, mDef Method, parentTag string, h http.HandlerFunc) http.Handler {
+	op := d.operationInfo(path, pathDef, method, mDef, parentTag)
+	middlewares := make(chi.Middlewares, 0, len(mDef.Middlewares)+2)
+	middlewares = append(middlewares, injectOperation(op))
+	if d.OperationMiddleware != nil {
+		if mw := d.OperationMiddleware(*op); mw != nil {
+			middlewares = append(middlewares, mw)
+		}
+	}
+	middlewares = append(middlewares, mDef.Middlewares...)
+	return middlewares.HandlerFunc(h)
+}
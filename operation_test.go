@@ -0,0 +1,266 @@
+package chioas
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDefinition_Method_Middlewares(t *testing.T) {
+	d := Definition{
+		Paths: Paths{
+			"/foo": {
+				Methods: Methods{
+					http.MethodGet: {
+						Handler: func(writer http.ResponseWriter, request *http.Request) {},
+					},
+					http.MethodPost: {
+						Handler:     func(writer http.ResponseWriter, request *http.Request) {},
+						Middlewares: chi.Middlewares{testUnAuthPostMiddleware},
+					},
+				},
+			},
+		},
+	}
+	router := chi.NewRouter()
+	err := d.SetupRoutes(router, nil)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, "/foo", nil)
+	assert.NoError(t, err)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	req, err = http.NewRequest(http.MethodPost, "/foo", nil)
+	assert.NoError(t, err)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusUnauthorized, res.Result().StatusCode)
+}
+
+func TestDefinition_OperationMiddleware(t *testing.T) {
+	collected := map[string]OperationInfo{}
+	d := Definition{
+		DocOptions: DocOptions{
+			OperationIdentifier: func(method Method, methodName string, path string, parentTag string) string {
+				if method.OperationId == "" {
+					return methodName + ":" + path
+				}
+				return ""
+			},
+		},
+		AutoHeadMethods: true,
+		Security: SecuritySchemes{
+			{Name: "root"},
+		},
+		Methods: Methods{
+			http.MethodGet: {
+				Handler: func(writer http.ResponseWriter, request *http.Request) {},
+			},
+		},
+		Paths: Paths{
+			"/foo": {
+				Tag: "Foo",
+				Methods: Methods{
+					http.MethodGet: {
+						Handler:     func(writer http.ResponseWriter, request *http.Request) {},
+						OperationId: "getFoo",
+						Extensions:  Extensions{"x-rate-limit": 10},
+					},
+					http.MethodPost: {
+						Handler:          func(writer http.ResponseWriter, request *http.Request) {},
+						Tag:              "Bar",
+						Security:         SecuritySchemes{{Name: "post"}},
+						OptionalSecurity: true,
+					},
+				},
+				Paths: Paths{
+					"/{id}": {
+						Methods: Methods{
+							http.MethodDelete: {
+								Handler: func(writer http.ResponseWriter, request *http.Request) {},
+							},
+						},
+					},
+				},
+			},
+		},
+		OperationMiddleware: func(op OperationInfo) func(http.Handler) http.Handler {
+			collected[op.Method+" "+op.Path] = op
+			if op.Method != http.MethodPost {
+				return nil
+			}
+			return func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if op.Security[0].Name == "post" {
+						w.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+					next.ServeHTTP(w, r)
+				})
+			}
+		},
+	}
+	router := chi.NewRouter()
+	err := d.SetupRoutes(router, nil)
+	require.NoError(t, err)
+
+	assert.Len(t, collected, 6)
+	op := collected["GET /"]
+	assert.Equal(t, "GET:/", op.OperationId)
+	assert.Nil(t, op.Tags)
+	assert.Equal(t, "root", op.Security[0].Name)
+	op = collected["HEAD /"]
+	assert.Equal(t, "HEAD:/", op.OperationId)
+	op = collected["GET /foo"]
+	assert.Equal(t, "getFoo", op.OperationId)
+	assert.Equal(t, []string{"Foo"}, op.Tags)
+	assert.Equal(t, 10, op.Extensions["x-rate-limit"])
+	assert.Equal(t, "root", op.Security[0].Name)
+	op = collected["HEAD /foo"]
+	assert.Equal(t, "getFoo", op.OperationId)
+	op = collected["POST /foo"]
+	assert.Equal(t, []string{"Bar"}, op.Tags)
+	assert.Equal(t, "post", op.Security[0].Name)
+	assert.True(t, op.OptionalSecurity)
+	op = collected["DELETE /foo/{id}"]
+	assert.Equal(t, "DELETE:/foo/{id}", op.OperationId)
+	assert.Equal(t, []string{"Foo"}, op.Tags)
+
+	req, err := http.NewRequest(http.MethodGet, "/foo", nil)
+	assert.NoError(t, err)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	req, err = http.NewRequest(http.MethodPost, "/foo", nil)
+	assert.NoError(t, err)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusUnauthorized, res.Result().StatusCode)
+}
+
+func TestDefinition_OperationMiddleware_Order(t *testing.T) {
+	order := make([]string, 0)
+	mw := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	d := Definition{
+		Middlewares: chi.Middlewares{mw("root")},
+		Paths: Paths{
+			"/foo": {
+				Middlewares: chi.Middlewares{mw("path")},
+				Methods: Methods{
+					http.MethodGet: {
+						Handler: func(writer http.ResponseWriter, request *http.Request) {
+							order = append(order, "handler")
+						},
+						Middlewares: chi.Middlewares{mw("method1"), mw("method2")},
+					},
+				},
+			},
+		},
+		OperationMiddleware: func(op OperationInfo) func(http.Handler) http.Handler {
+			return mw("operation")
+		},
+	}
+	router := chi.NewRouter()
+	err := d.SetupRoutes(router, nil)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, "/foo", nil)
+	assert.NoError(t, err)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.Equal(t, []string{"root", "path", "operation", "method1", "method2", "handler"}, order)
+}
+
+func TestDefinition_SetupRoutes_InjectsOperation(t *testing.T) {
+	var handlerOp *OperationInfo
+	var pathMiddlewareSaw bool
+	var methodMiddlewareOp *OperationInfo
+	d := Definition{
+		Methods: Methods{
+			http.MethodGet: {
+				Handler: func(writer http.ResponseWriter, request *http.Request) {
+					handlerOp, _ = OperationFromRequest(request)
+				},
+			},
+		},
+		Paths: Paths{
+			"/foo": {
+				Tag: "Foo",
+				Middlewares: chi.Middlewares{func(next http.Handler) http.Handler {
+					return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						_, pathMiddlewareSaw = OperationFromRequest(r)
+						next.ServeHTTP(w, r)
+					})
+				}},
+				Methods: Methods{
+					http.MethodGet: {
+						OperationId: "getFoo",
+						Handler: func(writer http.ResponseWriter, request *http.Request) {
+							handlerOp, _ = OperationFromContext(request.Context())
+						},
+						Middlewares: chi.Middlewares{func(next http.Handler) http.Handler {
+							return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+								methodMiddlewareOp, _ = OperationFromRequest(r)
+								next.ServeHTTP(w, r)
+							})
+						}},
+					},
+				},
+			},
+		},
+	}
+	router := chi.NewRouter()
+	err := d.SetupRoutes(router, nil)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, "/foo", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	require.NotNil(t, handlerOp)
+	assert.Equal(t, "/foo", handlerOp.Path)
+	assert.Equal(t, http.MethodGet, handlerOp.Method)
+	assert.Equal(t, "getFoo", handlerOp.OperationId)
+	assert.Equal(t, []string{"Foo"}, handlerOp.Tags)
+	require.NotNil(t, handlerOp.MethodDef)
+	assert.Equal(t, "getFoo", handlerOp.MethodDef.OperationId)
+	require.NotNil(t, handlerOp.PathDef)
+	assert.Equal(t, "Foo", handlerOp.PathDef.Tag)
+	assert.Same(t, handlerOp, methodMiddlewareOp)
+	assert.False(t, pathMiddlewareSaw)
+
+	req, err = http.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(t, err)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	require.NotNil(t, handlerOp)
+	assert.Equal(t, "/", handlerOp.Path)
+	assert.Nil(t, handlerOp.PathDef)
+}
+
+func TestOperationFromContext(t *testing.T) {
+	op, ok := OperationFromContext(nil)
+	assert.False(t, ok)
+	assert.Nil(t, op)
+	op, ok = OperationFromContext(context.Background())
+	assert.False(t, ok)
+	assert.Nil(t, op)
+	op, ok = OperationFromRequest(nil)
+	assert.False(t, ok)
+	assert.Nil(t, op)
+}
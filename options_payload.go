@@ -24,7 +24,7 @@ func (r *rootOptionsPayload) BuildPayload(path string, pathDef *Path, def *Defin
 			headers[hdrContentType] = contentTypeYaml
 		}
 		if def.DocOptions.specData != nil {
-			data = def.DocOptions.specData
+			data, _ = def.applyOverlays(def.DocOptions.specData)
 		} else if def.DocOptions.AsJson {
 			data, _ = def.AsJson()
 		} else {
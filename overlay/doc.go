@@ -0,0 +1,3 @@
+// Package overlay provides support for OpenAPI Overlays (see https://spec.openapis.org/overlay/v1.0.0.html) - applying
+// update/remove actions, targeted by JSONPath expressions, to OAS yaml/json or to a chioas.Definition
+package overlay
@@ -0,0 +1,611 @@
+package overlay
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// jsonPath is a compiled JSONPath query (see RFC 9535) - supporting:
+//
+//	$                     root
+//	.name ['name']        child member
+//	[0] [-1]              array index
+//	[start:end:step]      array slice
+//	.* [*]                wildcard
+//	..name ..* ..[...]    descendants
+//	[?<expr>]             filter - comparisons (== != < <= > >=), existence, !, && and || over @ (or $) queries and literals
+//	['a','b',0]           multiple selectors
+type jsonPath struct {
+	segments []segment
+}
+
+type segment struct {
+	descendant bool
+	selectors  []selector
+}
+
+// match is a node selected by a query - along with its parent (nil for the root) and index within parent.Content
+type match struct {
+	node   *yaml.Node
+	parent *yaml.Node
+	index  int
+}
+
+type selector interface {
+	selectFrom(m match, root *yaml.Node, out []match) []match
+}
+
+func compilePath(path string) (*jsonPath, error) {
+	p := &pathParser{s: path}
+	p.skipSpace()
+	if !p.consume('$') {
+		return nil, fmt.Errorf("invalid JSONPath '%s' - must start with '$'", path)
+	}
+	segments, err := p.parseSegments()
+	if err == nil {
+		p.skipSpace()
+		if !p.eof() {
+			err = p.errorf("unexpected '%c'", p.s[p.pos])
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &jsonPath{segments: segments}, nil
+}
+
+func (jp *jsonPath) find(root *yaml.Node) []match {
+	return jp.findFrom(match{node: root}, root)
+}
+
+func (jp *jsonPath) findFrom(start match, root *yaml.Node) []match {
+	current := []match{start}
+	for _, seg := range jp.segments {
+		next := make([]match, 0)
+		for _, m := range current {
+			if seg.descendant {
+				descend(m, func(dm match) {
+					for _, sel := range seg.selectors {
+						next = sel.selectFrom(dm, root, next)
+					}
+				})
+			} else {
+				for _, sel := range seg.selectors {
+					next = sel.selectFrom(m, root, next)
+				}
+			}
+		}
+		current = next
+	}
+	return current
+}
+
+func descend(m match, fn func(match)) {
+	fn(m)
+	for _, c := range children(m.node) {
+		descend(c, fn)
+	}
+}
+
+func children(n *yaml.Node) []match {
+	result := make([]match, 0)
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 1; i < len(n.Content); i += 2 {
+			result = append(result, match{node: n.Content[i], parent: n, index: i})
+		}
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			result = append(result, match{node: c, parent: n, index: i})
+		}
+	}
+	return result
+}
+
+type nameSelector string
+
+func (s nameSelector) selectFrom(m match, _ *yaml.Node, out []match) []match {
+	if m.node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(m.node.Content); i += 2 {
+			if m.node.Content[i].Value == string(s) {
+				out = append(out, match{node: m.node.Content[i+1], parent: m.node, index: i + 1})
+			}
+		}
+	}
+	return out
+}
+
+type wildcardSelector struct{}
+
+func (s wildcardSelector) selectFrom(m match, _ *yaml.Node, out []match) []match {
+	return append(out, children(m.node)...)
+}
+
+type indexSelector int
+
+func (s indexSelector) selectFrom(m match, _ *yaml.Node, out []match) []match {
+	if m.node.Kind == yaml.SequenceNode {
+		i := int(s)
+		if i < 0 {
+			i += len(m.node.Content)
+		}
+		if i >= 0 && i < len(m.node.Content) {
+			out = append(out, match{node: m.node.Content[i], parent: m.node, index: i})
+		}
+	}
+	return out
+}
+
+type sliceSelector struct {
+	start, end *int
+	step       int
+}
+
+func (s sliceSelector) selectFrom(m match, _ *yaml.Node, out []match) []match {
+	if m.node.Kind != yaml.SequenceNode || s.step == 0 {
+		return out
+	}
+	l := len(m.node.Content)
+	normalize := func(v *int, def int) int {
+		if v == nil {
+			return def
+		}
+		if *v < 0 {
+			return *v + l
+		}
+		return *v
+	}
+	if s.step > 0 {
+		start, end := max(normalize(s.start, 0), 0), min(normalize(s.end, l), l)
+		for i := start; i < end; i += s.step {
+			out = append(out, match{node: m.node.Content[i], parent: m.node, index: i})
+		}
+	} else {
+		start, end := min(normalize(s.start, l-1), l-1), max(normalize(s.end, -l-1), -1)
+		for i := start; i > end; i += s.step {
+			out = append(out, match{node: m.node.Content[i], parent: m.node, index: i})
+		}
+	}
+	return out
+}
+
+type filterSelector struct {
+	expr filterExpr
+}
+
+func (s filterSelector) selectFrom(m match, root *yaml.Node, out []match) []match {
+	for _, c := range children(m.node) {
+		if s.expr.eval(c, root) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+type filterExpr interface {
+	eval(current match, root *yaml.Node) bool
+}
+
+type orExpr []filterExpr
+
+func (e orExpr) eval(current match, root *yaml.Node) bool {
+	for _, sub := range e {
+		if sub.eval(current, root) {
+			return true
+		}
+	}
+	return false
+}
+
+type andExpr []filterExpr
+
+func (e andExpr) eval(current match, root *yaml.Node) bool {
+	for _, sub := range e {
+		if !sub.eval(current, root) {
+			return false
+		}
+	}
+	return true
+}
+
+type notExpr struct {
+	expr filterExpr
+}
+
+func (e notExpr) eval(current match, root *yaml.Node) bool {
+	return !e.expr.eval(current, root)
+}
+
+type existsExpr struct {
+	query *queryOperand
+}
+
+func (e existsExpr) eval(current match, root *yaml.Node) bool {
+	return len(e.query.find(current, root)) > 0
+}
+
+type compareExpr struct {
+	left, right operand
+	op          string
+}
+
+func (e compareExpr) eval(current match, root *yaml.Node) bool {
+	lv, lok := e.left.value(current, root)
+	rv, rok := e.right.value(current, root)
+	switch e.op {
+	case "==":
+		return lok == rok && (!lok || reflect.DeepEqual(lv, rv))
+	case "!=":
+		return lok != rok || (lok && !reflect.DeepEqual(lv, rv))
+	}
+	if !lok || !rok {
+		return false
+	}
+	var c int
+	switch lt := lv.(type) {
+	case float64:
+		rt, ok := rv.(float64)
+		if !ok {
+			return false
+		}
+		c = compareOrdered(lt, rt)
+	case string:
+		rt, ok := rv.(string)
+		if !ok {
+			return false
+		}
+		c = compareOrdered(lt, rt)
+	default:
+		return false
+	}
+	switch e.op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+func compareOrdered[T float64 | string](a, b T) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
+
+type operand interface {
+	value(current match, root *yaml.Node) (any, bool)
+}
+
+type literalOperand struct {
+	v any
+}
+
+func (o literalOperand) value(_ match, _ *yaml.Node) (any, bool) {
+	return o.v, true
+}
+
+type queryOperand struct {
+	fromRoot bool
+	path     *jsonPath
+}
+
+func (o *queryOperand) find(current match, root *yaml.Node) []match {
+	if o.fromRoot {
+		return o.path.find(root)
+	}
+	return o.path.findFrom(current, root)
+}
+
+func (o *queryOperand) value(current match, root *yaml.Node) (any, bool) {
+	if found := o.find(current, root); len(found) == 1 {
+		var v any
+		if err := found[0].node.Decode(&v); err == nil {
+			return normalizeValue(v), true
+		}
+	}
+	return nil, false
+}
+
+func normalizeValue(v any) any {
+	switch vt := v.(type) {
+	case int:
+		return float64(vt)
+	case int64:
+		return float64(vt)
+	case uint64:
+		return float64(vt)
+	}
+	return v
+}
+
+type pathParser struct {
+	s   string
+	pos int
+}
+
+func (p *pathParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("invalid JSONPath '%s' at position %d - %s", p.s, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *pathParser) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *pathParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *pathParser) consume(b byte) bool {
+	if p.peek() == b {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *pathParser) consumeString(s string) bool {
+	if strings.HasPrefix(p.s[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *pathParser) skipSpace() {
+	for !p.eof() && strings.IndexByte(" \t\r\n", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *pathParser) parseSegments() (segments []segment, err error) {
+	segments = make([]segment, 0)
+	for !p.eof() && err == nil {
+		var seg segment
+		switch {
+		case p.consumeString(".."):
+			seg.descendant = true
+			if p.peek() == '[' {
+				seg.selectors, err = p.parseBracket()
+			} else {
+				seg.selectors, err = p.parseDotSelector()
+			}
+		case p.consume('.'):
+			seg.selectors, err = p.parseDotSelector()
+		case p.peek() == '[':
+			seg.selectors, err = p.parseBracket()
+		default:
+			return segments, nil
+		}
+		segments = append(segments, seg)
+	}
+	return segments, err
+}
+
+func (p *pathParser) parseDotSelector() ([]selector, error) {
+	if p.consume('*') {
+		return []selector{wildcardSelector{}}, nil
+	}
+	start := p.pos
+	for !p.eof() && strings.IndexByte(".[]()=!<>&|,'\" \t\r\n", p.s[p.pos]) < 0 {
+		p.pos++
+	}
+	if p.pos == start {
+		return nil, p.errorf("expected member name")
+	}
+	return []selector{nameSelector(p.s[start:p.pos])}, nil
+}
+
+func (p *pathParser) parseBracket() (selectors []selector, err error) {
+	p.pos++ // skip '['
+	for {
+		p.skipSpace()
+		var sel selector
+		if sel, err = p.parseSelector(); err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, sel)
+		p.skipSpace()
+		if p.consume(']') {
+			return selectors, nil
+		} else if !p.consume(',') {
+			return nil, p.errorf("expected ',' or ']'")
+		}
+	}
+}
+
+func (p *pathParser) parseSelector() (selector, error) {
+	switch c := p.peek(); {
+	case c == '\'' || c == '"':
+		s, err := p.parseStringLiteral()
+		return nameSelector(s), err
+	case c == '*':
+		p.pos++
+		return wildcardSelector{}, nil
+	case c == '?':
+		p.pos++
+		expr, err := p.parseOr()
+		return filterSelector{expr: expr}, err
+	case c == '-' || c == ':' || (c >= '0' && c <= '9'):
+		return p.parseIndexOrSlice()
+	}
+	return nil, p.errorf("invalid selector")
+}
+
+func (p *pathParser) parseIndexOrSlice() (selector, error) {
+	var parts [3]*int
+	for i := 0; i < 3; i++ {
+		p.skipSpace()
+		if c := p.peek(); c == '-' || (c >= '0' && c <= '9') {
+			start := p.pos
+			p.pos++
+			for c = p.peek(); c >= '0' && c <= '9'; c = p.peek() {
+				p.pos++
+			}
+			v, err := strconv.Atoi(p.s[start:p.pos])
+			if err != nil {
+				return nil, p.errorf("invalid integer '%s'", p.s[start:p.pos])
+			}
+			parts[i] = &v
+		}
+		p.skipSpace()
+		if i == 0 && p.peek() != ':' {
+			if parts[0] == nil {
+				return nil, p.errorf("expected integer")
+			}
+			return indexSelector(*parts[0]), nil
+		}
+		if i == 2 || !p.consume(':') {
+			break
+		}
+	}
+	step := 1
+	if parts[2] != nil {
+		step = *parts[2]
+	}
+	return sliceSelector{start: parts[0], end: parts[1], step: step}, nil
+}
+
+func (p *pathParser) parseStringLiteral() (string, error) {
+	quote := p.s[p.pos]
+	p.pos++
+	var b strings.Builder
+	for !p.eof() {
+		c := p.s[p.pos]
+		p.pos++
+		switch {
+		case c == quote:
+			return b.String(), nil
+		case c == '\\' && !p.eof():
+			e := p.s[p.pos]
+			p.pos++
+			switch e {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			default:
+				b.WriteByte(e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *pathParser) parseOr() (filterExpr, error) {
+	exprs := make(orExpr, 0)
+	for {
+		expr, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+		p.skipSpace()
+		if !p.consumeString("||") {
+			break
+		}
+	}
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+	return exprs, nil
+}
+
+func (p *pathParser) parseAnd() (filterExpr, error) {
+	exprs := make(andExpr, 0)
+	for {
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+		p.skipSpace()
+		if !p.consumeString("&&") {
+			break
+		}
+	}
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+	return exprs, nil
+}
+
+func (p *pathParser) parseUnary() (filterExpr, error) {
+	p.skipSpace()
+	if p.peek() == '!' && !strings.HasPrefix(p.s[p.pos:], "!=") {
+		p.pos++
+		expr, err := p.parseUnary()
+		return notExpr{expr: expr}, err
+	} else if p.consume('(') {
+		expr, err := p.parseOr()
+		if err == nil {
+			p.skipSpace()
+			if !p.consume(')') {
+				err = p.errorf("expected ')'")
+			}
+		}
+		return expr, err
+	}
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.consumeString(op) {
+			p.skipSpace()
+			right, err := p.parseOperand()
+			return compareExpr{left: left, right: right, op: op}, err
+		}
+	}
+	if q, ok := left.(*queryOperand); ok {
+		return existsExpr{query: q}, nil
+	}
+	return nil, p.errorf("expected comparison")
+}
+
+func (p *pathParser) parseOperand() (operand, error) {
+	switch c := p.peek(); {
+	case c == '@' || c == '$':
+		p.pos++
+		segments, err := p.parseSegments()
+		return &queryOperand{fromRoot: c == '$', path: &jsonPath{segments: segments}}, err
+	case c == '\'' || c == '"':
+		s, err := p.parseStringLiteral()
+		return literalOperand{v: s}, err
+	case p.consumeString("true"):
+		return literalOperand{v: true}, nil
+	case p.consumeString("false"):
+		return literalOperand{v: false}, nil
+	case p.consumeString("null"):
+		return literalOperand{v: nil}, nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := p.pos
+		p.pos++
+		for !p.eof() && strings.IndexByte("0123456789.eE+-", p.s[p.pos]) >= 0 {
+			p.pos++
+		}
+		f, err := strconv.ParseFloat(p.s[start:p.pos], 64)
+		if err != nil {
+			return nil, p.errorf("invalid number '%s'", p.s[start:p.pos])
+		}
+		return literalOperand{v: f}, nil
+	}
+	return nil, p.errorf("expected query or literal")
+}
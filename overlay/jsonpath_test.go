@@ -0,0 +1,112 @@
+package overlay
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	"testing"
+)
+
+const testJsonPathDoc = `
+store:
+  name: "Pet Store"
+  pets:
+    - name: Felix
+      type: cat
+      age: 3
+      x-internal: true
+    - name: Rex
+      type: dog
+      age: 5
+    - name: Tom
+      type: cat
+      age: 8
+  owners:
+    alice:
+      name: Alice
+    bob:
+      name: Bob
+  limit: 5
+`
+
+func TestJsonPath_Find(t *testing.T) {
+	var doc yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(testJsonPathDoc), &doc))
+	root := doc.Content[0]
+	testCases := []struct {
+		path   string
+		expect []string
+	}{
+		{path: "$", expect: []string{"store"}},
+		{path: "$.store.name", expect: []string{"Pet Store"}},
+		{path: "$['store'][\"name\"]", expect: []string{"Pet Store"}},
+		{path: "$.store.pets[0].name", expect: []string{"Felix"}},
+		{path: "$.store.pets[-1].name", expect: []string{"Tom"}},
+		{path: "$.store.pets[5].name", expect: []string{}},
+		{path: "$.store.pets[*].name", expect: []string{"Felix", "Rex", "Tom"}},
+		{path: "$.store.pets.*.name", expect: []string{"Felix", "Rex", "Tom"}},
+		{path: "$.store.pets[0:2].name", expect: []string{"Felix", "Rex"}},
+		{path: "$.store.pets[1:].name", expect: []string{"Rex", "Tom"}},
+		{path: "$.store.pets[::-1].name", expect: []string{"Tom", "Rex", "Felix"}},
+		{path: "$.store.pets[0,2].name", expect: []string{"Felix", "Tom"}},
+		{path: "$.store.owners[*].name", expect: []string{"Alice", "Bob"}},
+		{path: "$..name", expect: []string{"Pet Store", "Felix", "Rex", "Tom", "Alice", "Bob"}},
+		{path: "$.store..[?@.type == 'cat'].name", expect: []string{"Felix", "Tom"}},
+		{path: "$.store.pets[?(@.type == \"dog\")].name", expect: []string{"Rex"}},
+		{path: "$.store.pets[?@.type != 'cat'].name", expect: []string{"Rex"}},
+		{path: "$.store.pets[?@.age > 3].name", expect: []string{"Rex", "Tom"}},
+		{path: "$.store.pets[?@.age >= 5 && @.type == 'cat'].name", expect: []string{"Tom"}},
+		{path: "$.store.pets[?@.age < 4 || @.type == 'dog'].name", expect: []string{"Felix", "Rex"}},
+		{path: "$.store.pets[?@.age <= $.store.limit].name", expect: []string{"Felix", "Rex"}},
+		{path: "$.store.pets[?@.x-internal].name", expect: []string{"Felix"}},
+		{path: "$.store.pets[?!@.x-internal].name", expect: []string{"Rex", "Tom"}},
+		{path: "$.store.pets[?@['x-internal'] == true].name", expect: []string{"Felix"}},
+		{path: "$.store.pets[?(@.name < 'S')].name", expect: []string{"Felix", "Rex"}},
+		{path: "$.store.pets[?@.missing == null].name", expect: []string{}},
+		{path: "$.store.pets[?@.missing != null].name", expect: []string{"Felix", "Rex", "Tom"}},
+		{path: "$.store.owners[?@.name == 'Bob']", expect: []string{"Bob"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			jp, err := compilePath(tc.path)
+			require.NoError(t, err)
+			found := jp.find(root)
+			values := make([]string, 0, len(found))
+			for _, m := range found {
+				switch m.node.Kind {
+				case yaml.ScalarNode:
+					values = append(values, m.node.Value)
+				case yaml.MappingNode:
+					values = append(values, m.node.Content[0].Value)
+					if m.node.Content[0].Value == "name" {
+						values[len(values)-1] = m.node.Content[1].Value
+					}
+				}
+			}
+			assert.Equal(t, tc.expect, values)
+		})
+	}
+}
+
+func TestCompilePath_Errors(t *testing.T) {
+	testCases := []string{
+		"",
+		"store",
+		"$.",
+		"$[",
+		"$['unterminated",
+		"$[foo]",
+		"$[0 1]",
+		"$[?@.a ==]",
+		"$[?(@.a == 1]",
+		"$[?1]",
+		"$[?@.a == 1e]",
+		"$.a)",
+	}
+	for _, tc := range testCases {
+		t.Run(tc, func(t *testing.T) {
+			_, err := compilePath(tc)
+			assert.Error(t, err)
+		})
+	}
+}
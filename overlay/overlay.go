@@ -0,0 +1,306 @@
+package overlay
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/go-andiamo/chioas"
+	"gopkg.in/yaml.v3"
+	"io"
+	k8yaml "sigs.k8s.io/yaml"
+	"slices"
+	"strings"
+)
+
+// Overlay is an OpenAPI Overlay document (see https://spec.openapis.org/overlay/v1.0.0.html)
+//
+// An Overlay can be applied to OAS yaml/json (see Overlay.Apply) or to a chioas.Definition (see Overlay.ApplyToDefinition) - or
+// can be set on chioas.DocOptions.Overlays so that it is applied to the spec served by the definition
+type Overlay struct {
+	// Overlay is the version of the Overlay spec (e.g. "1.0.0")
+	Overlay string `yaml:"overlay" json:"overlay"`
+	// Info is the overlay metadata
+	Info Info `yaml:"info" json:"info"`
+	// Extends is the optional URL of the spec the overlay is meant to be applied to
+	Extends string `yaml:"extends,omitempty" json:"extends,omitempty"`
+	// Actions is the actions to be applied - in order
+	Actions []Action `yaml:"actions" json:"actions"`
+}
+
+// Info is the metadata of an Overlay
+type Info struct {
+	Title   string `yaml:"title" json:"title"`
+	Version string `yaml:"version" json:"version"`
+}
+
+// Action is a single overlay action - either an update or a remove of the nodes selected by Target
+type Action struct {
+	// Target is the JSONPath expression selecting the nodes to be updated or removed (e.g. "$.paths['/pets'].get")
+	Target string `yaml:"target" json:"target"`
+	// Description is the optional description of the action
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	// Update is the value to be applied to each selected node
+	//
+	// objects are merged into selected objects (recursively - replacing existing properties), values are appended to selected
+	// arrays and selected primitive values are replaced
+	//
+	// can be any value that can be marshalled to yaml (or a *yaml.Node)
+	Update any `yaml:"update,omitempty" json:"update,omitempty"`
+	// Remove when set to true, the selected nodes are removed from their parent object or array
+	Remove bool `yaml:"remove,omitempty" json:"remove,omitempty"`
+}
+
+// Parse reads an Overlay document (yaml or json)
+//
+// the overlay is validated (see Overlay.Validate)
+func Parse(r io.Reader) (*Overlay, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	raw := struct {
+		Overlay string `yaml:"overlay"`
+		Info    Info   `yaml:"info"`
+		Extends string `yaml:"extends"`
+		Actions []struct {
+			Target      string    `yaml:"target"`
+			Description string    `yaml:"description"`
+			Update      yaml.Node `yaml:"update"`
+			Remove      bool      `yaml:"remove"`
+		} `yaml:"actions"`
+	}{}
+	if err = yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	result := &Overlay{
+		Overlay: raw.Overlay,
+		Info:    raw.Info,
+		Extends: raw.Extends,
+		Actions: make([]Action, 0, len(raw.Actions)),
+	}
+	for _, a := range raw.Actions {
+		action := Action{
+			Target:      a.Target,
+			Description: a.Description,
+			Remove:      a.Remove,
+		}
+		if a.Update.Kind != 0 {
+			update := a.Update
+			action.Update = &update
+		}
+		result.Actions = append(result.Actions, action)
+	}
+	return result, result.Validate()
+}
+
+// Validate checks that the overlay is valid - i.e. has a supported version, at least one action and each action has a valid
+// target and either an update or a remove
+func (o *Overlay) Validate() error {
+	errs := make([]error, 0)
+	if o.Overlay == "" {
+		errs = append(errs, errors.New("overlay version must be specified"))
+	} else if !strings.HasPrefix(o.Overlay, "1.") {
+		errs = append(errs, fmt.Errorf("unsupported overlay version '%s'", o.Overlay))
+	}
+	if len(o.Actions) == 0 {
+		errs = append(errs, errors.New("overlay must have at least one action"))
+	}
+	for i, a := range o.Actions {
+		if a.Target == "" {
+			errs = append(errs, fmt.Errorf("action[%d] - target must be specified", i))
+		} else if _, err := compilePath(a.Target); err != nil {
+			errs = append(errs, fmt.Errorf("action[%d] - %w", i, err))
+		}
+		if a.Remove == (a.Update != nil) {
+			errs = append(errs, fmt.Errorf("action[%d] - must have either update or remove", i))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Apply applies the overlay to the supplied OAS spec data (yaml or json)
+//
+// the result is in the same format as the supplied spec data
+func (o *Overlay) Apply(spec []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(spec, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, errors.New("empty spec")
+	}
+	if err := o.ApplyNode(doc.Content[0]); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	_ = enc.Close()
+	if isJson(spec) {
+		return k8yaml.YAMLToJSON(buf.Bytes())
+	}
+	return buf.Bytes(), nil
+}
+
+// ApplyToDefinition applies the overlay to the spec of the supplied definition and returns the resulting definition
+//
+// Note: the resulting definition is built from the spec - so has no handlers, middlewares etc. (to apply an overlay to the spec
+// served by a definition, use chioas.DocOptions.Overlays)
+func (o *Overlay) ApplyToDefinition(def *chioas.Definition) (*chioas.Definition, error) {
+	data, err := def.AsYaml()
+	if err == nil {
+		data, err = o.Apply(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := &chioas.Definition{}
+	if err = yaml.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ApplyNode applies the overlay to the supplied yaml node (the root node of an OAS spec)
+func (o *Overlay) ApplyNode(root *yaml.Node) error {
+	for i, a := range o.Actions {
+		if err := a.apply(root); err != nil {
+			return fmt.Errorf("action[%d] - %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (a Action) apply(root *yaml.Node) error {
+	jp, err := compilePath(a.Target)
+	if err != nil {
+		return err
+	}
+	matches := uniqueMatches(jp.find(root))
+	if a.Remove {
+		return removeMatches(a.Target, matches)
+	}
+	if a.Update == nil {
+		return errors.New("must have either update or remove")
+	}
+	update, err := toNode(a.Update)
+	if err != nil {
+		return err
+	}
+	for _, m := range matches {
+		if err = mergeNode(m.node, update); err != nil {
+			return fmt.Errorf("target '%s' - %w", a.Target, err)
+		}
+	}
+	return nil
+}
+
+func uniqueMatches(matches []match) []match {
+	seen := map[*yaml.Node]bool{}
+	result := make([]match, 0, len(matches))
+	for _, m := range matches {
+		if !seen[m.node] {
+			seen[m.node] = true
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+func removeMatches(target string, matches []match) error {
+	// remove in reverse index order (per parent) so that indices of remaining matches are unaffected...
+	slices.SortStableFunc(matches, func(a, b match) int {
+		return b.index - a.index
+	})
+	for _, m := range matches {
+		if m.parent == nil {
+			return fmt.Errorf("target '%s' - cannot remove root", target)
+		}
+	}
+	for _, m := range matches {
+		if m.parent.Kind == yaml.MappingNode {
+			m.parent.Content = slices.Delete(m.parent.Content, m.index-1, m.index+1)
+		} else {
+			m.parent.Content = slices.Delete(m.parent.Content, m.index, m.index+1)
+		}
+	}
+	return nil
+}
+
+func toNode(v any) (*yaml.Node, error) {
+	switch vt := v.(type) {
+	case *yaml.Node:
+		return vt, nil
+	case yaml.Node:
+		return &vt, nil
+	}
+	n := &yaml.Node{}
+	if err := n.Encode(v); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func mergeNode(target *yaml.Node, update *yaml.Node) error {
+	if update.Kind == yaml.DocumentNode && len(update.Content) > 0 {
+		update = update.Content[0]
+	}
+	switch target.Kind {
+	case yaml.MappingNode:
+		if update.Kind != yaml.MappingNode {
+			return errors.New("update must be an object to update an object")
+		}
+		for i := 0; i+1 < len(update.Content); i += 2 {
+			key, value := update.Content[i], update.Content[i+1]
+			if idx := mappingIndex(target, key.Value); idx != -1 {
+				if existing := target.Content[idx]; existing.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode {
+					if err := mergeNode(existing, value); err != nil {
+						return err
+					}
+				} else {
+					target.Content[idx] = copyNode(value)
+				}
+			} else {
+				target.Content = append(target.Content, copyNode(key), copyNode(value))
+			}
+		}
+	case yaml.SequenceNode:
+		if update.Kind == yaml.SequenceNode {
+			for _, c := range update.Content {
+				target.Content = append(target.Content, copyNode(c))
+			}
+		} else {
+			target.Content = append(target.Content, copyNode(update))
+		}
+	default:
+		*target = *copyNode(update)
+	}
+	return nil
+}
+
+func mappingIndex(n *yaml.Node, key string) int {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return i + 1
+		}
+	}
+	return -1
+}
+
+func copyNode(n *yaml.Node) *yaml.Node {
+	result := *n
+	if n.Content != nil {
+		result.Content = make([]*yaml.Node, len(n.Content))
+		for i, c := range n.Content {
+			result.Content[i] = copyNode(c)
+		}
+	}
+	return &result
+}
+
+func isJson(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("{"))
+}
@@ -0,0 +1,274 @@
+package overlay
+
+import (
+	"bytes"
+	"github.com/go-andiamo/chioas"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testOverlay = `overlay: 1.0.0
+info:
+  title: Production adjustments
+  version: 1.0.0
+actions:
+  - target: $.info
+    update:
+      title: Pets API (production)
+      x-audience: public
+  - target: $.servers
+    update:
+      url: https://api.example.com
+  - target: $.paths['/pets'].get.parameters[?@.name == 'debug']
+    remove: true
+  - target: $.paths.*[?@['x-internal'] == true]
+    remove: true
+  - target: $.paths['/pets'].get.summary
+    update: List all pets
+`
+
+const testSpec = `openapi: "3.0.3"
+info:
+  title: Pets API
+  version: "1.0.0"
+servers:
+  - url: http://localhost:8080
+paths:
+  "/pets":
+    get:
+      summary: List pets
+      parameters:
+        - name: debug
+          in: query
+        - name: limit
+          in: query
+    delete:
+      x-internal: true
+      responses:
+        204:
+          description: No Content
+`
+
+func TestParse(t *testing.T) {
+	o, err := Parse(strings.NewReader(testOverlay))
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", o.Overlay)
+	assert.Equal(t, "Production adjustments", o.Info.Title)
+	assert.Len(t, o.Actions, 5)
+	assert.NotNil(t, o.Actions[0].Update)
+	assert.False(t, o.Actions[0].Remove)
+	assert.Nil(t, o.Actions[2].Update)
+	assert.True(t, o.Actions[2].Remove)
+
+	// json...
+	o, err = Parse(strings.NewReader(`{"overlay": "1.0.0", "info": {"title": "t", "version": "1"}, "actions": [{"target": "$.info", "update": {"title": "x"}}]}`))
+	require.NoError(t, err)
+	assert.Len(t, o.Actions, 1)
+}
+
+func TestParse_Errors(t *testing.T) {
+	testCases := []struct {
+		data        string
+		expectError string
+	}{
+		{
+			data:        `[`,
+			expectError: "yaml",
+		},
+		{
+			data:        `info: {}`,
+			expectError: "overlay version must be specified\noverlay must have at least one action",
+		},
+		{
+			data:        `overlay: 2.0.0`,
+			expectError: "unsupported overlay version '2.0.0'",
+		},
+		{
+			data: `overlay: 1.0.0
+actions:
+  - update: {}
+  - target: foo
+    remove: true
+  - target: $.info
+  - target: $.info
+    remove: true
+    update: {}`,
+			expectError: "action[0] - target must be specified\n" +
+				"action[1] - invalid JSONPath 'foo' - must start with '$'\n" +
+				"action[2] - must have either update or remove\n" +
+				"action[3] - must have either update or remove",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expectError, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tc.data))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.expectError)
+		})
+	}
+}
+
+func TestOverlay_Apply(t *testing.T) {
+	o, err := Parse(strings.NewReader(testOverlay))
+	require.NoError(t, err)
+	data, err := o.Apply([]byte(testSpec))
+	require.NoError(t, err)
+	const expect = `openapi: "3.0.3"
+info:
+  title: Pets API (production)
+  version: "1.0.0"
+  x-audience: public
+servers:
+  - url: http://localhost:8080
+  - url: https://api.example.com
+paths:
+  "/pets":
+    get:
+      summary: List all pets
+      parameters:
+        - name: limit
+          in: query
+`
+	assert.Equal(t, expect, string(data))
+}
+
+func TestOverlay_Apply_Json(t *testing.T) {
+	o := &Overlay{
+		Overlay: "1.0.0",
+		Actions: []Action{
+			{Target: "$.info", Update: map[string]any{"title": "Updated", "contact": map[string]any{"name": "Bob"}}},
+			{Target: "$.info.contact", Update: map[string]any{"email": "bob@example.com"}},
+			{Target: "$.tags", Update: []any{map[string]any{"name": "b"}}},
+			{Target: "$.tags[0]", Remove: true},
+		},
+	}
+	data, err := o.Apply([]byte(`{"openapi": "3.0.3", "info": {"title": "Original"}, "tags": [{"name": "a"}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, `{"info":{"contact":{"email":"bob@example.com","name":"Bob"},"title":"Updated"},"openapi":"3.0.3","tags":[{"name":"b"}]}`, string(data))
+}
+
+func TestOverlay_Apply_Errors(t *testing.T) {
+	testCases := []struct {
+		spec        string
+		action      Action
+		expectError string
+	}{
+		{
+			spec:        `[`,
+			action:      Action{Target: "$", Remove: true},
+			expectError: "yaml",
+		},
+		{
+			spec:        ``,
+			action:      Action{Target: "$", Remove: true},
+			expectError: "empty spec",
+		},
+		{
+			spec:        `info: {}`,
+			action:      Action{Target: "$", Remove: true},
+			expectError: "action[0] - target '$' - cannot remove root",
+		},
+		{
+			spec:        `info: {}`,
+			action:      Action{Target: "$.info", Update: "foo"},
+			expectError: "action[0] - target '$.info' - update must be an object to update an object",
+		},
+		{
+			spec:        `info: {}`,
+			action:      Action{Target: "$.info"},
+			expectError: "action[0] - must have either update or remove",
+		},
+		{
+			spec:        `info: {}`,
+			action:      Action{Target: "info", Remove: true},
+			expectError: "action[0] - invalid JSONPath 'info' - must start with '$'",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expectError, func(t *testing.T) {
+			o := &Overlay{Overlay: "1.0.0", Actions: []Action{tc.action}}
+			_, err := o.Apply([]byte(tc.spec))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.expectError)
+		})
+	}
+}
+
+var testDefinition = chioas.Definition{
+	DocOptions: chioas.DocOptions{ServeDocs: true},
+	Info:       chioas.Info{Title: "Pets API"},
+	Paths: chioas.Paths{
+		"/pets": {
+			Methods: chioas.Methods{
+				http.MethodGet: {
+					Description: "List pets",
+					Handler: func(w http.ResponseWriter, r *http.Request) {
+						_, _ = w.Write([]byte("pets"))
+					},
+				},
+				http.MethodDelete: {
+					Description: "Delete all pets",
+					Handler: func(w http.ResponseWriter, r *http.Request) {
+						w.WriteHeader(http.StatusNoContent)
+					},
+				},
+			},
+		},
+	},
+}
+
+func TestOverlay_ApplyToDefinition(t *testing.T) {
+	o := &Overlay{
+		Overlay: "1.0.0",
+		Actions: []Action{
+			{Target: "$.paths['/pets'].delete", Remove: true},
+			{Target: "$.paths['/pets'].get", Update: map[string]any{"description": "List all pets"}},
+		},
+	}
+	def, err := o.ApplyToDefinition(&testDefinition)
+	require.NoError(t, err)
+	assert.Equal(t, "Pets API", def.Info.Title)
+	methods := def.Paths["/pets"].Methods
+	assert.Len(t, methods, 1)
+	assert.Equal(t, "List all pets", methods[http.MethodGet].Description)
+
+	o.Actions = append(o.Actions, Action{Target: "$.paths", Update: "foo"})
+	_, err = o.ApplyToDefinition(&testDefinition)
+	require.Error(t, err)
+}
+
+func TestOverlay_DocOptions(t *testing.T) {
+	o := &Overlay{
+		Overlay: "1.0.0",
+		Actions: []Action{
+			{Target: "$.paths['/pets'].delete", Remove: true},
+		},
+	}
+	def := testDefinition
+	def.DocOptions.Overlays = []chioas.SpecOverlay{o}
+	router := chi.NewRouter()
+	require.NoError(t, def.SetupRoutes(router, nil))
+
+	// removed from spec...
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/docs/spec.yaml", nil))
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Contains(t, res.Body.String(), "List pets")
+	assert.NotContains(t, res.Body.String(), "Delete all pets")
+	var buf bytes.Buffer
+	require.NoError(t, def.WriteYaml(&buf))
+	assert.Equal(t, res.Body.String(), buf.String())
+	buf.Reset()
+	require.NoError(t, def.WriteJson(&buf))
+	assert.NotContains(t, buf.String(), "Delete all pets")
+
+	// but routing unaffected...
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodDelete, "/pets", nil))
+	assert.Equal(t, http.StatusNoContent, res.Code)
+}
@@ -50,6 +50,9 @@ func (pp PathParam) writeYaml(name string, w yaml.Writer) {
 			WriteTagValue(tags.In, values.Path).
 			WriteTagValue(tags.Required, true).
 			WriteTagValue(tags.Example, pp.Example)
+		if pp.Example == nil {
+			writeGeneratedExample(pp.Schema, pp.SchemaRef, false, w)
+		}
 		w.WriteTagStart(tags.Schema)
 		if pp.Schema != nil {
 			pp.Schema.writeYaml(false, w)
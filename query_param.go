@@ -64,6 +64,9 @@ func (p QueryParam) writeYaml(w yaml.Writer) {
 			WriteTagValue(tags.In, defValue(p.In, values.Query)).
 			WriteTagValue(tags.Required, p.Required).
 			WriteTagValue(tags.Example, p.Example)
+		if p.Example == nil {
+			writeGeneratedExample(p.Schema, p.SchemaRef, false, w)
+		}
 		w.WriteTagStart(tags.Schema)
 		if p.Schema != nil {
 			p.Schema.writeYaml(false, w)
@@ -0,0 +1,240 @@
+package chioas
+
+import (
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// RouteDriftKind is the kind of a RouteDrift
+type RouteDriftKind int
+
+const (
+	// RouteUndocumented indicates a route registered on the router that is not in the Definition
+	RouteUndocumented RouteDriftKind = iota
+	// RouteHiddenReachable indicates an operation that is hidden from docs (see Path.HideDocs and Method.HideDocs)
+	// but is reachable on the router
+	RouteHiddenReachable
+	// RouteDisabledReachable indicates an operation on a disabled path (see Path.Disabled) that is reachable
+	// on the router (i.e. registered by other means)
+	RouteDisabledReachable
+	// RouteNotRegistered indicates an operation in the Definition that is not registered on the router
+	RouteNotRegistered
+)
+
+func (k RouteDriftKind) String() string {
+	switch k {
+	case RouteHiddenReachable:
+		return "hidden-reachable"
+	case RouteDisabledReachable:
+		return "disabled-reachable"
+	case RouteNotRegistered:
+		return "not-registered"
+	}
+	return "undocumented"
+}
+
+func (k RouteDriftKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// RouteDrift is a single difference between the routes registered on a router and the Definition (see Definition.CheckRoutes)
+type RouteDrift struct {
+	// Kind is the kind of drift
+	Kind RouteDriftKind `json:"kind"`
+	// Method is the http method
+	Method string `json:"method"`
+	// Path is the path (as registered on the router - or as in the Definition for RouteNotRegistered)
+	Path string `json:"path"`
+}
+
+func (d RouteDrift) String() string {
+	return d.Kind.String() + ": " + d.Method + " " + d.Path
+}
+
+// RouteDrifts is a collection of RouteDrift (as returned by Definition.CheckRoutes)
+type RouteDrifts []RouteDrift
+
+// Of returns only the drifts of the specified kinds
+func (ds RouteDrifts) Of(kinds ...RouteDriftKind) RouteDrifts {
+	result := make(RouteDrifts, 0, len(ds))
+	for _, d := range ds {
+		for _, k := range kinds {
+			if d.Kind == k {
+				result = append(result, d)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// CheckRoutesOptions is the options for Definition.CheckRoutes
+type CheckRoutesOptions struct {
+	// Prefix is the path prefix under which the Definition is mounted on the router (e.g. "/api")
+	//
+	// Routes outside the prefix are reported as RouteUndocumented
+	Prefix string
+	// IgnorePaths is an optional list of router paths to ignore - a path ending with "/*" ignores all paths under it (e.g. "/debug/*")
+	IgnorePaths []string
+	// IgnoreMethods is an optional list of http methods to ignore (e.g. http.MethodOptions)
+	IgnoreMethods []string
+	// IgnoreNotRegistered if set, operations in the Definition that are not registered on the router are not reported
+	IgnoreNotRegistered bool
+}
+
+// CheckRoutes walks the routes registered on the router (typically after Definition.SetupRoutes and any hand-registered
+// routes have been added) and reports any drift between the router and the Definition:
+//   - routes registered on the router that are not in the Definition (RouteUndocumented)
+//   - operations hidden from docs that are reachable (RouteHiddenReachable)
+//   - operations on disabled paths that are reachable (RouteDisabledReachable)
+//   - operations in the Definition that are not registered on the router (RouteNotRegistered)
+//
+// Routes are compared ignoring trailing slashes and path var names (so "/pets/{id}" matches "/pets/{petId}" - but
+// path var regexes must match).  Docs routes (see DocOptions.ServeDocs) and automatically added HEAD and OPTIONS methods
+// (see Definition.AutoHeadMethods and Definition.AutoOptionsMethods) are accounted for
+//
+// Drifts are always returned in a deterministic order (by path then method)
+func (d *Definition) CheckRoutes(router chi.Routes, opts *CheckRoutesOptions) RouteDrifts {
+	if opts == nil {
+		opts = &CheckRoutesOptions{}
+	}
+	prefix := strings.TrimSuffix(opts.Prefix, "/")
+	expected := map[string]*expectedRoute{}
+	d.expectRoutes(expected, prefix, false, false, root, d.Methods, d.RootAutoOptionsMethod)
+	d.expectPaths(expected, prefix, nil, false, false, d.Paths)
+	ignoredPaths := append([]string{}, opts.IgnorePaths...)
+	if d.DocOptions.ServeDocs {
+		ignoredPaths = append(ignoredPaths, prefix+defValue(d.DocOptions.Path, defaultDocsPath)+"/*")
+		for altPath := range d.DocOptions.AlternateUIDocs {
+			if !strings.HasPrefix(altPath, "/") {
+				altPath = "/" + altPath
+			}
+			ignoredPaths = append(ignoredPaths, prefix+altPath+"/*")
+		}
+	}
+	ignored := func(method string, path string) bool {
+		for _, m := range opts.IgnoreMethods {
+			if strings.EqualFold(m, method) {
+				return true
+			}
+		}
+		for _, ip := range ignoredPaths {
+			if under, ok := strings.CutSuffix(ip, "/*"); ok {
+				if under = routeKey(under); path == under || strings.HasPrefix(path, strings.TrimSuffix(under, "/")+"/") {
+					return true
+				}
+			} else if path == routeKey(ip) {
+				return true
+			}
+		}
+		return false
+	}
+	result := make(RouteDrifts, 0)
+	_ = chi.Walk(router, func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		key := routeKey(route)
+		if ignored(method, key) {
+			return nil
+		}
+		if er, ok := expected[method+" "+key]; !ok {
+			result = append(result, RouteDrift{Kind: RouteUndocumented, Method: method, Path: route})
+		} else {
+			er.registered = true
+			if er.disabled {
+				result = append(result, RouteDrift{Kind: RouteDisabledReachable, Method: method, Path: route})
+			} else if er.hidden {
+				result = append(result, RouteDrift{Kind: RouteHiddenReachable, Method: method, Path: route})
+			}
+		}
+		return nil
+	})
+	if !opts.IgnoreNotRegistered {
+		for _, er := range expected {
+			if !er.registered && !er.disabled && !ignored(er.method, routeKey(er.path)) {
+				result = append(result, RouteDrift{Kind: RouteNotRegistered, Method: er.method, Path: er.path})
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if pi, pj := routeKey(result[i].Path), routeKey(result[j].Path); pi != pj {
+			return pi < pj
+		}
+		if result[i].Method != result[j].Method {
+			return compareMethods(result[i].Method, result[j].Method)
+		}
+		return result[i].Kind < result[j].Kind
+	})
+	return result
+}
+
+type expectedRoute struct {
+	method     string
+	path       string
+	hidden     bool
+	disabled   bool
+	registered bool
+}
+
+func (d *Definition) expectPaths(expected map[string]*expectedRoute, prefix string, ancestry []string, hidden bool, disabled bool, paths Paths) {
+	for p, pDef := range paths {
+		newAncestry := append(append([]string{}, ancestry...), p)
+		pHidden := hidden || pDef.HideDocs
+		pDisabled := disabled || (pDef.Disabled != nil && pDef.Disabled())
+		d.expectRoutes(expected, prefix, pHidden, pDisabled, strings.Join(newAncestry, ""), pDef.Methods, d.AutoOptionsMethods || pDef.AutoOptionsMethod)
+		d.expectPaths(expected, prefix, newAncestry, pHidden, pDisabled, pDef.Paths)
+	}
+}
+
+func (d *Definition) expectRoutes(expected map[string]*expectedRoute, prefix string, hidden bool, disabled bool, path string, methods Methods, autoOptions bool) {
+	path = prefix + path
+	key := routeKey(path)
+	add := func(method string, mHidden bool) {
+		expected[method+" "+key] = &expectedRoute{
+			method:   method,
+			path:     path,
+			hidden:   hidden || mHidden,
+			disabled: disabled,
+		}
+	}
+	for m, mDef := range methods {
+		add(strings.ToUpper(m), mDef.HideDocs)
+	}
+	if len(methods) > 0 && d.AutoHeadMethods {
+		if mDef, ok := methods.getWithoutHead(); ok {
+			add(http.MethodHead, mDef.HideDocs)
+		}
+	}
+	// mirrors Definition.setupMethods - Definition.AutoOptionsMethods only applies where there are methods
+	if (autoOptions || (len(methods) > 0 && d.AutoOptionsMethods)) && !methods.hasOptions() {
+		add(http.MethodOptions, false)
+	}
+}
+
+// routeKey normalizes a route for comparison - trailing slashes are removed and path var names are
+// removed (e.g. "/pets/{petId:[0-9]+}/" -> "/pets/{:[0-9]+}")
+func routeKey(route string) string {
+	segments := splitRoute(route)
+	if len(segments) == 0 {
+		return root
+	}
+	var b strings.Builder
+	for _, seg := range segments {
+		for pos := 0; pos < len(seg); {
+			if seg[pos] == '{' {
+				if end := closingBrace(seg, pos); end != -1 {
+					b.WriteByte('{')
+					if _, rx, ok := strings.Cut(seg[pos+1:end], ":"); ok {
+						b.WriteString(":" + rx)
+					}
+					b.WriteByte('}')
+					pos = end + 1
+					continue
+				}
+			}
+			b.WriteByte(seg[pos])
+			pos++
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,235 @@
+package chioas
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maps"
+	"net/http"
+	"testing"
+)
+
+func testDriftHandler(w http.ResponseWriter, r *http.Request) {}
+
+var testDriftDefinition = Definition{
+	DocOptions: DocOptions{ServeDocs: true},
+	Methods: Methods{
+		http.MethodGet: {Handler: testDriftHandler},
+	},
+	Paths: Paths{
+		"/pets": {
+			Methods: Methods{
+				http.MethodGet:  {Handler: testDriftHandler},
+				http.MethodPost: {Handler: testDriftHandler, HideDocs: true},
+			},
+			Paths: Paths{
+				"/{petId:[0-9]+}": {
+					Methods: Methods{
+						http.MethodGet: {Handler: testDriftHandler},
+					},
+				},
+			},
+		},
+		"/internal": {
+			HideDocs: true,
+			Methods: Methods{
+				http.MethodGet: {Handler: testDriftHandler},
+			},
+		},
+		"/admin": {
+			Disabled: func() bool {
+				return true
+			},
+			Methods: Methods{
+				http.MethodGet: {Handler: testDriftHandler},
+			},
+		},
+	},
+}
+
+func TestDefinition_CheckRoutes(t *testing.T) {
+	def := testDriftDefinition
+	router := chi.NewRouter()
+	err := def.SetupRoutes(router, nil)
+	require.NoError(t, err)
+	h := func(w http.ResponseWriter, r *http.Request) {}
+	router.Get("/admin", h)
+	router.Get("/metrics", h)
+	router.Delete("/pets/{id:[0-9]+}", h)
+
+	drifts := def.CheckRoutes(router, nil)
+	require.Len(t, drifts, 5)
+	assert.Equal(t, "disabled-reachable: GET /admin", drifts[0].String())
+	assert.Equal(t, "hidden-reachable: GET /internal/", drifts[1].String())
+	assert.Equal(t, "undocumented: GET /metrics", drifts[2].String())
+	assert.Equal(t, "hidden-reachable: POST /pets/", drifts[3].String())
+	assert.Equal(t, "undocumented: DELETE /pets/{id:[0-9]+}", drifts[4].String())
+
+	assert.Len(t, drifts.Of(RouteHiddenReachable), 2)
+	assert.Len(t, drifts.Of(RouteUndocumented, RouteDisabledReachable), 3)
+	assert.Empty(t, drifts.Of(RouteNotRegistered))
+
+	drifts = def.CheckRoutes(router, &CheckRoutesOptions{
+		IgnorePaths:   []string{"/metrics", "/pets/*"},
+		IgnoreMethods: []string{"get"},
+	})
+	assert.Empty(t, drifts)
+
+	data, err := json.Marshal(def.CheckRoutes(router, &CheckRoutesOptions{IgnorePaths: []string{"/internal", "/pets/*", "/admin"}}))
+	require.NoError(t, err)
+	assert.Equal(t, `[{"kind":"undocumented","method":"GET","path":"/metrics"}]`, string(data))
+}
+
+func TestDefinition_CheckRoutes_NotRegistered(t *testing.T) {
+	def := testDriftDefinition
+	def.AutoHeadMethods = true
+	def.AutoOptionsMethods = true
+	h := func(w http.ResponseWriter, r *http.Request) {}
+	router := chi.NewRouter()
+	router.Route("/api", func(r chi.Router) {
+		r.Get("/", h)
+		r.Get("/pets", h)
+		r.Head("/pets", h)
+		r.Get("/pets/{id:[0-9]+}", h)
+	})
+
+	drifts := def.CheckRoutes(router, &CheckRoutesOptions{Prefix: "/api/", IgnoreMethods: []string{http.MethodOptions}})
+	require.Len(t, drifts, 5)
+	assert.Equal(t, "not-registered: HEAD /api/", drifts[0].String())
+	assert.Equal(t, "not-registered: GET /api/internal", drifts[1].String())
+	assert.Equal(t, "not-registered: HEAD /api/internal", drifts[2].String())
+	assert.Equal(t, "not-registered: POST /api/pets", drifts[3].String())
+	assert.Equal(t, "not-registered: HEAD /api/pets/{petId:[0-9]+}", drifts[4].String())
+
+	drifts = def.CheckRoutes(router, &CheckRoutesOptions{Prefix: "/api", IgnoreNotRegistered: true})
+	assert.Empty(t, drifts)
+}
+
+func TestDefinition_CheckRoutes_SetupRoutes(t *testing.T) {
+	def := testDriftDefinition
+	def.AutoHeadMethods = true
+	def.AutoOptionsMethods = true
+	def.DocOptions.Path = "/apidocs"
+	def.DocOptions.AlternateUIDocs = AlternateUIDocs{
+		"swagger": {UIStyle: Swagger},
+	}
+	// local copies of the paths (so that the shared definition isn't altered) - with no hidden operations...
+	def.Paths = maps.Clone(testDriftDefinition.Paths)
+	pets := def.Paths["/pets"]
+	pets.Methods = maps.Clone(pets.Methods)
+	for m, mDef := range pets.Methods {
+		mDef.HideDocs = false
+		pets.Methods[m] = mDef
+	}
+	def.Paths["/pets"] = pets
+	delete(def.Paths, "/internal")
+	router := chi.NewRouter()
+	err := def.SetupRoutes(router, nil)
+	require.NoError(t, err)
+	assert.Empty(t, def.CheckRoutes(router, nil))
+}
+
+func TestDefinition_CheckRoutes_SetupRoutesHidden(t *testing.T) {
+	def := testDriftDefinition
+	def.AutoHeadMethods = true
+	def.AutoOptionsMethods = true
+	def.DocOptions.Path = "/apidocs"
+	def.DocOptions.AlternateUIDocs = AlternateUIDocs{
+		"swagger": {UIStyle: Swagger},
+	}
+	router := chi.NewRouter()
+	err := def.SetupRoutes(router, nil)
+	require.NoError(t, err)
+	// only the hidden operations are reported - docs routes and auto HEAD/OPTIONS methods are accounted for
+	drifts := def.CheckRoutes(router, nil)
+	require.Len(t, drifts, 4)
+	assert.Len(t, drifts.Of(RouteHiddenReachable), 4)
+	assert.Equal(t, "hidden-reachable: GET /internal/", drifts[0].String())
+	assert.Equal(t, "hidden-reachable: HEAD /internal/", drifts[1].String())
+	assert.Equal(t, "hidden-reachable: OPTIONS /internal/", drifts[2].String())
+	assert.Equal(t, "hidden-reachable: POST /pets/", drifts[3].String())
+}
+
+func TestDefinition_CheckRoutes_RootAutoOptions(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {}
+	testCases := []struct {
+		def         Definition
+		expectDrift bool
+	}{
+		{
+			def: Definition{
+				AutoOptionsMethods: true,
+				Paths: Paths{
+					"/pets": {Methods: Methods{http.MethodGet: {Handler: h}}},
+				},
+			},
+		},
+		{
+			def: Definition{
+				AutoOptionsMethods: true,
+				Methods:            Methods{http.MethodGet: {Handler: h}},
+			},
+		},
+		{
+			def: Definition{
+				RootAutoOptionsMethod: true,
+				Paths: Paths{
+					"/pets": {Methods: Methods{http.MethodGet: {Handler: h}}},
+				},
+			},
+		},
+		{
+			def: Definition{
+				AutoOptionsMethods: true,
+				Paths: Paths{
+					"/pets": {Methods: Methods{http.MethodGet: {Handler: h}}},
+				},
+			},
+			expectDrift: true,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]", i+1), func(t *testing.T) {
+			router := chi.NewRouter()
+			if !tc.expectDrift {
+				err := tc.def.SetupRoutes(router, nil)
+				require.NoError(t, err)
+				assert.Empty(t, tc.def.CheckRoutes(router, nil))
+			} else {
+				router.Get("/pets", h)
+				drifts := tc.def.CheckRoutes(router, nil)
+				require.Len(t, drifts, 1)
+				assert.Equal(t, "not-registered: OPTIONS /pets", drifts[0].String())
+			}
+		})
+	}
+}
+
+func TestRouteKey(t *testing.T) {
+	testCases := []struct {
+		route  string
+		expect string
+	}{
+		{route: "", expect: "/"},
+		{route: "/", expect: "/"},
+		{route: "/pets/", expect: "/pets"},
+		{route: "/pets/{petId}", expect: "/pets/{}"},
+		{route: "/pets/{petId:[0-9]+}/", expect: "/pets/{:[0-9]+}"},
+		{route: "/files/{a}.{b:[a-z]+}", expect: "/files/{}.{:[a-z]+}"},
+		{route: "/files/*", expect: "/files/*"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.route, func(t *testing.T) {
+			assert.Equal(t, tc.expect, routeKey(tc.route))
+		})
+	}
+}
+
+func TestRouteDriftKind_String(t *testing.T) {
+	assert.Equal(t, "undocumented", RouteUndocumented.String())
+	assert.Equal(t, "hidden-reachable", RouteHiddenReachable.String())
+	assert.Equal(t, "disabled-reachable", RouteDisabledReachable.String())
+	assert.Equal(t, "not-registered", RouteNotRegistered.String())
+}
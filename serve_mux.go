@@ -0,0 +1,210 @@
+package chioas
+
+import (
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// ServeMuxOptions is the options for Definition.SetupServeMux
+type ServeMuxOptions struct {
+	// RejectRegexPathVars if set, path vars with a regex (e.g. "{id:[0-9]+}") are rejected with an error
+	//
+	// Otherwise, such path vars are translated to plain http.ServeMux wildcards (e.g. "{id}") and the regex is
+	// enforced by the registered handler (responding with 404 Not Found if a path value does not match)
+	RejectRegexPathVars bool
+}
+
+// SetupServeMux sets up the API routes on the supplied *http.ServeMux - as an alternative to a chi.Router (see SetupRoutes)
+//
+// Routes are registered using Go 1.22+ method and wildcard patterns (e.g. "GET /pets/{petId}") and path vars are
+// available using http.Request.PathValue (or PathValue).  Middlewares (root, path and method) are composed for each route
+//
+// Path vars with a regex (e.g. "{petId:[0-9]+}") are translated (see ServeMuxOptions.RejectRegexPathVars) - path vars must
+// be a whole path segment (e.g. "/files/{name}.{ext}" is rejected) and a catch-all "/*" is registered as a subtree pattern
+// (e.g. "/files/*" is registered as "/files/")
+//
+// Note: http.ServeMux responds with 405 Method Not Allowed (and Allow header) for any path that has no route for the
+// request method - so Definition.AutoMethodNotAllowed is not used
+//
+// Pass the thisApi arg if any of the methods use method by name
+func (d *Definition) SetupServeMux(mux *http.ServeMux, thisApi any, opts *ServeMuxOptions) (err error) {
+	if opts == nil {
+		opts = &ServeMuxOptions{}
+	}
+	defer func() {
+		// http.ServeMux panics on conflicting patterns...
+		if r := recover(); r != nil {
+			err = fmt.Errorf("serve mux: %v", r)
+		}
+	}()
+	// validate before anything is registered on the mux...
+	rm, err := d.RouteMatcher()
+	if err != nil {
+		return err
+	}
+	ots, err := d.buildOperationTemplates()
+	if err != nil {
+		return err
+	}
+	d.routeMatcher = rm
+	d.operationTemplates = ots
+	if err = d.setupServeMuxDocs(mux); err != nil {
+		return err
+	}
+	sm := &serveMuxSetup{
+		def:     d,
+		mux:     mux,
+		thisApi: thisApi,
+		opts:    opts,
+	}
+	middlewares := append(chi.Middlewares{}, d.Middlewares...)
+	if d.ApplyMiddlewares != nil {
+		middlewares = append(middlewares, d.ApplyMiddlewares(thisApi)...)
+	}
+	if err = sm.setupMethods(root, nil, "", d.Methods, d.RootAutoOptionsMethod, middlewares); err != nil {
+		return err
+	}
+	return sm.setupPaths(nil, "", d.Paths, middlewares)
+}
+
+func (d *Definition) setupServeMuxDocs(mux *http.ServeMux) error {
+	if d.DocOptions.ServeDocs {
+		// the docs routes are setup on a chi router - which is registered on the mux for each docs path...
+		docsRoute := chi.NewRouter()
+		if err := d.DocOptions.SetupRoutes(d, docsRoute); err != nil {
+			return err
+		}
+		mux.Handle(strings.TrimSuffix(defValue(d.DocOptions.Path, defaultDocsPath), root)+root, docsRoute)
+		for altPath := range d.DocOptions.AlternateUIDocs {
+			mux.Handle(root+strings.Trim(altPath, root)+root, docsRoute)
+		}
+	}
+	return nil
+}
+
+type serveMuxSetup struct {
+	def     *Definition
+	mux     *http.ServeMux
+	thisApi any
+	opts    *ServeMuxOptions
+}
+
+func (sm *serveMuxSetup) setupPaths(ancestry []string, parentTag string, paths Paths, parentMiddlewares chi.Middlewares) error {
+	for p, pDef := range paths {
+		if pDef.Disabled == nil || !pDef.Disabled() {
+			newAncestry := append(append([]string{}, ancestry...), p)
+			useTag := defaultTag(parentTag, pDef.Tag)
+			middlewares := append(append(chi.Middlewares{}, parentMiddlewares...), pDef.Middlewares...)
+			if pDef.ApplyMiddlewares != nil {
+				middlewares = append(middlewares, pDef.ApplyMiddlewares(sm.thisApi)...)
+			}
+			if err := sm.setupMethods(strings.Join(newAncestry, ""), &pDef, useTag, pDef.Methods, sm.def.AutoOptionsMethods || pDef.AutoOptionsMethod, middlewares); err != nil {
+				return err
+			}
+			if err := sm.setupPaths(newAncestry, useTag, pDef.Paths, middlewares); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (sm *serveMuxSetup) setupMethods(path string, pathDef *Path, parentTag string, methods Methods, pathAutoOptions bool, middlewares chi.Middlewares) error {
+	// as with Definition.setupMethods - Definition.AutoOptionsMethods only applies where there are methods
+	autoOptions := (pathAutoOptions || (len(methods) > 0 && sm.def.AutoOptionsMethods)) && !methods.hasOptions()
+	if len(methods) == 0 && !autoOptions {
+		return nil
+	}
+	pattern, pathVarRxs, err := serveMuxPattern(path, sm.opts.RejectRegexPathVars)
+	if err != nil {
+		return err
+	}
+	d := sm.def
+	for m, mDef := range methods {
+		h, err := getMethodHandlerBuilder(d.MethodHandlerBuilder).BuildHandler(path, m, mDef, sm.thisApi)
+		if err != nil {
+			return err
+		}
+		sm.handle(m, pattern, pathVarRxs, middlewares.Handler(d.methodHandler(path, pathDef, m, mDef, parentTag, h)))
+	}
+	if autoOptions {
+		sm.handle(http.MethodOptions, pattern, pathVarRxs, middlewares.Handler(d.optionsHandler(methods, path, pathDef)))
+	}
+	if d.AutoHeadMethods {
+		if mDef, ok := methods.getWithoutHead(); ok {
+			h, _ := getMethodHandlerBuilder(d.MethodHandlerBuilder).BuildHandler(path, http.MethodHead, mDef, sm.thisApi)
+			sm.handle(http.MethodHead, pattern, pathVarRxs, middlewares.Handler(d.methodHandler(path, pathDef, http.MethodHead, mDef, parentTag, h)))
+		}
+	}
+	return nil
+}
+
+func (sm *serveMuxSetup) handle(method string, pattern string, pathVarRxs map[string]*regexp.Regexp, h http.Handler) {
+	if len(pathVarRxs) > 0 {
+		next := h
+		h = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for name, rx := range pathVarRxs {
+				if !rx.MatchString(r.PathValue(name)) {
+					http.NotFound(w, r)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	sm.mux.Handle(method+" "+pattern, h)
+}
+
+// serveMuxPattern translates a path template to an http.ServeMux pattern (e.g. "/pets/{petId:[0-9]+}" -> "/pets/{petId}")
+//
+// returns the regexes (keyed by path var name) for any translated path vars
+func serveMuxPattern(path string, rejectRegex bool) (pattern string, pathVarRxs map[string]*regexp.Regexp, err error) {
+	segments := splitRoute(path)
+	if len(segments) == 0 {
+		return root + "{$}", nil, nil
+	}
+	var b strings.Builder
+	for i, seg := range segments {
+		switch {
+		case seg == "/*" && i == len(segments)-1:
+			b.WriteString(root)
+		case !strings.ContainsAny(seg, "{}*"):
+			b.WriteString(seg)
+		case strings.HasPrefix(seg, "/{") && closingBrace(seg, 1) == len(seg)-1:
+			name, rx, hasRx := strings.Cut(seg[2:len(seg)-1], ":")
+			if hasRx {
+				if rejectRegex {
+					return "", nil, fmt.Errorf("path '%s' - path var '%s' has a regex (not supported by http.ServeMux)", path, name)
+				}
+				cx, err := regexp.Compile("^(?:" + rx + ")$")
+				if err != nil {
+					return "", nil, fmt.Errorf("path '%s' - path var '%s' has invalid regex: %w", path, name, err)
+				}
+				if pathVarRxs == nil {
+					pathVarRxs = map[string]*regexp.Regexp{}
+				}
+				pathVarRxs[name] = cx
+			}
+			b.WriteString("/{" + name + "}")
+		default:
+			return "", nil, fmt.Errorf("path '%s' - segment '%s' not supported by http.ServeMux (path vars must be a whole path segment)", path, seg)
+		}
+	}
+	return b.String(), pathVarRxs, nil
+}
+
+// PathValue returns the value of the named path var for the request
+//
+// Works regardless of whether the routes were setup on a chi.Router (see Definition.SetupRoutes) or
+// an http.ServeMux (see Definition.SetupServeMux)
+func PathValue(r *http.Request, name string) string {
+	if ctx := chi.RouteContext(r.Context()); ctx != nil {
+		if v := ctx.URLParam(name); v != "" {
+			return v
+		}
+	}
+	return r.PathValue(name)
+}
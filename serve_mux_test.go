@@ -0,0 +1,293 @@
+package chioas
+
+import (
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testServeMuxApi struct{}
+
+func (a *testServeMuxApi) GetPets(w http.ResponseWriter, r *http.Request) {
+	_, _ = w.Write([]byte("pets " + w.Header().Get("X-Mw")))
+}
+
+func testServeMuxMiddleware(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Mw", strings.TrimPrefix(w.Header().Get("X-Mw")+","+name, ","))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+var testServeMuxDefinition = Definition{
+	DocOptions:         DocOptions{ServeDocs: true},
+	AutoHeadMethods:    true,
+	AutoOptionsMethods: true,
+	Middlewares:        chi.Middlewares{testServeMuxMiddleware("root")},
+	Methods: Methods{
+		http.MethodGet: {
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("root"))
+			},
+		},
+	},
+	Paths: Paths{
+		"/pets": {
+			Middlewares: chi.Middlewares{testServeMuxMiddleware("pets")},
+			Methods: Methods{
+				http.MethodGet: {
+					Handler: "GetPets",
+				},
+			},
+			Paths: Paths{
+				"/{petId:[0-9]+}": {
+					ApplyMiddlewares: func(thisApi any) chi.Middlewares {
+						return chi.Middlewares{testServeMuxMiddleware("pet")}
+					},
+					Methods: Methods{
+						http.MethodGet: {
+							OperationId: "getPet",
+							Middlewares: chi.Middlewares{testServeMuxMiddleware("method")},
+							Handler: func(w http.ResponseWriter, r *http.Request) {
+								op, _ := OperationFromRequest(r)
+								_, _ = w.Write([]byte(op.OperationId + " " + PathValue(r, "petId") + " " + w.Header().Get("X-Mw")))
+							},
+						},
+					},
+				},
+			},
+		},
+		"/files/*": {
+			Methods: Methods{
+				http.MethodGet: {
+					Handler: func(w http.ResponseWriter, r *http.Request) {
+						_, _ = w.Write([]byte(r.URL.Path))
+					},
+				},
+			},
+		},
+		"/admin": {
+			Disabled: func() bool {
+				return true
+			},
+			Methods: Methods{
+				http.MethodGet: {
+					Handler: func(w http.ResponseWriter, r *http.Request) {},
+				},
+			},
+		},
+	},
+}
+
+func TestDefinition_SetupServeMux(t *testing.T) {
+	def := testServeMuxDefinition
+	mux := http.NewServeMux()
+	err := def.SetupServeMux(mux, &testServeMuxApi{}, nil)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		method       string
+		path         string
+		expectStatus int
+		expectBody   string
+		expectAllow  string
+	}{
+		{method: http.MethodGet, path: "/", expectStatus: http.StatusOK, expectBody: "root"},
+		{method: http.MethodGet, path: "/pets", expectStatus: http.StatusOK, expectBody: "pets root,pets"},
+		{method: http.MethodHead, path: "/pets", expectStatus: http.StatusOK},
+		{method: http.MethodOptions, path: "/pets", expectStatus: http.StatusOK, expectAllow: "GET, HEAD, OPTIONS"},
+		{method: http.MethodDelete, path: "/pets", expectStatus: http.StatusMethodNotAllowed},
+		{method: http.MethodGet, path: "/pets/12", expectStatus: http.StatusOK, expectBody: "getPet 12 root,pets,pet,method"},
+		{method: http.MethodGet, path: "/pets/abc", expectStatus: http.StatusNotFound},
+		{method: http.MethodGet, path: "/files/a/b.txt", expectStatus: http.StatusOK, expectBody: "/files/a/b.txt"},
+		{method: http.MethodGet, path: "/admin", expectStatus: http.StatusNotFound},
+		{method: http.MethodGet, path: "/unknown", expectStatus: http.StatusNotFound},
+		{method: http.MethodGet, path: "/docs/index.html", expectStatus: http.StatusOK},
+		{method: http.MethodGet, path: "/docs/spec.yaml", expectStatus: http.StatusOK},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			mux.ServeHTTP(res, httptest.NewRequest(tc.method, tc.path, nil))
+			assert.Equal(t, tc.expectStatus, res.Code)
+			if tc.expectBody != "" {
+				assert.Equal(t, tc.expectBody, res.Body.String())
+			}
+			if tc.expectAllow != "" {
+				assert.Equal(t, tc.expectAllow, res.Header().Get("Allow"))
+			}
+		})
+	}
+
+	path, method := def.MapRequest(httptest.NewRequest(http.MethodGet, "/pets/12", nil))
+	require.NotNil(t, path)
+	require.NotNil(t, method)
+	assert.Equal(t, "getPet", method.OperationId)
+}
+
+func TestDefinition_SetupServeMux_RootAutoOptions(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {}
+	testCases := []struct {
+		def          Definition
+		expectStatus int
+	}{
+		{
+			def: Definition{
+				AutoOptionsMethods: true,
+				Paths: Paths{
+					"/pets": {Methods: Methods{http.MethodGet: {Handler: h}}},
+				},
+			},
+			expectStatus: http.StatusNotFound,
+		},
+		{
+			def: Definition{
+				AutoOptionsMethods: true,
+				Methods:            Methods{http.MethodGet: {Handler: h}},
+			},
+			expectStatus: http.StatusOK,
+		},
+		{
+			def: Definition{
+				RootAutoOptionsMethod: true,
+				Paths: Paths{
+					"/pets": {Methods: Methods{http.MethodGet: {Handler: h}}},
+				},
+			},
+			expectStatus: http.StatusOK,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]", i+1), func(t *testing.T) {
+			mux := http.NewServeMux()
+			err := tc.def.SetupServeMux(mux, nil, nil)
+			require.NoError(t, err)
+			res := httptest.NewRecorder()
+			mux.ServeHTTP(res, httptest.NewRequest(http.MethodOptions, "/", nil))
+			assert.Equal(t, tc.expectStatus, res.Code)
+			res = httptest.NewRecorder()
+			mux.ServeHTTP(res, httptest.NewRequest(http.MethodOptions, "/pets", nil))
+			if len(tc.def.Paths) > 0 {
+				assert.Equal(t, tc.def.AutoOptionsMethods, res.Code == http.StatusOK)
+			}
+		})
+	}
+}
+
+func TestDefinition_SetupServeMux_Errors(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {}
+	testCases := []struct {
+		def         *Definition
+		opts        *ServeMuxOptions
+		expectError string
+	}{
+		{
+			def: &Definition{
+				Paths: Paths{
+					"/pets/{petId:[0-9]+}": {Methods: Methods{http.MethodGet: {Handler: h}}},
+				},
+			},
+			opts:        &ServeMuxOptions{RejectRegexPathVars: true},
+			expectError: "path '/pets/{petId:[0-9]+}' - path var 'petId' has a regex (not supported by http.ServeMux)",
+		},
+		{
+			def: &Definition{
+				Paths: Paths{
+					"/files/{name}.{ext}": {Methods: Methods{http.MethodGet: {Handler: h}}},
+				},
+			},
+			expectError: "path '/files/{name}.{ext}' - segment '/{name}.{ext}' not supported by http.ServeMux (path vars must be a whole path segment)",
+		},
+		{
+			def: &Definition{
+				Paths: Paths{
+					"/pets": {
+						Paths: Paths{
+							"/{petId:[0-9]+}": {Methods: Methods{http.MethodGet: {Handler: h}}},
+							"/{name:[a-z]+}":  {Methods: Methods{http.MethodGet: {Handler: h}}},
+						},
+					},
+				},
+			},
+			expectError: "serve mux: pattern",
+		},
+		{
+			def: &Definition{
+				Paths: Paths{
+					"/pets": {Methods: Methods{http.MethodGet: {}}},
+				},
+			},
+			expectError: "handler not set (path: /pets, method: GET)",
+		},
+		{
+			def: &Definition{
+				DocOptions: DocOptions{ServeDocs: true, DocTemplate: "{{"},
+			},
+			expectError: "template",
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(tc.expectError, func(t *testing.T) {
+			err := tc.def.SetupServeMux(http.NewServeMux(), nil, tc.opts)
+			require.Error(t, err, i)
+			assert.Contains(t, err.Error(), tc.expectError)
+		})
+	}
+}
+
+func TestServeMuxPattern(t *testing.T) {
+	testCases := []struct {
+		path        string
+		expect      string
+		expectRxs   []string
+		expectError bool
+	}{
+		{path: "/", expect: "/{$}"},
+		{path: "/pets", expect: "/pets"},
+		{path: "/pets/{petId}", expect: "/pets/{petId}"},
+		{path: "/pets/{petId:[0-9]+}/photos/{photoId}", expect: "/pets/{petId}/photos/{photoId}", expectRxs: []string{"petId"}},
+		{path: "/files/*", expect: "/files/"},
+		{path: "/files/*/x", expectError: true},
+		{path: "/files/x{name}", expectError: true},
+		{path: "/files/{name:[}", expectError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			pattern, rxs, err := serveMuxPattern(tc.path, false)
+			if tc.expectError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expect, pattern)
+				assert.Len(t, rxs, len(tc.expectRxs))
+				for _, name := range tc.expectRxs {
+					assert.Contains(t, rxs, name)
+				}
+			}
+		})
+	}
+}
+
+func TestPathValue(t *testing.T) {
+	var chiValue, muxValue string
+	router := chi.NewRouter()
+	router.Get("/pets/{petId}", func(w http.ResponseWriter, r *http.Request) {
+		chiValue = PathValue(r, "petId")
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/pets/1", nil))
+	assert.Equal(t, "1", chiValue)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /pets/{petId}", func(w http.ResponseWriter, r *http.Request) {
+		muxValue = PathValue(r, "petId")
+	})
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/pets/2", nil))
+	assert.Equal(t, "2", muxValue)
+}
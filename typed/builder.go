@@ -108,6 +108,8 @@ func (b *builder) BuildHandler(path string, method string, mdef chioas.Method, t
 		return hf, nil
 	case func(string, string, any) (http.HandlerFunc, error):
 		return hf(path, method, thisApi)
+	case *GenericHandler:
+		return hf.build(b, thisApi), nil
 	case string:
 		if thisApi != nil {
 			return b.buildFromMethodName(path, method, thisApi, hf)
@@ -0,0 +1,140 @@
+package typed
+
+import (
+	"github.com/go-andiamo/chioas"
+	"github.com/go-andiamo/chioas/internal/values"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+const (
+	hdrContentDisposition = "Content-Disposition"
+	hdrETag               = "ETag"
+	contentTypeOctets     = "application/octet-stream"
+	formatBinary          = "binary"
+)
+
+// FileResponse is a struct that can be returned from a typed handler to serve a file (or blob)
+//
+// The content is served using http.ServeContent - so `Range`, `If-Range`, `If-Match`, `If-None-Match`,
+// `If-Modified-Since` and `If-Unmodified-Since` request headers are all honoured (as are HEAD requests) - and the content
+// is never buffered in its entirety
+//
+// If Content also implements io.Closer, it is closed after serving
+type FileResponse struct {
+	// Content is the content of the file to be served (required - if nil then a 404 Not Found is written)
+	Content io.ReadSeeker
+	// Name is the name of the file
+	//
+	// if ContentType is empty, the name extension is used to determine the `Content-Type` (and failing that, the content is sniffed)
+	//
+	// if the name is non-empty, a `Content-Disposition` header is written with the name as the filename
+	Name string
+	// ModTime is the modification time of the file
+	//
+	// if non-zero, the `Last-Modified` header is written and used for `If-Modified-Since`/`If-Range` checks
+	ModTime time.Time
+	// ContentType is the optional content type for the `Content-Type` header
+	ContentType string
+	// ETag is the optional entity tag (e.g. `"abc123"`) - used for `If-None-Match`/`If-Match`/`If-Range` checks
+	ETag string
+	// Inline indicates that the `Content-Disposition` should be "inline" (rather than "attachment")
+	Inline bool
+	// Headers is any additional headers to be set on the response
+	Headers [][2]string
+}
+
+func (fr *FileResponse) write(writer http.ResponseWriter, request *http.Request) {
+	if fr.Content == nil {
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if c, ok := fr.Content.(io.Closer); ok {
+		defer func() {
+			_ = c.Close()
+		}()
+	}
+	for _, hd := range fr.Headers {
+		writer.Header().Set(hd[0], hd[1])
+	}
+	if fr.ContentType != "" {
+		writer.Header().Set(hdrContentType, fr.ContentType)
+	}
+	if fr.ETag != "" {
+		writer.Header().Set(hdrETag, fr.ETag)
+	}
+	if fr.Name != "" {
+		disposition := "attachment"
+		if fr.Inline {
+			disposition = "inline"
+		}
+		if cd := mime.FormatMediaType(disposition, map[string]string{"filename": fr.Name}); cd != "" {
+			writer.Header().Set(hdrContentDisposition, cd)
+		} else {
+			writer.Header().Set(hdrContentDisposition, disposition)
+		}
+	}
+	http.ServeContent(writer, request, fr.Name, fr.ModTime, fr.Content)
+}
+
+func fileResponseHandler(v reflect.Value, b *builder, thisApi any, statusCode int, writer http.ResponseWriter, request *http.Request) bool {
+	fr := v.Interface().(FileResponse)
+	fr.write(writer, request)
+	return true
+}
+
+func fileResponsePtrHandler(v reflect.Value, b *builder, thisApi any, statusCode int, writer http.ResponseWriter, request *http.Request) bool {
+	if v.IsNil() {
+		writer.WriteHeader(defaultStatusCode(statusCode, http.StatusNoContent))
+		return true
+	}
+	v.Interface().(*FileResponse).write(writer, request)
+	return true
+}
+
+// FileResponses creates chioas.Responses that document a typed handler returning a FileResponse
+//
+// The 200 OK and 206 Partial Content responses are documented as binary (string with format "binary") for each
+// of the content types specified (if no content types specified, "application/octet-stream" is used) - and
+// 304 Not Modified and 416 Range Not Satisfiable responses are documented as having no content
+func FileResponses(description string, contentTypes ...string) chioas.Responses {
+	if len(contentTypes) == 0 {
+		contentTypes = []string{contentTypeOctets}
+	}
+	schema := &chioas.Schema{
+		Type:   values.TypeString,
+		Format: formatBinary,
+	}
+	var alts chioas.ContentTypes
+	if len(contentTypes) > 1 {
+		alts = make(chioas.ContentTypes, len(contentTypes)-1)
+		for _, ct := range contentTypes[1:] {
+			alts[ct] = chioas.ContentType{Schema: schema}
+		}
+	}
+	return chioas.Responses{
+		http.StatusOK: {
+			Description:             description,
+			ContentType:             contentTypes[0],
+			AlternativeContentTypes: alts,
+			Schema:                  schema,
+		},
+		http.StatusPartialContent: {
+			Description:             http.StatusText(http.StatusPartialContent),
+			ContentType:             contentTypes[0],
+			AlternativeContentTypes: alts,
+			Schema:                  schema,
+		},
+		http.StatusNotModified: {
+			Description: http.StatusText(http.StatusNotModified),
+			NoContent:   true,
+		},
+		http.StatusRequestedRangeNotSatisfiable: {
+			Description: http.StatusText(http.StatusRequestedRangeNotSatisfiable),
+			NoContent:   true,
+		},
+	}
+}
@@ -0,0 +1,287 @@
+package typed
+
+import (
+	"fmt"
+	"github.com/go-andiamo/chioas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testFileModTime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+type closingReader struct {
+	*strings.Reader
+	closed bool
+}
+
+func (c *closingReader) Close() error {
+	c.closed = true
+	return nil
+}
+
+func testFileHandler(fr *FileResponse) http.HandlerFunc {
+	mhb := NewTypedMethodsHandlerBuilder()
+	hf, _ := mhb.BuildHandler("/", http.MethodGet, chioas.Method{
+		Handler: func() (*FileResponse, error) {
+			if fr != nil {
+				fr.Content = strings.NewReader("0123456789")
+			}
+			return fr, nil
+		},
+	}, nil)
+	return hf
+}
+
+func TestFileResponse(t *testing.T) {
+	testCases := []struct {
+		method        string
+		fr            *FileResponse
+		hdrs          map[string]string
+		expectStatus  int
+		expectBody    string
+		expectHeaders map[string]string
+	}{
+		{
+			method:       http.MethodGet,
+			fr:           &FileResponse{Name: "test.txt", ModTime: testFileModTime},
+			expectStatus: http.StatusOK,
+			expectBody:   "0123456789",
+			expectHeaders: map[string]string{
+				hdrContentType:        "text/plain; charset=utf-8",
+				hdrContentDisposition: `attachment; filename=test.txt`,
+				"Last-Modified":       "Tue, 02 Jan 2024 03:04:05 GMT",
+				"Accept-Ranges":       "bytes",
+				"Content-Length":      "10",
+			},
+		},
+		{
+			method:       http.MethodGet,
+			fr:           &FileResponse{Name: "test.bin", ContentType: "application/foo", Inline: true, ETag: `"abc"`, Headers: [][2]string{{"X-Foo", "bar"}}},
+			expectStatus: http.StatusOK,
+			expectBody:   "0123456789",
+			expectHeaders: map[string]string{
+				hdrContentType:        "application/foo",
+				hdrContentDisposition: `inline; filename=test.bin`,
+				hdrETag:               `"abc"`,
+				"X-Foo":               "bar",
+			},
+		},
+		{
+			method:       http.MethodGet,
+			fr:           &FileResponse{},
+			expectStatus: http.StatusOK,
+			expectBody:   "0123456789",
+			expectHeaders: map[string]string{
+				hdrContentDisposition: "",
+			},
+		},
+		{
+			method:       http.MethodHead,
+			fr:           &FileResponse{Name: "test.txt"},
+			expectStatus: http.StatusOK,
+			expectBody:   "",
+			expectHeaders: map[string]string{
+				"Content-Length": "10",
+			},
+		},
+		{
+			method:       http.MethodGet,
+			fr:           &FileResponse{Name: "test.txt"},
+			hdrs:         map[string]string{"Range": "bytes=2-4"},
+			expectStatus: http.StatusPartialContent,
+			expectBody:   "234",
+			expectHeaders: map[string]string{
+				"Content-Range": "bytes 2-4/10",
+			},
+		},
+		{
+			method:       http.MethodGet,
+			fr:           &FileResponse{Name: "test.txt"},
+			hdrs:         map[string]string{"Range": "bytes=20-30"},
+			expectStatus: http.StatusRequestedRangeNotSatisfiable,
+			expectBody:   "invalid range: failed to overlap\n",
+		},
+		{
+			method:       http.MethodGet,
+			fr:           &FileResponse{Name: "test.txt", ETag: `"abc"`},
+			hdrs:         map[string]string{"Range": "bytes=2-4", "If-Range": `"abc"`},
+			expectStatus: http.StatusPartialContent,
+			expectBody:   "234",
+		},
+		{
+			method:       http.MethodGet,
+			fr:           &FileResponse{Name: "test.txt", ETag: `"abc"`},
+			hdrs:         map[string]string{"Range": "bytes=2-4", "If-Range": `"xyz"`},
+			expectStatus: http.StatusOK,
+			expectBody:   "0123456789",
+		},
+		{
+			method:       http.MethodGet,
+			fr:           &FileResponse{Name: "test.txt", ETag: `"abc"`},
+			hdrs:         map[string]string{"If-None-Match": `"abc"`},
+			expectStatus: http.StatusNotModified,
+		},
+		{
+			method:       http.MethodGet,
+			fr:           &FileResponse{Name: "test.txt", ModTime: testFileModTime},
+			hdrs:         map[string]string{"If-Modified-Since": "Tue, 02 Jan 2024 03:04:05 GMT"},
+			expectStatus: http.StatusNotModified,
+		},
+		{
+			method:       http.MethodGet,
+			fr:           nil,
+			expectStatus: http.StatusNoContent,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]", i), func(t *testing.T) {
+			hf := testFileHandler(tc.fr)
+			req, _ := http.NewRequest(tc.method, "/", nil)
+			for k, v := range tc.hdrs {
+				req.Header.Set(k, v)
+			}
+			res := httptest.NewRecorder()
+			hf.ServeHTTP(res, req)
+			assert.Equal(t, tc.expectStatus, res.Code)
+			assert.Equal(t, tc.expectBody, res.Body.String())
+			for k, v := range tc.expectHeaders {
+				assert.Equal(t, v, res.Header().Get(k), k)
+			}
+		})
+	}
+}
+
+func TestFileResponse_NilContent(t *testing.T) {
+	mhb := NewTypedMethodsHandlerBuilder()
+	hf, err := mhb.BuildHandler("/", http.MethodGet, chioas.Method{
+		Handler: func() FileResponse {
+			return FileResponse{Name: "test.txt"}
+		},
+	}, nil)
+	require.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	res := httptest.NewRecorder()
+	hf.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+}
+
+func TestFileResponse_Closes(t *testing.T) {
+	cr := &closingReader{Reader: strings.NewReader("0123456789")}
+	mhb := NewTypedMethodsHandlerBuilder()
+	hf, err := mhb.BuildHandler("/", http.MethodGet, chioas.Method{
+		Handler: func() FileResponse {
+			return FileResponse{Name: "test.txt", Content: cr}
+		},
+	}, nil)
+	require.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	res := httptest.NewRecorder()
+	hf.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "0123456789", res.Body.String())
+	assert.True(t, cr.closed)
+}
+
+func TestFileResponse_AnyReturn(t *testing.T) {
+	testCases := []any{
+		FileResponse{Name: "test.txt", Content: strings.NewReader("0123456789")},
+		&FileResponse{Name: "test.txt", Content: strings.NewReader("0123456789")},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]", i), func(t *testing.T) {
+			mhb := NewTypedMethodsHandlerBuilder()
+			hf, err := mhb.BuildHandler("/", http.MethodGet, chioas.Method{
+				Handler: func() any {
+					return tc
+				},
+			}, nil)
+			require.NoError(t, err)
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Range", "bytes=0-1")
+			res := httptest.NewRecorder()
+			hf.ServeHTTP(res, req)
+			assert.Equal(t, http.StatusPartialContent, res.Code)
+			assert.Equal(t, "01", res.Body.String())
+		})
+	}
+}
+
+func TestFileResponse_BypassesResponseHandler(t *testing.T) {
+	mhb := NewTypedMethodsHandlerBuilder()
+	hf, err := mhb.BuildHandler("/", http.MethodGet, chioas.Method{
+		Handler: func() FileResponse {
+			return FileResponse{Name: "test.txt", Content: strings.NewReader("0123456789")}
+		},
+	}, &testApiWithResponseHandler{statusCode: http.StatusTeapot})
+	require.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	res := httptest.NewRecorder()
+	hf.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "0123456789", res.Body.String())
+}
+
+func TestFileResponse_MultiMarshable(t *testing.T) {
+	mhb := NewTypedMethodsHandlerBuilder()
+	_, err := mhb.BuildHandler("/", http.MethodGet, chioas.Method{
+		Handler: func() (FileResponse, *FileResponse) {
+			return FileResponse{}, nil
+		},
+	}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), errMultiMarshable)
+}
+
+func TestFileResponses(t *testing.T) {
+	def := chioas.Definition{
+		Paths: chioas.Paths{
+			"/files/{id}": {
+				Methods: chioas.Methods{
+					http.MethodGet: {
+						Responses: FileResponses("the file", "application/pdf", "image/png"),
+					},
+				},
+			},
+		},
+	}
+	data, err := def.AsYaml()
+	require.NoError(t, err)
+	const expect = `      responses:
+        200:
+          description: "the file"
+          content:
+            "application/pdf":
+              schema:
+                type: string
+                format: binary
+            "image/png":
+              schema:
+                type: string
+                format: binary
+        206:
+          description: "Partial Content"
+          content:
+            "application/pdf":
+              schema:
+                type: string
+                format: binary
+            "image/png":
+              schema:
+                type: string
+                format: binary
+        304:
+          description: "Not Modified"
+        416:
+          description: "Requested Range Not Satisfiable"
+`
+	assert.Contains(t, string(data), expect)
+
+	rs := FileResponses("the file")
+	assert.Equal(t, contentTypeOctets, rs[http.StatusOK].ContentType)
+	assert.Nil(t, rs[http.StatusOK].AlternativeContentTypes)
+}
@@ -0,0 +1,274 @@
+package typed
+
+import (
+	"bytes"
+	"context"
+	"github.com/go-andiamo/chioas"
+	"io"
+	"net/http"
+	"reflect"
+)
+
+// GenericHandler is a statically typed handler - created using Handle or HandleNoBody
+//
+// A GenericHandler can be set as the chioas.Method.Handler (when the chioas.Definition.MethodHandlerBuilder is
+// a typed handler builder - see NewTypedMethodsHandlerBuilder) and, unlike other typed handlers, no reflection is
+// used to bind the request or call the handler
+//
+// The options passed to NewTypedMethodsHandlerBuilder (ErrorHandler, ResponseHandler and Unmarshaler) are
+// used by generic handlers (ArgBuilder and ArgExtractor options are not used - as the handler args are fixed)
+//
+// Note: only the request body is statically bound - path and query params are not bound (they can be read
+// from the context passed to the handler func, e.g. using chi.URLParamFromCtx).  Nor is the method documentation
+// inferred automatically - use InferDocs to fill in the method docs from the handler types
+type GenericHandler struct {
+	requestType  reflect.Type
+	responseType reflect.Type
+	build        func(b *builder, thisApi any) http.HandlerFunc
+}
+
+// RequestType returns the type of the request body (or nil if the handler takes no request body)
+func (gh *GenericHandler) RequestType() reflect.Type {
+	return gh.requestType
+}
+
+// ResponseType returns the type of the handler response
+func (gh *GenericHandler) ResponseType() reflect.Type {
+	return gh.responseType
+}
+
+// Handle creates a GenericHandler from a statically typed func
+//
+// The request body is unmarshalled into Req (using the Unmarshaler option passed to NewTypedMethodsHandlerBuilder - or
+// JSON by default) and the Resp is written in the same way as a typed handler return arg (see README)
+//
+// The context.Context passed to the func is the request context (so, for example, path params can
+// still be obtained using chi.URLParamFromCtx)
+//
+// Example:
+//
+//	chioas.Method{
+//	    Handler: typed.Handle(func(ctx context.Context, req AddPersonRequest) (*Person, error) {
+//	        ...
+//	    }),
+//	}
+func Handle[Req any, Resp any](fn func(ctx context.Context, req Req) (Resp, error)) *GenericHandler {
+	rw := newGenericResponseWriter[Resp]()
+	return &GenericHandler{
+		requestType:  reflect.TypeFor[Req](),
+		responseType: rw.t,
+		build: func(b *builder, thisApi any) http.HandlerFunc {
+			return func(writer http.ResponseWriter, request *http.Request) {
+				var req Req
+				if requestHasBody(request) {
+					if err := b.unmarshaler.Unmarshal(request, &req); err != nil {
+						b.getErrorHandler(thisApi).HandleError(writer, request, err)
+						return
+					}
+				}
+				resp, err := fn(request.Context(), req)
+				rw.write(resp, err, b, thisApi, writer, request)
+			}
+		},
+	}
+}
+
+// requestHasBody determines whether the request has a body to be unmarshalled
+//
+// where the content length is unknown, the body is peeked (and restored) to check that it is not empty
+func requestHasBody(request *http.Request) bool {
+	if request.Body == nil || request.Body == http.NoBody {
+		return false
+	} else if request.ContentLength > 0 {
+		return true
+	}
+	var peek [1]byte
+	if n, _ := io.ReadFull(request.Body, peek[:]); n == 0 {
+		return false
+	}
+	request.Body = &peekedBody{
+		Reader: io.MultiReader(bytes.NewReader(peek[:]), request.Body),
+		Closer: request.Body,
+	}
+	return true
+}
+
+type peekedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// HandleNoBody creates a GenericHandler from a statically typed func that takes no request body
+//
+// (see Handle)
+func HandleNoBody[Resp any](fn func(ctx context.Context) (Resp, error)) *GenericHandler {
+	rw := newGenericResponseWriter[Resp]()
+	return &GenericHandler{
+		responseType: rw.t,
+		build: func(b *builder, thisApi any) http.HandlerFunc {
+			return func(writer http.ResponseWriter, request *http.Request) {
+				resp, err := fn(request.Context())
+				rw.write(resp, err, b, thisApi, writer, request)
+			}
+		},
+	}
+}
+
+// genericResponseWriter writes the response of a generic handler
+//
+// any type assessment is done once (when the handler is created) rather than on every request
+type genericResponseWriter[Resp any] struct {
+	t       reflect.Type
+	isPtr   bool
+	isSeq   bool
+	withErr bool
+}
+
+func newGenericResponseWriter[Resp any]() *genericResponseWriter[Resp] {
+	t := reflect.TypeFor[Resp]()
+	isSeq, withErr, err := seqType(t)
+	return &genericResponseWriter[Resp]{
+		t:       t,
+		isPtr:   t.Kind() == reflect.Pointer,
+		isSeq:   isSeq && err == nil,
+		withErr: withErr,
+	}
+}
+
+func (rw *genericResponseWriter[Resp]) write(resp Resp, err error, b *builder, thisApi any, writer http.ResponseWriter, request *http.Request) {
+	if err != nil {
+		if rh := b.getResponseHandler(thisApi); rh != nil {
+			rh.WriteErrorResponse(writer, request, err, thisApi)
+		} else {
+			b.getErrorHandler(thisApi).HandleError(writer, request, err)
+		}
+		return
+	}
+	var zero Resp
+	value := any(resp)
+	if value == nil || (rw.isPtr && value == any(zero)) {
+		// as with reflective typed handlers, a nil *JsonResponse or *FileResponse is no content...
+		if rw.t == typeJsonResponsePtr || rw.t == typeFileResponsePtr {
+			writer.WriteHeader(http.StatusNoContent)
+		} else {
+			writer.WriteHeader(http.StatusOK)
+		}
+		return
+	}
+	handled := false
+	switch vt := value.(type) {
+	case FileResponse:
+		vt.write(writer, request)
+		handled = true
+	case *FileResponse:
+		vt.write(writer, request)
+		handled = true
+	default:
+		if rw.isSeq {
+			handled = streamSeq(reflect.ValueOf(value), rw.withErr, b, thisApi, 0, writer, request)
+		} else if rh := b.getResponseHandler(thisApi); rh != nil {
+			rh.WriteResponse(writer, request, value, 0, thisApi)
+			handled = true
+		} else {
+			handled = writeValue(value, b, thisApi, writer, request)
+		}
+	}
+	if !handled {
+		writer.WriteHeader(http.StatusOK)
+	}
+}
+
+func writeValue(value any, b *builder, thisApi any, writer http.ResponseWriter, request *http.Request) bool {
+	switch vt := value.(type) {
+	case []byte:
+		return writeBytes(vt, 0, writer)
+	case JsonResponse:
+		if err := vt.write(writer); err != nil {
+			b.getErrorHandler(thisApi).HandleError(writer, request, err)
+		}
+	case *JsonResponse:
+		if err := vt.write(writer); err != nil {
+			b.getErrorHandler(thisApi).HandleError(writer, request, err)
+		}
+	case ResponseMarshaler:
+		return writeResponseMarshaler(vt, b, thisApi, 0, writer, request)
+	default:
+		return writeMarshaled(value, b, thisApi, 0, writer, request)
+	}
+	return true
+}
+
+// InferDocs returns a copy of the chioas.Method with the request and response documentation inferred
+// from the types of a GenericHandler (if the method handler is a GenericHandler)
+//
+// The request is only inferred if chioas.Method.Request is nil, and the response is only inferred if
+// there are no success (2xx) responses in chioas.Method.Responses
+//
+// InferDocs must be called explicitly when defining the method (the docs are not otherwise inferred) and only
+// the request body and responses are inferred (path and query params are not)
+func InferDocs(m chioas.Method) chioas.Method {
+	if gh, ok := m.Handler.(*GenericHandler); ok {
+		if m.Request == nil && gh.requestType != nil {
+			schema, isArray := schemaForDocs(gh.requestType)
+			m.Request = &chioas.Request{
+				Schema:  schema,
+				IsArray: isArray,
+			}
+		}
+		if !hasSuccessResponse(m.Responses) {
+			responses := make(chioas.Responses, len(m.Responses)+1)
+			for sc, r := range m.Responses {
+				responses[sc] = r
+			}
+			switch gh.responseType {
+			case typeFileResponse, typeFileResponsePtr:
+				for sc, r := range FileResponses("") {
+					responses[sc] = r
+				}
+			case typeBodySliceByte, typeJsonResponse, typeJsonResponsePtr, typeAny:
+				responses[http.StatusOK] = chioas.Response{}
+			default:
+				if gh.responseType.Implements(interfaceTypeResponseMarshaler) {
+					responses[http.StatusOK] = chioas.Response{}
+				} else if isSeq, _, _ := seqType(gh.responseType); isSeq {
+					responses[http.StatusOK] = streamResponse("", schemaForType(gh.responseType.In(0).In(0)))
+				} else {
+					schema, isArray := schemaForDocs(gh.responseType)
+					responses[http.StatusOK] = chioas.Response{
+						Schema:  schema,
+						IsArray: isArray,
+					}
+				}
+			}
+			m.Responses = responses
+		}
+	}
+	return m
+}
+
+var (
+	typeFileResponse    = reflect.TypeFor[FileResponse]()
+	typeFileResponsePtr = reflect.TypeFor[*FileResponse]()
+	typeJsonResponse    = reflect.TypeFor[JsonResponse]()
+	typeJsonResponsePtr = reflect.TypeFor[*JsonResponse]()
+	typeAny             = reflect.TypeFor[any]()
+)
+
+func schemaForDocs(t reflect.Type) (*chioas.Schema, bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		return schemaForType(t.Elem()), true
+	}
+	return schemaForType(t), false
+}
+
+func hasSuccessResponse(responses chioas.Responses) bool {
+	for sc := range responses {
+		if sc >= http.StatusOK && sc < http.StatusMultipleChoices {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,383 @@
+package typed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-andiamo/chioas"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type genericReq struct {
+	Name string `json:"name"`
+}
+
+type genericResp struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestHandle(t *testing.T) {
+	gh := Handle(func(ctx context.Context, req genericReq) (*genericResp, error) {
+		if req.Name == "" {
+			return nil, NewApiError(http.StatusBadRequest, "name required")
+		} else if req.Name == "nil" {
+			return nil, nil
+		}
+		return &genericResp{Id: 1, Name: req.Name}, nil
+	})
+	assert.Equal(t, reflect.TypeFor[genericReq](), gh.RequestType())
+	assert.Equal(t, reflect.TypeFor[*genericResp](), gh.ResponseType())
+	mhb := NewTypedMethodsHandlerBuilder()
+	hf, err := mhb.BuildHandler("/", http.MethodPost, chioas.Method{Handler: gh}, nil)
+	require.NoError(t, err)
+	testCases := []struct {
+		body         string
+		expectStatus int
+		expectBody   string
+	}{
+		{
+			body:         `{"name":"foo"}`,
+			expectStatus: http.StatusOK,
+			expectBody:   `{"id":1,"name":"foo"}`,
+		},
+		{
+			body:         `{}`,
+			expectStatus: http.StatusBadRequest,
+			expectBody:   `name required`,
+		},
+		{
+			body:         `{"name":"nil"}`,
+			expectStatus: http.StatusOK,
+			expectBody:   ``,
+		},
+		{
+			body:         `not json`,
+			expectStatus: http.StatusInternalServerError,
+			expectBody:   `invalid character 'o' in literal null (expecting 'u')`,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]", i), func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+			res := httptest.NewRecorder()
+			hf.ServeHTTP(res, req)
+			assert.Equal(t, tc.expectStatus, res.Code)
+			assert.Equal(t, tc.expectBody, res.Body.String())
+		})
+	}
+}
+
+func TestHandle_NoBody(t *testing.T) {
+	gh := Handle(func(ctx context.Context, req genericReq) (*genericResp, error) {
+		return &genericResp{Name: req.Name}, nil
+	})
+	mhb := NewTypedMethodsHandlerBuilder()
+	hf, err := mhb.BuildHandler("/", http.MethodGet, chioas.Method{Handler: gh}, nil)
+	require.NoError(t, err)
+	testCases := []struct {
+		body       io.Reader
+		expectBody string
+	}{
+		{
+			body:       nil,
+			expectBody: `{"id":0,"name":""}`,
+		},
+		{
+			body:       http.NoBody,
+			expectBody: `{"id":0,"name":""}`,
+		},
+		{
+			body:       strings.NewReader(""),
+			expectBody: `{"id":0,"name":""}`,
+		},
+		{
+			body:       io.NopCloser(strings.NewReader("")),
+			expectBody: `{"id":0,"name":""}`,
+		},
+		{
+			body:       io.NopCloser(strings.NewReader(`{"name":"foo"}`)),
+			expectBody: `{"id":0,"name":"foo"}`,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]", i), func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/", tc.body)
+			res := httptest.NewRecorder()
+			hf.ServeHTTP(res, req)
+			assert.Equal(t, http.StatusOK, res.Code)
+			assert.Equal(t, tc.expectBody, res.Body.String())
+		})
+	}
+
+	// server requests...
+	srv := httptest.NewServer(hf)
+	defer srv.Close()
+	res, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func TestHandle_Unmarshaler(t *testing.T) {
+	mhb := NewTypedMethodsHandlerBuilder(MultiUnmarshaler)
+	hf, err := mhb.BuildHandler("/", http.MethodPost, chioas.Method{
+		Handler: Handle(func(ctx context.Context, req genericReq) (genericResp, error) {
+			return genericResp{Name: req.Name}, nil
+		}),
+	}, nil)
+	require.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader("name: foo"))
+	req.Header.Set(hdrContentType, contentTypeYaml)
+	res := httptest.NewRecorder()
+	hf.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, `{"id":0,"name":"foo"}`, res.Body.String())
+
+	req, _ = http.NewRequest(http.MethodPost, "/", strings.NewReader("name: foo"))
+	req.Header.Set(hdrContentType, "text/plain")
+	res = httptest.NewRecorder()
+	hf.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, res.Code)
+}
+
+func TestHandle_ErrorHandler(t *testing.T) {
+	mhb := NewTypedMethodsHandlerBuilder(&testErrorHandler{})
+	hf, err := mhb.BuildHandler("/", http.MethodGet, chioas.Method{
+		Handler: HandleNoBody(func(ctx context.Context) (any, error) {
+			return nil, errors.New("fooey")
+		}),
+	}, nil)
+	require.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	res := httptest.NewRecorder()
+	hf.ServeHTTP(res, req)
+	// test error handler writes nothing...
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "", res.Body.String())
+}
+
+func TestHandle_ResponseHandler(t *testing.T) {
+	mhb := NewTypedMethodsHandlerBuilder()
+	hf, err := mhb.BuildHandler("/", http.MethodGet, chioas.Method{
+		Handler: HandleNoBody(func(ctx context.Context) (genericResp, error) {
+			return genericResp{}, nil
+		}),
+	}, &testApiWithResponseHandler{statusCode: http.StatusTeapot})
+	require.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	res := httptest.NewRecorder()
+	hf.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusTeapot, res.Code)
+}
+
+func TestHandleNoBody_PathParams(t *testing.T) {
+	def := chioas.Definition{
+		MethodHandlerBuilder: NewTypedMethodsHandlerBuilder(),
+		Paths: chioas.Paths{
+			"/people/{id}": {
+				Methods: chioas.Methods{
+					http.MethodGet: {
+						Handler: HandleNoBody(func(ctx context.Context) (string, error) {
+							return chi.URLParamFromCtx(ctx, "id"), nil
+						}),
+					},
+				},
+			},
+		},
+	}
+	router := chi.NewRouter()
+	require.NoError(t, def.SetupRoutes(router, nil))
+	req, _ := http.NewRequest(http.MethodGet, "/people/123", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, `"123"`, res.Body.String())
+}
+
+func TestHandle_ResponseTypes(t *testing.T) {
+	testCases := []struct {
+		handler      *GenericHandler
+		expectStatus int
+		expectBody   string
+	}{
+		{
+			handler: HandleNoBody(func(ctx context.Context) ([]byte, error) {
+				return []byte("raw"), nil
+			}),
+			expectStatus: http.StatusOK,
+			expectBody:   "raw",
+		},
+		{
+			handler: HandleNoBody(func(ctx context.Context) ([]byte, error) {
+				return nil, nil
+			}),
+			expectStatus: http.StatusNoContent,
+		},
+		{
+			handler: HandleNoBody(func(ctx context.Context) ([]byte, error) {
+				return []byte{}, nil
+			}),
+			expectStatus: http.StatusNoContent,
+		},
+		{
+			handler: HandleNoBody(func(ctx context.Context) (JsonResponse, error) {
+				return JsonResponse{StatusCode: http.StatusCreated, Body: "created"}, nil
+			}),
+			expectStatus: http.StatusCreated,
+			expectBody:   `"created"`,
+		},
+		{
+			handler: HandleNoBody(func(ctx context.Context) (*JsonResponse, error) {
+				return &JsonResponse{Error: errors.New("fooey")}, nil
+			}),
+			expectStatus: http.StatusInternalServerError,
+			expectBody:   `fooey`,
+		},
+		{
+			handler: HandleNoBody(func(ctx context.Context) (*JsonResponse, error) {
+				return nil, nil
+			}),
+			expectStatus: http.StatusNoContent,
+		},
+		{
+			handler: HandleNoBody(func(ctx context.Context) (*FileResponse, error) {
+				return nil, nil
+			}),
+			expectStatus: http.StatusNoContent,
+		},
+		{
+			handler: HandleNoBody(func(ctx context.Context) (*genericResp, error) {
+				return nil, nil
+			}),
+			expectStatus: http.StatusOK,
+		},
+		{
+			handler: HandleNoBody(func(ctx context.Context) (ResponseMarshaler, error) {
+				return &testResponseMarshaler{data: []byte("marshaled"), statusCode: http.StatusAccepted}, nil
+			}),
+			expectStatus: http.StatusAccepted,
+			expectBody:   `marshaled`,
+		},
+		{
+			handler: HandleNoBody(func(ctx context.Context) (FileResponse, error) {
+				return FileResponse{Name: "test.txt", Content: strings.NewReader("file")}, nil
+			}),
+			expectStatus: http.StatusOK,
+			expectBody:   `file`,
+		},
+		{
+			handler: HandleNoBody(func(ctx context.Context) (iter.Seq[streamItem], error) {
+				return streamItems(1), nil
+			}),
+			expectStatus: http.StatusOK,
+			expectBody:   `[{"id":0,"name":"item"}]`,
+		},
+		{
+			handler: HandleNoBody(func(ctx context.Context) (map[string]any, error) {
+				return map[string]any{"foo": func() {}}, nil
+			}),
+			expectStatus: http.StatusInternalServerError,
+			expectBody:   `json: unsupported type: func()`,
+		},
+	}
+	mhb := NewTypedMethodsHandlerBuilder()
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]", i), func(t *testing.T) {
+			hf, err := mhb.BuildHandler("/", http.MethodGet, chioas.Method{Handler: tc.handler}, nil)
+			require.NoError(t, err)
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			res := httptest.NewRecorder()
+			hf.ServeHTTP(res, req)
+			assert.Equal(t, tc.expectStatus, res.Code)
+			assert.Equal(t, tc.expectBody, res.Body.String())
+		})
+	}
+}
+
+func TestInferDocs(t *testing.T) {
+	m := InferDocs(chioas.Method{
+		Handler: Handle(func(ctx context.Context, req []genericReq) (*genericResp, error) {
+			return nil, nil
+		}),
+		Responses: chioas.Responses{
+			http.StatusBadRequest: {Description: "bad request"},
+		},
+	})
+	require.NotNil(t, m.Request)
+	assert.True(t, m.Request.IsArray)
+	assert.Equal(t, "object", m.Request.Schema.(*chioas.Schema).Type)
+	assert.Len(t, m.Responses, 2)
+	assert.False(t, m.Responses[http.StatusOK].IsArray)
+	assert.Len(t, m.Responses[http.StatusOK].Schema.(*chioas.Schema).Properties, 2)
+
+	// existing docs not overwritten...
+	m = InferDocs(chioas.Method{
+		Handler: Handle(func(ctx context.Context, req genericReq) (genericResp, error) {
+			return genericResp{}, nil
+		}),
+		Request: &chioas.Request{Description: "explicit"},
+		Responses: chioas.Responses{
+			http.StatusCreated: {Description: "explicit"},
+		},
+	})
+	assert.Equal(t, "explicit", m.Request.Description)
+	assert.Len(t, m.Responses, 1)
+
+	// response types...
+	m = InferDocs(chioas.Method{
+		Handler: HandleNoBody(func(ctx context.Context) (*FileResponse, error) {
+			return nil, nil
+		}),
+	})
+	assert.Nil(t, m.Request)
+	assert.Len(t, m.Responses, 4)
+	m = InferDocs(chioas.Method{
+		Handler: HandleNoBody(func(ctx context.Context) (iter.Seq[streamItem], error) {
+			return nil, nil
+		}),
+	})
+	assert.True(t, m.Responses[http.StatusOK].IsArray)
+	assert.Len(t, m.Responses[http.StatusOK].AlternativeContentTypes, 1)
+	m = InferDocs(chioas.Method{
+		Handler: HandleNoBody(func(ctx context.Context) (any, error) {
+			return nil, nil
+		}),
+	})
+	assert.Nil(t, m.Responses[http.StatusOK].Schema)
+	m = InferDocs(chioas.Method{
+		Handler: HandleNoBody(func(ctx context.Context) (*testResponseMarshaler, error) {
+			return nil, nil
+		}),
+	})
+	assert.Nil(t, m.Responses[http.StatusOK].Schema)
+
+	// not a generic handler...
+	m = InferDocs(chioas.Method{Handler: "Foo"})
+	assert.Nil(t, m.Request)
+	assert.Nil(t, m.Responses)
+}
+
+func BenchmarkHandle(b *testing.B) {
+	mhb := NewTypedMethodsHandlerBuilder()
+	hf, _ := mhb.BuildHandler("/", http.MethodPost, chioas.Method{
+		Handler: Handle(func(ctx context.Context, req genericReq) (genericResp, error) {
+			return genericResp{Name: req.Name}, nil
+		}),
+	}, nil)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"foo"}`))
+		res := httptest.NewRecorder()
+		hf.ServeHTTP(res, req)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/go-andiamo/chioas"
 	"github.com/go-andiamo/urit"
 	"github.com/go-chi/chi/v5"
 	"io"
@@ -419,6 +420,8 @@ var (
 	typeBasicAuthPtr   = reflect.TypeFor[*BasicAuth]()
 	typeBodySliceUint8 = reflect.TypeFor[[]uint8]()
 	typeBodySliceByte  = reflect.TypeFor[[]byte]()
+	typeOperationPtr   = reflect.TypeFor[*chioas.OperationInfo]()
+	typeOperation      = reflect.TypeFor[chioas.OperationInfo]()
 )
 
 func (inb *insBuilder) makeBuilderCommon(arg reflect.Type, i int) (ok bool, countBody int) {
@@ -467,6 +470,10 @@ func (inb *insBuilder) makeBuilderCommon(arg reflect.Type, i int) (ok bool, coun
 	case typeBodySliceUint8, typeBodySliceByte:
 		inb.valueBuilders[i] = commonBuilderByteBody
 		countBody = 1
+	case typeOperationPtr:
+		inb.valueBuilders[i] = commonBuilderOperationPtr
+	case typeOperation:
+		inb.valueBuilders[i] = commonBuilderOperation
 	default:
 		ok = false
 	}
@@ -515,6 +522,16 @@ func commonBuilderChiContext(argType reflect.Type, writer http.ResponseWriter, r
 	}
 	return reflect.ValueOf(chi.Context{}), nil
 }
+func commonBuilderOperationPtr(argType reflect.Type, writer http.ResponseWriter, request *http.Request, params []urit.PathVar) (reflect.Value, error) {
+	op, _ := chioas.OperationFromRequest(request)
+	return reflect.ValueOf(op), nil
+}
+func commonBuilderOperation(argType reflect.Type, writer http.ResponseWriter, request *http.Request, params []urit.PathVar) (reflect.Value, error) {
+	if op, ok := chioas.OperationFromRequest(request); ok {
+		return reflect.ValueOf(*op), nil
+	}
+	return reflect.ValueOf(chioas.OperationInfo{}), nil
+}
 func commonBuilderHttpHeader(argType reflect.Type, writer http.ResponseWriter, request *http.Request, params []urit.PathVar) (reflect.Value, error) {
 	return reflect.ValueOf(request.Header), nil
 }
@@ -677,6 +694,20 @@ func (inb *insBuilder) build(writer http.ResponseWriter, request *http.Request)
 				npos[k] = npos[k] + 1
 			}
 		}
+	} else if request.Pattern != "" {
+		// served by http.ServeMux (see chioas.Definition.SetupServeMux)...
+		vars := inb.pathTemplate.Vars()
+		params = make([]urit.PathVar, 0, len(vars))
+		npos := map[string]int{}
+		for i, v := range vars {
+			params = append(params, urit.PathVar{
+				Position:      i,
+				NamedPosition: npos[v.Name],
+				Name:          v.Name,
+				Value:         request.PathValue(v.Name),
+			})
+			npos[v.Name] = npos[v.Name] + 1
+		}
 	} else {
 		// fallback path params - if context is not Chi (usually during direct testing)
 		if matchParams, ok := inb.pathTemplate.MatchesRequest(request); ok {
@@ -283,6 +283,16 @@ func TestInsBuilder_MakeBuilders(t *testing.T) {
 			expectBuilders: 1,
 			checkTypes:     []any{commonBuilderBasicAuthPtr},
 		},
+		{
+			fn:             func(op *chioas.OperationInfo) {},
+			expectBuilders: 1,
+			checkTypes:     []any{commonBuilderOperationPtr},
+		},
+		{
+			fn:             func(op chioas.OperationInfo) {},
+			expectBuilders: 1,
+			checkTypes:     []any{commonBuilderOperation},
+		},
 		{
 			fn:             func(my myNamedQP) {},
 			expectBuilders: 1,
@@ -726,6 +736,39 @@ func TestCommonBuilderBasicAuthPtr(t *testing.T) {
 	assert.Equal(t, "password", av.Password)
 }
 
+func TestCommonBuilderOperation(t *testing.T) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	v, err := commonBuilderOperationPtr(dummyType, w, req, nil)
+	require.NoError(t, err)
+	assert.Nil(t, v.Interface())
+	v, err = commonBuilderOperation(dummyType, w, req, nil)
+	require.NoError(t, err)
+	assert.Equal(t, chioas.OperationInfo{}, v.Interface())
+
+	def := chioas.Definition{
+		MethodHandlerBuilder: NewTypedMethodsHandlerBuilder(),
+		Paths: chioas.Paths{
+			"/people/{id}": {
+				Methods: chioas.Methods{
+					http.MethodGet: {
+						OperationId: "getPerson",
+						Handler: func(op *chioas.OperationInfo, opv chioas.OperationInfo) (string, error) {
+							return op.OperationId + " " + opv.Path, nil
+						},
+					},
+				},
+			},
+		},
+	}
+	router := chi.NewRouter()
+	require.NoError(t, def.SetupRoutes(router, nil))
+	req = httptest.NewRequest(http.MethodGet, "/people/1", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"getPerson /people/{id}"`, w.Body.String())
+}
+
 func TestCommonBuilderByteBody(t *testing.T) {
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodGet, "/", nil)
@@ -1434,3 +1477,30 @@ type testErrorReader struct {
 func (t *testErrorReader) Read(p []byte) (n int, err error) {
 	return 0, errors.New("foo")
 }
+
+func TestInsBuilder_ServeMuxPathParams(t *testing.T) {
+	def := chioas.Definition{
+		MethodHandlerBuilder: NewTypedMethodsHandlerBuilder(),
+		Paths: chioas.Paths{
+			"/people/{id:[0-9]+}/{sub}": {
+				Methods: chioas.Methods{
+					http.MethodGet: {
+						Handler: func(id string, sub string, pps PathParams, op *chioas.OperationInfo) (string, error) {
+							return id + " " + sub + " " + pps["id"][0] + " " + op.Path, nil
+						},
+					},
+				},
+			},
+		},
+	}
+	mux := http.NewServeMux()
+	require.NoError(t, def.SetupServeMux(mux, nil, nil))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/people/1/foo", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"1 foo 1 /people/{id:[0-9]+}/{sub}"`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/people/x/foo", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -10,11 +10,12 @@ import (
 type outValueHandler = func(v reflect.Value, b *builder, thisApi any, statusCode int, writer http.ResponseWriter, request *http.Request) bool
 
 type outsBuilder struct {
-	len              int
-	errArg           int
-	statusCodeArg    int
-	marshableArg     int
-	marshableHandler outValueHandler
+	len                   int
+	errArg                int
+	statusCodeArg         int
+	marshableArg          int
+	marshableHandler      outValueHandler
+	bypassResponseHandler bool
 }
 
 func newOutsBuilder(mf reflect.Value) (*outsBuilder, error) {
@@ -74,6 +75,20 @@ func (ob *outsBuilder) makeHandlers(mft reflect.Type) error {
 			}
 			ob.marshableArg = i
 			ob.marshableHandler = jsonResponsePtrHandler
+		case "typed.FileResponse":
+			if ob.marshableArg != -1 {
+				return errors.New(errMultiMarshable)
+			}
+			ob.marshableArg = i
+			ob.marshableHandler = fileResponseHandler
+			ob.bypassResponseHandler = true
+		case "*typed.FileResponse":
+			if ob.marshableArg != -1 {
+				return errors.New(errMultiMarshable)
+			}
+			ob.marshableArg = i
+			ob.marshableHandler = fileResponsePtrHandler
+			ob.bypassResponseHandler = true
 		case "[]byte", "[]uint8":
 			if ob.marshableArg != -1 {
 				return errors.New(errMultiMarshable)
@@ -97,6 +112,19 @@ func (ob *outsBuilder) makeHandlers(mft reflect.Type) error {
 				}
 				ob.marshableArg = i
 				ob.marshableHandler = responseMarshalerHandler
+			} else if isSeq, withErr, err := seqType(arg); isSeq {
+				if err != nil {
+					return err
+				} else if ob.marshableArg != -1 {
+					return errors.New(errMultiMarshable)
+				}
+				ob.marshableArg = i
+				ob.bypassResponseHandler = true
+				if withErr {
+					ob.marshableHandler = seq2Handler
+				} else {
+					ob.marshableHandler = seqHandler
+				}
 			} else {
 				if ob.marshableArg != -1 {
 					return errors.New(errMultiMarshable)
@@ -126,10 +154,18 @@ func anyHandler(v reflect.Value, b *builder, thisApi any, statusCode int, writer
 			result = jsonResponseHandler(v, b, thisApi, statusCode, writer, request)
 		case *JsonResponse:
 			result = jsonResponsePtrHandler(v, b, thisApi, statusCode, writer, request)
+		case FileResponse:
+			result = fileResponseHandler(v.Elem(), b, thisApi, statusCode, writer, request)
+		case *FileResponse:
+			result = fileResponsePtrHandler(v.Elem(), b, thisApi, statusCode, writer, request)
 		case ResponseMarshaler:
 			result = responseMarshalerHandler(v, b, thisApi, statusCode, writer, request)
 		default:
-			result = marshalerHandler(v, b, thisApi, statusCode, writer, request)
+			if isSeq, withErr, err := seqType(v.Elem().Type()); isSeq && err == nil {
+				result = streamSeq(v.Elem(), withErr, b, thisApi, statusCode, writer, request)
+			} else {
+				result = marshalerHandler(v, b, thisApi, statusCode, writer, request)
+			}
 		}
 	}
 	return result
@@ -168,7 +204,11 @@ func jsonResponsePtrHandler(v reflect.Value, b *builder, thisApi any, statusCode
 }
 
 func bytesResponseHandler(v reflect.Value, b *builder, thisApi any, statusCode int, writer http.ResponseWriter, request *http.Request) bool {
-	if data := v.Interface().([]byte); len(data) > 0 {
+	return writeBytes(v.Interface().([]byte), statusCode, writer)
+}
+
+func writeBytes(data []byte, statusCode int, writer http.ResponseWriter) bool {
+	if len(data) > 0 {
 		writer.WriteHeader(defaultStatusCode(statusCode, http.StatusOK))
 		_, _ = writer.Write(data)
 	} else {
@@ -179,28 +219,35 @@ func bytesResponseHandler(v reflect.Value, b *builder, thisApi any, statusCode i
 
 func responseMarshalerHandler(v reflect.Value, b *builder, thisApi any, statusCode int, writer http.ResponseWriter, request *http.Request) bool {
 	if v.IsValid() && !v.IsZero() && !v.IsNil() {
-		rm := v.Interface().(ResponseMarshaler)
-		if data, sc, hdrs, err := rm.Marshal(request); err == nil {
-			if len(data) == 0 {
-				sc = defaultStatusCode(sc, defaultStatusCode(statusCode, http.StatusNoContent))
-			} else {
-				sc = defaultStatusCode(sc, defaultStatusCode(statusCode, http.StatusOK))
-			}
-			for _, hd := range hdrs {
-				writer.Header().Set(hd[0], hd[1])
-			}
-			writer.WriteHeader(sc)
-			_, _ = writer.Write(data)
+		return writeResponseMarshaler(v.Interface().(ResponseMarshaler), b, thisApi, statusCode, writer, request)
+	}
+	return false
+}
+
+func writeResponseMarshaler(rm ResponseMarshaler, b *builder, thisApi any, statusCode int, writer http.ResponseWriter, request *http.Request) bool {
+	if data, sc, hdrs, err := rm.Marshal(request); err == nil {
+		if len(data) == 0 {
+			sc = defaultStatusCode(sc, defaultStatusCode(statusCode, http.StatusNoContent))
 		} else {
-			b.getErrorHandler(thisApi).HandleError(writer, request, err)
+			sc = defaultStatusCode(sc, defaultStatusCode(statusCode, http.StatusOK))
 		}
-		return true
+		for _, hd := range hdrs {
+			writer.Header().Set(hd[0], hd[1])
+		}
+		writer.WriteHeader(sc)
+		_, _ = writer.Write(data)
+	} else {
+		b.getErrorHandler(thisApi).HandleError(writer, request, err)
 	}
-	return false
+	return true
 }
 
 func marshalerHandler(v reflect.Value, b *builder, thisApi any, statusCode int, writer http.ResponseWriter, request *http.Request) bool {
-	if data, err := json.Marshal(v.Interface()); err == nil {
+	return writeMarshaled(v.Interface(), b, thisApi, statusCode, writer, request)
+}
+
+func writeMarshaled(value any, b *builder, thisApi any, statusCode int, writer http.ResponseWriter, request *http.Request) bool {
+	if data, err := json.Marshal(value); err == nil {
 		writer.Header().Set(hdrContentType, contentTypeJson)
 		writer.WriteHeader(defaultStatusCode(statusCode, http.StatusOK))
 		_, _ = writer.Write(data)
@@ -234,7 +281,7 @@ func (ob *outsBuilder) handleReturnArgs(retArgs []reflect.Value, b *builder, thi
 	}
 	handled := false
 	if ob.marshableArg != -1 && retArgs[ob.marshableArg].IsValid() {
-		if rh := b.getResponseHandler(thisApi); rh != nil {
+		if rh := b.getResponseHandler(thisApi); rh != nil && !ob.bypassResponseHandler && !bypassesResponseHandler(retArgs[ob.marshableArg]) {
 			handled = true
 			rh.WriteResponse(writer, request, retArgs[ob.marshableArg].Interface(), statusCode, thisApi)
 		} else {
@@ -245,3 +292,17 @@ func (ob *outsBuilder) handleReturnArgs(retArgs []reflect.Value, b *builder, thi
 		writer.WriteHeader(defaultStatusCode(statusCode, http.StatusOK))
 	}
 }
+
+// bypassesResponseHandler determines whether a value returned as an any is a stream (iter.Seq/iter.Seq2) or file response
+// - which, as with the statically typed returns, bypass any ResponseHandler
+func bypassesResponseHandler(v reflect.Value) bool {
+	if v.Kind() != reflect.Interface || v.IsNil() {
+		return false
+	}
+	switch v.Interface().(type) {
+	case FileResponse, *FileResponse:
+		return true
+	}
+	isSeq, _, err := seqType(v.Elem().Type())
+	return isSeq && err == nil
+}
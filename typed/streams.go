@@ -0,0 +1,255 @@
+package typed
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-andiamo/chioas"
+	"github.com/go-andiamo/chioas/internal/values"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+const (
+	contentTypeNdJson = "application/x-ndjson"
+	hdrTrailer        = "Trailer"
+	// StreamErrorTrailer is the name of the http trailer that is set (with the error message) when an error
+	// occurs part way through streaming an iter.Seq / iter.Seq2 response
+	StreamErrorTrailer = "X-Stream-Error"
+)
+
+const errSeq2NotError = "iter.Seq2 return arg must have error as second type (i.e. iter.Seq2[T, error])"
+
+// seqType determines whether the type is an iterator func (e.g. iter.Seq[T] or iter.Seq2[T, error])
+func seqType(t reflect.Type) (isSeq bool, withErr bool, err error) {
+	if t.Kind() == reflect.Func && t.NumIn() == 1 && t.NumOut() == 0 {
+		if yt := t.In(0); yt.Kind() == reflect.Func && yt.NumOut() == 1 && yt.Out(0).Kind() == reflect.Bool {
+			switch yt.NumIn() {
+			case 1:
+				isSeq = true
+			case 2:
+				isSeq = true
+				if withErr = yt.In(1) == interfaceTypeError; !withErr {
+					err = errors.New(errSeq2NotError)
+				}
+			}
+		}
+	}
+	return
+}
+
+func seqHandler(v reflect.Value, b *builder, thisApi any, statusCode int, writer http.ResponseWriter, request *http.Request) bool {
+	return streamSeq(v, false, b, thisApi, statusCode, writer, request)
+}
+
+func seq2Handler(v reflect.Value, b *builder, thisApi any, statusCode int, writer http.ResponseWriter, request *http.Request) bool {
+	return streamSeq(v, true, b, thisApi, statusCode, writer, request)
+}
+
+func streamSeq(v reflect.Value, withErr bool, b *builder, thisApi any, statusCode int, writer http.ResponseWriter, request *http.Request) bool {
+	if v.IsNil() {
+		return false
+	}
+	sw := &streamWriter{
+		writer:     writer,
+		request:    request,
+		ndJson:     acceptsNdJson(request),
+		statusCode: statusCode,
+	}
+	if err := sw.stream(v, withErr); err != nil {
+		if sw.started {
+			sw.fail(err)
+		} else if rh := b.getResponseHandler(thisApi); rh != nil {
+			rh.WriteErrorResponse(writer, request, err, thisApi)
+		} else {
+			b.getErrorHandler(thisApi).HandleError(writer, request, err)
+		}
+	}
+	return true
+}
+
+// acceptsNdJson determines whether the request `Accept` header prefers NDJSON over JSON
+func acceptsNdJson(request *http.Request) bool {
+	for _, accept := range request.Header.Values(hdrAccept) {
+		for _, mt := range strings.Split(accept, ",") {
+			if cAt := strings.IndexByte(mt, ';'); cAt != -1 {
+				mt = mt[:cAt]
+			}
+			switch strings.TrimSpace(mt) {
+			case contentTypeNdJson:
+				return true
+			case contentTypeJson:
+				return false
+			}
+		}
+	}
+	return false
+}
+
+// streamWriter writes iterator items to the response as either a JSON array or NDJSON
+//
+// nothing is written to the response until the first item is available - so that any error
+// yielded before the first item can still be written as a normal error response
+type streamWriter struct {
+	writer     http.ResponseWriter
+	request    *http.Request
+	ndJson     bool
+	statusCode int
+	started    bool
+	count      int
+	writeErr   error
+}
+
+func (sw *streamWriter) stream(v reflect.Value, withErr bool) error {
+	ctx := sw.request.Context()
+	if withErr {
+		for item, errV := range v.Seq2() {
+			if !errV.IsNil() {
+				return errV.Interface().(error)
+			} else if err := sw.item(item); err != nil {
+				return err
+			} else if sw.writeErr != nil || ctx.Err() != nil {
+				// client has gone away - nothing more can be written
+				return nil
+			}
+		}
+	} else {
+		for item := range v.Seq() {
+			if err := sw.item(item); err != nil {
+				return err
+			} else if sw.writeErr != nil || ctx.Err() != nil {
+				// client has gone away - nothing more can be written
+				return nil
+			}
+		}
+	}
+	sw.end()
+	return nil
+}
+
+func (sw *streamWriter) start() {
+	sw.started = true
+	if sw.ndJson {
+		sw.writer.Header().Set(hdrContentType, contentTypeNdJson)
+	} else {
+		sw.writer.Header().Set(hdrContentType, contentTypeJson)
+	}
+	sw.writer.Header().Add(hdrTrailer, StreamErrorTrailer)
+	sw.writer.WriteHeader(defaultStatusCode(sw.statusCode, http.StatusOK))
+	if !sw.ndJson {
+		sw.write([]byte{'['})
+	}
+}
+
+func (sw *streamWriter) item(v reflect.Value) error {
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return err
+	}
+	if !sw.started {
+		sw.start()
+	}
+	if sw.ndJson {
+		sw.write(append(data, '\n'))
+		sw.flush()
+	} else {
+		if sw.count > 0 {
+			sw.write([]byte{','})
+		}
+		sw.write(data)
+	}
+	sw.count++
+	return nil
+}
+
+func (sw *streamWriter) end() {
+	if !sw.started {
+		sw.start()
+	}
+	if !sw.ndJson {
+		sw.write([]byte{']'})
+	}
+	sw.flush()
+}
+
+// fail handles an error that occurs after the response has started
+//
+// the status code has already been written, so the error is reported in the StreamErrorTrailer - and, for NDJSON,
+// as a final error line.  For a JSON array, the array is deliberately left unterminated so that clients
+// cannot mistake the truncated response for a complete one
+func (sw *streamWriter) fail(err error) {
+	sw.writer.Header().Set(StreamErrorTrailer, err.Error())
+	if sw.ndJson {
+		var data []byte
+		if em, ok := err.(json.Marshaler); ok {
+			data, _ = em.MarshalJSON()
+		}
+		if len(data) == 0 {
+			data, _ = json.Marshal(map[string]string{"error": err.Error()})
+		}
+		sw.write(append(data, '\n'))
+	}
+	sw.flush()
+}
+
+func (sw *streamWriter) write(data []byte) {
+	if sw.writeErr == nil {
+		_, sw.writeErr = sw.writer.Write(data)
+	}
+}
+
+func (sw *streamWriter) flush() {
+	if sw.writeErr == nil {
+		_ = http.NewResponseController(sw.writer).Flush()
+	}
+}
+
+// StreamResponse creates a chioas.Response that documents a typed handler returning
+// an iter.Seq[T] (or iter.Seq2[T, error])
+//
+// The response is documented as an array of T - for both "application/json" and "application/x-ndjson" content types
+//
+// The schema for T is derived from the type (see chioas.Schema.From) - if T is a component schema, you may want to
+// clear the Schema and set the SchemaRef on the returned response
+func StreamResponse[T any](description string) chioas.Response {
+	return streamResponse(description, schemaForType(reflect.TypeFor[T]()))
+}
+
+func streamResponse(description string, schema *chioas.Schema) chioas.Response {
+	return chioas.Response{
+		Description: description,
+		Schema:      schema,
+		IsArray:     true,
+		AlternativeContentTypes: chioas.ContentTypes{
+			contentTypeNdJson: {
+				Schema:  schema,
+				IsArray: true,
+			},
+		},
+	}
+}
+
+func schemaForType(t reflect.Type) *chioas.Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if s, err := (&chioas.Schema{}).From(t); err == nil {
+			return s
+		}
+		return &chioas.Schema{Type: values.TypeObject}
+	case reflect.String:
+		return &chioas.Schema{Type: values.TypeString}
+	case reflect.Bool:
+		return &chioas.Schema{Type: values.TypeBoolean}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &chioas.Schema{Type: values.TypeInteger}
+	case reflect.Float32, reflect.Float64:
+		return &chioas.Schema{Type: values.TypeNumber}
+	case reflect.Slice, reflect.Array:
+		return &chioas.Schema{Type: values.TypeArray}
+	}
+	return &chioas.Schema{Type: values.TypeObject}
+}
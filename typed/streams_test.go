@@ -0,0 +1,422 @@
+package typed
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-andiamo/chioas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type streamItem struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func streamItems(n int) iter.Seq[streamItem] {
+	return func(yield func(streamItem) bool) {
+		for i := 0; i < n; i++ {
+			if !yield(streamItem{Id: i, Name: "item"}) {
+				return
+			}
+		}
+	}
+}
+
+func streamItemsErr(n int, failAt int) iter.Seq2[streamItem, error] {
+	return func(yield func(streamItem, error) bool) {
+		for i := 0; i < n; i++ {
+			if i == failAt {
+				yield(streamItem{}, errors.New("fooey"))
+				return
+			}
+			if !yield(streamItem{Id: i, Name: "item"}, nil) {
+				return
+			}
+		}
+	}
+}
+
+func TestSeqType(t *testing.T) {
+	testCases := []struct {
+		fn            any
+		expectSeq     bool
+		expectWithErr bool
+		expectErr     bool
+	}{
+		{
+			fn:        streamItems(0),
+			expectSeq: true,
+		},
+		{
+			fn:            streamItemsErr(0, -1),
+			expectSeq:     true,
+			expectWithErr: true,
+		},
+		{
+			fn:        func(yield func(string) bool) {},
+			expectSeq: true,
+		},
+		{
+			fn:        func(yield func(int, string) bool) {},
+			expectSeq: true,
+			expectErr: true,
+		},
+		{
+			fn: func(yield func(string)) {},
+		},
+		{
+			fn: func() {},
+		},
+		{
+			fn: "not a func",
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]", i), func(t *testing.T) {
+			isSeq, withErr, err := seqType(reflect.TypeOf(tc.fn))
+			assert.Equal(t, tc.expectSeq, isSeq)
+			assert.Equal(t, tc.expectWithErr, withErr)
+			if tc.expectErr {
+				assert.Error(t, err)
+				assert.Equal(t, errSeq2NotError, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestStream_JsonArray(t *testing.T) {
+	mhb := NewTypedMethodsHandlerBuilder()
+	hf, err := mhb.BuildHandler("/", http.MethodGet, chioas.Method{
+		Handler: func() iter.Seq[streamItem] {
+			return streamItems(3)
+		},
+	}, nil)
+	require.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	res := httptest.NewRecorder()
+	hf.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, contentTypeJson, res.Header().Get(hdrContentType))
+	assert.Equal(t, `[{"id":0,"name":"item"},{"id":1,"name":"item"},{"id":2,"name":"item"}]`, res.Body.String())
+	assert.Equal(t, "", res.Result().Trailer.Get(StreamErrorTrailer))
+}
+
+func TestStream_JsonArray_Empty(t *testing.T) {
+	mhb := NewTypedMethodsHandlerBuilder()
+	hf, err := mhb.BuildHandler("/", http.MethodGet, chioas.Method{
+		Handler: func() (iter.Seq[streamItem], error) {
+			return streamItems(0), nil
+		},
+	}, nil)
+	require.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	res := httptest.NewRecorder()
+	hf.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, `[]`, res.Body.String())
+}
+
+func TestStream_StatusCode(t *testing.T) {
+	mhb := NewTypedMethodsHandlerBuilder()
+	hf, err := mhb.BuildHandler("/", http.MethodGet, chioas.Method{
+		Handler: func() (iter.Seq[streamItem], int, error) {
+			return streamItems(1), http.StatusPartialContent, nil
+		},
+	}, nil)
+	require.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	res := httptest.NewRecorder()
+	hf.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusPartialContent, res.Code)
+	assert.Equal(t, `[{"id":0,"name":"item"}]`, res.Body.String())
+}
+
+func TestStream_NilSeq(t *testing.T) {
+	mhb := NewTypedMethodsHandlerBuilder()
+	hf, err := mhb.BuildHandler("/", http.MethodGet, chioas.Method{
+		Handler: func() (iter.Seq[streamItem], int) {
+			return nil, http.StatusNoContent
+		},
+	}, nil)
+	require.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	res := httptest.NewRecorder()
+	hf.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusNoContent, res.Code)
+	assert.Equal(t, "", res.Body.String())
+}
+
+func TestStream_NdJson(t *testing.T) {
+	mhb := NewTypedMethodsHandlerBuilder()
+	hf, err := mhb.BuildHandler("/", http.MethodGet, chioas.Method{
+		Handler: func() iter.Seq[streamItem] {
+			return streamItems(2)
+		},
+	}, nil)
+	require.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(hdrAccept, "application/x-ndjson, application/json;q=0.5")
+	res := httptest.NewRecorder()
+	hf.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, contentTypeNdJson, res.Header().Get(hdrContentType))
+	assert.Equal(t, "{\"id\":0,\"name\":\"item\"}\n{\"id\":1,\"name\":\"item\"}\n", res.Body.String())
+	assert.True(t, res.Flushed)
+}
+
+func TestStream_Seq2_ErrorBeforeFirst(t *testing.T) {
+	mhb := NewTypedMethodsHandlerBuilder()
+	hf, err := mhb.BuildHandler("/", http.MethodGet, chioas.Method{
+		Handler: func() iter.Seq2[streamItem, error] {
+			return streamItemsErr(3, 0)
+		},
+	}, nil)
+	require.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	res := httptest.NewRecorder()
+	hf.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.Equal(t, "fooey", res.Body.String())
+}
+
+func TestStream_Seq2_ErrorBeforeFirst_ResponseHandler(t *testing.T) {
+	mhb := NewTypedMethodsHandlerBuilder(&testApiWithResponseHandler{statusCode: http.StatusTeapot})
+	hf, err := mhb.BuildHandler("/", http.MethodGet, chioas.Method{
+		Handler: func() iter.Seq2[streamItem, error] {
+			return streamItemsErr(3, 0)
+		},
+	}, nil)
+	require.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	res := httptest.NewRecorder()
+	hf.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusTeapot, res.Code)
+}
+
+func TestStream_Seq2_ErrorMidStream_JsonArray(t *testing.T) {
+	mhb := NewTypedMethodsHandlerBuilder()
+	hf, err := mhb.BuildHandler("/", http.MethodGet, chioas.Method{
+		Handler: func() iter.Seq2[streamItem, error] {
+			return streamItemsErr(3, 2)
+		},
+	}, nil)
+	require.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	res := httptest.NewRecorder()
+	hf.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	// array deliberately not terminated...
+	assert.Equal(t, `[{"id":0,"name":"item"},{"id":1,"name":"item"}`, res.Body.String())
+	assert.Equal(t, "fooey", res.Result().Trailer.Get(StreamErrorTrailer))
+}
+
+func TestStream_Seq2_ErrorMidStream_NdJson(t *testing.T) {
+	mhb := NewTypedMethodsHandlerBuilder()
+	hf, err := mhb.BuildHandler("/", http.MethodGet, chioas.Method{
+		Handler: func() iter.Seq2[streamItem, error] {
+			return streamItemsErr(3, 1)
+		},
+	}, nil)
+	require.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(hdrAccept, contentTypeNdJson)
+	res := httptest.NewRecorder()
+	hf.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "{\"id\":0,\"name\":\"item\"}\n{\"error\":\"fooey\"}\n", res.Body.String())
+	assert.Equal(t, "fooey", res.Result().Trailer.Get(StreamErrorTrailer))
+}
+
+func TestStream_MarshalError(t *testing.T) {
+	mhb := NewTypedMethodsHandlerBuilder()
+	hf, err := mhb.BuildHandler("/", http.MethodGet, chioas.Method{
+		Handler: func() iter.Seq[any] {
+			return func(yield func(any) bool) {
+				_ = yield(func() {})
+			}
+		},
+	}, nil)
+	require.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	res := httptest.NewRecorder()
+	hf.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+}
+
+func TestStream_AnyReturn(t *testing.T) {
+	mhb := NewTypedMethodsHandlerBuilder()
+	hf, err := mhb.BuildHandler("/", http.MethodGet, chioas.Method{
+		Handler: func() (any, error) {
+			return streamItems(1), nil
+		},
+	}, nil)
+	require.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	res := httptest.NewRecorder()
+	hf.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, `[{"id":0,"name":"item"}]`, res.Body.String())
+}
+
+func TestStream_BypassesResponseHandler(t *testing.T) {
+	mhb := NewTypedMethodsHandlerBuilder(&testApiWithResponseHandler{statusCode: http.StatusTeapot})
+	hf, err := mhb.BuildHandler("/", http.MethodGet, chioas.Method{
+		Handler: func() iter.Seq[streamItem] {
+			return streamItems(1)
+		},
+	}, nil)
+	require.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	res := httptest.NewRecorder()
+	hf.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, `[{"id":0,"name":"item"}]`, res.Body.String())
+}
+
+func TestStream_AnyReturn_BypassesResponseHandler(t *testing.T) {
+	mhb := NewTypedMethodsHandlerBuilder(&testApiWithResponseHandler{statusCode: http.StatusTeapot})
+	hf, err := mhb.BuildHandler("/", http.MethodGet, chioas.Method{
+		Handler: func() (any, error) {
+			return streamItemsErr(1, -1), nil
+		},
+	}, nil)
+	require.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	res := httptest.NewRecorder()
+	hf.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, `[{"id":0,"name":"item"}]`, res.Body.String())
+
+	// non-stream any values still use the response handler...
+	hf, err = mhb.BuildHandler("/", http.MethodGet, chioas.Method{
+		Handler: func() (any, error) {
+			return streamItem{}, nil
+		},
+	}, nil)
+	require.NoError(t, err)
+	res = httptest.NewRecorder()
+	hf.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusTeapot, res.Code)
+}
+
+func TestStream_BadSeq2(t *testing.T) {
+	mhb := NewTypedMethodsHandlerBuilder()
+	_, err := mhb.BuildHandler("/", http.MethodGet, chioas.Method{
+		Handler: func() iter.Seq2[int, streamItem] {
+			return nil
+		},
+	}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), errSeq2NotError)
+}
+
+func TestStream_MultiMarshable(t *testing.T) {
+	mhb := NewTypedMethodsHandlerBuilder()
+	_, err := mhb.BuildHandler("/", http.MethodGet, chioas.Method{
+		Handler: func() (streamItem, iter.Seq[streamItem]) {
+			return streamItem{}, nil
+		},
+	}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), errMultiMarshable)
+}
+
+func TestAcceptsNdJson(t *testing.T) {
+	testCases := []struct {
+		accept []string
+		expect bool
+	}{
+		{},
+		{
+			accept: []string{"application/json"},
+		},
+		{
+			accept: []string{"application/x-ndjson"},
+			expect: true,
+		},
+		{
+			accept: []string{"application/json, application/x-ndjson"},
+		},
+		{
+			accept: []string{"text/html; q=0.9, application/x-ndjson; q=0.8"},
+			expect: true,
+		},
+		{
+			accept: []string{"text/html", "application/x-ndjson"},
+			expect: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(strings.Join(tc.accept, "|"), func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			for _, a := range tc.accept {
+				req.Header.Add(hdrAccept, a)
+			}
+			assert.Equal(t, tc.expect, acceptsNdJson(req))
+		})
+	}
+}
+
+func TestStreamResponse(t *testing.T) {
+	def := chioas.Definition{
+		Paths: chioas.Paths{
+			"/items": {
+				Methods: chioas.Methods{
+					http.MethodGet: {
+						Responses: chioas.Responses{
+							http.StatusOK: StreamResponse[streamItem]("the items"),
+						},
+					},
+				},
+			},
+		},
+	}
+	data, err := def.AsYaml()
+	require.NoError(t, err)
+	const expect = `        200:
+          description: "the items"
+          content:
+            "application/json":
+              schema:
+                type: array
+                items:
+                  type: object
+                  properties:
+                    "id":
+                      type: integer
+                    "name":
+                      type: string
+            "application/x-ndjson":
+              schema:
+                type: array
+                items:
+                  type: object
+                  properties:
+                    "id":
+                      type: integer
+                    "name":
+                      type: string
+`
+	assert.Contains(t, string(data), expect)
+}
+
+func TestSchemaForType(t *testing.T) {
+	assert.Equal(t, "string", schemaForType(reflect.TypeOf("")).Type)
+	assert.Equal(t, "boolean", schemaForType(reflect.TypeOf(true)).Type)
+	assert.Equal(t, "integer", schemaForType(reflect.TypeOf(1)).Type)
+	assert.Equal(t, "number", schemaForType(reflect.TypeOf(1.1)).Type)
+	assert.Equal(t, "array", schemaForType(reflect.TypeOf([]string{})).Type)
+	assert.Equal(t, "object", schemaForType(reflect.TypeOf(map[string]any{})).Type)
+	s := schemaForType(reflect.TypeOf(&streamItem{}))
+	assert.Equal(t, "object", s.Type)
+	assert.Len(t, s.Properties, 2)
+}
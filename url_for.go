@@ -0,0 +1,125 @@
+package chioas
+
+import (
+	"fmt"
+	"github.com/go-andiamo/urit"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// URLFor builds the url path for the operation with the given operationId
+//
+// The params are the path param values (keyed by path param name) - each value must match any regex
+// specified for the path var in the path template (values are path escaped).  The query (if non-empty) is
+// appended as the url query string - and the returned url path is prefixed with any DocOptions.Context
+//
+// Note: operationIds are as they would appear in the spec (i.e. taking into account any DocOptions.OperationIdentifier) - and
+// if Definition.SetupRoutes has been called, the operation templates built by that are used - otherwise they are built on each call
+func (d *Definition) URLFor(operationId string, params map[string]any, query url.Values) (string, error) {
+	ops := d.operationTemplates
+	if ops == nil {
+		var err error
+		if ops, err = d.buildOperationTemplates(); err != nil {
+			return "", err
+		}
+	}
+	op, ok := ops[operationId]
+	if !ok {
+		return "", fmt.Errorf("unknown operationId '%s'", operationId)
+	}
+	ck := &urlForVarCheck{}
+	path, err := op.template.PathFrom(urit.PathVarsFromMap(params), ck)
+	if err != nil {
+		if ck.failed != "" {
+			return "", fmt.Errorf("path param '%s' value '%s' does not match pattern '%s' (operationId: %s)", ck.failed, ck.value, ck.pattern, operationId)
+		}
+		return "", fmt.Errorf("%s (operationId: %s)", err.Error(), operationId)
+	}
+	if path == "" {
+		path = root
+	}
+	if ctx := strings.Trim(d.DocOptions.Context, "/"); ctx != "" {
+		path = "/" + ctx + path
+	}
+	if len(query) > 0 {
+		path = path + "?" + query.Encode()
+	}
+	return path, nil
+}
+
+type operationTemplate struct {
+	template urit.Template
+	method   string
+}
+
+type operationTemplates map[string]operationTemplate
+
+// buildOperationTemplates builds the url templates for each operationId - errors if any operationIds are duplicated
+func (d *Definition) buildOperationTemplates() (operationTemplates, error) {
+	result := operationTemplates{}
+	if err := result.addMethods(d, root, "", d.Methods); err != nil {
+		return nil, err
+	}
+	if err := result.addPaths(d, nil, "", d.Paths); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (ots operationTemplates) addPaths(d *Definition, ancestry []string, parentTag string, paths Paths) error {
+	for p, pDef := range paths {
+		if pDef.Disabled == nil || !pDef.Disabled() {
+			newAncestry := append(ancestry, p)
+			useTag := defaultTag(parentTag, pDef.Tag)
+			if err := ots.addMethods(d, strings.Join(newAncestry, ""), useTag, pDef.Methods); err != nil {
+				return err
+			}
+			if err := ots.addPaths(d, newAncestry, useTag, pDef.Paths); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (ots operationTemplates) addMethods(d *Definition, path string, parentTag string, methods Methods) error {
+	var template urit.Template
+	for m, mDef := range methods {
+		if opId := mDef.getOperationIdForPath(&d.DocOptions, m, path, parentTag); opId != "" {
+			if existing, ok := ots[opId]; ok {
+				return fmt.Errorf("duplicate operationId '%s' (path: %s, method: %s - and path: %s, method: %s)", opId, path, m, existing.template.OriginalTemplate(), existing.method)
+			}
+			if template == nil {
+				var err error
+				if template, err = urit.NewTemplate(path); err != nil {
+					return fmt.Errorf("invalid path template - %s (path: %s)", err.Error(), path)
+				}
+			}
+			ots[opId] = operationTemplate{
+				template: template,
+				method:   m,
+			}
+		}
+	}
+	return nil
+}
+
+// urlForVarCheck is a urit.VarMatchOption that checks path var values against path var regexes (and path escapes values)
+type urlForVarCheck struct {
+	failed  string
+	value   string
+	pattern string
+}
+
+func (ck *urlForVarCheck) Applicable(value string, position int, name string, rx *regexp.Regexp, rxs string, pathPos int, vars urit.PathVars) bool {
+	return true
+}
+
+func (ck *urlForVarCheck) Match(value string, position int, name string, rx *regexp.Regexp, rxs string, pathPos int, vars urit.PathVars) (string, bool) {
+	if rx != nil && !rx.MatchString(value) {
+		ck.failed, ck.value, ck.pattern = name, value, rxs
+		return value, false
+	}
+	return url.PathEscape(value), true
+}
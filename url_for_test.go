@@ -0,0 +1,199 @@
+package chioas
+
+import (
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+var testUrlForDefinition = Definition{
+	Methods: Methods{
+		http.MethodGet: {OperationId: "getRoot"},
+	},
+	Paths: Paths{
+		"/people": {
+			Methods: Methods{
+				http.MethodGet: {OperationId: "getPeople"},
+			},
+			Paths: Paths{
+				"/{id:[0-9]+}": {
+					Methods: Methods{
+						http.MethodGet: {OperationId: "getPerson"},
+					},
+					Paths: Paths{
+						"/addresses/{addressId}": {
+							Methods: Methods{
+								http.MethodGet: {OperationId: "getAddress"},
+							},
+						},
+					},
+				},
+			},
+		},
+		"/disabled": {
+			Disabled: func() bool {
+				return true
+			},
+			Methods: Methods{
+				http.MethodGet: {OperationId: "getDisabled"},
+			},
+		},
+	},
+}
+
+func TestDefinition_URLFor(t *testing.T) {
+	testCases := []struct {
+		context     string
+		operationId string
+		params      map[string]any
+		query       url.Values
+		expect      string
+		expectErr   string
+	}{
+		{
+			operationId: "getRoot",
+			expect:      "/",
+		},
+		{
+			operationId: "getPeople",
+			expect:      "/people",
+		},
+		{
+			operationId: "getPeople",
+			query:       url.Values{"name": {"bilbo baggins"}, "age": {"111"}},
+			expect:      "/people?age=111&name=bilbo+baggins",
+		},
+		{
+			operationId: "getPerson",
+			params:      map[string]any{"id": 123},
+			expect:      "/people/123",
+		},
+		{
+			context:     "/api/",
+			operationId: "getPerson",
+			params:      map[string]any{"id": "123"},
+			expect:      "/api/people/123",
+		},
+		{
+			operationId: "getAddress",
+			params:      map[string]any{"id": 1, "addressId": "a/b c"},
+			expect:      "/people/1/addresses/a%2Fb%20c",
+		},
+		{
+			operationId: "getPerson",
+			params:      map[string]any{"id": "abc"},
+			expectErr:   "path param 'id' value 'abc' does not match pattern '[0-9]+' (operationId: getPerson)",
+		},
+		{
+			operationId: "getPerson",
+			expectErr:   "no var for 'id' (operationId: getPerson)",
+		},
+		{
+			operationId: "getDisabled",
+			expectErr:   "unknown operationId 'getDisabled'",
+		},
+		{
+			operationId: "unknown",
+			expectErr:   "unknown operationId 'unknown'",
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i, tc.operationId), func(t *testing.T) {
+			d := testUrlForDefinition
+			d.DocOptions.Context = tc.context
+			u, err := d.URLFor(tc.operationId, tc.params, tc.query)
+			if tc.expectErr != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tc.expectErr, err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expect, u)
+			}
+		})
+	}
+}
+
+func TestDefinition_URLFor_OperationIdentifier(t *testing.T) {
+	d := Definition{
+		DocOptions: DocOptions{
+			OperationIdentifier: func(method Method, methodName string, path string, parentTag string) string {
+				return parentTag + "_" + methodName
+			},
+		},
+		Paths: Paths{
+			"/people": {
+				Tag: "People",
+				Methods: Methods{
+					http.MethodGet: {},
+				},
+			},
+		},
+	}
+	u, err := d.URLFor("People_GET", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "/people", u)
+}
+
+func TestDefinition_URLFor_AfterSetupRoutes(t *testing.T) {
+	d := Definition{
+		Paths: Paths{
+			"/people/{id}": {
+				Methods: Methods{
+					http.MethodGet: {
+						OperationId: "getPerson",
+						Handler:     func(writer http.ResponseWriter, request *http.Request) {},
+					},
+				},
+			},
+		},
+	}
+	require.NoError(t, d.SetupRoutes(chi.NewRouter(), nil))
+	require.NotNil(t, d.operationTemplates)
+	u, err := d.URLFor("getPerson", map[string]any{"id": "123"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "/people/123", u)
+}
+
+func TestDefinition_URLFor_DuplicateOperationIds(t *testing.T) {
+	d := Definition{
+		DocOptions: DocOptions{ServeDocs: true},
+		Methods: Methods{
+			http.MethodGet: {
+				OperationId: "dup",
+				Handler:     func(writer http.ResponseWriter, request *http.Request) {},
+			},
+		},
+		Paths: Paths{
+			"/people": {
+				Methods: Methods{
+					http.MethodGet: {
+						OperationId: "dup",
+						Handler:     func(writer http.ResponseWriter, request *http.Request) {},
+					},
+				},
+			},
+		},
+	}
+	_, err := d.URLFor("dup", nil, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "duplicate operationId 'dup'")
+	router := chi.NewRouter()
+	err = d.SetupRoutes(router, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "duplicate operationId 'dup'")
+	// nothing (not even docs) setup on the router...
+	assert.Empty(t, router.Routes())
+
+	mux := http.NewServeMux()
+	err = d.SetupServeMux(mux, nil, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "duplicate operationId 'dup'")
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/docs/index.html", nil))
+	assert.Equal(t, http.StatusNotFound, res.Code)
+}
@@ -0,0 +1,527 @@
+package chioas
+
+import (
+	"errors"
+	"github.com/go-andiamo/chioas/internal/tags"
+	"github.com/go-andiamo/chioas/internal/values"
+	"github.com/go-andiamo/urit"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// VisitAction is returned by Visitor funcs to control how Definition.Visit proceeds
+type VisitAction int
+
+const (
+	// VisitContinue continues the visit into the children of the node
+	VisitContinue VisitAction = iota
+	// VisitSkip skips the children of the node (the visit continues with the node's next sibling)
+	VisitSkip
+	// VisitStop stops the visit
+	VisitStop
+)
+
+// Visitor is the set of funcs called by Definition.Visit for the nodes of a definition
+//
+// All funcs are optional - where a func is nil, the node is not called back but its children are still visited.  Nodes are
+// passed as pointers - if a node is altered, it is also altered in the definition (unless the func returns an error)
+type Visitor struct {
+	// Path is called for each path (including sub-paths)
+	Path func(ctx *VisitContext, path *Path) (VisitAction, error)
+	// Method is called for each method (root methods and path methods)
+	Method func(ctx *VisitContext, method *Method) (VisitAction, error)
+	// PathParam is called for each path param of a path
+	PathParam func(ctx *VisitContext, param *PathParam) (VisitAction, error)
+	// QueryParam is called for each query param of a method
+	QueryParam func(ctx *VisitContext, param *QueryParam) (VisitAction, error)
+	// CommonParameter is called for each parameter in components
+	CommonParameter func(ctx *VisitContext, param *CommonParameter) (VisitAction, error)
+	// Request is called for each method request and each request body in components
+	Request func(ctx *VisitContext, request *Request) (VisitAction, error)
+	// Response is called for each method response and each response in components
+	Response func(ctx *VisitContext, response *Response) (VisitAction, error)
+	// ContentType is called for each alternative content type of a request or response
+	ContentType func(ctx *VisitContext, contentType *ContentType) (VisitAction, error)
+	// Schema is called for each schema - components schemas, param schemas, non-ref ofs schemas and the schemas of
+	// requests, responses and content types (where the schema is a Schema or *Schema)
+	Schema func(ctx *VisitContext, schema *Schema) (VisitAction, error)
+	// Property is called for each property of a schema (including nested properties)
+	Property func(ctx *VisitContext, property *Property) (VisitAction, error)
+	// Example is called for each example of a request, response or content type - and each example in components
+	Example func(ctx *VisitContext, example *Example) (VisitAction, error)
+	// SecurityScheme is called for each security scheme of the definition, a method or components
+	SecurityScheme func(ctx *VisitContext, scheme *SecurityScheme) (VisitAction, error)
+	// Leave is called after the children of a node have been visited (it is not called for nodes that were skipped)
+	//
+	// the node is the same pointer that was passed to the node's visit func (or *Components for the components node)
+	Leave func(ctx *VisitContext, node any) error
+}
+
+// VisitContext is the context passed to Visitor funcs
+type VisitContext struct {
+	// Pointer is the JSON pointer to the node in the OAS spec (e.g. "/paths/~1pets~1{id}/get/responses/200")
+	Pointer string
+	// Path is the full path of the node (e.g. "/pets/{id}") - "/" for root methods and empty outside of paths
+	Path string
+	// Method is the method of the node (empty if the node is not within a method)
+	Method string
+	// Name is the name of the node - i.e. the path key, method, param name, property name, content type,
+	// response status code, example name, security scheme name or component name
+	Name   string
+	frames []visitFrame
+}
+
+type visitFrame struct {
+	node    any
+	pointer string
+	path    string
+	method  string
+	name    string
+}
+
+// Parent returns the parent node of the current node (or nil if the node is top-level - e.g. root methods, top-level
+// paths, definition security schemes and components)
+func (c *VisitContext) Parent() any {
+	if l := len(c.frames); l > 1 {
+		return c.frames[l-2].node
+	}
+	return nil
+}
+
+// Ancestors returns the ancestor nodes of the current node (outermost first)
+func (c *VisitContext) Ancestors() []any {
+	result := make([]any, 0, len(c.frames))
+	for i := 0; i < len(c.frames)-1; i++ {
+		result = append(result, c.frames[i].node)
+	}
+	return result
+}
+
+// PathParams returns the path params of the current path - merged from all ancestor paths (nil if not within a path)
+func (c *VisitContext) PathParams() (result PathParams) {
+	for _, f := range c.frames {
+		if p, ok := f.node.(*Path); ok {
+			if result == nil {
+				result = PathParams{}
+			}
+			for n, pp := range p.PathParams {
+				result[n] = pp
+			}
+		}
+	}
+	return result
+}
+
+func (c *VisitContext) push(f visitFrame) {
+	c.frames = append(c.frames, f)
+	c.Pointer, c.Path, c.Method, c.Name = f.pointer, f.path, f.method, f.name
+}
+
+func (c *VisitContext) pop() {
+	c.frames = c.frames[:len(c.frames)-1]
+	var f visitFrame
+	if l := len(c.frames); l > 0 {
+		f = c.frames[l-1]
+	}
+	c.Pointer, c.Path, c.Method, c.Name = f.pointer, f.path, f.method, f.name
+}
+
+// Visit walks every node of the definition calling the appropriate Visitor func for each
+//
+// Nodes are visited in a deterministic order - root methods, paths (each path's params, methods and then sub-paths),
+// definition security and then components.  Within a method, query params are visited first, then the request, responses
+// and security
+//
+// Each Visitor func can return VisitSkip to skip the children of the node or VisitStop to stop the visit.  If a func returns an
+// error, the visit stops and that error is returned (and the node is not altered in the definition)
+func (d *Definition) Visit(v *Visitor) error {
+	err := newVisitWalker(d, v).definition()
+	if errors.Is(err, errVisitStop) {
+		err = nil
+	}
+	return err
+}
+
+var errVisitStop = errors.New("visit stopped")
+
+type visitWalker struct {
+	def     *Definition
+	v       *Visitor
+	ctx     *VisitContext
+	context string
+}
+
+func newVisitWalker(d *Definition, v *Visitor) *visitWalker {
+	return &visitWalker{
+		def:     d,
+		v:       v,
+		ctx:     &VisitContext{},
+		context: strings.Trim(d.DocOptions.Context, "/"),
+	}
+}
+
+// visitNode calls the visit func for the node and then, unless skipped, visits its children
+//
+// keep is returned as true unless the visit func returned an error - i.e. whether an altered copy of the node should be
+// written back into the definition
+func visitNode[T any](w *visitWalker, fn func(*VisitContext, *T) (VisitAction, error), f visitFrame, node *T, children func(*T) error) (keep bool, err error) {
+	f.node = node
+	w.ctx.push(f)
+	defer w.ctx.pop()
+	action := VisitContinue
+	if fn != nil {
+		if action, err = fn(w.ctx, node); err != nil {
+			return false, err
+		}
+	}
+	switch action {
+	case VisitStop:
+		return true, errVisitStop
+	case VisitSkip:
+		return true, nil
+	}
+	if children != nil {
+		err = children(node)
+	}
+	if err == nil && w.v.Leave != nil {
+		err = w.v.Leave(w.ctx, node)
+	}
+	return true, err
+}
+
+// frame returns a new frame inheriting the path and method of the current node
+func (w *visitWalker) frame(pointer string, name string) visitFrame {
+	return visitFrame{
+		pointer: pointer,
+		path:    w.ctx.Path,
+		method:  w.ctx.Method,
+		name:    name,
+	}
+}
+
+func (w *visitWalker) pathPointer(path string) string {
+	return jsonPointerPath(w.context, path)
+}
+
+func (w *visitWalker) definition() error {
+	err := w.methods(w.pathPointer(root), root, 0, w.def.Methods)
+	if err == nil {
+		err = w.paths("", w.def.Paths)
+	}
+	if err == nil {
+		err = w.securitySchemes("/"+tags.Security+"/", 0, w.def.Security)
+	}
+	if err == nil && w.def.Components != nil {
+		err = w.components(w.def.Components)
+	}
+	return err
+}
+
+func (w *visitWalker) paths(parent string, paths Paths) error {
+	for _, k := range sortedKeys(paths) {
+		path := parent + k
+		specPath := path
+		var pathVars []urit.PathVar
+		if template, err := urit.NewTemplate(path); err == nil {
+			specPath = template.Template(true)
+			pathVars = template.Vars()
+		}
+		ptr := w.pathPointer(specPath)
+		pDef := paths[k]
+		keep, err := visitNode(w, w.v.Path, visitFrame{pointer: ptr, path: path, name: k}, &pDef, func(p *Path) error {
+			return w.path(ptr, path, pathVars, p)
+		})
+		if keep {
+			paths[k] = pDef
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *visitWalker) path(ptr string, path string, pathVars []urit.PathVar, p *Path) error {
+	paramsPtr := ptr + "/" + tags.Parameters + "/"
+	for _, name := range sortedKeys(p.PathParams) {
+		idx := jsonPointerEscape(name)
+		if i := slices.IndexFunc(pathVars, func(pv urit.PathVar) bool {
+			return pv.Name == name
+		}); i != -1 {
+			idx = strconv.Itoa(i)
+		}
+		pp := p.PathParams[name]
+		keep, err := w.pathParam(paramsPtr+idx, name, &pp)
+		if keep {
+			p.PathParams[name] = pp
+		}
+		if err != nil {
+			return err
+		}
+	}
+	err := w.methods(ptr, path, len(pathVars), p.Methods)
+	if err == nil {
+		err = w.paths(path, p.Paths)
+	}
+	return err
+}
+
+func (w *visitWalker) pathParam(ptr string, name string, pp *PathParam) (bool, error) {
+	return visitNode(w, w.v.PathParam, w.frame(ptr, name), pp, func(pp *PathParam) error {
+		return w.paramSchema(ptr, pp.Schema)
+	})
+}
+
+func (w *visitWalker) paramSchema(ptr string, s *Schema) error {
+	if s != nil {
+		return w.schema(ptr+"/"+tags.Schema, s)
+	}
+	return nil
+}
+
+func (w *visitWalker) methods(ptr string, path string, paramsOffset int, methods Methods) error {
+	for _, m := range methods.sorted() {
+		mDef := methods[m]
+		keep, err := w.method(ptr+"/"+strings.ToLower(m), path, m, paramsOffset, &mDef)
+		if keep {
+			methods[m] = mDef
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *visitWalker) method(ptr string, path string, method string, paramsOffset int, m *Method) (bool, error) {
+	return visitNode(w, w.v.Method, visitFrame{pointer: ptr, path: path, method: method, name: method}, m, func(m *Method) error {
+		err := w.queryParams(ptr+"/"+tags.Parameters+"/", paramsOffset, m.QueryParams)
+		if err == nil && m.Request != nil {
+			_, err = w.request(ptr+"/"+tags.RequestBody, "", m.Request)
+		}
+		if err == nil {
+			err = w.responses(ptr+"/"+tags.Responses+"/", m.Responses)
+		}
+		if err == nil {
+			// optional security adds an empty entry before the method's security schemes...
+			offset := 0
+			if m.OptionalSecurity {
+				offset = 1
+			}
+			err = w.securitySchemes(ptr+"/"+tags.Security+"/", offset, m.Security)
+		}
+		return err
+	})
+}
+
+func (w *visitWalker) queryParams(ptr string, offset int, qps QueryParams) error {
+	for i := range qps {
+		qpPtr := ptr + strconv.Itoa(offset+i)
+		if _, err := visitNode(w, w.v.QueryParam, w.frame(qpPtr, qps[i].Name), &qps[i], func(qp *QueryParam) error {
+			return w.paramSchema(qpPtr, qp.Schema)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *visitWalker) request(ptr string, name string, r *Request) (bool, error) {
+	return visitNode(w, w.v.Request, w.frame(ptr, name), r, func(r *Request) error {
+		return w.content(ptr, r.ContentType, &r.Schema, r.IsArray, r.Examples, r.AlternativeContentTypes)
+	})
+}
+
+func (w *visitWalker) responses(ptr string, rs Responses) error {
+	for _, sc := range sortedKeys(rs) {
+		r := rs[sc]
+		code := strconv.Itoa(sc)
+		keep, err := w.response(ptr+code, code, &r)
+		if keep {
+			rs[sc] = r
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *visitWalker) response(ptr string, name string, r *Response) (bool, error) {
+	return visitNode(w, w.v.Response, w.frame(ptr, name), r, func(r *Response) error {
+		return w.content(ptr, r.ContentType, &r.Schema, r.IsArray, r.Examples, r.AlternativeContentTypes)
+	})
+}
+
+func (w *visitWalker) content(ptr string, contentType string, schema *any, isArray bool, examples Examples, alts ContentTypes) error {
+	ctPtr := ptr + "/" + tags.Content + "/"
+	err := w.contentSchema(ctPtr+jsonPointerEscape(defValue(contentType, tags.ApplicationJson)), schema, isArray, examples)
+	if err == nil {
+		err = w.contentTypes(ctPtr, alts)
+	}
+	return err
+}
+
+func (w *visitWalker) contentTypes(ptr string, cts ContentTypes) error {
+	for _, ct := range sortedKeys(cts) {
+		ctPtr := ptr + jsonPointerEscape(ct)
+		cDef := cts[ct]
+		keep, err := visitNode(w, w.v.ContentType, w.frame(ctPtr, ct), &cDef, func(c *ContentType) error {
+			return w.contentSchema(ctPtr, &c.Schema, c.IsArray, c.Examples)
+		})
+		if keep {
+			cts[ct] = cDef
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *visitWalker) contentSchema(ptr string, schema *any, isArray bool, examples Examples) error {
+	schemaPtr := ptr + "/" + tags.Schema
+	if isArray {
+		schemaPtr += "/" + tags.Items
+	}
+	var err error
+	switch st := (*schema).(type) {
+	case Schema:
+		var keep bool
+		keep, err = visitNode(w, w.v.Schema, w.frame(schemaPtr, st.Name), &st, func(s *Schema) error {
+			return w.schemaChildren(schemaPtr, s)
+		})
+		if keep {
+			*schema = st
+		}
+	case *Schema:
+		if st != nil {
+			err = w.schema(schemaPtr, st)
+		}
+	}
+	if err == nil {
+		err = w.examples(ptr+"/"+tags.Examples+"/", examples)
+	}
+	return err
+}
+
+func (w *visitWalker) examples(ptr string, egs Examples) error {
+	for i := range egs {
+		if _, err := visitNode(w, w.v.Example, w.frame(ptr+jsonPointerEscape(egs[i].Name), egs[i].Name), &egs[i], nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *visitWalker) schema(ptr string, s *Schema) error {
+	_, err := visitNode(w, w.v.Schema, w.frame(ptr, s.Name), s, func(s *Schema) error {
+		return w.schemaChildren(ptr, s)
+	})
+	return err
+}
+
+func (w *visitWalker) schemaChildren(ptr string, s *Schema) error {
+	if err := w.properties(ptr, s.Properties); err != nil {
+		return err
+	}
+	if s.Ofs != nil {
+		for i, of := range s.Ofs.Of {
+			if !of.IsRef() {
+				if os := of.Schema(); os != nil {
+					if err := w.schema(ptr+"/"+s.Ofs.OfType.TagName()+"/"+strconv.Itoa(i), os); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (w *visitWalker) properties(ptr string, ptys Properties) error {
+	for i := range ptys {
+		if err := w.property(ptr+jsonPointerPropertiesSegment+jsonPointerEscape(ptys[i].Name), &ptys[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *visitWalker) property(ptr string, p *Property) error {
+	_, err := visitNode(w, w.v.Property, w.frame(ptr, p.Name), p, func(p *Property) error {
+		if p.Type == values.TypeArray {
+			return w.properties(ptr+"/"+tags.Items, p.Properties)
+		}
+		return w.properties(ptr, p.Properties)
+	})
+	return err
+}
+
+func (w *visitWalker) securitySchemes(ptr string, offset int, ss SecuritySchemes) error {
+	for i := range ss {
+		if _, err := visitNode(w, w.v.SecurityScheme, w.frame(ptr+strconv.Itoa(offset+i), ss[i].Name), &ss[i], nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *visitWalker) components(c *Components) error {
+	ptr := jsonPointerComponentsPrefix
+	_, err := visitNode[Components](w, nil, visitFrame{pointer: "/" + tags.Components}, c, func(c *Components) error {
+		for i := range c.Schemas {
+			if err := w.schema(ptr+tags.Schemas+"/"+jsonPointerEscape(c.Schemas[i].Name), &c.Schemas[i]); err != nil {
+				return err
+			}
+		}
+		for _, name := range sortedKeys(c.Requests) {
+			r := c.Requests[name]
+			keep, err := w.request(ptr+tags.RequestBodies+"/"+jsonPointerEscape(name), name, &r)
+			if keep {
+				c.Requests[name] = r
+			}
+			if err != nil {
+				return err
+			}
+		}
+		for _, name := range sortedKeys(c.Responses) {
+			r := c.Responses[name]
+			keep, err := w.response(ptr+tags.Responses+"/"+jsonPointerEscape(name), name, &r)
+			if keep {
+				c.Responses[name] = r
+			}
+			if err != nil {
+				return err
+			}
+		}
+		for _, name := range sortedKeys(c.Parameters) {
+			p := c.Parameters[name]
+			keep, err := w.commonParameter(ptr+tags.Parameters+"/"+jsonPointerEscape(name), name, &p)
+			if keep {
+				c.Parameters[name] = p
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if err := w.examples(ptr+tags.Examples+"/", c.Examples); err != nil {
+			return err
+		}
+		for i := range c.SecuritySchemes {
+			name := c.SecuritySchemes[i].Name
+			if _, err := visitNode(w, w.v.SecurityScheme, w.frame(ptr+tags.SecuritySchemes+"/"+jsonPointerEscape(name), name), &c.SecuritySchemes[i], nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+func (w *visitWalker) commonParameter(ptr string, name string, p *CommonParameter) (bool, error) {
+	return visitNode(w, w.v.CommonParameter, w.frame(ptr, name), p, func(p *CommonParameter) error {
+		return w.paramSchema(ptr, p.Schema)
+	})
+}
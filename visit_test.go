@@ -0,0 +1,402 @@
+package chioas
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+// testVisitDefinition is shared by tests that only read the definition (tests that alter it use newTestVisitDefinition)
+var testVisitDefinition = newTestVisitDefinition()
+
+func newTestVisitDefinition() *Definition {
+	return &Definition{
+		DocOptions: DocOptions{Context: "/api"},
+		Methods: Methods{
+			http.MethodGet: {},
+		},
+		Paths: Paths{
+			"/pets": {
+				Methods: Methods{
+					http.MethodPost: {
+						QueryParams: QueryParams{
+							{Name: "dry-run", Schema: &Schema{Type: "boolean"}},
+						},
+						Request: &Request{
+							Schema: Schema{
+								Properties: Properties{
+									{Name: "name"},
+									{Name: "tags", Type: "array", Properties: Properties{{Name: "label"}}},
+								},
+							},
+							Examples: Examples{{Name: "eg"}},
+							AlternativeContentTypes: ContentTypes{
+								"application/xml": {SchemaRef: "Pet", IsArray: true},
+							},
+						},
+						Responses: Responses{
+							http.StatusCreated: {},
+							http.StatusBadRequest: {
+								Schema: &Schema{
+									Ofs: &Ofs{
+										OfType: OneOf,
+										Of: []OfSchema{
+											OfRef("Error"),
+											&Of{SchemaDef: &Schema{Name: "other"}},
+										},
+									},
+								},
+							},
+						},
+						Security:         SecuritySchemes{{Name: "bearer"}},
+						OptionalSecurity: true,
+					},
+					http.MethodGet: {},
+				},
+				Paths: Paths{
+					"/{petId:[0-9]+}": {
+						PathParams: PathParams{
+							"petId": {Schema: &Schema{Type: "integer"}},
+						},
+						Methods: Methods{
+							http.MethodGet: {
+								QueryParams: QueryParams{{Name: "fields"}},
+							},
+						},
+					},
+				},
+			},
+		},
+		Security: SecuritySchemes{{Name: "apiKey"}},
+		Components: &Components{
+			Schemas: Schemas{
+				{Name: "Pet", Properties: Properties{{Name: "id"}}},
+			},
+			Requests: CommonRequests{
+				"PetRequest": {SchemaRef: "Pet"},
+			},
+			Responses: CommonResponses{
+				"PetResponse": {SchemaRef: "Pet"},
+			},
+			Parameters: CommonParameters{
+				"limit": {Schema: &Schema{Type: "integer"}},
+			},
+			Examples:        Examples{{Name: "pet"}},
+			SecuritySchemes: SecuritySchemes{{Name: "bearer"}},
+		},
+	}
+}
+
+func recordingVisitor(visited *[]string) *Visitor {
+	add := func(kind string, ctx *VisitContext) {
+		*visited = append(*visited, kind+" "+ctx.Pointer)
+	}
+	return &Visitor{
+		Path: func(ctx *VisitContext, path *Path) (VisitAction, error) {
+			add("path", ctx)
+			return VisitContinue, nil
+		},
+		Method: func(ctx *VisitContext, method *Method) (VisitAction, error) {
+			add("method", ctx)
+			return VisitContinue, nil
+		},
+		PathParam: func(ctx *VisitContext, param *PathParam) (VisitAction, error) {
+			add("pathParam", ctx)
+			return VisitContinue, nil
+		},
+		QueryParam: func(ctx *VisitContext, param *QueryParam) (VisitAction, error) {
+			add("queryParam", ctx)
+			return VisitContinue, nil
+		},
+		CommonParameter: func(ctx *VisitContext, param *CommonParameter) (VisitAction, error) {
+			add("commonParameter", ctx)
+			return VisitContinue, nil
+		},
+		Request: func(ctx *VisitContext, request *Request) (VisitAction, error) {
+			add("request", ctx)
+			return VisitContinue, nil
+		},
+		Response: func(ctx *VisitContext, response *Response) (VisitAction, error) {
+			add("response", ctx)
+			return VisitContinue, nil
+		},
+		ContentType: func(ctx *VisitContext, contentType *ContentType) (VisitAction, error) {
+			add("contentType", ctx)
+			return VisitContinue, nil
+		},
+		Schema: func(ctx *VisitContext, schema *Schema) (VisitAction, error) {
+			add("schema", ctx)
+			return VisitContinue, nil
+		},
+		Property: func(ctx *VisitContext, property *Property) (VisitAction, error) {
+			add("property", ctx)
+			return VisitContinue, nil
+		},
+		Example: func(ctx *VisitContext, example *Example) (VisitAction, error) {
+			add("example", ctx)
+			return VisitContinue, nil
+		},
+		SecurityScheme: func(ctx *VisitContext, scheme *SecurityScheme) (VisitAction, error) {
+			add("securityScheme", ctx)
+			return VisitContinue, nil
+		},
+	}
+}
+
+func TestDefinition_Visit(t *testing.T) {
+	d := testVisitDefinition
+	visited := make([]string, 0)
+	err := d.Visit(recordingVisitor(&visited))
+	require.NoError(t, err)
+	expect := []string{
+		"method /paths/~1api~1/get",
+		"path /paths/~1api~1pets",
+		"method /paths/~1api~1pets/get",
+		"method /paths/~1api~1pets/post",
+		"queryParam /paths/~1api~1pets/post/parameters/0",
+		"schema /paths/~1api~1pets/post/parameters/0/schema",
+		"request /paths/~1api~1pets/post/requestBody",
+		"schema /paths/~1api~1pets/post/requestBody/content/application~1json/schema",
+		"property /paths/~1api~1pets/post/requestBody/content/application~1json/schema/properties/name",
+		"property /paths/~1api~1pets/post/requestBody/content/application~1json/schema/properties/tags",
+		"property /paths/~1api~1pets/post/requestBody/content/application~1json/schema/properties/tags/items/properties/label",
+		"example /paths/~1api~1pets/post/requestBody/content/application~1json/examples/eg",
+		"contentType /paths/~1api~1pets/post/requestBody/content/application~1xml",
+		"response /paths/~1api~1pets/post/responses/201",
+		"response /paths/~1api~1pets/post/responses/400",
+		"schema /paths/~1api~1pets/post/responses/400/content/application~1json/schema",
+		"schema /paths/~1api~1pets/post/responses/400/content/application~1json/schema/oneOf/1",
+		"securityScheme /paths/~1api~1pets/post/security/1",
+		"path /paths/~1api~1pets~1{petId}",
+		"pathParam /paths/~1api~1pets~1{petId}/parameters/0",
+		"schema /paths/~1api~1pets~1{petId}/parameters/0/schema",
+		"method /paths/~1api~1pets~1{petId}/get",
+		"queryParam /paths/~1api~1pets~1{petId}/get/parameters/1",
+		"securityScheme /security/0",
+		"schema /components/schemas/Pet",
+		"property /components/schemas/Pet/properties/id",
+		"request /components/requestBodies/PetRequest",
+		"response /components/responses/PetResponse",
+		"commonParameter /components/parameters/limit",
+		"schema /components/parameters/limit/schema",
+		"example /components/examples/pet",
+		"securityScheme /components/securitySchemes/bearer",
+	}
+	assert.Equal(t, expect, visited)
+}
+
+func TestDefinition_Visit_Context(t *testing.T) {
+	d := testVisitDefinition
+	checked := 0
+	err := d.Visit(&Visitor{
+		Method: func(ctx *VisitContext, method *Method) (VisitAction, error) {
+			switch ctx.Path {
+			case root:
+				assert.Nil(t, ctx.Parent())
+				assert.Empty(t, ctx.Ancestors())
+				assert.Nil(t, ctx.PathParams())
+				checked++
+			case "/pets/{petId:[0-9]+}":
+				assert.Equal(t, http.MethodGet, ctx.Method)
+				assert.Equal(t, http.MethodGet, ctx.Name)
+				assert.IsType(t, &Path{}, ctx.Parent())
+				assert.Len(t, ctx.Ancestors(), 2)
+				pps := ctx.PathParams()
+				assert.Len(t, pps, 1)
+				assert.Contains(t, pps, "petId")
+				checked++
+			}
+			return VisitContinue, nil
+		},
+		Property: func(ctx *VisitContext, property *Property) (VisitAction, error) {
+			if property.Name == "label" {
+				assert.Equal(t, "/pets", ctx.Path)
+				assert.Equal(t, http.MethodPost, ctx.Method)
+				assert.Equal(t, "label", ctx.Name)
+				assert.IsType(t, &Property{}, ctx.Parent())
+				checked++
+			}
+			return VisitContinue, nil
+		},
+		Schema: func(ctx *VisitContext, schema *Schema) (VisitAction, error) {
+			if schema.Name == "Pet" {
+				assert.Equal(t, "", ctx.Path)
+				assert.Equal(t, "", ctx.Method)
+				assert.IsType(t, &Components{}, ctx.Parent())
+				assert.Nil(t, ctx.PathParams())
+				checked++
+			}
+			return VisitContinue, nil
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 4, checked)
+}
+
+func TestDefinition_Visit_Leave(t *testing.T) {
+	d := testVisitDefinition
+	depth := 0
+	maxDepth := 0
+	err := d.Visit(&Visitor{
+		Property: func(ctx *VisitContext, property *Property) (VisitAction, error) {
+			depth++
+			maxDepth = max(maxDepth, depth)
+			return VisitContinue, nil
+		},
+		Leave: func(ctx *VisitContext, node any) error {
+			if _, ok := node.(*Property); ok {
+				depth--
+			}
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, depth)
+	assert.Equal(t, 2, maxDepth)
+
+	err = d.Visit(&Visitor{
+		Leave: func(ctx *VisitContext, node any) error {
+			if _, ok := node.(*Components); ok {
+				return errors.New("fooey")
+			}
+			return nil
+		},
+	})
+	require.Error(t, err)
+	assert.Equal(t, "fooey", err.Error())
+}
+
+func TestDefinition_Visit_SkipAndStop(t *testing.T) {
+	d := testVisitDefinition
+	methods := 0
+	err := d.Visit(&Visitor{
+		Path: func(ctx *VisitContext, path *Path) (VisitAction, error) {
+			return VisitSkip, nil
+		},
+		Method: func(ctx *VisitContext, method *Method) (VisitAction, error) {
+			methods++
+			return VisitContinue, nil
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, methods)
+
+	visited := make([]string, 0)
+	v := recordingVisitor(&visited)
+	v.Method = func(ctx *VisitContext, method *Method) (VisitAction, error) {
+		visited = append(visited, "method "+ctx.Pointer)
+		if ctx.Method == http.MethodPost {
+			return VisitStop, nil
+		}
+		return VisitContinue, nil
+	}
+	err = d.Visit(v)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"method /paths/~1api~1/get",
+		"path /paths/~1api~1pets",
+		"method /paths/~1api~1pets/get",
+		"method /paths/~1api~1pets/post",
+	}, visited)
+}
+
+func TestDefinition_Visit_Mutation(t *testing.T) {
+	d := newTestVisitDefinition()
+	err := d.Visit(&Visitor{
+		Method: func(ctx *VisitContext, method *Method) (VisitAction, error) {
+			method.Description = "altered " + ctx.Method
+			return VisitContinue, nil
+		},
+		PathParam: func(ctx *VisitContext, param *PathParam) (VisitAction, error) {
+			param.Description = "altered"
+			return VisitContinue, nil
+		},
+		Response: func(ctx *VisitContext, response *Response) (VisitAction, error) {
+			response.Description = "altered " + ctx.Name
+			return VisitContinue, nil
+		},
+		ContentType: func(ctx *VisitContext, contentType *ContentType) (VisitAction, error) {
+			contentType.IsArray = false
+			return VisitContinue, nil
+		},
+		Schema: func(ctx *VisitContext, schema *Schema) (VisitAction, error) {
+			schema.Description = "altered"
+			return VisitContinue, nil
+		},
+		Property: func(ctx *VisitContext, property *Property) (VisitAction, error) {
+			property.Description = "altered"
+			return VisitContinue, nil
+		},
+		CommonParameter: func(ctx *VisitContext, param *CommonParameter) (VisitAction, error) {
+			param.Required = true
+			return VisitContinue, nil
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "altered GET", d.Methods[http.MethodGet].Description)
+	pets := d.Paths["/pets"]
+	post := pets.Methods[http.MethodPost]
+	assert.Equal(t, "altered POST", post.Description)
+	reqSchema := post.Request.Schema.(Schema)
+	assert.Equal(t, "altered", reqSchema.Description)
+	assert.Equal(t, "altered", reqSchema.Properties[1].Properties[0].Description)
+	assert.False(t, post.Request.AlternativeContentTypes["application/xml"].IsArray)
+	assert.Equal(t, "altered 201", post.Responses[http.StatusCreated].Description)
+	assert.Equal(t, "altered", post.Responses[http.StatusBadRequest].Schema.(*Schema).Description)
+	assert.Equal(t, "altered", pets.Paths["/{petId:[0-9]+}"].PathParams["petId"].Description)
+	assert.Equal(t, "altered", d.Components.Schemas[0].Description)
+	assert.True(t, d.Components.Parameters["limit"].Required)
+}
+
+func TestDefinition_Visit_Error(t *testing.T) {
+	d := newTestVisitDefinition()
+	err := d.Visit(&Visitor{
+		Method: func(ctx *VisitContext, method *Method) (VisitAction, error) {
+			method.Description = "altered"
+			if ctx.Path == "/pets" {
+				return VisitContinue, errors.New("fooey")
+			}
+			return VisitContinue, nil
+		},
+	})
+	require.Error(t, err)
+	assert.Equal(t, "fooey", err.Error())
+	assert.Equal(t, "altered", d.Methods[http.MethodGet].Description)
+	assert.Equal(t, "", d.Paths["/pets"].Methods[http.MethodGet].Description)
+	assert.Equal(t, "", d.Paths["/pets"].Paths["/{petId:[0-9]+}"].Methods[http.MethodGet].Description)
+}
+
+func TestDefinition_CheckRefs_Pointers(t *testing.T) {
+	d := &Definition{
+		Paths: Paths{
+			"/pets": {
+				Methods: Methods{
+					http.MethodGet: {
+						Responses: Responses{
+							http.StatusOK: {SchemaRef: "Unknown"},
+						},
+					},
+				},
+			},
+		},
+		Components: &Components{
+			Schemas: Schemas{
+				{Name: "Pet", Properties: Properties{{Name: "owner", SchemaRef: "Pet"}}},
+			},
+		},
+	}
+	errs := d.CheckRefs()
+	require.Len(t, errs, 2)
+	err := errs[0].(*RefError)
+	assert.Equal(t, "/paths/~1pets/get/responses/200", err.Pointer)
+	assert.Equal(t, "/pets", err.Path)
+	assert.Equal(t, http.MethodGet, err.Method)
+	assert.Equal(t, "Unknown", err.Ref)
+	err = errs[1].(*RefError)
+	assert.Equal(t, "cyclic ref", err.Msg)
+	assert.Equal(t, "/components/schemas/Pet/properties/owner", err.Pointer)
+	assert.Equal(t, "#/components/schemas[0]", err.Path)
+	assert.Equal(t, "", err.ItemName)
+}
@@ -39,7 +39,18 @@ type RefChecker interface {
 	RefCheck(area, ref string) error
 }
 
+// ExampleGenerator is an interface optionally used by ExampleGeneratorWriter.ExampleGenerator so that examples can be generated for schemas (where no examples are specified)
+type ExampleGenerator interface {
+	GenerateExample(schema any) (any, bool)
+}
+
+// ExampleGeneratorWriter is an optional interface that a Writer can implement to support generated examples
+type ExampleGeneratorWriter interface {
+	ExampleGenerator(eg ExampleGenerator) ExampleGenerator
+}
+
 var _ Writer = &writer{}
+var _ ExampleGeneratorWriter = &writer{}
 
 type writer struct {
 	buffer     bytes.Buffer
@@ -47,6 +58,7 @@ type writer struct {
 	indent     []byte
 	err        error
 	refChecker RefChecker
+	exampleGen ExampleGenerator
 }
 
 func newWriter(w *bufio.Writer) *writer {
@@ -449,6 +461,16 @@ func (y *writer) RefChecker(rc RefChecker) RefChecker {
 	return nullRefChecker
 }
 
+func (y *writer) ExampleGenerator(eg ExampleGenerator) ExampleGenerator {
+	if eg != nil {
+		y.exampleGen = eg
+		return eg
+	} else if y.exampleGen != nil {
+		return y.exampleGen
+	}
+	return nullExampleGenerator
+}
+
 var nullRefChecker RefChecker = &refChecker{}
 
 type refChecker struct {
@@ -457,3 +479,12 @@ type refChecker struct {
 func (r *refChecker) RefCheck(area, ref string) error {
 	return nil
 }
+
+var nullExampleGenerator ExampleGenerator = &exampleGenerator{}
+
+type exampleGenerator struct {
+}
+
+func (e *exampleGenerator) GenerateExample(schema any) (any, bool) {
+	return nil, false
+}
@@ -710,6 +710,30 @@ func (r *testRefChecker) RefCheck(area, ref string) error {
 	return errors.New("fooey")
 }
 
+func TestWriter_ExampleGenerator(t *testing.T) {
+	w := newWriter(nil)
+	assert.Nil(t, w.exampleGen)
+	eg := w.ExampleGenerator(nil)
+	assert.NotNil(t, eg)
+	assert.Equal(t, nullExampleGenerator, eg)
+	_, ok := eg.GenerateExample(nil)
+	assert.False(t, ok)
+
+	w.ExampleGenerator(&testExampleGenerator{})
+	eg = w.ExampleGenerator(nil)
+	assert.NotEqual(t, nullExampleGenerator, eg)
+	v, ok := eg.GenerateExample(nil)
+	assert.True(t, ok)
+	assert.Equal(t, "foo", v)
+}
+
+type testExampleGenerator struct {
+}
+
+func (e *testExampleGenerator) GenerateExample(schema any) (any, bool) {
+	return "foo", true
+}
+
 func TestFormattedString(t *testing.T) {
 	testCases := []struct {
 		s         string